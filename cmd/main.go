@@ -5,14 +5,16 @@
 package main
 
 import (
-	"os"
+	"flag"
 
 	"github.com/H0llyW00dzZ/GoGenAI-Terminal-Chat/terminal"
 )
 
 const (
-	api_Key  = "API_KEY" // Fixed the typo here
 	logFatal = "API_KEY environment variable is not set"
+	logSetup = "Setup failed: %v"
+	viewFlag = "view"
+	viewHelp = "browse the stored session with this name in read-only mode, without an API key"
 )
 
 // why this so simple ? hahahaha
@@ -20,7 +22,26 @@ func main() {
 	logger := terminal.NewDebugOrErrorLogger() // Assuming NewDebugOrErrorLogger is exported from the terminal package
 	// this goroutines logger panic are not because code of function "terminal package" causing panic, goroutines will tell if there's a panic in other side, indicate that other side system are bad (e.g, too complex).
 	defer logger.RecoverFromPanic() // Assuming RecoverFromPanic is exported from the terminal package
-	apiKey := os.Getenv(api_Key)
+	view := flag.String(viewFlag, "", viewHelp)
+	flag.Parse()
+
+	// The viewer never calls the API, so it does not need an API key.
+	if *view != "" {
+		if session := terminal.NewViewerSession(*view); session != nil {
+			session.Start()
+		}
+		return
+	}
+
+	// On first run without an API key, guide the user through the setup instead of exiting.
+	if terminal.NeedsSetup() {
+		if _, err := terminal.RunSetupWizard(); err != nil {
+			logger.Error(logSetup, err)
+			return
+		}
+	}
+
+	apiKey := terminal.ResolveAPIKey() // API_KEY environment variable, or the key saved by the setup
 	if apiKey == "" {
 		logger.Error(logFatal)
 		return // Exit the main function if there's no API key
@@ -3,7 +3,9 @@ module github.com/H0llyW00dzZ/GoGenAI-Terminal-Chat
 go 1.22.3
 
 require (
+	github.com/fsnotify/fsnotify v1.9.0 // direct
 	github.com/google/generative-ai-go v0.19.0 // direct
+	golang.org/x/sys v0.28.0 // direct
 	google.golang.org/api v0.213.0 // direct
 )
 
@@ -30,7 +32,6 @@ require (
 	golang.org/x/net v0.32.0 // indirect
 	golang.org/x/oauth2 v0.24.0 // indirect
 	golang.org/x/sync v0.10.0 // indirect
-	golang.org/x/sys v0.28.0 // indirect
 	golang.org/x/text v0.21.0 // indirect
 	golang.org/x/time v0.8.0 // indirect
 	google.golang.org/genproto/googleapis/api v0.0.0-20241118233622-e639e219e697 // indirect
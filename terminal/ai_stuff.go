@@ -7,11 +7,17 @@
 package terminal
 
 import (
+	"context"
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"text/tabwriter"
 
 	genai "github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/iterator"
 )
 
 // addMessageWithContext adds a message to the chat history with context.
@@ -23,11 +29,10 @@ func addMessageWithContext(session *Session, sender, message string) {
 func executeCommand(session *Session, command string, constructPrompt func(string) string) (bool, error) {
 	// Assuming command is the user input that triggered the AI.
 	// Note: The command execution process is now more dynamic.
-	addMessageWithContext(session, YouNerd, command)
+	// The command is added to the chat history together with the response, once it has arrived.
 	success, err := sendCommandToAI(session, command, constructPrompt)
 	if err != nil {
-		logger.Error(ErrorFailedToSendCommandToAI, err)
-		return false, err
+		return false, fmt.Errorf(ErrorFailedToSendCommandToAI, err)
 	}
 	return !success, nil // Return false to continue the session if successful.
 }
@@ -41,7 +46,7 @@ func sendCommandToAI(session *Session, command string, constructPrompt func(stri
 	operation := RetryableOperation{
 		retryFunc: func() (bool, error) {
 			// This function is called repeatedly by retryWithExponentialBackoff if it fails.
-			return sendMessageToAI(session, aiPrompt)
+			return sendMessageToAI(session, command, aiPrompt)
 		},
 	}
 
@@ -50,9 +55,10 @@ func sendCommandToAI(session *Session, command string, constructPrompt func(stri
 }
 
 // sendMessageToAI sends a message to the AI and handles the response.
-func sendMessageToAI(session *Session, message string) (bool, error) {
+// The command is added to the chat history together with the response.
+func sendMessageToAI(session *Session, command, message string) (bool, error) {
 	// Fix Duplicated by using Magic "_" Identifier
-	_, err := session.SendMessage(session.Ctx, session.Client, message)
+	_, err := session.sendExchange(session.Ctx, command, message)
 	return err == nil, err
 }
 
@@ -60,18 +66,17 @@ func sendMessageToAI(session *Session, message string) (bool, error) {
 func sendShutdownMessage(session *Session) error {
 	// Clear the chat history in preparation for shutdown.
 	session.ChatHistory.Clear()
-	// Add context and quit command messages to the chat history.
-	addMessageWithContext(session, AiNerd, ContextPrompt)
-	addMessageWithContext(session, YouNerd, QuitCommand)
+	// Add the context to the chat history; the quit command is added with the response.
+	addMessageWithContext(session, AiNerd, promptTemplate(PromptTemplateContext))
 
 	// Construct the AI prompt for shutdown.
-	aiPrompt := fmt.Sprintf(ContextPromptShutdown, QuitCommand, ApplicationName)
+	aiPrompt := fmt.Sprintf(promptTemplate(PromptTemplateShutdown), QuitCommand, ApplicationName)
 
 	// Define a retryable operation with a function that sends the shutdown message to the AI.
 	operation := RetryableOperation{
 		retryFunc: func() (bool, error) {
 			// This function is called repeatedly by retryWithExponentialBackoff if it fails.
-			return sendMessageToAI(session, aiPrompt)
+			return sendMessageToAI(session, QuitCommand, aiPrompt)
 		},
 	}
 
@@ -82,11 +87,11 @@ func sendShutdownMessage(session *Session) error {
 
 // constructSummarizePrompt constructs the prompt to be sent to the AI for summarization.
 func (h *handleSummarizeCommand) constructSummarizePrompt() string {
-	return fmt.Sprintf(SummarizePrompt)
+	return promptTemplate(PromptTemplateSummarize)
 }
 
 // sendSummarizePrompt sends the summarize prompt to the AI and handles the response.
-func (h *handleSummarizeCommand) sendSummarizePrompt(session *Session, sanitizedMessage string) (bool, error) {
+func (h *handleSummarizeCommand) sendSummarizePrompt(ctx context.Context, session *Session, sanitizedMessage string) (bool, error) {
 	// Define a retryable operation with a function that sends the summarize prompt to the AI.
 	operation := RetryableOperation{
 		retryFunc: func() (bool, error) {
@@ -94,7 +99,7 @@ func (h *handleSummarizeCommand) sendSummarizePrompt(session *Session, sanitized
 			// to implement another functionality without displaying AI response in the terminal,
 			// but only adding it to the chat history.
 			// This function is called repeatedly by retryWithExponentialBackoff if it fails.
-			aiResponse, err := session.SendMessage(session.Ctx, session.Client, sanitizedMessage)
+			aiResponse, err := session.SendMessage(ctx, session.Client, sanitizedMessage)
 			if err != nil {
 				return false, err
 			}
@@ -113,13 +118,8 @@ func (h *handleSummarizeCommand) handleAIResponse(session *Session, sanitizedMes
 	// Concatenate custom text with the AI response.
 	fullResponse := SummaryPrefix + aiResponse
 
-	// Instead of directly adding, check if a system message already exists and replace it.
-	formattedResponse := fmt.Sprintf(ObjectHighLevelString, SYSTEMPREFIX, fullResponse)
-	if !session.ChatHistory.handleSystemMessage(sanitizedMessage, formattedResponse, session.ChatHistory.hashMessage(fullResponse)) {
-		// If it was not a system message or no existing system message was found to replace,
-		// add the new system message to the chat history.
-		session.ChatHistory.AddMessage(SYSTEMPREFIX, fullResponse, session.ChatConfig)
-	}
+	// The new summary replaces the previous one, while other system messages (e.g., notices) are kept.
+	session.recordMessage(SYSTEMPREFIX, fullResponse)
 }
 
 // handleTokenCount processes multiple file paths to count the number of tokens for each file.
@@ -132,6 +132,8 @@ func (h *handleSummarizeCommand) handleAIResponse(session *Session, sanitizedMes
 //
 //	apiKey string: The API key used for authenticating requests to the AI service.
 //	filePaths []string: A slice of file paths to be processed for token counting.
+//	estimateOnly bool: Whether to estimate the tokens offline instead of counting them with the API.
+//	output TokenCountOutput: The machine-readable format of the results, if any, replacing the progress messages.
 //
 // Returns:
 //
@@ -140,12 +142,28 @@ func (h *handleSummarizeCommand) handleAIResponse(session *Session, sanitizedMes
 //
 // Note: This approach simplifies maintenance and improvements by abstracting logic in this manner,
 // in contrast to less optimal practices where functions are made overly complex (e.g, stupid human) with excessive conditional statements.
-func (cmd *handleTokeCountingCommand) handleTokenCount(apiKey string, filePaths []string) (bool, error) {
+func (cmd *handleTokeCountingCommand) handleTokenCount(session *Session, apiKey string, filePaths []string, estimateOnly bool, output TokenCountOutput) (bool, error) {
 	var validFilePaths []string
 	totalTokenCount := 0
+	anyEstimated := false
+	report := TokenCountReport{Files: []TokenCountFile{}}
+	// Directories are expanded into the supported files they contain.
+	filePaths = expandTokenCountPaths(filePaths)
+
+	// Note: Ctrl+C or the deadline cancels the remaining files instead of ending the session.
+	ctx, done := session.beginTimedOperation(TokenCountCommandTimeout)
+	defer done()
+
 	// Note: This functionality may only be compatible with Go version 1.22 and onwards hahahaha.
 	// Additionally, while it may seem complex due to the 'if statement' error handling, it's not actually that complex.
-	for _, filePath := range filePaths {
+	for i, filePath := range filePaths {
+		if ctx.Err() != nil {
+			if !reportTimeout(ctx, TokenCountCommands, TokenCountCommandTimeout) {
+				logger.Any(InfoTokenCountCancelled, i, len(filePaths))
+			}
+			break
+		}
+
 		// Prepare the parameters for token counting based on the file type.
 		params, err := cmd.prepareTokenCountParams(apiKey, filePath)
 		if err != nil {
@@ -154,27 +172,95 @@ func (cmd *handleTokeCountingCommand) handleTokenCount(apiKey string, filePaths
 			continue
 		}
 
-		// Count the tokens using the prepared parameters.
-		tokenCount, err := params.CountTokens()
+		tokenCount, estimated, err := cmd.countFileTokens(ctx, session, i+1, len(filePaths), filePath, params, estimateOnly)
 		if err != nil {
-			// Log the error with the file path and error details.
-			logger.Error(ErrorFailedToCountTokens, filePath, err)
+			// The error was caused by the cancellation; report it at the top of the next iteration.
 			continue
 		}
 
 		// Add the valid file path to the list and accumulate the token count.
 		validFilePaths = append(validFilePaths, filePath)
 		totalTokenCount += tokenCount
+		anyEstimated = anyEstimated || estimated
+		report.Files = append(report.Files, TokenCountFile{Path: filePath, Tokens: tokenCount, Estimated: estimated})
+		if output.Format != "" {
+			continue // The results are written at the end instead.
+		}
+		if estimated {
+			logger.Any(InfoTokenCountEstimateProgress, i+1, len(filePaths), filePath, tokenCount, totalTokenCount)
+		} else {
+			logger.Any(InfoTokenCountProgress, i+1, len(filePaths), filePath, tokenCount, totalTokenCount)
+		}
+	}
+
+	if output.Format != "" {
+		report.TotalTokens, report.Estimated = totalTokenCount, anyEstimated
+		writeTokenCountReport(session.Output, report, output)
+		return false, nil
 	}
 
 	if len(validFilePaths) > 0 {
 		// Log the total token count for all valid files if any valid files were processed.
-		logger.Any(InfoTokenCountFile, strings.Join(validFilePaths, dotStringComma), totalTokenCount)
+		if anyEstimated {
+			logger.Any(InfoTokenCountFileEstimate, strings.Join(validFilePaths, dotStringComma), totalTokenCount)
+		} else {
+			logger.Any(InfoTokenCountFile, strings.Join(validFilePaths, dotStringComma), totalTokenCount)
+		}
 	}
 
 	return false, nil // The session continues regardless of token counting results.
 }
 
+// countFileTokens counts the tokens of a single prepared file.
+//
+// When estimateOnly is true, or when the API cannot be reached, the offline approximate tokenizer is used
+// and the returned estimated flag is true. An error is only returned if the operation was cancelled.
+func (cmd *handleTokeCountingCommand) countFileTokens(ctx context.Context, session *Session, index, total int, filePath string, params TokenCountParams, estimateOnly bool) (int, bool, error) {
+	if estimateOnly {
+		return params.EstimateTokens(), true, nil
+	}
+
+	// Count the tokens using the prepared parameters, skipping the API for unchanged files.
+	spinner := NewSpinner(session.Output, fmt.Sprintf(TokenCountSpinner, index, total, filePath))
+	spinner.Start()
+	tokenCount, err := countTokensCached(ctx, params)
+	spinner.Stop()
+	if err == nil {
+		return tokenCount, false, nil
+	}
+	if ctx.Err() != nil {
+		return 0, false, ctx.Err()
+	}
+
+	// Log the error with the file path and error details, then fall back to an offline estimate.
+	logger.Error(ErrorTokenCountFallbackToEstimate, filePath, err)
+	return params.EstimateTokens(), true, nil
+}
+
+// expandTokenCountPaths replaces every directory in paths with the supported files it contains, recursively.
+// Other paths are kept as-is, so errors for missing or unsupported files are still reported per file.
+func expandTokenCountPaths(paths []string) []string {
+	var expanded []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || !info.IsDir() {
+			expanded = append(expanded, path)
+			continue
+		}
+		filepath.WalkDir(path, func(filePath string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				logger.Error(ErrorFailedToReadFile, filePath, err)
+				return nil
+			}
+			if !entry.IsDir() && (verifyFileExtension(filePath) == nil || verifyImageFileExtension(filePath) == nil) {
+				expanded = append(expanded, filePath)
+			}
+			return nil
+		})
+	}
+	return expanded
+}
+
 // prepareTokenCountParams prepares the necessary parameters for counting tokens based on file type.
 // It differentiates between text and image files and sets up the parameters accordingly.
 //
@@ -194,10 +280,17 @@ func (cmd *handleTokeCountingCommand) prepareTokenCountParams(apiKey, filePath s
 	var params TokenCountParams
 	params.APIKey = apiKey
 
+	// Note: The file type is detected from its content, so a misnamed image is still counted as an image.
 	if isImage := verifyImageFileExtension(filePath) == nil; isImage {
 		if err := cmd.readImageFile(filePath, &params); err != nil {
 			return TokenCountParams{}, err
 		}
+	} else if hasImageFileExtension(filePath) {
+		// The extension claims an image, but the file is unreadable or its content is not a supported image.
+		if _, err := readFileHeader(filePath); err != nil {
+			return TokenCountParams{}, fmt.Errorf(ErrorFailedToReadFile, filePath, err)
+		}
+		return TokenCountParams{}, fmt.Errorf(ErrorImageContentMismatch, filePath)
 	} else {
 		if err := cmd.readTextFile(filePath, &params); err != nil {
 			return TokenCountParams{}, err
@@ -260,9 +353,9 @@ func (cmd *handleTokeCountingCommand) readTextFile(filePath string, params *Toke
 		return fmt.Errorf(ErrorFailedToReadFile, filePath, err) // low level in 2024
 	}
 	params.Input = string(fileContent)
-	// Note: This may change in the future, allowing users to utilize other models or depend on the current session model.
-	// Users can switch models using the :switchmodel <model-name> command
-	params.ModelName = GeminiPro
+	// Note: The tokens are counted with the model of the session, which users can switch with the :switchmodel <model-name> command,
+	// or configure with MODEL_NAME or "model" in the config file.
+	params.ModelName = currentModelName()
 	return nil
 }
 
@@ -270,7 +363,7 @@ func (cmd *handleTokeCountingCommand) readTextFile(filePath string, params *Toke
 //
 // Note: This is currently unstable and will be fixed later. The issue lies only with the prompt.
 func constructAITranslatePrompt(applicationName, command, text, targetLanguage string) string {
-	return fmt.Sprintf(AITranslateCommandPrompt,
+	return fmt.Sprintf(promptTemplate(PromptTemplateTranslate),
 		applicationName,
 		command,
 		text,
@@ -316,10 +409,59 @@ func postProcessAITranslate(session *Session, aiResponse string) error {
 	// Sanitize AI's response to remove any separators
 	aiResponse = sanitizeAIResponse(aiResponse)
 	// Add the sanitized AI's response to the chat history
-	session.ChatHistory.AddMessage(AiNerd, aiResponse, session.ChatConfig)
+	session.recordMessage(AiNerd, aiResponse)
 	return nil
 }
 
+// fetchModelInfo retrieves the metadata of a model, querying it through the model type
+// that matches its name. Embedding models (e.g., "embedding-001") are queried as
+// EmbeddingModel, while everything else is treated as a GenerativeModel.
+func fetchModelInfo(ctx context.Context, client *genai.Client, modelName string) (*genai.ModelInfo, error) {
+	if isEmbeddingModelName(modelName) {
+		return client.EmbeddingModel(modelName).Info(ctx)
+	}
+	return client.GenerativeModel(modelName).Info(ctx)
+}
+
+// fetchAllModelInfo retrieves the metadata of every model available to the client.
+func fetchAllModelInfo(ctx context.Context, client *genai.Client) ([]*genai.ModelInfo, error) {
+	var models []*genai.ModelInfo
+	iter := client.ListModels(ctx)
+	for {
+		info, err := iter.Next()
+		if err == iterator.Done {
+			return models, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		models = append(models, info)
+	}
+}
+
+// formatModelTable renders the given models as an aligned comparison table.
+func formatModelTable(models []*genai.ModelInfo) string {
+	var builder strings.Builder
+	table := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, ModelTableHeader)
+	for _, info := range models {
+		fmt.Fprintf(table, ModelTableRow,
+			strings.TrimPrefix(info.Name, ModelResourcePrefix),
+			info.Version,
+			info.InputTokenLimit,
+			info.OutputTokenLimit,
+			formatGenerationMethods(info.SupportedGenerationMethods),
+		)
+	}
+	table.Flush()
+	return builder.String()
+}
+
+// isEmbeddingModelName reports whether the model name refers to an embedding model.
+func isEmbeddingModelName(modelName string) bool {
+	return strings.Contains(strings.ToLower(modelName), EmbeddingModelKeyword)
+}
+
 // DisplayModelInfo formats and logs the information about a generative AI model.
 // It compiles the model's details into a single string and logs it using the
 // logger.Any method for a consistent logging experience.
@@ -332,13 +474,93 @@ func DisplayModelInfo(info *genai.ModelInfo) {
 	modelInfo := fmt.Sprintf(
 		ModelFormat,
 		info.DisplayName,
+		info.Name,
+		info.BaseModelID,
 		info.Version,
 		info.Description,
-		info.SupportedGenerationMethods,
+		formatGenerationMethods(info.SupportedGenerationMethods),
 		info.InputTokenLimit,
 		info.OutputTokenLimit,
+		info.Temperature,
+		formatOptionalFloat(info.MaxTemperature),
+		info.TopP,
+		formatTopK(info.TopK),
 	)
 
 	// Log the compiled model information.
 	logger.Any(modelInfo)
 }
+
+// DisplayGenerationConfig formats and logs the generation configuration of a model.
+// Fields that are not set are reported as using the model default.
+//
+// Parameters:
+//
+//	modelName string: The name of the model the configuration applies to.
+//	config *genai.GenerationConfig: The generation configuration to display.
+func DisplayGenerationConfig(modelName string, config *genai.GenerationConfig) {
+	genConfig := fmt.Sprintf(
+		GenerationConfigFormat,
+		modelName,
+		formatConfigFloat(config.Temperature),
+		formatConfigFloat(config.TopP),
+		formatConfigInt(config.TopK),
+		formatConfigInt(config.MaxOutputTokens),
+		formatConfigInt(config.CandidateCount),
+		formatStopSequences(config.StopSequences),
+	)
+
+	logger.Any(genConfig)
+}
+
+// formatConfigFloat formats an optional generation parameter, falling back to ModelDefault when unset.
+func formatConfigFloat(value *float32) string {
+	if value == nil {
+		return ModelDefault
+	}
+	return strconv.FormatFloat(float64(*value), 'g', -1, 32)
+}
+
+// formatConfigInt formats an optional generation parameter, falling back to ModelDefault when unset.
+func formatConfigInt(value *int32) string {
+	if value == nil {
+		return ModelDefault
+	}
+	return strconv.Itoa(int(*value))
+}
+
+// formatStopSequences formats the stop sequences as a quoted list, falling back to NotAvailable when none are set.
+func formatStopSequences(sequences []string) string {
+	if len(sequences) == 0 {
+		return NotAvailable
+	}
+	quoted := make([]string, len(sequences))
+	for i, sequence := range sequences {
+		quoted[i] = strconv.Quote(sequence)
+	}
+	return strings.Join(quoted, dotStringComma)
+}
+
+// formatGenerationMethods joins the supported generation methods into a readable list.
+func formatGenerationMethods(methods []string) string {
+	if len(methods) == 0 {
+		return NotAvailable
+	}
+	return strings.Join(methods, dotStringComma)
+}
+
+// formatOptionalFloat formats an optional float value, returning NotAvailable when it is not set.
+func formatOptionalFloat(value *float32) string {
+	if value == nil {
+		return NotAvailable
+	}
+	return strconv.FormatFloat(float64(*value), 'g', -1, 32)
+}
+
+// formatTopK formats the default top_k value. A zero value means the model does not use top-k sampling.
+func formatTopK(topK int32) string {
+	if topK == 0 {
+		return NotAvailable
+	}
+	return strconv.Itoa(int(topK))
+}
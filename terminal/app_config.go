@@ -0,0 +1,334 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: The application config is an optional JSON file (~/.gogenai/config.json by default).
+// Environment variables always take precedence over the file, and a missing file simply means the defaults are used.
+
+package terminal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DefaultAppConfig returns an AppConfig populated with safe defaults.
+func DefaultAppConfig() *AppConfig {
+	return &AppConfig{
+		AllowedFileExtensions: []string{dotMD, dotTxt},
+		RedactionProfiles:     []string{RedactionProfileSecrets, RedactionProfilePII},
+		MemoryFacts:           DefaultMemoryFacts,
+	}
+}
+
+// AppConfigPath returns the location of the application config file.
+// The GOGENAI_CONFIG environment variable overrides the default location in the user's home directory.
+func AppConfigPath() (string, error) {
+	if path := os.Getenv(AppConfigEnv); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, AppConfigDir, AppConfigFile), nil
+}
+
+// LoadAppConfig reads the application config file and applies environment variable overrides.
+//
+// Returns:
+//
+//	*AppConfig: The loaded configuration. The defaults are always included, so user settings can only extend them.
+//	error: An error if the config file exists but cannot be read or parsed.
+func LoadAppConfig() (*AppConfig, error) {
+	config := DefaultAppConfig()
+
+	path, err := AppConfigPath()
+	if err != nil {
+		return config, err
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		// No config file, keep the defaults.
+	case err != nil:
+		return config, fmt.Errorf(ErrorFailedToReadFile, path, err)
+	default:
+		// Note: Options whose zero value is meaningful keep their default when they are not in the file.
+		fileConfig := AppConfig{MemoryFacts: config.MemoryFacts}
+		if err = json.Unmarshal(data, &fileConfig); err != nil {
+			return config, fmt.Errorf(ErrorFailedToParseAppConfig, path, err)
+		}
+		config.addFileExtensions(fileConfig.AllowedFileExtensions...)
+		if fileConfig.RedactionProfiles != nil {
+			config.RedactionProfiles = normalizeList(fileConfig.RedactionProfiles)
+		}
+		config.AuditLog = fileConfig.AuditLog
+		config.SessionName = fileConfig.SessionName
+		config.APIKey = fileConfig.APIKey
+		config.Model = fileConfig.Model
+		config.SafetyLevel = fileConfig.SafetyLevel
+		config.Theme = fileConfig.Theme
+		config.CrashReports = fileConfig.CrashReports
+		config.Telemetry = fileConfig.Telemetry
+		config.TelemetryEndpoint = fileConfig.TelemetryEndpoint
+		config.AutoCompactPercent = fileConfig.AutoCompactPercent
+		config.TypingEffect = fileConfig.TypingEffect
+		config.ContextCache = fileConfig.ContextCache
+		config.MemoryFacts = fileConfig.MemoryFacts
+		config.ExtractMemoryFacts = fileConfig.ExtractMemoryFacts
+		config.CommandSafety = normalizeCommandSafety(fileConfig.CommandSafety)
+		config.HideStatusBar = fileConfig.HideStatusBar
+		config.Quiet = fileConfig.Quiet
+		config.Preflight = fileConfig.Preflight
+		config.PreflightTokens = fileConfig.PreflightTokens
+		config.DeduplicateUserMessages = fileConfig.DeduplicateUserMessages
+		config.Prompts = fileConfig.Prompts
+		config.HTTP = fileConfig.HTTP
+		config.RetryPolicy = fileConfig.RetryPolicy
+		config.WebSearch = fileConfig.WebSearch
+		config.Backup = fileConfig.Backup
+		config.AutoSummary = fileConfig.AutoSummary
+	}
+
+	if extensions := os.Getenv(AllowedFileExtensionsEnv); extensions != "" {
+		config.addFileExtensions(strings.Split(extensions, commaString)...)
+	}
+	if profiles := os.Getenv(RedactionProfilesEnv); profiles != "" {
+		config.RedactionProfiles = normalizeList(strings.Split(profiles, commaString))
+	}
+	if auditLog := os.Getenv(AuditLogEnv); auditLog != "" {
+		config.AuditLog = auditLog == "true"
+	}
+	if crashReports := os.Getenv(CrashReportsEnv); crashReports != "" {
+		config.CrashReports = crashReports == "true"
+	}
+	if telemetry := os.Getenv(TelemetryEnv); telemetry != "" {
+		config.Telemetry = telemetry == "true"
+	}
+	if endpoint := os.Getenv(TelemetryEndpointEnv); endpoint != "" {
+		config.TelemetryEndpoint = endpoint
+	}
+	if percent := os.Getenv(AutoCompactPercentEnv); percent != "" {
+		value, err := strconv.Atoi(percent)
+		if err != nil {
+			return config, fmt.Errorf(ErrorInvalidAutoCompactPercent, percent)
+		}
+		config.AutoCompactPercent = value
+	}
+	if config.AutoCompactPercent < 0 || config.AutoCompactPercent > 100 {
+		return config, fmt.Errorf(ErrorInvalidAutoCompactPercent, strconv.Itoa(config.AutoCompactPercent))
+	}
+	if typingEffect := os.Getenv(TypingEffectEnv); typingEffect != "" {
+		config.TypingEffect = typingEffect
+	}
+	switch config.TypingEffect {
+	case "", TypingEffectChar, TypingEffectWord, TypingEffectLine:
+	default:
+		return config, fmt.Errorf(ErrorInvalidTypingEffect, config.TypingEffect)
+	}
+	if facts := os.Getenv(MemoryFactsEnv); facts != "" {
+		value, err := strconv.Atoi(facts)
+		if err != nil {
+			return config, fmt.Errorf(ErrorInvalidMemoryFacts, facts)
+		}
+		config.MemoryFacts = value
+	}
+	if config.MemoryFacts < 0 {
+		return config, fmt.Errorf(ErrorInvalidMemoryFacts, strconv.Itoa(config.MemoryFacts))
+	}
+	if preflight := os.Getenv(PreflightEnv); preflight != "" {
+		config.Preflight = preflight
+	}
+	switch config.Preflight {
+	case "", PreflightEstimate, PreflightCount:
+	default:
+		return config, fmt.Errorf(ErrorInvalidPreflight, config.Preflight)
+	}
+	if tokens := os.Getenv(PreflightTokensEnv); tokens != "" {
+		value, err := strconv.Atoi(tokens)
+		if err != nil {
+			return config, fmt.Errorf(ErrorInvalidPreflightTokens, tokens)
+		}
+		config.PreflightTokens = value
+	}
+	if config.PreflightTokens < 0 {
+		return config, fmt.Errorf(ErrorInvalidPreflightTokens, strconv.Itoa(config.PreflightTokens))
+	}
+	if extract := os.Getenv(MemoryExtractEnv); extract != "" {
+		config.ExtractMemoryFacts = extract == "true"
+	}
+	if contextCache := os.Getenv(ContextCacheEnv); contextCache != "" {
+		config.ContextCache = contextCache == "true"
+	}
+	if statusBar := os.Getenv(StatusBarEnv); statusBar != "" {
+		config.HideStatusBar = statusBar == "false"
+	}
+	if quiet := os.Getenv(QuietEnv); quiet != "" {
+		config.Quiet = quiet == "true"
+	}
+	if model := os.Getenv(ModelNameEnv); model != "" {
+		config.Model = strings.TrimSpace(model)
+	}
+	if instruction := os.Getenv(SystemInstructionEnv); instruction != "" {
+		config.SystemInstruction = strings.TrimSpace(instruction)
+	}
+	if sessionName := os.Getenv(SessionNameEnv); sessionName != "" {
+		config.SessionName = sessionName
+	}
+	if engine := os.Getenv(WebSearchEnv); engine != "" {
+		if config.WebSearch == nil {
+			config.WebSearch = &WebSearchConfig{}
+		}
+		config.WebSearch.Engine = engine
+	}
+	if key := os.Getenv(WebSearchAPIKeyEnv); key != "" && config.WebSearch != nil {
+		config.WebSearch.APIKey = key
+	}
+	if err := applyRetryPolicyEnv(config); err != nil {
+		return config, err
+	}
+	if dir := os.Getenv(BackupDirEnv); dir != "" {
+		if config.Backup == nil {
+			config.Backup = &BackupConfig{}
+		}
+		config.Backup.Dir = dir
+	}
+	if messages := os.Getenv(AutoSummaryMessagesEnv); messages != "" {
+		value, err := strconv.Atoi(messages)
+		if err != nil {
+			return config, fmt.Errorf(ErrorInvalidAutoSummary, AutoSummaryMessagesEnv+" must be a number")
+		}
+		if config.AutoSummary == nil {
+			config.AutoSummary = &AutoSummaryConfig{}
+		}
+		config.AutoSummary.Messages = value
+	}
+
+	// Note: Unlike the file extensions, the redaction profiles replace the defaults, so they can be reduced.
+	// The secrets profile is applied regardless (see NewRedactionFilter).
+	if err := validateRedactionProfiles(config.RedactionProfiles); err != nil {
+		return DefaultAppConfig(), err
+	}
+	if err := validatePromptTemplates(config.Prompts); err != nil {
+		return DefaultAppConfig(), err
+	}
+	if err := validateCommandSafety(config.CommandSafety); err != nil {
+		return DefaultAppConfig(), err
+	}
+	if err := validateWebSearch(config.WebSearch); err != nil {
+		return DefaultAppConfig(), err
+	}
+	if err := validateBackup(config.Backup); err != nil {
+		return DefaultAppConfig(), err
+	}
+	if err := validateAutoSummary(config.AutoSummary); err != nil {
+		return DefaultAppConfig(), err
+	}
+
+	return config, nil
+}
+
+// loadAppConfigOrDefault loads the application config and falls back to the defaults on error,
+// so a broken config file never prevents the application from starting.
+func loadAppConfigOrDefault() *AppConfig {
+	config, err := LoadAppConfig()
+	if err != nil {
+		logger.Error(ErrorFailedToLoadAppConfig, err)
+		return DefaultAppConfig()
+	}
+	return config
+}
+
+// addFileExtensions normalizes the given extensions (lowercase with a leading dot)
+// and adds the ones that are not allowed yet.
+func (c *AppConfig) addFileExtensions(extensions ...string) {
+	for _, ext := range extensions {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, dotString) {
+			ext = dotString + ext
+		}
+		if !slices.Contains(c.AllowedFileExtensions, ext) {
+			c.AllowedFileExtensions = append(c.AllowedFileExtensions, ext)
+		}
+	}
+}
+
+// IsAllowedFileExtension reports whether the given extension (including the leading dot) is allowed.
+func (c *AppConfig) IsAllowedFileExtension(ext string) bool {
+	return slices.Contains(c.AllowedFileExtensions, strings.ToLower(ext))
+}
+
+// normalizeList lowercases and trims the values and drops empty ones.
+func normalizeList(values []string) []string {
+	normalized := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.ToLower(strings.TrimSpace(value)); value != "" {
+			normalized = append(normalized, value)
+		}
+	}
+	return normalized
+}
+
+// updateAppConfigFile reads the config file as written by the user (without defaults or environment overrides),
+// applies the update and writes it back with owner-only permissions. It returns the path of the config file.
+func updateAppConfigFile(update func(config *AppConfig)) (string, error) {
+	path, err := AppConfigPath()
+	if err != nil {
+		return "", err
+	}
+
+	var config AppConfig
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		// First run, start from an empty config.
+	case err != nil:
+		return "", fmt.Errorf(ErrorFailedToReadFile, path, err)
+	default:
+		if err = json.Unmarshal(data, &config); err != nil {
+			return "", fmt.Errorf(ErrorFailedToParseAppConfig, path, err)
+		}
+	}
+
+	update(&config)
+	if data, err = json.MarshalIndent(config, "", "  "); err != nil {
+		return "", err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, data, 0600)
+}
+
+// applyTheme replaces the package colors with the colors of the named theme. Unknown themes are ignored.
+func applyTheme(name string) {
+	if theme, ok := themes[name]; ok {
+		colors = theme
+	} else if name != "" {
+		logger.Debug(DebugUnknownTheme, name)
+	}
+}
+
+// themeNames returns the names of the available themes, the default theme first.
+func themeNames() []string {
+	names := []string{DefaultTheme}
+	for name := range themes {
+		if name != DefaultTheme {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names[1:])
+	return names
+}
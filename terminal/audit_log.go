@@ -0,0 +1,139 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: The audit log is a JSON Lines file next to the application config. It is written through a command registry hook,
+// so every command is recorded without changing the command handlers. Secrets in the arguments are masked before writing.
+
+package terminal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// NewAuditLog creates an AuditLog that appends to the file at path.
+func NewAuditLog(path string) *AuditLog {
+	// Note: The secrets profile is always valid, so the error can be ignored.
+	filter, _ := NewRedactionFilter(RedactionProfileSecrets)
+	return &AuditLog{
+		path:   path,
+		filter: filter,
+	}
+}
+
+// defaultAuditLog creates the audit log stored next to the application config file.
+// It returns nil if the audit log is disabled or its location cannot be determined.
+func defaultAuditLog() *AuditLog {
+	if !appConfig.AuditLog {
+		return nil
+	}
+	configPath, err := AppConfigPath()
+	if err != nil {
+		return nil
+	}
+	return NewAuditLog(filepath.Join(filepath.Dir(configPath), AuditLogFile))
+}
+
+// Record appends the command event to the audit log. It is used as a CommandHook.
+// Failures are only reported in debug mode, since auditing must never break a command.
+func (a *AuditLog) Record(event CommandEvent) {
+	args, _, _ := a.filter.Apply(strings.Join(event.Args, " "))
+	if event.Name == IncognitoCommand {
+		args = IncognitoArgsOmitted // The prompt must not be kept anywhere.
+	}
+	entry := AuditEntry{
+		Time:       event.Time.Format(time.RFC3339),
+		Command:    event.Name,
+		Args:       args,
+		Outcome:    event.Outcome(),
+		DurationMs: event.Duration.Milliseconds(),
+	}
+
+	if err := a.append(entry); err != nil {
+		logger.Debug(DebugAuditLogNotWritten, a.path, err)
+	}
+}
+
+// append writes a single entry as one JSON line.
+func (a *AuditLog) append(entry AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(a.path), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(a.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Entries returns the last limit entries of the audit log, oldest first.
+// Lines that cannot be parsed are skipped. A missing file results in no entries.
+func (a *AuditLog) Entries(limit int) ([]AuditEntry, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	file, err := os.Open(a.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+			continue
+		}
+		entries = append(entries, entry)
+		if len(entries) > limit {
+			entries = entries[1:]
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// Outcome describes how the command ended, for the audit log.
+func (e CommandEvent) Outcome() string {
+	switch {
+	case !e.Recognized:
+		return AuditOutcomeUnrecognized
+	case e.Err != nil:
+		return fmt.Sprintf(AuditOutcomeError, e.Err)
+	case e.EndsSession:
+		return AuditOutcomeEndedSession
+	default:
+		return AuditOutcomeOK
+	}
+}
+
+// formatAuditEntries formats audit entries for display.
+func formatAuditEntries(entries []AuditEntry) string {
+	var builder strings.Builder
+	for _, entry := range entries {
+		fmt.Fprintf(&builder, AuditEntryFormat, entry.Time, entry.Command, entry.Args, entry.Outcome, entry.DurationMs)
+	}
+	return builder.String()
+}
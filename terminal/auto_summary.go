@@ -0,0 +1,98 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: The background summary is ":compact" scheduled by the worker (see ChatWorker.Start): once the chat history
+// grows beyond the limits of the auto summary config, the main loop summarizes the oldest messages between prompts,
+// without printing the summary, and replaces them with it, pinned as a system message. This keeps the tokens sent
+// with every message bounded over a long session, while AUTO_COMPACT_PERCENT only acts right before a message would
+// not fit.
+
+package terminal
+
+import (
+	"fmt"
+	"time"
+)
+
+// validateAutoSummary checks that the auto summary config has a limit, no negative values, and keeps fewer
+// messages than its message limit.
+func validateAutoSummary(config *AutoSummaryConfig) error {
+	switch {
+	case config == nil:
+		return nil
+	case config.Messages < 0 || config.Tokens < 0 || config.IntervalMinutes < 0 || config.Keep < 0:
+		return fmt.Errorf(ErrorInvalidAutoSummary, "messages, tokens, interval_minutes and keep must not be negative")
+	case config.Messages == 0 && config.Tokens == 0:
+		return fmt.Errorf(ErrorInvalidAutoSummary, "messages, tokens or "+AutoSummaryMessagesEnv+" is required")
+	case config.Messages > 0 && config.Messages <= autoSummaryKeep(config):
+		return fmt.Errorf(ErrorInvalidAutoSummary, "messages must be more than keep")
+	}
+	return nil
+}
+
+// autoSummaryInterval returns the time between two checks of the chat history.
+func autoSummaryInterval(config *AutoSummaryConfig) time.Duration {
+	if config.IntervalMinutes > 0 {
+		return time.Duration(config.IntervalMinutes) * time.Minute
+	}
+	return DefaultAutoSummaryInterval
+}
+
+// autoSummaryKeep returns the number of most recent user and AI messages that are not summarized.
+func autoSummaryKeep(config *AutoSummaryConfig) int {
+	if config.Keep > 0 {
+		return config.Keep
+	}
+	return DefaultAutoSummaryKeep
+}
+
+// summaryDue reports whether the chat history exceeds the message or token limit of the config.
+// A history with no more messages than are kept is never due, since there is nothing to summarize.
+func (h *ChatHistory) summaryDue(config *AutoSummaryConfig) bool {
+	stats := h.GetMessageStats()
+	messages := stats.UserMessages + stats.AIMessages
+	if messages <= autoSummaryKeep(config) {
+		return false
+	}
+	return (config.Messages > 0 && messages > config.Messages) ||
+		(config.Tokens > 0 && h.estimateHistoryTokens() > config.Tokens)
+}
+
+// runDueSummary submits the summary of the oldest messages to the main loop (see runAutoSummary) once the interval of
+// the auto summary config has passed since the last check and the chat history exceeds its limits. The worker only
+// reads the chat history, which has its own lock; the summary itself runs between prompts, like a typed line.
+func (cw *ChatWorker) runDueSummary(now time.Time) {
+	config := appConfig.AutoSummary
+	if config == nil || now.Sub(cw.lastSummary) < autoSummaryInterval(config) {
+		return
+	}
+	cw.lastSummary = now
+	if !cw.session.ChatHistory.summaryDue(config) {
+		return
+	}
+	cw.session.Input.Submit(AutoSummaryQueueLabel, func(s *Session) bool {
+		s.runAutoSummary(config)
+		return false // Continue the session.
+	})
+}
+
+// runAutoSummary summarizes the oldest messages and replaces them with a pinned summary, if the chat history still
+// exceeds the limits of the config. Viewer sessions, dry runs, and sessions without a client are never summarized.
+func (s *Session) runAutoSummary(config *AutoSummaryConfig) {
+	if s.ViewOnly || s.DryRun || s.Client == nil || !s.ChatHistory.summaryDue(config) {
+		return
+	}
+
+	// Note: Ctrl+C or the deadline cancels the summary instead of waiting for a stalled API.
+	ctx, done := s.beginTimedOperation(SummarizeTimeout)
+	defer done()
+	result, err := s.compactHistory(ctx, autoSummaryKeep(config))
+	if err != nil {
+		if !reportTimeout(ctx, CompactCommand, SummarizeTimeout) {
+			logger.Error(ErrorFailedToAutoSummarize, err)
+		}
+		return
+	}
+	s.notify(InfoAutoSummarized, result.Removed, result.TokensBefore, result.TokensAfter)
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: Backups are written by the background worker (see ChatWorker.Start), so the chat history survives a crash even
+// when it is only kept in memory. A backup has the format of a stored session, and is only written if the history
+// changed since the previous one.
+
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// validateBackup checks that the backup config names a directory and has no negative values.
+func validateBackup(config *BackupConfig) error {
+	switch {
+	case config == nil:
+		return nil
+	case config.Dir == "":
+		return fmt.Errorf(ErrorInvalidBackup, "dir or "+BackupDirEnv+" is required")
+	case config.IntervalMinutes < 0:
+		return fmt.Errorf(ErrorInvalidBackup, "interval_minutes must not be negative")
+	case config.Keep < 0:
+		return fmt.Errorf(ErrorInvalidBackup, "keep must not be negative")
+	}
+	return nil
+}
+
+// backupInterval returns the time between two backups.
+func backupInterval(config *BackupConfig) time.Duration {
+	if config.IntervalMinutes > 0 {
+		return time.Duration(config.IntervalMinutes) * time.Minute
+	}
+	return DefaultBackupInterval
+}
+
+// runDueBackup backs up the chat history once the interval of the backup config has passed since the last backup,
+// and removes the oldest backups beyond the number to keep. Viewer sessions are never backed up.
+func (cw *ChatWorker) runDueBackup(now time.Time) {
+	config := appConfig.Backup
+	if config == nil || cw.session.ViewOnly || now.Sub(cw.lastBackup) < backupInterval(config) {
+		return
+	}
+	cw.lastBackup = now
+
+	snapshot := cw.session.ChatHistory.snapshot()
+	digest := hashMessages(snapshot.messages)
+	if len(snapshot.messages) == 0 || digest == cw.backupDigest {
+		return
+	}
+	name := MemoryBackupName
+	if cw.session.Store != nil {
+		name = cw.session.Store.name
+	}
+	path, err := writeBackup(config.Dir, name, snapshot, now)
+	if err != nil {
+		logger.Error(ErrorFailedToBackupSession, config.Dir, err)
+		return
+	}
+	cw.backupDigest = digest
+	logger.Debug(DebugSessionBackedUp, path)
+
+	keep := config.Keep
+	if keep == 0 {
+		keep = DefaultBackupKeep
+	}
+	pruneBackups(config.Dir, name, keep)
+}
+
+// writeBackup writes the snapshot to a new backup file of the session in dir and returns its path.
+func writeBackup(dir, name string, snapshot chatHistorySnapshot, now time.Time) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	data, err := marshalStoredSession(snapshot, nil)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, name+"-"+now.Format(BackupTimeFormat)+SessionFileExtension)
+	return path, writeSessionFile(path, data)
+}
+
+// backupFiles returns the paths of the backup files of the session in dir, oldest first.
+// Files of other sessions whose name starts with the same prefix are not included.
+func backupFiles(dir, name string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, entry := range entries {
+		stamp, ok := strings.CutPrefix(entry.Name(), name+"-")
+		if !ok || !strings.HasSuffix(stamp, SessionFileExtension) {
+			continue
+		}
+		if _, err := time.Parse(BackupTimeFormat, strings.TrimSuffix(stamp, SessionFileExtension)); err == nil {
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+	// The time format sorts in chronological order.
+	slices.Sort(paths)
+	return paths
+}
+
+// pruneBackups removes the oldest backup files of the session in dir, keeping the most recent ones.
+func pruneBackups(dir, name string, keep int) {
+	paths := backupFiles(dir, name)
+	for _, path := range paths[:max(len(paths)-keep, 0)] {
+		if err := os.Remove(path); err != nil {
+			logger.Debug(DebugBackupNotRemoved, path, err)
+		}
+	}
+}
@@ -54,45 +54,32 @@ func (h *ChatHistory) AddMessage(user string, text string, config *ChatConfig) {
 	h.mu.Lock()         // Lock for writing
 	defer h.mu.Unlock() // Unlock when the function returns
 
+	h.addMessage(user, text, config)
+}
+
+// addMessage is AddMessage for a caller that already holds the lock (see HistoryTransaction.Commit).
+func (h *ChatHistory) addMessage(user string, text string, config *ChatConfig) {
 	// Sanitize and format the message before adding it to the history of RAM's labyrinth.
 	sanitizedText := h.SanitizeMessage(text)
 	message := fmt.Sprintf(ObjectHighLevelStringWithNewLine, user, sanitizedText) // Add newlines around the message
-	hashValue := h.hashMessage(sanitizedText)
+	// Note: The hash covers the formatted message, like every other lookup in Hashes (see reindexHashes).
+	hashValue := h.hashMessage(message)
 	messageType := DetermineMessageType(sanitizedText)
 
 	// Delegate message handling based on type.
 	// Note: This becomes easier to maintain by Go routines.
-	switch messageType {
-	case SystemMessage:
-		h.handleSystemMessage(sanitizedText, message, hashValue)
-	case AIMessage:
+	switch {
+	case user == SYSTEMPREFIX || messageType == SystemMessage:
+		h.addSystemMessage(message)
+	case messageType == AIMessage:
 		h.handleAIMessage(message, hashValue)
 	default:
-		h.handleUserMessage(user, message, hashValue)
+		h.handleUserMessage(user, message, hashValue, config.DeduplicateUserMessages)
 	}
 	// Check if the message hash already exists to prevent duplicates
 	h.manageHistorySize(config)
 }
 
-// handleSystemMessage checks and replaces an existing system message.
-// Returns true if a system message was handled (either replaced or identified for addition).
-func (h *ChatHistory) handleSystemMessage(sanitizedText, message, hashValue string) bool {
-	if !isSysMessage(sanitizedText) {
-		return false // Not a system message, no action required.
-	}
-	// Warning!!! Explicit 🤪
-	h.mu.Lock()         // Lock for writing
-	defer h.mu.Unlock() // Ensure unlocking
-	// Check if there is an existing system message
-	if h.isExistingSysMessage(hashValue) {
-		h.replaceExistingSysMessage(message, hashValue)
-	} else {
-		h.addNewSysMessage(message, hashValue)
-	}
-	h.cleanupOldSysMessages()
-	return true // Indicate a system message was handled.
-}
-
 // handleAIMessage is responsible for processing an AI message.
 func (h *ChatHistory) handleAIMessage(message, hashValue string) {
 	// Note: The mu (explicit) was removed from this function as it is already
@@ -104,9 +91,10 @@ func (h *ChatHistory) handleAIMessage(message, hashValue string) {
 	}
 }
 
-// handleUserMessage processes a user message.
-func (h *ChatHistory) handleUserMessage(user, message, hashValue string) {
-	if _, exists := h.Hashes[hashValue]; exists {
+// handleUserMessage processes a user message. A repeated message is only dropped if deduplication is enabled,
+// otherwise the hash refers to its latest occurrence.
+func (h *ChatHistory) handleUserMessage(user, message, hashValue string, deduplicate bool) {
+	if _, exists := h.Hashes[hashValue]; exists && deduplicate {
 		return
 	}
 
@@ -117,22 +105,13 @@ func (h *ChatHistory) handleUserMessage(user, message, hashValue string) {
 // updateMessageCounts updates the message counts based on the user.
 func (h *ChatHistory) updateMessageCounts(user string) {
 
-	if user == SYSTEMPREFIX {
-		h.updateSystemMessageCount()
-	} else if user == AiNerd {
+	if user == AiNerd {
 		h.AIMessageCount++
 	} else if user == YouNerd {
 		h.UserMessageCount++
 	}
 }
 
-// updateSystemMessageCount increments the system message count if it's currently zero.
-func (h *ChatHistory) updateSystemMessageCount() {
-	if h.SystemMessageCount == 0 {
-		h.SystemMessageCount = 1
-	}
-}
-
 // addMessageToHistory adds a message to the history.
 func (h *ChatHistory) addMessageToHistory(message, hashValue string) {
 	// Note: this remove the oldest message are automated handle by Garbage Collector.
@@ -141,49 +120,39 @@ func (h *ChatHistory) addMessageToHistory(message, hashValue string) {
 	h.Hashes[hashValue] = len(h.Messages) - 1 // Map the hash to the new message index
 }
 
-// isExistingSysMessage checks if there is an existing system message with the same hash.
-func (h *ChatHistory) isExistingSysMessage(hashValue string) bool {
-	_, exists := h.Hashes[hashValue]
-	return exists && isSysMessage(h.Messages[h.Hashes[hashValue]])
-}
-
-// replaceExistingSysMessage replaces the existing system message with the new one.
-func (h *ChatHistory) replaceExistingSysMessage(message, hashValue string) {
-	existingIndex := h.Hashes[hashValue]
-	h.Messages[existingIndex] = message
-}
-
-// addNewSysMessage adds a new system message to the history.
-func (h *ChatHistory) addNewSysMessage(message, hashValue string) {
-	h.Messages = append(h.Messages, message)
-	h.Hashes[hashValue] = len(h.Messages) - 1
-}
-
-// cleanupOldSysMessages removes older system messages from the history.
-func (h *ChatHistory) cleanupOldSysMessages() {
-	// Search for the last system message from the end of the slice.
-	// Note: This only work in go 1.22
-	for i := range h.Messages {
-		idx := len(h.Messages) - 1 - i // Calculate the index from the end.
-		if isSysMessage(h.Messages[idx]) {
-			// Remove the system message.
-			h.Messages = append(h.Messages[:idx], h.Messages[idx+1:]...)
-			break // Assuming only one system message exists at a time.
-		}
-	}
-}
-
 // manageHistorySize manages the size of the chat history based on the ChatConfig.
 func (h *ChatHistory) manageHistorySize(config *ChatConfig) {
 	// Remove the oldest two messages (one user and one AI) to maintain a fixed history size in RAM's labyrinth.
 	// Note: The fixed history size might be increased in the future. Currently, the application's memory usage is minimal, consuming only 16 MB (Average).
 	// then keep a maximum of 10 history entries for transmission to Google AI.
+	if len(h.Messages) <= config.HistorySize*2 {
+		return
+	}
 	for len(h.Messages) > config.HistorySize*2 {
-		oldestUserHash := h.hashMessage(h.Messages[0])
-		oldestAIHash := h.hashMessage(h.Messages[1])
-		delete(h.Hashes, oldestUserHash) // Remove the hash of the oldest user message
-		delete(h.Hashes, oldestAIHash)   // Remove the hash of the oldest AI message
-		h.Messages = h.Messages[2:]      // Remove the oldest two messages
+		h.Messages = h.Messages[2:] // Remove the oldest two messages
+	}
+	// The remaining messages moved, so their indices must be updated as well.
+	h.reindexHashes()
+}
+
+// Trim removes the oldest messages beyond the history size of the config, as AddMessage does after adding
+// a message. It returns the number of removed messages.
+func (h *ChatHistory) Trim(config *ChatConfig) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	before := len(h.Messages)
+	h.manageHistorySize(config)
+	h.recountMessages()
+	return before - len(h.Messages)
+}
+
+// reindexHashes rebuilds Hashes from Messages, so every hash refers to the current index of its message.
+// It must be called after messages are removed, since that shifts the indices of the following messages.
+// For repeated messages, the hash refers to the latest occurrence. The caller must hold the lock.
+func (h *ChatHistory) reindexHashes() {
+	h.Hashes = make(map[string]int, len(h.Messages))
+	for i, message := range h.Messages {
+		h.Hashes[h.hashMessage(message)] = i
 	}
 }
 
@@ -200,8 +169,8 @@ func (h *ChatHistory) SanitizeMessage(message string) string {
 	// This better way to sanitize message instead of struct again.
 	// It fix truncated message about color codes.
 	// Note: now more Simplicity and yet powerful.
-	// Remove all ANSI color codes from the message.
-	return ansiRegex.ReplaceAllString(message, "")
+	// Remove all ANSI escape sequences (not only the color codes) and control characters from the message.
+	return stripControlSequences(message)
 }
 
 // GetHistory concatenates all messages in the chat history into a single
@@ -228,6 +197,27 @@ func (h *ChatHistory) GetHistory(config *ChatConfig) string {
 	return h.buildHistoryString(historySubset)
 }
 
+// contextChatMessages returns the chat messages of the history sent as context (see GetHistory), oldest first,
+// without the system messages.
+func (h *ChatHistory) contextChatMessages(config *ChatConfig) []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	_, chatMsgs := h.separateSystemMessages(h.Messages[max(0, len(h.Messages)-config.HistorySize):])
+	return chatMsgs
+}
+
+// getHistoryWithout returns the chat history like GetHistory, without its drop oldest chat messages.
+// The system messages are always kept, since each is the latest of its category (e.g., the summary).
+func (h *ChatHistory) getHistoryWithout(config *ChatConfig, drop int) string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	sysMsgs, chatMsgs := h.separateSystemMessages(h.Messages[max(0, len(h.Messages)-config.HistorySize):])
+	builder := strings.Builder{}
+	h.appendSystemMessages(&builder, sysMsgs)
+	h.appendChatMessages(&builder, chatMsgs[min(drop, len(chatMsgs)):])
+	return builder.String()
+}
+
 // buildHistoryString builds the chat history string from a subset of messages.
 func (h *ChatHistory) buildHistoryString(historySubset []string) string {
 	// Use a strings.Builder to build the chat history string efficiently.
@@ -242,24 +232,13 @@ func (h *ChatHistory) buildHistoryString(historySubset []string) string {
 }
 
 // appendSystemMessages appends system messages to the StringBuilder.
+// Every system message is included, since each category only keeps its most recent messages (see addSystemMessage).
 func (h *ChatHistory) appendSystemMessages(builder *strings.Builder, sysMsgs []string) {
-	// Keep track of the latest system message index
-	latestSysMsgIndex := -1
-
-	for i, sysMsg := range sysMsgs {
+	for _, sysMsg := range sysMsgs {
 		if !isSysMessage(sysMsg) {
 			continue // Skip non-system messages
 		}
 
-		// Check if this system message is the latest one
-		if latestSysMsgIndex == -1 {
-			latestSysMsgIndex = i
-		} else {
-			// Remove the older system message
-			builder.Reset()
-			latestSysMsgIndex = i
-		}
-
 		builder.WriteString(sysMsg)
 		builder.WriteRune(nl.NewLineChars)
 		builder.WriteString(StripChars)    // Append the separator
@@ -337,8 +316,15 @@ func isSysMessage(message string) bool {
 
 // hashMessage generates a SHA-256 hash for a given message.
 func (h *ChatHistory) hashMessage(message string) string {
+	return hashSHA256([]byte(message))
+}
+
+// hashSHA256 generates a hex-encoded SHA-256 hash over the given data, written in order.
+func hashSHA256(data ...[]byte) string {
 	hasher := sha256.New()
-	hasher.Write([]byte(message))
+	for _, d := range data {
+		hasher.Write(d)
+	}
 	return hex.EncodeToString(hasher.Sum(nil))
 }
 
@@ -376,12 +362,10 @@ func (h *ChatHistory) removeMessagesByContent(content string) {
 	for _, message := range h.Messages {
 		if !strings.Contains(message, content) {
 			newMessages = append(newMessages, message)
-		} else {
-			// Remove the hash of the message being removed.
-			delete(h.Hashes, h.hashMessage(message))
 		}
 	}
 	h.Messages = newMessages
+	h.reindexHashes()
 }
 
 // removeRecentMessages removes the specified number of most recent messages.
@@ -390,11 +374,9 @@ func (h *ChatHistory) removeRecentMessages(num int) {
 	if numToRemove == 0 {
 		return
 	}
-	// Remove hashes of messages being removed.
-	for _, message := range h.Messages[len(h.Messages)-numToRemove:] {
-		delete(h.Hashes, h.hashMessage(message))
-	}
 	h.Messages = h.Messages[:len(h.Messages)-numToRemove]
+	// Note: Rebuilding rather than deleting the removed hashes keeps repeated messages that are still present.
+	h.reindexHashes()
 }
 
 // FilterMessages returns a slice of messages that match the predicate function.
@@ -450,22 +432,24 @@ func (h *ChatHistory) ClearAllSystemMessages() {
 	h.mu.Lock()         // Lock for writing
 	defer h.mu.Unlock() // Ensure unlocking
 
+	h.clearAllSystemMessages()
+}
+
+// clearAllSystemMessages is ClearAllSystemMessages for a caller that already holds the lock.
+func (h *ChatHistory) clearAllSystemMessages() {
 	var newMessages []string
-	var newHashes = make(map[string]int)
 	h.SystemMessageCount = 0 // Reset the system message count
 
 	for _, message := range h.Messages {
 		if !isSysMessage(message) {
 			// This is not a system message; keep it.
 			newMessages = append(newMessages, message)
-			hashValue := h.hashMessage(message)
-			newHashes[hashValue] = len(newMessages) - 1
 		}
 	}
 
 	// Replace the old Messages and Hashes with the new ones that exclude system messages.
 	h.Messages = newMessages
-	h.Hashes = newHashes
+	h.reindexHashes()
 }
 
 // GetMessageStats safely retrieves the message counts from the ChatHistory instance.
@@ -0,0 +1,108 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+
+package terminal
+
+import (
+	"fmt"
+	"testing"
+)
+
+// newTestChatHistory returns a chat history with the given number of exchanges, and a user message repeated in
+// the first and last exchange.
+func newTestChatHistory(t *testing.T, exchanges int) *ChatHistory {
+	t.Helper()
+	h := NewChatHistory()
+	config := &ChatConfig{HistorySize: 100}
+	for i := 0; i < exchanges; i++ {
+		question := fmt.Sprintf("question %d", i)
+		if i == exchanges-1 {
+			question = "question 0"
+		}
+		h.AddMessage(YouNerd, question, config)
+		h.AddMessage(AiNerd, fmt.Sprintf("answer %d", i), config)
+	}
+	return h
+}
+
+// checkHashIndex checks that every hash refers to a message with that hash, that every message is indexed at its
+// latest occurrence, and that none of the removed messages is still indexed.
+func checkHashIndex(t *testing.T, h *ChatHistory, removed []string) {
+	t.Helper()
+	present := make(map[string]bool, len(h.Messages))
+	for i, message := range h.Messages {
+		hash := h.hashMessage(message)
+		present[hash] = true
+		if index, ok := h.Hashes[hash]; !ok || index < i {
+			t.Errorf("message %d %q is indexed at %d (indexed: %v)", i, message, index, ok)
+		}
+	}
+	for hash, index := range h.Hashes {
+		if index < 0 || index >= len(h.Messages) {
+			t.Errorf("hash %s refers to index %d, out of %d messages", hash, index, len(h.Messages))
+			continue
+		}
+		if got := h.hashMessage(h.Messages[index]); got != hash {
+			t.Errorf("hash %s refers to message %d %q, whose hash is %s", hash, index, h.Messages[index], got)
+		}
+	}
+	for _, message := range removed {
+		hash := h.hashMessage(message)
+		if _, ok := h.Hashes[hash]; ok && !present[hash] {
+			t.Errorf("removed message %q is still indexed", message)
+		}
+	}
+}
+
+func TestRemoveRecentMessagesReindexesHashes(t *testing.T) {
+	h := newTestChatHistory(t, 4)
+	removed := append([]string(nil), h.Messages[len(h.Messages)-3:]...)
+
+	h.RemoveMessages(3, "")
+
+	if len(h.Messages) != 5 {
+		t.Fatalf("got %d messages, want 5", len(h.Messages))
+	}
+	checkHashIndex(t, h, removed)
+	// The repeated question was removed at the end, but its first occurrence is still indexed.
+	first := h.Messages[0]
+	if index := h.Hashes[h.hashMessage(first)]; index != 0 {
+		t.Errorf("repeated message %q is indexed at %d, want 0", first, index)
+	}
+}
+
+func TestRemoveMessagesByContentReindexesHashes(t *testing.T) {
+	h := newTestChatHistory(t, 4)
+	removed := []string{h.Messages[1], h.Messages[3]}
+
+	h.RemoveMessages(0, "answer 0")
+	h.RemoveMessages(0, "answer 1")
+
+	if len(h.Messages) != 6 {
+		t.Fatalf("got %d messages, want 6", len(h.Messages))
+	}
+	checkHashIndex(t, h, removed)
+}
+
+func TestRemovingEveryMessageEmptiesHashes(t *testing.T) {
+	h := newTestChatHistory(t, 2)
+	removed := append([]string(nil), h.Messages...)
+
+	h.RemoveMessages(len(h.Messages)+1, "")
+
+	if len(h.Messages) != 0 || len(h.Hashes) != 0 {
+		t.Fatalf("got %d messages and %d hashes, want none", len(h.Messages), len(h.Hashes))
+	}
+	checkHashIndex(t, h, removed)
+}
+
+func TestTrimReindexesHashes(t *testing.T) {
+	h := newTestChatHistory(t, 5)
+	removed := append([]string(nil), h.Messages[:4]...)
+
+	if n := h.Trim(&ChatConfig{HistorySize: 3}); n != 4 {
+		t.Fatalf("Trim removed %d messages, want 4", n)
+	}
+	checkHashIndex(t, h, removed)
+}
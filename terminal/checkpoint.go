@@ -0,0 +1,53 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: Checkpoints are kept in memory only and end with the session. A checkpoint can be restored any number of times,
+// so it is possible to try a question one way, go back, and try it again differently.
+// Restoring a checkpoint in a named session also overwrites the stored history, like any other change to the history.
+
+package terminal
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// saveCheckpoint stores a copy of the current chat history under the name, replacing any checkpoint with the same name.
+func (s *Session) saveCheckpoint(name string) {
+	if s.checkpoints == nil {
+		s.checkpoints = make(map[string]chatHistorySnapshot)
+	}
+	s.checkpoints[name] = s.ChatHistory.snapshot()
+}
+
+// restoreCheckpoint replaces the chat history with the named checkpoint.
+// The checkpoint is copied, so it stays unchanged by the messages added afterwards.
+func (s *Session) restoreCheckpoint(name string) error {
+	checkpoint, ok := s.checkpoints[name]
+	if !ok {
+		return fmt.Errorf(ErrorCheckpointNotFound, name)
+	}
+	checkpoint.messages = slices.Clone(checkpoint.messages)
+	checkpoint.hashes = maps.Clone(checkpoint.hashes)
+	s.ChatHistory.restore(checkpoint)
+	s.saveHistory()
+	return nil
+}
+
+// formatCheckpoints lists the checkpoints sorted by name, with the number of messages in each.
+func formatCheckpoints(checkpoints map[string]chatHistorySnapshot) string {
+	names := make([]string, 0, len(checkpoints))
+	for name := range checkpoints {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var builder strings.Builder
+	for _, name := range names {
+		builder.WriteString(fmt.Sprintf(CheckpointFormat, name, len(checkpoints[name].messages)))
+	}
+	return builder.String()
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: This is the single place where large inputs are split for the AI. Commands such as ":explain",
+// ":summarize :file", translation and RAG indexing should use ChunkText/ChunkFile instead of rolling their own splitting.
+// Token sizes are measured with the offline EstimateTokens, so chunking never calls the API.
+
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// chunkUnitRegex matches a word together with its trailing whitespace. Chunks are built from these units
+// so that words are never cut in half.
+var chunkUnitRegex = regexp.MustCompile(`\s*\S+\s*`)
+
+// DefaultChunkOptions returns the chunk options used when a command does not need anything specific.
+func DefaultChunkOptions() ChunkOptions {
+	return ChunkOptions{
+		MaxTokens:     DefaultChunkMaxTokens,
+		OverlapTokens: DefaultChunkOverlapTokens,
+	}
+}
+
+// Validate checks that the options can produce chunks.
+func (o ChunkOptions) Validate() error {
+	if o.MaxTokens <= 0 {
+		return fmt.Errorf(ErrorInvalidChunkMaxTokens, o.MaxTokens)
+	}
+	if o.OverlapTokens < 0 || o.OverlapTokens >= o.MaxTokens {
+		return fmt.Errorf(ErrorInvalidChunkOverlap, o.OverlapTokens, o.MaxTokens)
+	}
+	return nil
+}
+
+// ChunkText splits text into chunks of at most opts.MaxTokens estimated tokens.
+// Consecutive chunks share up to opts.OverlapTokens tokens, so context is not lost at the boundaries.
+// When possible, a chunk ends at a line break rather than in the middle of a line.
+//
+// Parameters:
+//
+//	text string: The text to split.
+//	opts ChunkOptions: The chunk size and overlap.
+//
+// Returns:
+//
+//	[]Chunk: The chunks in order. Empty text results in no chunks.
+//	error: An error if the options are invalid.
+func ChunkText(text string, opts ChunkOptions) ([]Chunk, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	units, costs := splitChunkUnits(text, opts.MaxTokens)
+	var chunks []Chunk
+	for start := 0; start < len(units); {
+		end, tokens := packChunkUnits(units, costs, start, opts.MaxTokens)
+		chunks = append(chunks, Chunk{
+			Index:           len(chunks),
+			Text:            strings.Join(units[start:end], ""),
+			EstimatedTokens: tokens,
+		})
+		if end == len(units) {
+			break
+		}
+		start = overlapStart(costs, start, end, opts.OverlapTokens)
+	}
+	return chunks, nil
+}
+
+// ChunkFile reads a text file and splits its content with ChunkText.
+// The file must have an allowed text file extension (see verifyFileExtension).
+func ChunkFile(filePath string, opts ChunkOptions) ([]Chunk, error) {
+	if err := verifyFileExtension(filePath); err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf(ErrorFailedToReadFile, filePath, err)
+	}
+	return ChunkText(string(content), opts)
+}
+
+// splitChunkUnits splits text into word units and their estimated token costs.
+// A unit that alone exceeds maxTokens (e.g., minified code) is cut into pieces that fit.
+func splitChunkUnits(text string, maxTokens int) ([]string, []int) {
+	var units []string
+	var costs []int
+	for _, unit := range chunkUnitRegex.FindAllString(text, -1) {
+		for _, piece := range splitOversizedUnit(unit, maxTokens) {
+			units = append(units, piece)
+			costs = append(costs, EstimateTokens(piece))
+		}
+	}
+	return units, costs
+}
+
+// splitOversizedUnit cuts a unit into pieces of at most maxTokens estimated tokens.
+func splitOversizedUnit(unit string, maxTokens int) []string {
+	if EstimateTokens(unit) <= maxTokens {
+		return []string{unit}
+	}
+	var pieces []string
+	var current strings.Builder
+	for _, r := range unit {
+		if current.Len() > 0 && EstimateTokens(current.String()+string(r)) > maxTokens {
+			pieces = append(pieces, current.String())
+			current.Reset()
+		}
+		current.WriteRune(r)
+	}
+	if current.Len() > 0 {
+		pieces = append(pieces, current.String())
+	}
+	return pieces
+}
+
+// packChunkUnits returns the end index (exclusive) and token count of the chunk starting at start.
+// It prefers ending after a line break when that keeps at least half of the chunk.
+func packChunkUnits(units []string, costs []int, start, maxTokens int) (int, int) {
+	end, tokens := start, 0
+	lineEnd, lineTokens := -1, 0
+	for end < len(units) && (end == start || tokens+costs[end] <= maxTokens) {
+		tokens += costs[end]
+		end++
+		if strings.HasSuffix(units[end-1], StringNewLine) {
+			lineEnd, lineTokens = end, tokens
+		}
+	}
+	if end < len(units) && lineEnd > start && lineTokens*2 >= tokens {
+		return lineEnd, lineTokens
+	}
+	return end, tokens
+}
+
+// overlapStart returns the start index of the next chunk, stepping back from end until up to
+// overlapTokens are repeated. The next chunk always starts after the previous one, so chunking always progresses.
+func overlapStart(costs []int, start, end, overlapTokens int) int {
+	next, tokens := end, 0
+	for next > start+1 && tokens+costs[next-1] <= overlapTokens {
+		tokens += costs[next-1]
+		next--
+	}
+	return next
+}
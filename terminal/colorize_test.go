@@ -0,0 +1,47 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+
+package terminal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestColorize(t *testing.T) {
+	code := colors.ColorYellow
+	fence := colors.ColorPurple24Bit + TripleBacktick + ColorReset
+	bold := BoldText + colors.ColorHex95b806
+	endBold := ColorReset + ResetBoldText + ResetItalicText
+
+	tests := []struct {
+		name, in, want string
+	}{
+		{"inline code", "use `fmt` here", "use " + code + "fmt" + ColorReset + " here"},
+		{"bold", "**bold**", bold + "bold" + endBold},
+		{"code block", "```\nx := `raw`\n```", fence + "\nx := `raw`\n" + fence},
+		{"bold in inline code", "`**x**`", code + "**x**" + ColorReset},
+		{"unclosed inline code", "open `code", "open " + code + "code" + ColorReset},
+		{"unclosed code block", "```\nno close", fence + "\nno close"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := responseColorizationOptions(tt.in)
+			if got := Colorize(options); got != tt.want {
+				t.Errorf("Colorize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+
+			// Streamed one byte at a time, the text must look the same.
+			colorizer := NewStreamColorizer(options)
+			var streamed strings.Builder
+			for i := range len(tt.in) {
+				streamed.WriteString(colorizer.Write(tt.in[i : i+1]))
+			}
+			streamed.WriteString(colorizer.Flush())
+			if got := streamed.String(); got != tt.want {
+				t.Errorf("streamed %q = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: Commands are split into arguments like a shell does with double quotes, so an argument can contain spaces
+// (e.g., a file path or a language). Commands that take free text join their arguments again with single spaces.
+
+package terminal
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// splitQuotedArgs splits the input into arguments at whitespace. Double-quoted text is part of a single argument,
+// without its quotes (e.g., "pt BR"), and a backslash keeps the space or double quote after it (e.g., my\ file.txt).
+// Other backslashes are kept as they are, so Windows paths need no escaping. A double quote that is never closed
+// is kept as it is.
+func splitQuotedArgs(input string) []string {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	for i := 0; i < len(input); i++ {
+		c := input[i]
+		end := -1
+		if c == '"' {
+			end = closingQuote(input[i+1:])
+		}
+		switch {
+		case isEscapedArgChar(input, i):
+			arg.WriteByte(input[i+1])
+			i++
+		case end >= 0:
+			arg.WriteString(strings.ReplaceAll(input[i+1:i+1+end], `\"`, `"`))
+			i += end + 1
+		case isArgSpace(c):
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+			continue
+		default:
+			arg.WriteByte(c)
+		}
+		inArg = true
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args
+}
+
+// isEscapedArgChar reports whether the byte at i is a backslash that escapes the space or double quote after it.
+func isEscapedArgChar(input string, i int) bool {
+	return input[i] == '\\' && i+1 < len(input) && (input[i+1] == '"' || isArgSpace(input[i+1]))
+}
+
+// closingQuote returns the index of the first double quote in text that is not escaped by a backslash, or -1.
+func closingQuote(text string) int {
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			if i+1 < len(text) && text[i+1] == '"' {
+				i++
+			}
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// isArgSpace reports whether c separates arguments. Only ASCII whitespace does, as the other bytes may be part
+// of a multi-byte character.
+func isArgSpace(c byte) bool {
+	return c < utf8.RuneSelf && unicode.IsSpace(rune(c))
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: Commands report their failures by returning them, not by logging them. The registry gives every failure
+// an error code (see CommandError), the interactive loop shows it as a system message (see handleCommand), and
+// programs that call HandleCommand get it back to handle themselves.
+
+package terminal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Error returns the message of the failure with its error code.
+func (e *CommandError) Error() string {
+	return fmt.Sprintf(CommandErrorFormat, e.Err, e.Code)
+}
+
+// Unwrap returns the underlying error, so errors.Is and errors.As see through the command error.
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// newCommandError returns the failure of the command with the error code.
+func newCommandError(command, code string, err error) *CommandError {
+	return &CommandError{Command: command, Code: code, Err: err}
+}
+
+// invalidArgsError returns the failure of a command typed with invalid arguments.
+func invalidArgsError(command string, parts []string) error {
+	return newCommandError(command, CommandErrorInvalidArgs, fmt.Errorf(ErrorWhileTypingCommandArgs, command, parts))
+}
+
+// deadlineError returns that the command was cancelled by its deadline if the context expired, since that is more
+// helpful than the error of the cancelled request, and err otherwise.
+func deadlineError(ctx context.Context, command string, timeout time.Duration, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf(ErrorOperationTimedOut, command, timeout)
+	}
+	return err
+}
+
+// asCommandError returns the error of a command handler as a command error. An error without a code yet is a
+// failure of the command itself.
+func asCommandError(command string, err error) error {
+	var cmdErr *CommandError
+	if err == nil || errors.As(err, &cmdErr) {
+		return err
+	}
+	return newCommandError(command, CommandErrorFailed, err)
+}
+
+// reportCommandError shows the failure of a command to the user as a system message, with its error code.
+func reportCommandError(err error) {
+	var cmdErr *CommandError
+	if errors.As(err, &cmdErr) {
+		logger.Error(CommandErrorFormat, cmdErr.Err, cmdErr.Code)
+		return
+	}
+	logger.Error(ErrorUnknown, err)
+}
@@ -6,9 +6,10 @@ package terminal
 
 import (
 	"fmt"
-	"os"
+	"math"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/H0llyW00dzZ/GoGenAI-Terminal-Chat/terminal/fun_stuff"
 	"github.com/H0llyW00dzZ/GoGenAI-Terminal-Chat/terminal/tools"
@@ -37,11 +38,16 @@ import (
 // any necessary cleanup. The method's return value of true indicates to the calling code that the session loop
 // should exit and the application should terminate.
 func (q *handleQuitCommand) Execute(session *Session, parts []string) (bool, error) {
-	if err := sendShutdownMessage(session); err != nil {
-		logger.Error(ErrorFailedToSendShutdownMessage, err)
+	// Save the stored history first, since the shutdown message replaces the chat history.
+	session.closeStore()
+	// A viewer session has no AI to say goodbye.
+	if !session.ViewOnly {
+		if err := sendShutdownMessage(session); err != nil {
+			logger.Error(ErrorFailedToSendShutdownMessage, err)
+		}
 	}
 	// Proceed with shutdown regardless of the error
-	fmt.Println(ShutdownMessage)
+	fmt.Fprintln(session.Output, ShutdownMessage)
 	session.endSession() // End the session and perform cleanup
 	return true, nil
 }
@@ -72,6 +78,11 @@ func (q *handleQuitCommand) Execute(session *Session, parts []string) (bool, err
 // Note: The method does not add the AI's response to the chat history to avoid potential
 // loops in the AI's behavior.
 func (cmd *handleHelpCommand) Execute(session *Session, parts []string) (bool, error) {
+	// A viewer session cannot ask the AI, so it lists the available commands instead.
+	if session.ViewOnly {
+		logger.Any(InfoViewerCommands, viewerCommandList())
+		return false, nil
+	}
 	return executeCommand(session, HelpCommand, func(cmd string) string {
 		// Note: This a better fmt formatting unlike 'C' or 'RUST' hahahaha
 		return fmt.Sprintf(HelpCommandPrompt,
@@ -89,6 +100,8 @@ func (cmd *handleHelpCommand) Execute(session *Session, parts []string) (bool, e
 			CryptoRandCommand,
 			LengthArgs,
 			SummarizeCommands,
+			ShowCommands,
+			SummaryArgs,
 			SwitchModelCommands,
 			GeminiPro, GeminiProTuning, GeminiProLatest,
 			ChatCommands,
@@ -101,8 +114,113 @@ func (cmd *handleHelpCommand) Execute(session *Session, parts []string) (bool, e
 			ClearCommand,
 			ChatCommands,
 			CheckModelCommands,
+			CheckModelCommands,
+			AllArgs,
+			GenConfigCommand,
+			TemperatureCommand, TopPCommand, TopKCommand, MaxTokensCommand, None,
+			RetryPolicyCommand, RetryPolicyCommand, SetArgs, RetryPolicyCommand, ResetArgs,
+			PersonaCommand, None,
+			ReplayCommand,
+			ShareCommand,
+			ShareCommand,
+			HTMLArgs,
+			ExportCommand,
+			FileCommands,
+			ImportCommand,
+			ChatGPTArgs,
+			ImportCommand,
+			GeminiArgs,
+			WatchCommand,
+			ScheduleCommand,
+			OutputArgs,
+			ScheduleCommand,
+			ListArgs,
+			ScheduleCommand,
+			CancelArgs,
+			FanoutCommand,
+			DryRunCommand,
+			On,
+			Off,
+			HistoryCommand,
+			HistoryCommand,
+			SizeArgs,
+			QuietCommand,
+			On,
+			Off,
+			AuditCommand,
+			ShowCommands,
+			SearchCommand,
+			SearchCommand,
+			WebArgs,
+			K8sCommand,
+			ExplainArgs,
+			DockerCommand,
+			LogsArgs,
+			DockerCommand,
+			ComposeArgs,
+			LogsCommand,
+			SystemArgs,
+			FixCommand,
+			ApplyDiffCommand,
+			ImageCommand,
+			GoCommand,
+			DocArgs,
+			GoCommand,
+			TestArgs,
+			GoExplainArgs,
+			CompactCommand,
+			DiffCommand,
+			CheckpointCommand,
+			SaveArgs,
+			CheckpointCommand,
+			RestoreArgs,
+			CheckpointCommand,
+			QuoteCommand,
+			SearchCommand,
+			IncognitoCommand,
+			InfoCommand,
+			ApplyDiffCommand,
+			RememberCommand,
+			MemoryCommand,
+			MemoryCommand,
+			ListFactsArgs,
+			MemoryCommand,
+			ForgetArgs,
+			PromptCommand,
+			PromptCommand,
+			SetArgs,
+			PromptCommand,
+			FileCommands,
+			SetupCommand,
+			ReportCommand,
+			ReportCommand,
+			CrashArgs,
+			WorkersCommand,
+			TelemetryCommand,
+			On,
+			Off,
+			StatusArgs,
+			QueueCommand,
+			QueueCommand,
+			CancelArgs,
+			QueueCommand,
+			ClearCommand,
+			ConfigCommand,
+			SetArgs,
+			ConfigMaxTokens,
+			ConfigCommand,
+			SetArgs,
+			ConfigStop,
+			None,
+			ConfigCommand,
+			SetArgs,
+			ConfigSeed,
+			TokenCountCommands,
+			FileCommands,
 			TokenCountCommands,
-			FileCommands)
+			EstimateArgs,
+			FormatArgs,
+			OutputArgs)
 	})
 }
 
@@ -126,18 +244,28 @@ func (cmd *handleHelpCommand) Execute(session *Session, parts []string) (bool, e
 // for formatting messages to the AI (YouAreUsingLatest and ReleaseNotesPrompt) and relies on external
 // functions (CheckLatestVersion and GetFullReleaseInfo) to determine version information and fetch release details.
 func (c *handleCheckVersionCommand) Execute(session *Session, parts []string) (bool, error) {
-	// Pass ContextPrompt 🤪
-	// Add messages to the chat history to provide context for the version check.
-	session.ChatHistory.AddMessage(AiNerd, ContextPrompt, session.ChatConfig)
-	session.ChatHistory.AddMessage(YouNerd, VersionCommand, session.ChatConfig)
+	// Note: The context messages and the response are only added to the chat history if the version check succeeds.
+	return false, session.withHistoryTransaction(func(tx *HistoryTransaction) error {
+		// Pass ContextPrompt 🤪
+		// Add messages to the chat history to provide context for the version check.
+		tx.AddMessage(AiNerd, promptTemplate(PromptTemplateContext), session.ChatConfig)
+		tx.AddMessage(YouNerd, VersionCommand, session.ChatConfig)
+		_, err := c.checkVersionWithAI(session)
+		return err
+	})
+}
 
+// checkVersionWithAI checks the version and sends the result to the AI, see Execute.
+func (c *handleCheckVersionCommand) checkVersionWithAI(session *Session) (bool, error) {
 	// Check if the current version is the latest and get the prompt for the AI.
-	aiPrompt, err := c.checkVersionAndGetPrompt()
+	// Note: Ctrl+C or the deadline cancels the requests instead of waiting for a stalled API.
+	ctx, done := session.beginTimedOperation(VersionCheckTimeout)
+	defer done()
+	aiPrompt, err := c.checkVersionAndGetPrompt(ctx)
 	if err != nil {
-		// Log errors related to version checking and Google API issues.
-		logger.Error(ErrorFailedTosendmessagesToAI, err)
+		// Explain Google API issues, besides the error itself.
 		logger.HandleGoogleAPIError(err)
-		return false, err
+		return false, deadlineError(ctx, VersionCommand, VersionCheckTimeout, fmt.Errorf(ErrorFailedTosendmessagesToAI, err))
 	}
 
 	// Sanitize the AI prompt to ensure it is safe to send.
@@ -148,7 +276,7 @@ func (c *handleCheckVersionCommand) Execute(session *Session, parts []string) (b
 		retryFunc: func() (bool, error) {
 			// Fix Duplicated by using Magic "_" Identifier
 			// Send the message to the AI, discarding the response since it's not needed here.
-			_, err := session.SendMessage(session.Ctx, session.Client, sanitizedMessage)
+			_, err := session.SendMessage(ctx, session.Client, sanitizedMessage)
 			// If there's no error, the operation is successful.
 			return err == nil, err
 		},
@@ -158,9 +286,8 @@ func (c *handleCheckVersionCommand) Execute(session *Session, parts []string) (b
 	success, err := operation.retryWithExponentialBackoff(standardAPIErrorHandler)
 
 	if err != nil {
-		// If an error occurs that is not recoverable by retries, log it and return the error.
-		logger.Error(ErrorFailedToSendVersionCheckMessage, err)
-		return false, err
+		// An error that is not recoverable by retries.
+		return false, deadlineError(ctx, VersionCommand, VersionCheckTimeout, fmt.Errorf(ErrorFailedToSendVersionCheckMessage, err))
 	}
 
 	if !success {
@@ -183,14 +310,12 @@ func (cmd *handlepingCommand) Execute(session *Session, parts []string, subcomma
 	// Note: WIP
 	// Validate the command arguments.
 	if !cmd.IsValid(parts) {
-		logger.Error(ErrorWhileTypingCommandArgs, subcommand, parts)
-		return true, nil
+		return false, invalidArgsError(PingCommand, parts)
 	}
 
 	ip := parts[1]
-	_, err := fun_stuff.PingIP(ip)
-	if err != nil {
-		logger.Error(ErrorPingFailed, err)
+	if _, err := fun_stuff.PingIP(ip); err != nil {
+		return false, fmt.Errorf(ErrorPingFailed, err)
 	}
 
 	return false, nil
@@ -220,8 +345,7 @@ func (cmd *handleClearCommand) HandleSubcommand(subcommand string, session *Sess
 		return cmd.clearSummarizeHistory(session)
 	default:
 		// Handle unrecognized subcommand
-		logger.Error(ErrorWhileTypingCommandArgs, subcommand, parts)
-		return false, nil
+		return false, invalidArgsError(subcommand, parts)
 	}
 }
 
@@ -240,8 +364,7 @@ func (cmd *handleSafetyCommand) HandleSubcommand(subcommand string, session *Ses
 	// Note: The code in "safety_settings.go" employs advanced idiomatic Go practices. 🤪
 	// Caution is advised: if you're not familiar with these practices, improper handling in this "Execute" could lead to frequent panics 24/7 🤪.
 	if !cmd.IsValid(parts) {
-		logger.Error(ErrorWhileTypingCommandArgs, subcommand, parts)
-		return false, nil
+		return false, invalidArgsError(subcommand, parts)
 	}
 
 	// Set the safety level based on the command argument.
@@ -249,39 +372,40 @@ func (cmd *handleSafetyCommand) HandleSubcommand(subcommand string, session *Ses
 
 	// Apply the updated safety settings and notify the user.
 	// Note: It should be working now. If it still doesn't work, this may indicate a problem with your machine hahaha.
-	session.SafetySettings.ApplyToModel(session.Client.GenerativeModel(GeminiPro), GeminiPro)
+	modelName := session.activeModelName()
+	session.SafetySettings.ApplyToModel(session.Client.GenerativeModel(modelName), modelName)
 	// Pass ContextPrompt
-	session.ChatHistory.AddMessage(AiNerd, ContextPrompt, session.ChatConfig)
+	session.ChatHistory.AddMessage(AiNerd, promptTemplate(PromptTemplateContext), session.ChatConfig)
 	logger.Any(fmt.Sprintf(SystemSafety, parts[1])) // simplify
 	return false, nil
 }
 
 // Execute processes the ":aitranslate" command within a chat session.
 func (cmd *handleAITranslateCommand) Execute(session *Session, parts []string) (bool, error) {
-	// Ensure that the command is valid before proceeding.
-	if !cmd.IsValid(parts) {
-		// Log the error with the logger instead of returning fmt.Errorf
-		logger.Error(ErrorWhileTypingCommandArgs, AITranslateCommand, parts)
-		return false, nil // Return nil error because the logger already handled it
-	}
-
 	// Find the index of the language flag ":lang" to separate text and target language.
 	languageFlagIndex := len(parts) - 2
 	textToTranslate := strings.Join(parts[1:languageFlagIndex], " ")
 	targetLanguage := parts[languageFlagIndex+1]
 
+	textToTranslate, ok := session.filterOutbound(textToTranslate)
+	if !ok {
+		return false, nil // The filter already informed the user
+	}
+
 	aiPrompt := constructAITranslatePrompt(ApplicationName, AITranslateCommand, textToTranslate, targetLanguage)
 
-	err := handleAIInteraction(session, aiPrompt, func(session *Session, aiResponse string) error {
-		// Add a message to the chat history indicating the translation command was invoked
-		translationCommandMessage := fmt.Sprintf(ContextUserInvokeTranslateCommands, targetLanguage, textToTranslate)
-		session.ChatHistory.AddMessage(YouNerd, translationCommandMessage, session.ChatConfig)
-		return postProcessAITranslate(session, aiResponse)
+	// Note: The translation is only added to the chat history once it has been received.
+	err := session.withHistoryTransaction(func(tx *HistoryTransaction) error {
+		return handleAIInteraction(session, aiPrompt, func(session *Session, aiResponse string) error {
+			// Add a message to the chat history indicating the translation command was invoked
+			translationCommandMessage := fmt.Sprintf(ContextUserInvokeTranslateCommands, targetLanguage, textToTranslate)
+			tx.AddMessage(YouNerd, translationCommandMessage, session.ChatConfig)
+			return postProcessAITranslate(session, aiResponse)
+		})
 	})
 
 	if err != nil {
-		logger.Error(ErrorFailedToSendTranslationMessage, err)
-		return false, err
+		return false, fmt.Errorf(ErrorFailedToSendTranslationMessage, err)
 	}
 
 	// Indicate that the command was handled; return false to continue the session.
@@ -297,26 +421,35 @@ func (cmd *handleCryptoRandCommand) Execute(session *Session, parts []string) (b
 func (cmd *handleCryptoRandCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
 	// Check if there are enough parts to contain the length argument.
 	if len(parts) < 3 {
-		logger.Error(ErrorWhileTypingCommandArgs, subcommand, parts)
-		return false, nil
+		return false, invalidArgsError(subcommand, parts)
 	}
 
 	lengthStr := parts[2] // The length argument is now the second part of the command
 	length, err := strconv.Atoi(lengthStr)
 	if err != nil {
-		logger.Error(ErrorInvalidLengthArgs, err)
-		return false, fmt.Errorf(errorinvalidlengthArgs, err)
+		return false, newCommandError(CryptoRandCommand, CommandErrorInvalidArgs, fmt.Errorf(ErrorInvalidLengthArgs, err))
 	}
 
 	randomString, err := tools.GenerateRandomString(length)
 	if err != nil {
-		logger.Error(ErrorFailedtoGenerateRandomString, err)
-		return false, fmt.Errorf(errorfailedtogeneraterandomstring, err)
+		return false, fmt.Errorf(ErrorFailedtoGenerateRandomString, err)
 	}
 	logger.Any(CryptoRandRes, lengthStr, randomString)
 	return false, nil
 }
 
+// Inline returns a random string for a prompt, e.g. "!{:cryptorand :length 8}".
+func (cmd *handleCryptoRandCommand) Inline(session *Session, parts []string) (string, error) {
+	if len(parts) != 3 || parts[1] != LengthArgs {
+		return "", fmt.Errorf(ErrorWhileTypingCommandArgs, parts[0], parts[1:])
+	}
+	length, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return "", fmt.Errorf(errorinvalidlengthArgs, err)
+	}
+	return tools.GenerateRandomString(length)
+}
+
 // Execute displays the entire chat history.
 //
 // session *Session: The current chat session containing state and context.
@@ -332,8 +465,7 @@ func (cmd *handleShowChatCommand) HandleSubcommand(subcommand string, session *S
 
 	// Check if there are enough parts to contain the length argument.
 	if len(parts) < 3 {
-		logger.Error(ErrorWhileTypingCommandArgs, subcommand, parts)
-		return false, nil
+		return false, invalidArgsError(subcommand, parts)
 	}
 
 	// Retrieve and log the entire chat history.
@@ -344,23 +476,43 @@ func (cmd *handleShowChatCommand) HandleSubcommand(subcommand string, session *S
 
 // Execute processes the ":summarize" command within a chat session.
 func (h *handleSummarizeCommand) Execute(session *Session, parts []string) (bool, error) {
-	// Add a message to the chat history indicating the summarize command was invoked
-	session.ChatHistory.AddMessage(YouNerd, SummarizeCommands, session.ChatConfig)
-	// Check if there are system messages in the chat history before summarizing.
-	if session.ChatHistory.HasSystemMessages() {
-		// Remove system messages from the chat history.
-		session.ChatHistory.ClearAllSystemMessages()
+	conversation := session.ChatHistory.GetHistory(&ChatConfig{HistorySize: math.MaxInt})
+	// Note: The chat history is only changed if the summary succeeds.
+	err := session.withHistoryTransaction(func(tx *HistoryTransaction) error {
+		// Add a message to the chat history indicating the summarize command was invoked
+		tx.AddMessage(YouNerd, SummarizeCommands, session.ChatConfig)
+		// Check if there are system messages in the chat history before summarizing.
+		if session.ChatHistory.HasSystemMessages() {
+			// Remove system messages from the chat history.
+			tx.ClearAllSystemMessages()
+		}
+		_, err := h.summarize(session)
+		return err
+	})
+	if err != nil {
+		return false, err
 	}
 
+	// The facts are extracted from the conversation that was summarized, if enabled.
+	ctx, done := session.beginTimedOperation(SummarizeTimeout)
+	defer done()
+	session.extractMemoryFacts(ctx, conversation)
+	return false, nil
+}
+
+// summarize sends the summarize prompt to the AI, see Execute.
+func (h *handleSummarizeCommand) summarize(session *Session) (bool, error) {
 	// Define the summarize prompt to be sent to the AI.
 	aiPrompt := h.constructSummarizePrompt()
 	// Sanitize the message before sending it to the AI
 	sanitizedMessage := session.ChatHistory.SanitizeMessage(aiPrompt)
 
-	success, err := h.sendSummarizePrompt(session, sanitizedMessage)
+	// Note: Ctrl+C or the deadline cancels the summary instead of waiting for a stalled API.
+	ctx, done := session.beginTimedOperation(SummarizeTimeout)
+	defer done()
+	success, err := h.sendSummarizePrompt(ctx, session, sanitizedMessage)
 	if err != nil {
-		logger.Error(ErrorFailedToSendSummarizeMessage, err)
-		return false, err
+		return false, deadlineError(ctx, SummarizeCommands, SummarizeTimeout, fmt.Errorf(ErrorFailedToSendSummarizeMessage, err))
 	}
 
 	if !success {
@@ -383,7 +535,7 @@ func (cmd *handleStatsCommand) Execute(session *Session, parts []string) (bool,
 // HandleSubcommand dispatches the handling of specific subcommands for the stats command.
 // It takes a subcommand string, the current session, and the command parts as arguments.
 // Based on the subcommand, it calls the appropriate method to handle it.
-// If the subcommand is not recognized, it logs an error and continues the session.
+// If the subcommand is not recognized, it returns an error and continues the session.
 func (cmd *handleStatsCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
 	// Dispatch handling based on the subcommand.
 	switch subcommand {
@@ -391,9 +543,8 @@ func (cmd *handleStatsCommand) HandleSubcommand(subcommand string, session *Sess
 		// Handle the ':chat' subcommand to show chat statistics.
 		return cmd.showChatStats(session)
 	default:
-		// Log an error for unrecognized subcommands and continue the session.
-		logger.Error(ErrorWhileTypingCommandArgs, subcommand, parts)
-		return false, nil
+		// Report unrecognized subcommands and continue the session.
+		return false, invalidArgsError(subcommand, parts)
 	}
 }
 
@@ -404,42 +555,44 @@ func (cmd *handleTokeCountingCommand) Execute(session *Session, parts []string)
 
 func (cmd *handleTokeCountingCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
 	if !cmd.IsValid(parts) {
-		logger.Error(ErrorWhileTypingCommandArgs, subcommand, parts)
-		return false, nil
+		return false, invalidArgsError(subcommand, parts)
 	}
 
-	// The file paths start from index 2
-	filePaths := parts[2:]
+	// The file paths start from index 2, possibly followed by the ":format" and ":output" options.
+	filePaths, output, err := splitTokenCountOutput(parts[2:])
+	if err != nil {
+		return false, newCommandError(TokenCountCommands, CommandErrorInvalidArgs, err)
+	}
 
-	apiKey := os.Getenv(APIKey) // Retrieve the API_KEY from the environment
+	apiKey := ResolveAPIKey() // Retrieve the API_KEY from the environment or the config file
 	switch subcommand {
 	case FileCommands:
-		return cmd.handleTokenCount(apiKey, filePaths)
+		return cmd.handleTokenCount(session, apiKey, filePaths, false, output)
+	case EstimateArgs:
+		// Offline estimate only, useful to pre-filter files before an exact count.
+		return cmd.handleTokenCount(session, apiKey, filePaths, true, output)
 	default:
-		// Log an error for unrecognized subcommands and continue the session.
-		logger.Error(ErrorUnrecognizedSubcommandForTokenCount, subcommand)
-		return false, nil
+		// Report unrecognized subcommands and continue the session.
+		return false, fmt.Errorf(ErrorUnrecognizedSubcommandForTokenCount, subcommand)
 	}
 
 }
 
 func (cmd *handleCheckModelCommand) Execute(session *Session, parts []string) (bool, error) {
-	// Validate the command arguments.
-	if !cmd.IsValid(parts) {
-		logger.Error(ErrorWhileTypingCommandArgs, CheckModelCommands, parts)
-		return false, nil
-	}
-
 	modelName := parts[1] // The model name is the second part.
+	if modelName == AllArgs {
+		// Compare every available model instead of showing a single one.
+		return cmd.listAllModels(session)
+	}
 
 	// Define a retryable operation for retrieving model info.
 	operation := RetryableOperation{
 		retryFunc: func() (bool, error) {
-			// Note: this a magic method
-			modelInfo, err := session.Client.EmbeddingModel(modelName).Info(session.Ctx)
+			// Note: The model type is chosen from the model name, so generative models
+			// are no longer misreported as embedding models.
+			modelInfo, err := fetchModelInfo(session.Ctx, session.Client, modelName)
 			if err != nil {
-				// Log the error and decide if it's worth retrying based on the error type.
-				logger.Error(ErrorFailedToRetriveModelInfo, err)
+				// Whether it's worth retrying is decided from the error type.
 				return false, err
 			}
 
@@ -450,10 +603,8 @@ func (cmd *handleCheckModelCommand) Execute(session *Session, parts []string) (b
 	}
 
 	// Execute the retryable operation with an exponential backoff strategy.
-	success, err := operation.retryWithExponentialBackoff(standardAPIErrorHandler)
-
-	if err != nil || !success {
-		logger.Error("%s", err)
+	if _, err := operation.retryWithExponentialBackoff(standardAPIErrorHandler); err != nil {
+		return false, fmt.Errorf(ErrorFailedToRetriveModelInfo, err)
 	}
 
 	// Return false to indicate the session should continue.
@@ -470,11 +621,10 @@ func isValidModelName(modelName string) (bool, error) {
 }
 
 // Execute changes the current AI model used in the session to the one specified in the command.
+// Without a model name, the user picks the model from the available models (see pickModel).
 func (cmd *handleSwitchModelCommand) Execute(session *Session, parts []string) (bool, error) {
-	// Check if the command is valid.
-	if !cmd.IsValid(parts) {
-		logger.Error(ErrorWhileTypingCommandArgs, SwitchModelCommands, parts)
-		return false, nil
+	if len(parts) == 1 {
+		return cmd.pickModel(session)
 	}
 
 	// Extract the model name from the command parts.
@@ -483,9 +633,7 @@ func (cmd *handleSwitchModelCommand) Execute(session *Session, parts []string) (
 	// Validate the model name.
 	valid, err := isValidModelName(modelName)
 	if !valid {
-		// Log the error using logger.Error and return the error message.
-		logger.Error(err.Error())
-		return false, nil // Continue the session
+		return false, err // Continue the session
 	}
 
 	// Update the session with the new model name.
@@ -496,3 +644,957 @@ func (cmd *handleSwitchModelCommand) Execute(session *Session, parts []string) (
 
 	return false, nil // Continue the session.
 }
+
+// Execute prints the generation configuration that is currently applied to the active model.
+// The model is configured exactly as it would be for a chat message, so the output reflects
+// what the AI actually receives rather than what the user last typed.
+func (cmd *handleGenConfigCommand) Execute(session *Session, parts []string) (bool, error) {
+	if !cmd.IsValid(parts) {
+		return false, invalidArgsError(GenConfigCommand, parts)
+	}
+
+	model := session.ConfigureModelForSession(session.Ctx)
+	DisplayGenerationConfig(session.activeModelName(), &model.GenerationConfig)
+	return false, nil // Continue the session.
+}
+
+// Execute shows the generation parameter of the command, or sets it to the value given as argument.
+// The value is validated by the setter of the parameter, like ":config set" does.
+func (cmd *handleGenerationParamCommand) Execute(session *Session, parts []string) (bool, error) {
+	if len(parts) == 1 {
+		logger.Any(InfoGenerationParam, cmd.key, session.GenerationSettings.generationParamValue(cmd.key))
+		return false, nil
+	}
+
+	if err := generationOptions[cmd.key].Setter(session, parts[1]); err != nil {
+		return false, fmt.Errorf(ErrorFailedToApplyConfig, err)
+	}
+	logger.Any(ConfigUpdated, cmd.key, parts[1])
+	return false, nil // Continue the session.
+}
+
+// Execute processes ":persona [text]", which shows the persona of the session, sets it to the text,
+// or removes it with "none".
+func (cmd *handlePersonaCommand) Execute(session *Session, parts []string) (bool, error) {
+	if len(parts) == 1 {
+		if session.SystemInstruction == "" {
+			logger.Any(InfoNoPersona, PersonaCommand)
+		} else {
+			logger.Any(InfoPersona, session.SystemInstruction)
+		}
+		return false, nil
+	}
+
+	text := strings.Join(parts[1:], " ")
+	if text == None {
+		session.SystemInstruction = ""
+		logger.Any(InfoPersonaCleared)
+		return false, nil
+	}
+	// The persona is sent with every message, so it passes through the outbound filter like any other user input.
+	text, ok := session.filterOutbound(text)
+	if !ok {
+		return false, nil // The filter already informed the user
+	}
+	session.SystemInstruction = text
+	logger.Any(InfoPersonaSet)
+	return false, nil // Continue the session.
+}
+
+// Execute is called when ":config" is typed without a subcommand, which is not a complete command.
+func (cmd *handleConfigCommand) Execute(session *Session, parts []string) (bool, error) {
+	return false, invalidArgsError(ConfigCommand, parts)
+}
+
+// HandleSubcommand processes ":config set <key> <value>" by looking up the setter
+// for the key and applying the value to the session's generation settings.
+// Validation errors are reported to the user and the session continues.
+func (cmd *handleConfigCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	if !cmd.IsValid(parts) {
+		return false, invalidArgsError(ConfigCommand, parts)
+	}
+
+	key, value := parts[2], strings.Join(parts[3:], " ")
+	if err := generationOptions[key].Setter(session, value); err != nil {
+		return false, fmt.Errorf(ErrorFailedToApplyConfig, err)
+	}
+
+	logger.Any(ConfigUpdated, key, value)
+	return false, nil // Continue the session.
+}
+
+// Execute re-renders the chat history with the typing effect and colors, as if it happened again.
+// No API calls are made, which makes it useful for demos and for reviewing a loaded session.
+// Pressing Ctrl+C stops the replay without ending the session.
+func (cmd *handleReplayCommand) Execute(session *Session, parts []string) (bool, error) {
+	if !cmd.IsValid(parts) {
+		return false, invalidArgsError(ReplayCommand, parts)
+	}
+
+	messages := session.ChatHistory.FilterMessages(func(string) bool { return true })
+	if len(messages) == 0 {
+		logger.Any(InfoReplayEmpty)
+		return false, nil
+	}
+
+	ctx, done := session.beginOperation()
+	defer done()
+
+	logger.Any(InfoReplayStart, len(messages))
+	for i, message := range messages {
+		if ctx.Err() != nil {
+			logger.Any(InfoReplayCancelled, i, len(messages))
+			return false, nil
+		}
+		replayMessage(session.Output, message)
+	}
+	logger.Any(InfoReplayEnd)
+	return false, nil // Continue the session.
+}
+
+// Execute exports the sanitized chat transcript as Markdown. If the GITHUB_TOKEN environment variable
+// is set, the transcript is uploaded as a private GitHub Gist and its URL is printed; otherwise it is
+// saved to a Markdown file in the current directory.
+func (cmd *handleShareCommand) Execute(session *Session, parts []string) (bool, error) {
+	if !cmd.IsValid(parts) {
+		return false, invalidArgsError(ShareCommand, parts)
+	}
+
+	messages := session.ChatHistory.FilterMessages(func(string) bool { return true })
+	if len(messages) == 0 {
+		logger.Any(InfoShareEmpty)
+		return false, nil
+	}
+
+	exportedAt := time.Now()
+	location, err := shareTranscript(session.Ctx, BuildTranscriptMarkdown(messages, exportedAt), exportedAt)
+	if err != nil {
+		return false, fmt.Errorf(ErrorFailedToShareTranscript, err)
+	}
+
+	logger.Any(InfoShareCompleted, location)
+	return false, nil // Continue the session.
+}
+
+// HandleSubcommand processes ":share :html", which writes a standalone HTML transcript
+// to the current directory.
+func (cmd *handleShareCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	if len(parts) != 2 {
+		return false, invalidArgsError(ShareCommand, parts)
+	}
+
+	messages := session.ChatHistory.FilterMessages(func(string) bool { return true })
+	if len(messages) == 0 {
+		logger.Any(InfoShareEmpty)
+		return false, nil
+	}
+
+	location, err := exportTranscriptHTML(messages, time.Now())
+	if err != nil {
+		return false, fmt.Errorf(ErrorFailedToShareTranscript, err)
+	}
+
+	logger.Any(InfoShareCompleted, location)
+	return false, nil // Continue the session.
+}
+
+// Execute reports invalid usage, since the export command requires the ":file" subcommand.
+func (cmd *handleExportCommand) Execute(session *Session, parts []string) (bool, error) {
+	return false, invalidArgsError(ExportCommand, parts)
+}
+
+// HandleSubcommand processes ":export :file <path>", which writes the sanitized transcript of the chat history
+// to the file. Unlike ":share", the path is chosen by the user and nothing is uploaded.
+func (cmd *handleExportCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	if !cmd.IsValid(parts) {
+		return false, invalidArgsError(ExportCommand, parts)
+	}
+
+	messages := session.ChatHistory.FilterMessages(func(string) bool { return true })
+	if len(messages) == 0 {
+		logger.Any(InfoShareEmpty)
+		return false, nil
+	}
+
+	path := parts[2]
+	if err := exportTranscriptFile(messages, path, time.Now()); err != nil {
+		return false, fmt.Errorf(ErrorFailedToExportTranscript, path, err)
+	}
+
+	logger.Any(InfoTranscriptExported, len(messages), path)
+	return false, nil // Continue the session.
+}
+
+// Execute reports invalid usage, since the import command requires a subcommand
+// such as ":chatgpt" or ":gemini".
+func (cmd *handleImportCommand) Execute(session *Session, parts []string) (bool, error) {
+	return false, invalidArgsError(ImportCommand, parts)
+}
+
+// HandleSubcommand processes ":import :chatgpt <file> [number]" and ":import :gemini <file>".
+// The imported messages are appended to the chat history, so the conversation can be continued with the AI.
+func (cmd *handleImportCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	parse, exists := importParsers[subcommand]
+	if !exists || len(parts) < 3 || len(parts) > 4 {
+		return false, invalidArgsError(ImportCommand, parts)
+	}
+
+	var selector string
+	if len(parts) == 4 {
+		selector = parts[3]
+	}
+
+	count, err := importTranscript(session, parse, parts[2], selector)
+	if err != nil {
+		return false, fmt.Errorf(ErrorFailedToImportTranscript, err)
+	}
+
+	logger.Any(InfoImportCompleted, count, parts[2])
+	return false, nil // Continue the session.
+}
+
+// Execute watches a file and sends the prompt with the file content to the AI, then again every time
+// the file changes. Sends are rate limited to protect the API quota. The command blocks until Ctrl+C is pressed.
+func (cmd *handleWatchCommand) Execute(session *Session, parts []string) (bool, error) {
+	filePath := parts[1]
+	prompt := strings.Join(parts[2:], " ")
+	if err := verifyTextFile(filePath); err != nil {
+		return false, fmt.Errorf(ErrorFailedToWatchFile, err)
+	}
+	if !session.ensureClientIsValid() {
+		return true, nil // End the session if the client is not valid
+	}
+
+	ctx, done := session.beginOperation()
+	defer done()
+
+	logger.Any(InfoWatchStarted, filePath)
+	if err := session.watchFile(ctx, filePath, prompt); err != nil {
+		return false, fmt.Errorf(ErrorFailedToWatchFile, err)
+	}
+	logger.Any(InfoWatchStopped, filePath)
+	return false, nil // Continue the session.
+}
+
+// Execute manages scheduled prompts:
+//
+//	:schedule "<cron>" "<prompt>" [:output <file>]
+//	:schedule :list
+//	:schedule :cancel <id>
+//
+// The ChatWorker queues scheduled prompts when they are due, until they are cancelled or the session ends.
+func (cmd *handleScheduleCommand) Execute(session *Session, parts []string) (bool, error) {
+	// Note: The cron expression and the prompt contain spaces, so they are quoted (see splitQuotedArgs).
+	args := parts[1:]
+	var outputFile string
+	if len(args) == 4 && args[2] == OutputArgs {
+		outputFile = args[3]
+	} else if len(args) != 2 {
+		return false, invalidArgsError(ScheduleCommand, parts)
+	}
+
+	prompt, ok := session.filterOutbound(args[1])
+	if !ok {
+		return false, nil // The filter already informed the user
+	}
+
+	scheduled, err := session.Worker.AddSchedule(args[0], prompt, outputFile, time.Now())
+	if err != nil {
+		return false, fmt.Errorf(ErrorFailedToSchedulePrompt, err)
+	}
+
+	logger.Any(InfoScheduleAdded, scheduled.ID, scheduled.Next.Format(time.RFC1123))
+	return false, nil // Continue the session.
+}
+
+// HandleSubcommand processes ":schedule :list" and ":schedule :cancel <id>".
+func (cmd *handleScheduleCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	if subcommand == ListArgs {
+		listSchedules(session.Worker.Schedules())
+		return false, nil
+	}
+	return cmd.cancelSchedule(session, parts)
+}
+
+// Execute lists the prompts that were typed while a response was in flight and are waiting to be processed.
+// While a response is in flight, the prompt queue runs the command itself (see interceptQueueCommand).
+func (cmd *handleQueueCommand) Execute(session *Session, parts []string) (bool, error) {
+	return false, session.Input.runCommand(parts)
+}
+
+// HandleSubcommand processes ":queue :cancel <number>" and ":queue :clear".
+func (cmd *handleQueueCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	return false, session.Input.runCommand(parts)
+}
+
+// Execute reads prompts from a file, sends them to the AI concurrently and saves the responses to a Markdown file.
+// The prompts are independent of the chat history, and the chat history is not changed.
+func (cmd *handleFanoutCommand) Execute(session *Session, parts []string) (bool, error) {
+	prompts, err := readFanoutPrompts(session, parts[1])
+	if err != nil {
+		return false, fmt.Errorf(ErrorFailedToRunFanout, err)
+	}
+	if prompts == nil {
+		return false, nil // The outbound filter blocked a prompt and already informed the user.
+	}
+	if !session.ensureClientIsValid() {
+		return true, nil // End the session if the client is not valid
+	}
+
+	if session.DryRun {
+		model := session.ConfigureModelForSession(session.Ctx)
+		for _, prompt := range prompts {
+			session.previewRequest(model, prompt)
+		}
+		return false, nil
+	}
+
+	ctx, done := session.beginOperation()
+	defer done()
+
+	spinner := NewSpinner(session.Output, fmt.Sprintf(FanoutSpinner, len(prompts), FanoutMaxWorkers))
+	spinner.Start()
+	results := session.runFanout(ctx, prompts)
+	spinner.Stop()
+
+	var outputFile string
+	if len(parts) == 3 {
+		outputFile = parts[2]
+	}
+	location, err := writeFanoutOutput(outputFile, results, session.activeModelName(), time.Now())
+	if err != nil {
+		return false, fmt.Errorf(ErrorFailedToRunFanout, err)
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+	logger.Any(InfoFanoutCompleted, len(results)-failed, failed, location)
+	return false, nil // Continue the session.
+}
+
+// Execute shows whether dry-run mode is enabled.
+func (cmd *handleDryRunCommand) Execute(session *Session, parts []string) (bool, error) {
+	if !cmd.IsValid(parts) {
+		return false, invalidArgsError(DryRunCommand, parts)
+	}
+	logger.Any(InfoDryRunStatus, formatOnOff(session.DryRun))
+	return false, nil // Continue the session.
+}
+
+// HandleSubcommand processes ":dryrun on" and ":dryrun off".
+func (cmd *handleDryRunCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	if len(parts) != 2 {
+		return false, invalidArgsError(DryRunCommand, parts)
+	}
+	session.DryRun = subcommand == On
+	logger.Any(InfoDryRunStatus, formatOnOff(session.DryRun))
+	return false, nil // Continue the session.
+}
+
+// Execute shows whether quiet mode is enabled.
+func (cmd *handleQuietCommand) Execute(session *Session, parts []string) (bool, error) {
+	if !cmd.IsValid(parts) {
+		return false, invalidArgsError(QuietCommand, parts)
+	}
+	logger.Any(InfoQuietStatus, formatOnOff(session.Quiet))
+	return false, nil // Continue the session.
+}
+
+// HandleSubcommand processes ":quiet on" and ":quiet off".
+func (cmd *handleQuietCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	if len(parts) != 2 {
+		return false, invalidArgsError(QuietCommand, parts)
+	}
+	session.Quiet = subcommand == On
+	logger.Any(InfoQuietStatus, formatOnOff(session.Quiet))
+	return false, nil // Continue the session.
+}
+
+// Execute reports invalid usage, since the audit command requires the ":show" subcommand.
+func (cmd *handleAuditCommand) Execute(session *Session, parts []string) (bool, error) {
+	return false, invalidArgsError(AuditCommand, parts)
+}
+
+// HandleSubcommand processes ":audit :show [number]", which prints the most recent entries of the audit log.
+func (cmd *handleAuditCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	if len(parts) > 3 {
+		return false, invalidArgsError(AuditCommand, parts)
+	}
+	if auditLog == nil {
+		logger.Any(InfoAuditLogDisabled)
+		return false, nil
+	}
+
+	limit := DefaultAuditShowEntries
+	if len(parts) == 3 {
+		n, err := strconv.Atoi(parts[2])
+		if err != nil || n <= 0 {
+			return false, invalidArgsError(AuditCommand, parts)
+		}
+		limit = n
+	}
+
+	entries, err := auditLog.Entries(limit)
+	if err != nil {
+		return false, fmt.Errorf(ErrorFailedToReadAuditLog, err)
+	}
+	if len(entries) == 0 {
+		logger.Any(InfoAuditLogEmpty)
+		return false, nil
+	}
+	session.printPaged(formatAuditEntries(entries), DefaultPageSize)
+	return false, nil // Continue the session.
+}
+
+// Execute processes ":search <text>", which lists the chat history messages containing the text, ignoring case,
+// and ":search :web <query>", which searches the web and asks the AI to summarize the results (see searchWebCommand).
+func (cmd *handleSearchCommand) Execute(session *Session, parts []string) (bool, error) {
+	query := strings.Join(parts[1:], " ")
+	matches := searchHistory(session.ChatHistory, query)
+	if len(matches) == 0 {
+		logger.Any(InfoSearchNoMatches, query)
+		return false, nil
+	}
+	session.printPaged(fmt.Sprintf(InfoSearchHeader, len(matches), query)+strings.Join(matches, StringNewLine), DefaultPageSize)
+	return false, nil // Continue the session.
+}
+
+// HandleSubcommand processes ":search :web <query>".
+func (cmd *handleSearchCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	if len(parts) < 3 {
+		return false, invalidArgsError(SearchCommand, parts)
+	}
+	// Unlike searching the chat history, searching the web sends the results to the API.
+	if session.ViewOnly {
+		return false, newCommandError(SearchCommand, CommandErrorNotAvailable, fmt.Errorf(ErrorNotAvailableInViewer, SearchCommand+" "+WebArgs))
+	}
+	return session.searchWebCommand(strings.Join(parts, " "), strings.Join(parts[2:], " "))
+}
+
+// Execute processes ":diff <sessionA> <sessionB>", which lists the messages that differ between two stored sessions.
+func (cmd *handleDiffCommand) Execute(session *Session, parts []string) (bool, error) {
+	diff, err := diffStoredSessions(parts[1], parts[2])
+	if err != nil {
+		return false, fmt.Errorf(ErrorFailedToDiffSessions, parts[1], parts[2], err)
+	}
+	session.printPaged(formatHistoryDiff(parts[1], parts[2], diff), DefaultPageSize)
+	return false, nil // Continue the session.
+}
+
+// Execute lists the checkpoints of the session.
+func (cmd *handleCheckpointCommand) Execute(session *Session, parts []string) (bool, error) {
+	if !cmd.IsValid(parts) {
+		return false, invalidArgsError(CheckpointCommand, parts)
+	}
+	if len(session.checkpoints) == 0 {
+		logger.Any(InfoNoCheckpoints)
+		return false, nil
+	}
+	fmt.Fprint(session.Output, formatCheckpoints(session.checkpoints))
+	return false, nil // Continue the session.
+}
+
+// HandleSubcommand processes ":checkpoint save <name>" and ":checkpoint restore <name>".
+func (cmd *handleCheckpointCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	if len(parts) != 3 {
+		return false, invalidArgsError(CheckpointCommand, parts)
+	}
+
+	name := parts[2]
+	if subcommand == SaveArgs {
+		session.saveCheckpoint(name)
+		logger.Any(InfoCheckpointSaved, name)
+		return false, nil
+	}
+	if err := session.restoreCheckpoint(name); err != nil {
+		return false, fmt.Errorf(ErrorFailedToRestoreCheckpoint, err)
+	}
+	logger.Any(InfoCheckpointRestored, name)
+	return false, nil // Continue the session.
+}
+
+// Execute processes ":quote <number> <prompt>", which sends the prompt with only the quoted message as context.
+func (cmd *handleQuoteCommand) Execute(session *Session, parts []string) (bool, error) {
+	number, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return false, invalidArgsError(QuoteCommand, parts)
+	}
+
+	prompt, ok := session.filterOutbound(strings.Join(parts[2:], " "))
+	if !ok {
+		return false, nil // The filter already informed the user
+	}
+	if err := session.sendQuote(number, prompt); err != nil {
+		return false, fmt.Errorf(ErrorFailedToSendQuote, err)
+	}
+	return false, nil // Continue the session.
+}
+
+// Execute processes ":incognito <prompt>", which sends the prompt without adding it or the response to the chat history.
+func (cmd *handleIncognitoCommand) Execute(session *Session, parts []string) (bool, error) {
+	prompt, ok := session.filterOutbound(strings.Join(parts[1:], " "))
+	if !ok {
+		return false, nil // The filter already informed the user
+	}
+	if err := session.sendIncognito(prompt); err != nil {
+		return false, fmt.Errorf(ErrorFailedToSendIncognito, err)
+	}
+	return false, nil // Continue the session.
+}
+
+// Execute lists the current prompts.
+func (cmd *handlePromptCommand) Execute(session *Session, parts []string) (bool, error) {
+	if !cmd.IsValid(parts) {
+		return false, invalidArgsError(PromptCommand, parts)
+	}
+	session.printPaged(formatPromptTemplates(), DefaultPageSize)
+	return false, nil // Continue the session.
+}
+
+// HandleSubcommand processes ":prompt set <name> <text>", which overrides a prompt and saves it to the config file.
+func (cmd *handlePromptCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	if len(parts) < 4 {
+		return false, invalidArgsError(PromptCommand, parts)
+	}
+	if err := setPromptTemplate(parts[2], strings.Join(parts[3:], " ")); err != nil {
+		return false, fmt.Errorf(ErrorFailedToSetPromptTemplate, err)
+	}
+	return false, nil // Continue the session.
+}
+
+// Execute handles ":prompt" without ":file", which is handled by handlePromptCommand instead.
+func (cmd *handlePromptfileCommand) Execute(session *Session, parts []string) (bool, error) {
+	return false, invalidArgsError(PromptCommand, parts)
+}
+
+// HandleSubcommand processes ":prompt :file <path> [path...]", which loads a seed prompt from the files (see seedPrompt).
+func (cmd *handlePromptfileCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	if !cmd.IsValid(parts) {
+		return false, invalidArgsError(PromptCommand, parts)
+	}
+	prompt, err := readPromptFiles(parts[2:])
+	if err != nil {
+		return false, fmt.Errorf(ErrorFailedToLoadPromptFiles, err)
+	}
+	if session.seedPrompt(prompt) {
+		session.notify(InfoSeedPromptLoaded, len(parts)-2, EstimateTokens(prompt))
+	}
+	return false, nil // Continue the session.
+}
+
+// Execute shows the status of the session.
+func (cmd *handleInfoCommand) Execute(session *Session, parts []string) (bool, error) {
+	if !cmd.IsValid(parts) {
+		return false, invalidArgsError(InfoCommand, parts)
+	}
+	fmt.Fprint(session.Output, session.formatSessionInfo(time.Now()))
+	return false, nil // Continue the session.
+}
+
+// Execute processes ":applydiff <file>", which applies the latest diff for the file from the AI responses.
+func (cmd *handleApplyDiffCommand) Execute(session *Session, parts []string) (bool, error) {
+	diff, err := session.ChatHistory.latestFileDiff(parts[1])
+	if err != nil {
+		return false, fmt.Errorf(ErrorFailedToApplyDiff, err)
+	}
+	applied, err := session.applyDiff(parts[1], diff)
+	switch {
+	case err != nil:
+		return false, fmt.Errorf(ErrorFailedToApplyDiff, err)
+	case applied:
+		logger.Any(InfoDiffApplied, len(diff.Hunks), parts[1])
+	default:
+		logger.Any(InfoDiffNotApplied)
+	}
+	return false, nil // Continue the session.
+}
+
+// Execute processes ":remember <fact>", which adds the fact to the remembered facts shared by all sessions.
+func (cmd *handleRememberCommand) Execute(session *Session, parts []string) (bool, error) {
+	// The fact is sent with every message, so it passes through the outbound filter like any other user input.
+	text, ok := session.filterOutbound(strings.Join(parts[1:], " "))
+	if !ok {
+		return false, nil // The filter already informed the user
+	}
+	added, err := session.remember(text)
+	if err != nil {
+		return false, fmt.Errorf(ErrorFailedToRemember, err)
+	}
+	if len(added) == 0 {
+		logger.Any(InfoFactAlreadyRemembered, text)
+		return false, nil
+	}
+	logger.Any(InfoFactRemembered, added[0].ID, added[0].Text)
+	return false, nil // Continue the session.
+}
+
+// Execute lists the remembered facts.
+func (cmd *handleMemoryCommand) Execute(session *Session, parts []string) (bool, error) {
+	if !cmd.IsValid(parts) {
+		return false, invalidArgsError(MemoryCommand, parts)
+	}
+	if session.memory == nil || len(session.memory.Facts) == 0 {
+		logger.Any(InfoNoFacts, RememberCommand)
+		return false, nil
+	}
+	fmt.Fprint(session.Output, formatMemoryFacts(session.memory.Facts))
+	return false, nil // Continue the session.
+}
+
+// HandleSubcommand processes ":memory list" and ":memory forget <id>".
+func (cmd *handleMemoryCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	switch {
+	case subcommand == ListFactsArgs && len(parts) == 2:
+		return cmd.Execute(session, parts[:1])
+	case subcommand == ForgetArgs && len(parts) == 3:
+		id, err := strconv.Atoi(strings.TrimPrefix(parts[2], "#"))
+		if err != nil {
+			return false, invalidArgsError(MemoryCommand, parts)
+		}
+		if err := session.forget(id); err != nil {
+			return false, fmt.Errorf(ErrorFailedToForget, err)
+		}
+		logger.Any(InfoFactForgotten, id)
+	default:
+		return false, invalidArgsError(MemoryCommand, parts)
+	}
+	return false, nil // Continue the session.
+}
+
+// Execute processes ":retrypolicy", which shows the retry policy of the session.
+func (cmd *handleRetryPolicyCommand) Execute(session *Session, parts []string) (bool, error) {
+	if !cmd.IsValid(parts) {
+		return false, invalidArgsError(RetryPolicyCommand, parts)
+	}
+	policy := session.RetryPolicy
+	logger.Any(InfoRetryPolicy, policy.MaxRetries, policy.BaseDelay, policy.MaxDelay, policy.Jitter, formatStatusCodes(policy.RetryableStatusCodes))
+	return false, nil // Continue the session.
+}
+
+// HandleSubcommand processes ":retrypolicy set <setting> <value>", which changes the retry policy of the session,
+// and ":retrypolicy reset", which goes back to the retry policy of the config.
+func (cmd *handleRetryPolicyCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	switch {
+	case subcommand == SetArgs && len(parts) == 4:
+		var config RetryPolicyConfig
+		if err := config.setValue(parts[2], parts[3]); err != nil {
+			return false, fmt.Errorf(ErrorFailedToSetRetryPolicy, err)
+		}
+		policy, err := applyRetryPolicyConfig(session.RetryPolicy, &config)
+		if err != nil {
+			return false, fmt.Errorf(ErrorFailedToSetRetryPolicy, err)
+		}
+		session.RetryPolicy = policy
+		logger.Any(ConfigUpdated, parts[2], parts[3])
+	case subcommand == ResetArgs && len(parts) == 2:
+		session.RetryPolicy = retryPolicy
+		logger.Any(InfoRetryPolicyReset)
+	default:
+		return false, invalidArgsError(RetryPolicyCommand, parts)
+	}
+	return false, nil // Continue the session.
+}
+
+// Execute reports invalid usage, since the show command requires a subcommand.
+func (cmd *handleShowCommand) Execute(session *Session, parts []string) (bool, error) {
+	return false, invalidArgsError(ShowCommands, parts)
+}
+
+// HandleSubcommand processes ":show summary", which prints the summary system messages produced by
+// ":summarize" or ":compact", without the rest of the chat history.
+func (cmd *handleShowCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	if len(parts) != 2 {
+		return false, invalidArgsError(ShowCommands, parts)
+	}
+	summaries := session.ChatHistory.systemMessagesOf(SystemCategorySummary)
+	if len(summaries) == 0 {
+		logger.Any(InfoNoSummary, SummarizeCommands)
+		return false, nil
+	}
+	logger.Info("%s", strings.Join(summaries, StringNewLine+StringNewLine))
+	return false, nil // Continue the session.
+}
+
+// Execute handles ":k8s" without a subcommand, which has nothing to run.
+func (cmd *handleK8sCommand) Execute(session *Session, parts []string) (bool, error) {
+	return false, invalidArgsError(K8sCommand, parts)
+}
+
+// HandleSubcommand processes ":k8s explain <verb> <args>", which runs the kubectl command and asks the AI
+// to explain its output. Only the verbs in kubectlVerbs are run, and never on secrets.
+func (cmd *handleK8sCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	if !cmd.IsValid(parts) {
+		return false, invalidArgsError(K8sCommand, parts)
+	}
+	args := parts[2:]
+	if err := checkKubectlArgs(args); err != nil {
+		return false, newCommandError(K8sCommand, CommandErrorInvalidArgs, err)
+	}
+
+	ctx, done := session.beginTimedOperation(KubectlTimeout)
+	output, err := runTool(ctx, KubectlBinary, args, KubectlMaxOutput)
+	done()
+	kubectl := strings.Join(args, " ")
+	if err != nil {
+		return false, deadlineError(ctx, K8sCommand, KubectlTimeout, fmt.Errorf(ErrorFailedToRunKubectl, err))
+	}
+	if output == "" {
+		logger.Any(InfoKubectlNoOutput, kubectl)
+		return false, nil
+	}
+
+	session.printPaged(output, DefaultPageSize)
+	return executeCommand(session, strings.Join(parts, " "), func(string) string {
+		return fmt.Sprintf(K8sExplainPrompt, kubectl, output)
+	})
+}
+
+// Execute handles ":docker" without a subcommand, which has nothing to read.
+func (cmd *handleDockerCommand) Execute(session *Session, parts []string) (bool, error) {
+	return false, invalidArgsError(DockerCommand, parts)
+}
+
+// HandleSubcommand processes ":docker logs <container> [lines]" and ":docker compose <service> [lines]",
+// which read the most recent log lines and ask the AI to diagnose the errors (see analyzeLogs).
+func (cmd *handleDockerCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	if !cmd.IsValid(parts) {
+		return false, invalidArgsError(DockerCommand, parts)
+	}
+	args, err := dockerLogsArgs(parts)
+	if err != nil {
+		return false, newCommandError(DockerCommand, CommandErrorInvalidArgs, err)
+	}
+
+	source := fmt.Sprintf(DockerContainerSource, parts[2])
+	if subcommand == ComposeArgs {
+		source = fmt.Sprintf(ComposeServiceSource, parts[2])
+	}
+	ctx, done := session.beginTimedOperation(LogsTimeout)
+	logs, err := runTool(ctx, DockerBinary, args, LogsMaxOutput)
+	done()
+	if err != nil {
+		return false, deadlineError(ctx, DockerCommand, LogsTimeout, fmt.Errorf(ErrorFailedToReadLogs, source, err))
+	}
+	return session.analyzeLogs(strings.Join(parts, " "), source, logs)
+}
+
+// Execute handles ":logs" without a subcommand, which has nothing to read.
+func (cmd *handleLogsCommand) Execute(session *Session, parts []string) (bool, error) {
+	return false, invalidArgsError(LogsCommand, parts)
+}
+
+// HandleSubcommand processes ":logs :system [unit]", which reads the most recent lines of the system journal
+// (see readSystemLogs), masks the hostnames and IP addresses, and asks the AI to summarize the anomalies.
+func (cmd *handleLogsCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	if !cmd.IsValid(parts) {
+		return false, invalidArgsError(LogsCommand, parts)
+	}
+	unit, source := "", SystemLogSource
+	if len(parts) == 3 {
+		unit, source = parts[2], fmt.Sprintf(SystemUnitLogSource, parts[2])
+	}
+
+	ctx, done := session.beginTimedOperation(LogsTimeout)
+	logs, err := readSystemLogs(ctx, unit)
+	done()
+	if err != nil {
+		return false, deadlineError(ctx, LogsCommand, LogsTimeout, fmt.Errorf(ErrorFailedToReadLogs, source, err))
+	}
+	logs, masked, err := systemLogRedactionFilter().Apply(logs)
+	if err != nil {
+		return false, fmt.Errorf(ErrorOutboundFilter, err)
+	}
+	if len(masked) > 0 {
+		logger.Any(OutboundContentMasked, strings.Join(masked, dotStringComma))
+	}
+	return session.analyzeLogs(strings.Join(parts, " "), source, logs)
+}
+
+// Execute processes ":fix" and ":fix <<MARKER", which read an error from the clipboard or from the lines pasted
+// up to the marker, and ask the AI to diagnose it and show the fix as a diff (see triageError).
+func (cmd *handleFixCommand) Execute(session *Session, parts []string) (bool, error) {
+	if len(parts) == 2 {
+		errorText, err := session.readPastedLines(strings.TrimPrefix(parts[1], HeredocPrefix))
+		if err != nil {
+			return false, fmt.Errorf(ErrorFailedToReadErrorText, err)
+		}
+		return session.triageError(strings.Join(parts, " "), PastedSource, errorText)
+	}
+
+	ctx, done := session.beginTimedOperation(ClipboardTimeout)
+	errorText, err := readClipboard(ctx)
+	done()
+	if err != nil {
+		return false, deadlineError(ctx, FixCommand, ClipboardTimeout, fmt.Errorf(ErrorFailedToReadErrorText, err))
+	}
+	return session.triageError(FixCommand, ClipboardSource, errorText)
+}
+
+// Execute handles ":go" without a subcommand, which has nothing to run.
+func (cmd *handleGoCommand) Execute(session *Session, parts []string) (bool, error) {
+	return false, invalidArgsError(GoCommand, parts)
+}
+
+// HandleSubcommand processes ":go doc <symbol>" and ":go test :explain [packages and flags]", which run the go
+// command in the current directory and ask the AI to explain its output.
+func (cmd *handleGoCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	if !cmd.IsValid(parts) {
+		return false, invalidArgsError(GoCommand, parts)
+	}
+	args, prompt, timeout := append([]string{DocArgs}, parts[2:]...), GoDocPrompt, GoDocTimeout
+	if subcommand == TestArgs {
+		args, prompt, timeout = goTestArgs(parts[3:]), GoTestPrompt, GoTestTimeout
+	}
+
+	ctx, done := session.beginTimedOperation(timeout)
+	output, err := runGoCommand(ctx, args)
+	done()
+	goCommand := strings.Join(args, " ")
+	if err != nil {
+		return false, deadlineError(ctx, GoCommand, timeout, fmt.Errorf(ErrorFailedToRunGo, goCommand, err))
+	}
+	if output == "" {
+		logger.Any(InfoGoNoOutput, goCommand)
+		return false, nil
+	}
+
+	session.printPaged(output, DefaultPageSize)
+	return executeCommand(session, strings.Join(parts, " "), func(string) string {
+		return fmt.Sprintf(prompt, goCommand, output)
+	})
+}
+
+// Execute processes ":image <path> [prompt]", which sends the image and the prompt to a vision model (see sendImage).
+func (cmd *handleImageCommand) Execute(session *Session, parts []string) (bool, error) {
+	prompt := ImageDefaultPrompt
+	if len(parts) > 2 {
+		prompt = strings.Join(parts[2:], " ")
+	}
+	if err := session.sendImage(parts[1], prompt); err != nil {
+		return false, fmt.Errorf(ErrorFailedToSendImage, parts[1], err)
+	}
+	return false, nil // Continue the session.
+}
+
+// Execute shows the size of the chat history.
+func (cmd *handleHistoryCommand) Execute(session *Session, parts []string) (bool, error) {
+	if !cmd.IsValid(parts) {
+		return false, invalidArgsError(HistoryCommand, parts)
+	}
+	stats := session.ChatHistory.GetMessageStats()
+	stored := stats.UserMessages + stats.AIMessages + stats.SystemMessages
+	logger.Any(InfoHistorySize, session.ChatConfig.HistorySize, session.ChatConfig.HistorySize*2, stored)
+	return false, nil // Continue the session.
+}
+
+// HandleSubcommand processes ":history :size <number>", which changes the number of messages sent to the AI
+// and kept in the chat history. Messages beyond the new size are dropped right away.
+func (cmd *handleHistoryCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	if len(parts) != 3 {
+		return false, invalidArgsError(HistoryCommand, parts)
+	}
+	size, err := strconv.Atoi(parts[2])
+	if err != nil || size < 1 {
+		return false, invalidArgsError(HistoryCommand, parts)
+	}
+	session.ChatConfig.HistorySize = size
+	session.ChatConfig.HistorySendToAI = size
+	dropped := session.ChatHistory.Trim(session.ChatConfig)
+	logger.Any(InfoHistorySizeSet, size, dropped)
+	return false, nil // Continue the session.
+}
+
+// Execute runs the setup wizard from within a session and applies the new model and safety level to it.
+// The new API key is used the next time the client is created.
+func (cmd *handleSetupCommand) Execute(session *Session, parts []string) (bool, error) {
+	if !cmd.IsValid(parts) {
+		return false, invalidArgsError(SetupCommand, parts)
+	}
+
+	config, err := session.runSetupWizard()
+	if err != nil {
+		return false, fmt.Errorf(ErrorSetupFailed, err)
+	}
+	session.applyAppConfig(config)
+	return false, nil // Continue the session.
+}
+
+// Execute processes the ":report" command, which prints a bug report with the environment, the recent errors
+// and the retry statistics, and a link that opens a pre-filled GitHub issue.
+func (c *reportshitFunctionthatTooComplexCommand) Execute(session *Session, parts []string) (bool, error) {
+	if !c.IsValid(parts) {
+		return false, invalidArgsError(ReportCommand, parts)
+	}
+	session.printIssueReport(false)
+	return false, nil // Continue the session.
+}
+
+// HandleSubcommand processes ":report :crash", which also includes the latest crash report file.
+func (c *reportshitFunctionthatTooComplexCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	if len(parts) != 2 {
+		return false, invalidArgsError(ReportCommand, parts)
+	}
+	session.printIssueReport(true)
+	return false, nil // Continue the session.
+}
+
+// Execute shows the telemetry status and the exact payload that is sent.
+func (cmd *handleTelemetryCommand) Execute(session *Session, parts []string) (bool, error) {
+	if !cmd.IsValid(parts) {
+		return false, invalidArgsError(TelemetryCommand, parts)
+	}
+	printTelemetryStatus()
+	return false, nil // Continue the session.
+}
+
+// HandleSubcommand processes ":telemetry on", ":telemetry off" and ":telemetry status".
+// The choice is saved to the config file, so it is kept for the next sessions.
+func (cmd *handleTelemetryCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	if len(parts) != 2 {
+		return false, invalidArgsError(TelemetryCommand, parts)
+	}
+	if subcommand != StatusArgs {
+		if err := setTelemetry(subcommand == On); err != nil {
+			return false, fmt.Errorf(ErrorFailedToSaveTelemetry, err)
+		}
+	}
+	printTelemetryStatus()
+	return false, nil // Continue the session.
+}
+
+// Execute shows the status of the supervised background workers.
+func (cmd *handleWorkersCommand) Execute(session *Session, parts []string) (bool, error) {
+	if !cmd.IsValid(parts) {
+		return false, invalidArgsError(WorkersCommand, parts)
+	}
+	fmt.Fprint(session.Output, formatWorkerStatuses(supervisor.Statuses(), time.Now()))
+	return false, nil // Continue the session.
+}
+
+// Execute processes ":compact [number]", which summarizes the conversation and replaces all but
+// the most recent messages (DefaultCompactKeep unless a number is given) with the summary.
+func (cmd *handleCompactCommand) Execute(session *Session, parts []string) (bool, error) {
+	keep := DefaultCompactKeep
+	if len(parts) == 2 {
+		number, err := strconv.Atoi(parts[1])
+		if err != nil || number < 0 {
+			return false, invalidArgsError(CompactCommand, parts)
+		}
+		keep = number
+	}
+
+	// Note: Ctrl+C or the deadline cancels the summary instead of waiting for a stalled API.
+	ctx, done := session.beginTimedOperation(SummarizeTimeout)
+	defer done()
+	logger.Any(InfoCompacting)
+	result, err := session.compactHistory(ctx, keep)
+	if err != nil {
+		return false, deadlineError(ctx, CompactCommand, SummarizeTimeout, fmt.Errorf(ErrorFailedToCompact, err))
+	}
+	logger.Any(InfoCompacted, result.Removed, result.TokensBefore, result.TokensAfter, result.TokensBefore-result.TokensAfter)
+	return false, nil // Continue the session.
+}
@@ -6,7 +6,9 @@ package terminal
 
 import (
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 )
 
 // CommandHandler defines the function signature for handling chat commands.
@@ -19,20 +21,32 @@ type CommandHandler interface {
 }
 
 // CommandRegistry is a centralized registry to manage chat commands.
-// It maps command names to their corresponding CommandHandler implementations.
+// It maps command names to the CommandSpec that routes their arguments to the CommandHandler implementations.
 // This allows for a scalable and maintainable way to manage chat commands
 // and their execution within a chat session.
 type CommandRegistry struct {
-	commands    map[string]CommandHandler            // commands holds the association of command names to their handlers.
-	subcommands map[string]map[string]CommandHandler // New field for subcommands
+	commands map[string]*CommandSpec // commands holds the association of command names to their specs.
+	hooks    []CommandHook           // hooks are notified after every command execution (e.g., the audit log).
 }
 
-// RegisterSubcommand for a base command.
+// AddHook registers a hook that is called after every command execution, including unrecognized commands.
+// Hooks are called in the order they were added.
+func (r *CommandRegistry) AddHook(hook CommandHook) {
+	r.hooks = append(r.hooks, hook)
+}
+
+// RegisterSubcommand adds a subcommand and its handler to the spec of a base command.
+// The base command is registered first, with Register or RegisterSpec.
 func (r *CommandRegistry) RegisterSubcommand(baseCommand, subcommand string, handler CommandHandler) {
-	if _, exists := r.subcommands[baseCommand]; !exists {
-		r.subcommands[baseCommand] = make(map[string]CommandHandler)
+	spec, exists := r.commands[baseCommand]
+	if !exists {
+		spec = &CommandSpec{}
+		r.commands[baseCommand] = spec
+	}
+	if spec.Subcommands == nil {
+		spec.Subcommands = make(map[string]CommandHandler)
 	}
-	r.subcommands[baseCommand][subcommand] = handler
+	spec.Subcommands[subcommand] = handler
 }
 
 // NewCommandRegistry initializes a new instance of CommandRegistry.
@@ -43,26 +57,43 @@ func (r *CommandRegistry) RegisterSubcommand(baseCommand, subcommand string, han
 //	*CommandRegistry: A pointer to a newly created CommandRegistry with initialized command map.
 func NewCommandRegistry() *CommandRegistry {
 	return &CommandRegistry{
-		commands:    make(map[string]CommandHandler),
-		subcommands: make(map[string]map[string]CommandHandler), // Initialize the subcommands map
+		commands: make(map[string]*CommandSpec),
 	}
 }
 
 // Register adds a new command and its associated handler to the registry.
-// If a command with the same name is already registered, it will be overwritten.
+// The command takes no arguments other than its subcommands (see RegisterSpec).
+// If a command with the same name is already registered, its handler will be overwritten.
 //
 // Parameters:
 //
 //	name string: The name of the command to register.
 //	cmd  CommandHandler: The handler that will be associated with the command.
 func (r *CommandRegistry) Register(name string, cmd CommandHandler) {
-	r.commands[name] = cmd
+	r.RegisterSpec(name, CommandSpec{Handler: cmd})
+}
+
+// RegisterSpec adds a new command and the spec that routes its arguments to the registry.
+// The subcommands already registered for the command are kept.
+func (r *CommandRegistry) RegisterSpec(name string, spec CommandSpec) {
+	if existing, exists := r.commands[name]; exists && spec.Subcommands == nil {
+		spec.Subcommands = existing.Subcommands
+	}
+	r.commands[name] = &spec
+}
+
+// handler returns the handler of the command, or nil if the command is not registered.
+func (r *CommandRegistry) handler(name string) CommandHandler {
+	if spec, exists := r.commands[name]; exists {
+		return spec.Handler
+	}
+	return nil
 }
 
 // ExecuteCommand looks up and executes a command based on its name.
-// It first validates the command arguments using the IsValid method of the command handler.
-// If the command is valid, it executes the command using the Execute method.
-// If the command name is not registered, it logs an error.
+// The arguments are routed by the spec of the command: to the handler of a subcommand, or, after they are
+// validated, to the Execute method of the command handler.
+// Every failure is returned as a *CommandError with its error code, including an unregistered command name.
 //
 // Parameters:
 //
@@ -73,56 +104,80 @@ func (r *CommandRegistry) Register(name string, cmd CommandHandler) {
 // Returns:
 //
 //	bool: A boolean indicating if the command execution should terminate the session.
-//	error: An error if one occurs during command validation or execution. Returns nil if no error occurs.
+//	error: A *CommandError if the command is unrecognized, its arguments are invalid, or it fails.
+//	       Returns nil if no error occurs.
 //
 // Note:
 //
-//	If the command is unrecognized, it logs an error but does not return it,
-//	as the error is already handled within the method.
+//	The error is not shown to the user here; the caller decides how to report it (see reportCommandError).
 func (r *CommandRegistry) ExecuteCommand(name string, session *Session, parts []string) (bool, error) {
 	// Note: For better dynamic logging, further debugging is needed here.
 	logger.Debug(DEBUGEXECUTINGCMD, name, parts)
 
-	// Look up the command handler in the registry.
-	cmd, exists := r.commands[name]
-	if !exists {
-		logger.Error(ErrorUnrecognizedCommand, name)
-		return false, nil
+	event := CommandEvent{Name: name, Args: parts[1:], Time: time.Now()}
+	defer func() {
+		event.Duration = time.Since(event.Time)
+		for _, hook := range r.hooks {
+			hook(event)
+		}
+	}()
+
+	// Look up the command spec in the registry.
+	spec, exists := r.commands[name]
+	if !exists || spec.Handler == nil {
+		event.Err = newCommandError(name, CommandErrorUnknownCommand, fmt.Errorf(ErrorUnrecognizedCommand, name))
+		return false, event.Err
 	}
+	event.Recognized = true
 
-	// Use a switch to handle special commands or default to subcommand execution.
-	// Note: By refactoring with a switch statement like this, the complexity of multiple if statements is avoided.
-	switch name {
-	case AITranslateCommand,
-		CheckModelCommands,
-		SwitchModelCommands:
-		return cmd.Execute(session, parts)
-	default:
-		// For other commands, check for subcommands.s
-		if len(parts) > 1 {
-			return r.executeSubcommand(name, session, parts)
+	// Note: The safety settings of the session are restored even if the command fails.
+	defer session.useCommandSafety(name)()
+
+	event.EndsSession, event.Err = r.dispatch(name, spec, session, parts)
+	event.Err = asCommandError(name, event.Err)
+	return event.EndsSession, event.Err
+}
+
+// dispatch routes the arguments as declared by the spec of the command: a registered subcommand goes to its
+// handler, and anything else goes to the command handler, provided the command accepts it.
+func (r *CommandRegistry) dispatch(name string, spec *CommandSpec, session *Session, parts []string) (bool, error) {
+	if len(parts) > 1 {
+		if subcmdHandler, ok := spec.Subcommands[parts[1]]; ok {
+			return subcmdHandler.HandleSubcommand(parts[1], session, parts)
+		}
+		if !spec.FreeArgs {
+			// The subcommand does not exist, so the error lists the subcommands that do.
+			err := fmt.Errorf(ErrorUnrecognizedSubCommand, name, parts[1], spec.subcommandNames())
+			return false, newCommandError(name, CommandErrorUnknownSubcommand, err)
 		}
-		// If no subcommands, execute the main command.
-		return cmd.Execute(session, parts)
 	}
+	if spec.Validate != nil && !spec.Validate(parts) {
+		return false, invalidArgsError(name, parts)
+	}
+	return spec.Handler.Execute(session, parts)
 }
 
-func (r *CommandRegistry) executeSubcommand(baseCommand string, session *Session, parts []string) (bool, error) {
-	subcommand := parts[1]
-	subcmdHandler, ok := r.subcommands[baseCommand][subcommand]
-	if !ok {
-		// If the subcommand does not exist, log the error and return.
-		logger.Error(ErrorUnrecognizedSubCommand, baseCommand, subcommand)
-		return false, nil
-	}
+// freeArgsSpec returns the spec of a command whose arguments are free text (e.g., a prompt or a path) rather than
+// subcommands. The arguments are checked by the IsValid method of the handler.
+func freeArgsSpec(cmd CommandHandler) CommandSpec {
+	return CommandSpec{Handler: cmd, FreeArgs: true, Validate: cmd.IsValid}
+}
 
-	// Execute the subcommand handler.
-	return subcmdHandler.HandleSubcommand(subcommand, session, parts)
+// subcommandNames returns the sorted names of the subcommands, or "none" if the command has no subcommands.
+func (spec *CommandSpec) subcommandNames() string {
+	if len(spec.Subcommands) == 0 {
+		return None
+	}
+	names := make([]string, 0, len(spec.Subcommands))
+	for name := range spec.Subcommands {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return strings.Join(names, dotStringComma)
 }
 
 // isCommand checks if the input is a command based on the prefix.
 func isCommand(input string) bool {
-	fmt.Println() // Add newline if it's a command or unrecognized command
 	return strings.HasPrefix(input, PrefixChar)
 }
 
@@ -130,7 +185,7 @@ func isCommand(input string) bool {
 func (s *Session) handleCommand(input string) bool {
 	handled, err := HandleCommand(input, s)
 	if err != nil {
-		logger.Error(ErrorUnknown, err)
+		reportCommandError(err)
 	}
 	return handled
 }
@@ -138,7 +193,7 @@ func (s *Session) handleCommand(input string) bool {
 // HandleCommand interprets the user input as a command and executes the associated action.
 // It uses a map of command strings to their corresponding handler functions to manage
 // different commands and their execution. If the command is recognized, the respective
-// handler is called; otherwise, an unknown command message is displayed.
+// handler is called; otherwise, an unknown command error is returned.
 //
 // Parameters:
 //
@@ -148,14 +203,14 @@ func (s *Session) handleCommand(input string) bool {
 // Returns:
 //
 //	bool: A boolean indicating if the input was a command and was handled.
-//	error: An error that may occur while handling the command.
+//	error: A *CommandError if the command failed, which is not shown to the user (see handleCommand).
 func HandleCommand(input string, session *Session) (bool, error) {
 	trimmedInput := strings.TrimSpace(input)
 	if !strings.HasPrefix(trimmedInput, PrefixChar) {
 		return false, nil
 	}
 
-	parts := strings.Fields(trimmedInput)
+	parts := splitQuotedArgs(trimmedInput)
 	if len(parts) == 0 {
 		// Note: this low-level error and should be not happen, but just in case
 		return true, fmt.Errorf(ErrorLowLevelCommand)
@@ -163,14 +218,11 @@ func HandleCommand(input string, session *Session) (bool, error) {
 
 	// Validate the command arguments.
 	commandName := parts[0]
-	// Use Magic identifier "_" to ignore the error element, since it duplicates the error handling.
-	handled, _ := registry.ExecuteCommand(commandName, session, parts)
-	// if err != nil {
-	// 	// Since ExecuteCommand already logs errors,
-	// 	// keep like this for now, because this palace are low-level error
-	// 	return false, err
-	// }
-	return handled, nil
+	// In viewer mode, only the commands that never call the API are available.
+	if session.ViewOnly && !viewerCommands[commandName] {
+		return false, newCommandError(commandName, CommandErrorNotAvailable, fmt.Errorf(ErrorNotAvailableInViewer, commandName))
+	}
+	return registry.ExecuteCommand(commandName, session, parts)
 }
 
 // Note: This list of commands has already been implemented.
@@ -344,8 +396,8 @@ type handleTokeCountingCommand struct{}
 
 func (cmd *handleTokeCountingCommand) IsValid(parts []string) bool {
 	// The token count command should have at least three parts: the command, subcommand, and at least one file path.
-	// Additionally, the second part should be the FileCommands subcommand.
-	return len(parts) >= 3 && parts[1] == FileCommands
+	// Additionally, the second part should be the FileCommands or EstimateArgs subcommand.
+	return len(parts) >= 3 && (parts[1] == FileCommands || parts[1] == EstimateArgs)
 }
 
 type handleCheckModelCommand struct{}
@@ -364,8 +416,8 @@ func (cmd *handleCheckModelCommand) HandleSubcommand(subcommand string, session
 type handleSwitchModelCommand struct{}
 
 func (cmd *handleSwitchModelCommand) IsValid(parts []string) bool {
-	// The command should have exactly two parts: the command itself and the model name.
-	return len(parts) == 2
+	// The command takes the model name, or nothing to pick the model from a list.
+	return len(parts) == 1 || len(parts) == 2
 }
 
 func (cmd *handleSwitchModelCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
@@ -373,17 +425,430 @@ func (cmd *handleSwitchModelCommand) HandleSubcommand(subcommand string, session
 	return true, nil
 }
 
+// handleGenConfigCommand is the command to show the effective generation configuration.
+type handleGenConfigCommand struct{}
+
+func (cmd *handleGenConfigCommand) IsValid(parts []string) bool {
+	// The genconfig command should not have any arguments.
+	return len(parts) == 1
+}
+
+func (cmd *handleGenConfigCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	// The genconfig command should not have any subcommand.
+	return true, nil
+}
+
+// handleGenerationParamCommand is the command to show or set a generation parameter of the session, such as
+// ":temperature 0.5". The key names the parameter in generationOptions.
+type handleGenerationParamCommand struct {
+	key string
+}
+
+func (cmd *handleGenerationParamCommand) IsValid(parts []string) bool {
+	// The command has at most one argument: the new value.
+	return len(parts) <= 2
+}
+
+func (cmd *handleGenerationParamCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	// The generation parameter commands are always executed directly, see freeArgsSpec.
+	return false, nil
+}
+
+// handlePersonaCommand is the command to show, set or remove the persona of the session.
+type handlePersonaCommand struct{}
+
+func (cmd *handlePersonaCommand) IsValid(parts []string) bool {
+	// Without arguments, the persona command shows the persona. Otherwise, the text spans the remaining parts.
+	return len(parts) >= 1
+}
+
+func (cmd *handlePersonaCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	// The persona command is always executed directly, see freeArgsSpec.
+	return false, nil
+}
+
+// handleExportCommand is the command to write the chat transcript to a file.
+// The export command is expected to follow the pattern: :export :file <path>
+type handleExportCommand struct{}
+
+func (cmd *handleExportCommand) IsValid(parts []string) bool {
+	return len(parts) == 3 && parts[1] == FileCommands
+}
+
+// handleConfigCommand is the command to adjust generation settings.
+// The config command is expected to follow the pattern: :config set <key> <value>
+// The value may contain spaces (e.g., a quoted stop sequence), so it spans the remaining parts.
+type handleConfigCommand struct{}
+
+// IsValid checks if the config command is valid based on the input parts.
+func (cmd *handleConfigCommand) IsValid(parts []string) bool {
+	if len(parts) < 4 || parts[1] != SetArgs {
+		return false
+	}
+	option, exists := generationOptions[parts[2]]
+	return exists && option.Valid
+}
+
+// handleReplayCommand is the command to re-render the chat history without calling the AI.
+type handleReplayCommand struct{}
+
+func (cmd *handleReplayCommand) IsValid(parts []string) bool {
+	// The replay command should not have any arguments.
+	return len(parts) == 1
+}
+
+func (cmd *handleReplayCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	// The replay command should not have any subcommand.
+	return true, nil
+}
+
+// handleShareCommand is the command to export the chat transcript as Markdown.
+type handleShareCommand struct{}
+
+func (cmd *handleShareCommand) IsValid(parts []string) bool {
+	// The share command should not have any arguments.
+	return len(parts) == 1
+}
+
+// handleImportCommand is the command to import chats exported from the ChatGPT or Gemini web UIs.
+type handleImportCommand struct{}
+
+func (cmd *handleImportCommand) IsValid(parts []string) bool {
+	// The import command only works through its subcommands.
+	return false
+}
+
+// handleWatchCommand is the command to re-send a prompt whenever a watched file changes.
+type handleWatchCommand struct{}
+
+func (cmd *handleWatchCommand) IsValid(parts []string) bool {
+	// The watch command requires a file path followed by a prompt.
+	return len(parts) >= 3
+}
+
+func (cmd *handleWatchCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	// The watch command is always executed directly, see freeArgsSpec.
+	return false, nil
+}
+
+// handleScheduleCommand is the command to manage prompts that run on a cron schedule.
+type handleScheduleCommand struct{}
+
+func (cmd *handleScheduleCommand) IsValid(parts []string) bool {
+	// The schedule command requires a cron expression and a prompt, or a subcommand.
+	return len(parts) >= 2
+}
+
+// handleFanoutCommand is the command to send many prompts from a file concurrently.
+type handleFanoutCommand struct{}
+
+func (cmd *handleFanoutCommand) IsValid(parts []string) bool {
+	// The fanout command requires a prompt file and accepts an optional output file.
+	return len(parts) == 2 || len(parts) == 3
+}
+
+func (cmd *handleFanoutCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	// The fanout command is always executed directly, see freeArgsSpec.
+	return false, nil
+}
+
+// handleDryRunCommand is the command to toggle dry-run mode, where requests are shown instead of sent.
+type handleDryRunCommand struct{}
+
+func (cmd *handleDryRunCommand) IsValid(parts []string) bool {
+	// Without arguments, the dryrun command shows the current state.
+	return len(parts) == 1
+}
+
+// handleQuietCommand is the command to toggle quiet mode, where non-essential system messages are hidden.
+type handleQuietCommand struct{}
+
+func (cmd *handleQuietCommand) IsValid(parts []string) bool {
+	// Without arguments, the quiet command shows the current state.
+	return len(parts) == 1
+}
+
+// handleAuditCommand is the command to review the audit log of executed commands.
+type handleAuditCommand struct{}
+
+func (cmd *handleAuditCommand) IsValid(parts []string) bool {
+	// The audit command only works through its subcommands.
+	return false
+}
+
+// reportshitFunctionthatTooComplexCommand is the ":report" command, which prepares a bug report from inside the app.
+// Note: The name is kept from when it was only an idea, for reporting problems that are not caused by this terminal.
+type reportshitFunctionthatTooComplexCommand struct{}
+
+func (c *reportshitFunctionthatTooComplexCommand) IsValid(parts []string) bool {
+	// The report command takes no arguments, or the ":crash" subcommand.
+	return len(parts) == 1
+}
+
+// handleCompactCommand is the command to replace the older chat history with a summary.
+type handleCompactCommand struct{}
+
+func (cmd *handleCompactCommand) IsValid(parts []string) bool {
+	// The compact command takes an optional number of recent messages to keep.
+	return len(parts) == 1 || len(parts) == 2
+}
+
+func (cmd *handleCompactCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	// The compact command is always executed directly, see freeArgsSpec.
+	return false, nil
+}
+
+// handleWorkersCommand is the command to show the status of the supervised background workers.
+type handleWorkersCommand struct{}
+
+func (cmd *handleWorkersCommand) IsValid(parts []string) bool {
+	// The workers command does not take any arguments.
+	return len(parts) == 1
+}
+
+func (cmd *handleWorkersCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	// The workers command has no subcommands.
+	return false, nil
+}
+
+// handleTelemetryCommand is the command to opt in to or out of the anonymous telemetry.
+type handleTelemetryCommand struct{}
+
+func (cmd *handleTelemetryCommand) IsValid(parts []string) bool {
+	// Without arguments, the telemetry command shows the current state.
+	return len(parts) == 1
+}
+
+// handleSetupCommand is the command to run the setup wizard again.
+type handleSetupCommand struct{}
+
+func (cmd *handleSetupCommand) IsValid(parts []string) bool {
+	// The setup command does not take any arguments.
+	return len(parts) == 1
+}
+
+func (cmd *handleSetupCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	// The setup command has no subcommands.
+	return false, nil
+}
+
+// handleSearchCommand is the command to search the chat history for a text.
+type handleSearchCommand struct{}
+
+func (cmd *handleSearchCommand) IsValid(parts []string) bool {
+	// The search command requires the text to search for.
+	return len(parts) >= 2
+}
+
+// handleDiffCommand is the command to compare two stored sessions.
+type handleDiffCommand struct{}
+
+func (cmd *handleDiffCommand) IsValid(parts []string) bool {
+	// The diff command requires the names of both sessions.
+	return len(parts) == 3
+}
+
+func (cmd *handleDiffCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	// The diff command is always executed directly, see freeArgsSpec.
+	return false, nil
+}
+
+// handleCheckpointCommand is the command to save and restore named copies of the chat history.
+type handleCheckpointCommand struct{}
+
+func (cmd *handleCheckpointCommand) IsValid(parts []string) bool {
+	// Without arguments, the checkpoint command lists the checkpoints.
+	return len(parts) == 1
+}
+
+// handleQuoteCommand is the command to ask a follow-up about a single earlier message.
+type handleQuoteCommand struct{}
+
+func (cmd *handleQuoteCommand) IsValid(parts []string) bool {
+	// The quote command requires the message number and the prompt.
+	return len(parts) >= 3
+}
+
+func (cmd *handleQuoteCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	// The quote command is always executed directly, see freeArgsSpec.
+	return false, nil
+}
+
+// handleIncognitoCommand is the command to send a prompt without keeping it in the chat history.
+type handleIncognitoCommand struct{}
+
+func (cmd *handleIncognitoCommand) IsValid(parts []string) bool {
+	// The incognito command requires the prompt.
+	return len(parts) >= 2
+}
+
+func (cmd *handleIncognitoCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	// The incognito command is always executed directly, see freeArgsSpec.
+	return false, nil
+}
+
+// handlePromptCommand is the command to list and override the built-in prompts.
+type handlePromptCommand struct{}
+
+func (cmd *handlePromptCommand) IsValid(parts []string) bool {
+	// Without arguments, the prompt command lists the prompts.
+	return len(parts) == 1
+}
+
+// handlePromptfileCommand is the command to load a seed prompt from files.
+type handlePromptfileCommand struct{}
+
+func (cmd *handlePromptfileCommand) IsValid(parts []string) bool {
+	// The prompt file command requires the file subcommand and at least one path.
+	return len(parts) >= 3 && parts[1] == FileCommands
+}
+
+// handleInfoCommand is the command to show the status of the session.
+type handleInfoCommand struct{}
+
+func (cmd *handleInfoCommand) IsValid(parts []string) bool {
+	// The info command does not take any arguments.
+	return len(parts) == 1
+}
+
+func (cmd *handleInfoCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	// The info command has no subcommands.
+	return false, nil
+}
+
+// handleApplyDiffCommand is the command to apply a unified diff from the AI responses to a file.
+type handleApplyDiffCommand struct{}
+
+func (cmd *handleApplyDiffCommand) IsValid(parts []string) bool {
+	// The applydiff command requires the file.
+	return len(parts) == 2
+}
+
+func (cmd *handleApplyDiffCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	// The applydiff command is always executed directly, see freeArgsSpec.
+	return false, nil
+}
+
+// handleRememberCommand is the command to remember a fact across sessions.
+type handleRememberCommand struct{}
+
+func (cmd *handleRememberCommand) IsValid(parts []string) bool {
+	// The remember command requires the fact.
+	return len(parts) >= 2
+}
+
+func (cmd *handleRememberCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	// The remember command is always executed directly, see freeArgsSpec.
+	return false, nil
+}
+
+// handleMemoryCommand is the command to list and forget the remembered facts.
+type handleMemoryCommand struct{}
+
+func (cmd *handleMemoryCommand) IsValid(parts []string) bool {
+	// Without arguments, the memory command lists the remembered facts.
+	return len(parts) == 1
+}
+
+// handleRetryPolicyCommand is the command to show and change the retry policy of the session.
+type handleRetryPolicyCommand struct{}
+
+func (cmd *handleRetryPolicyCommand) IsValid(parts []string) bool {
+	// Without arguments, the retry policy command shows the retry policy.
+	return len(parts) == 1
+}
+
+// handleShowCommand is the command to show a part of the chat history, such as the summary.
+type handleShowCommand struct{}
+
+func (cmd *handleShowCommand) IsValid(parts []string) bool {
+	// The show command only works through its subcommands.
+	return false
+}
+
+// handleHistoryCommand is the command to show or change the size of the chat history.
+type handleHistoryCommand struct{}
+
+func (cmd *handleHistoryCommand) IsValid(parts []string) bool {
+	// Without arguments, the history command shows the current size.
+	return len(parts) == 1
+}
+
+// handleK8sCommand is the command to explain the output of read-only kubectl commands with the AI.
+type handleK8sCommand struct{}
+
+func (cmd *handleK8sCommand) IsValid(parts []string) bool {
+	// The k8s command requires the explain subcommand, a kubectl verb and its arguments.
+	return len(parts) >= 3 && parts[1] == ExplainArgs
+}
+
+// handleDockerCommand is the command to diagnose the logs of Docker containers and Compose services with the AI.
+type handleDockerCommand struct{}
+
+func (cmd *handleDockerCommand) IsValid(parts []string) bool {
+	// The docker command requires a subcommand, the container or service and optionally the number of lines.
+	return len(parts) == 3 || len(parts) == 4
+}
+
+// handleLogsCommand is the command to summarize the anomalies in the system logs with the AI.
+type handleLogsCommand struct{}
+
+func (cmd *handleLogsCommand) IsValid(parts []string) bool {
+	// The logs command requires the system subcommand and optionally a unit.
+	return (len(parts) == 2 || len(parts) == 3) && parts[1] == SystemArgs
+}
+
+// handleFixCommand is the command to diagnose and fix an error from the clipboard or pasted text with the AI.
+type handleFixCommand struct{}
+
+func (cmd *handleFixCommand) IsValid(parts []string) bool {
+	// The fix command reads the clipboard without arguments, or pasted lines up to the marker of "<<MARKER".
+	return len(parts) == 1 || len(parts) == 2 && len(parts[1]) > len(HeredocPrefix) && strings.HasPrefix(parts[1], HeredocPrefix)
+}
+
+func (cmd *handleFixCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	// The fix command is always executed directly, see freeArgsSpec.
+	return false, nil
+}
+
+// handleGoCommand is the command to explain the output of go doc and go test with the AI.
+type handleGoCommand struct{}
+
+func (cmd *handleGoCommand) IsValid(parts []string) bool {
+	// The go command requires the doc subcommand and a symbol, or the test subcommand followed by ":explain".
+	return len(parts) >= 3 && (parts[1] == DocArgs || parts[1] == TestArgs && parts[2] == GoExplainArgs)
+}
+
+// handleImageCommand is the command to ask the AI about an image.
+type handleImageCommand struct{}
+
+func (cmd *handleImageCommand) IsValid(parts []string) bool {
+	// The image command requires the path of the image, optionally followed by a prompt.
+	return len(parts) >= 2
+}
+
+func (cmd *handleImageCommand) HandleSubcommand(subcommand string, session *Session, parts []string) (bool, error) {
+	// The image command is always executed directly, see freeArgsSpec.
+	return false, nil
+}
+
+// handleQueueCommand is the command to inspect and cancel prompts queued while a response is in flight.
+type handleQueueCommand struct{}
+
+func (cmd *handleQueueCommand) IsValid(parts []string) bool {
+	// The queue command lists the pending prompts when it has no arguments.
+	return len(parts) == 1
+}
+
 // Note: this unimplemented
 // Now even it's unimplemented, it wont detected in deadcode indicate that "unreachable func"
 //
 // Additional Note: The reason 'unimplemented' is placed here is because it's relatively easy to implement, and I want to ensure I don't forget about it.
 // In contrast, implementing features like colorization or ASCII Art is more challenging.
 // For instance, colorization requires capturing patterns from AI responses and reformatting them, which can be complex.
-type handleK8sCommand struct{}
 type storageCommand struct{}
 type savehistorytostorageCommand struct{}
 type loadhistoryfromstorageCommand struct{}
-type reportshitFunctionthatTooComplexCommand struct{}
 type handlepingCommand struct{}
 
 // IsValid checks if the ping command is valid based on the input parts.
@@ -401,6 +866,4 @@ type translateCommand struct{}
 
 type fixDocsFormattingCommand struct{}
 
-type handlePromptfileCommand struct{}
-
 type handleKaliDocsCommand struct{}
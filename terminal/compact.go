@@ -0,0 +1,179 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: Compacting combines ":summarize" with trimming the history: the AI summarizes the whole conversation,
+// then everything except the most recent messages is replaced by that summary as a system message.
+// The token counts before and after are offline estimates (see EstimateTokens), so compacting itself costs a single request.
+
+package terminal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Compact replaces all messages except the keep most recent chat messages with the summary as a system message.
+// The previous summary is dropped as well, since the new summary covers it, while system messages of other
+// categories (e.g., a persona) are kept.
+//
+// Parameters:
+//
+//	summary string: The summary of the conversation, without the system prefix.
+//	keep int: The number of most recent user and AI messages to keep.
+//
+// Returns:
+//
+//	int: The number of messages that were replaced by the summary.
+func (h *ChatHistory) Compact(summary string, keep int) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sysMsgs, chatMsgs := h.separateSystemMessages(h.Messages)
+	kept := chatMsgs[len(chatMsgs)-min(keep, len(chatMsgs)):]
+	summaryMessage := fmt.Sprintf(ObjectHighLevelStringWithNewLine, SYSTEMPREFIX, h.SanitizeMessage(summary))
+
+	messages := make([]string, 0, len(sysMsgs)+len(kept)+1)
+	for _, sysMsg := range sysMsgs {
+		if systemMessageRule(sysMsg).Category != systemMessageRule(summaryMessage).Category {
+			messages = append(messages, sysMsg)
+		}
+	}
+	removed := len(h.Messages) - len(messages) - len(kept)
+	messages = append(messages, summaryMessage)
+	h.Messages = append(messages, kept...)
+
+	// Rebuild the hashes and the counts for the new messages.
+	h.reindexHashes()
+	h.recountMessages()
+	return removed
+}
+
+// recountMessages recomputes the message counts from Messages after messages were removed.
+// The caller must hold the lock.
+func (h *ChatHistory) recountMessages() {
+	h.UserMessageCount, h.AIMessageCount, h.SystemMessageCount = 0, 0, 0
+	for _, message := range h.Messages {
+		switch DetermineMessageType(message) {
+		case SystemMessage:
+			h.SystemMessageCount++
+		case AIMessage:
+			h.AIMessageCount++
+		default:
+			h.UserMessageCount++
+		}
+	}
+}
+
+// autoCompact compacts the chat history before the input is sent, if the history and the input would exceed
+// the configured percentage of the model's input token limit (see AutoCompactPercent). It prints a one-line notice.
+// Failures are reported, but the input is still sent, since the history may still fit.
+func (s *Session) autoCompact(input string) {
+	percent := appConfig.AutoCompactPercent
+	if percent == 0 || s.DryRun {
+		return
+	}
+
+	ctx, done := s.beginTimedOperation(SummarizeTimeout)
+	defer done()
+	limit, err := s.inputTokenLimit(ctx)
+	if err != nil {
+		logger.Debug(DebugInputTokenLimitUnknown, err)
+		return
+	}
+	estimate := EstimateTokens(s.ChatHistory.GetHistory(s.ChatConfig) + input)
+	if estimate*100 <= limit*percent {
+		return
+	}
+
+	result, err := s.compactHistory(ctx, DefaultCompactKeep)
+	if err != nil {
+		if !reportTimeout(ctx, CompactCommand, SummarizeTimeout) {
+			logger.Error(ErrorFailedToCompact, err)
+		}
+		return
+	}
+	s.notify(InfoAutoCompacted, percent, result.Removed, result.TokensBefore, result.TokensAfter)
+}
+
+// inputTokenLimit returns the input token limit of the current model, fetched once per model.
+// A model whose limit cannot be fetched is not asked again, unless the fetch was cancelled.
+func (s *Session) inputTokenLimit(ctx context.Context) (int, error) {
+	modelName := s.DefaultModelName
+	if s.CurrentModelName != "" {
+		modelName = s.CurrentModelName
+	}
+	if limit, ok := s.inputTokenLimits[modelName]; ok {
+		return limit, nil
+	}
+	if err, ok := s.inputTokenLimitErrs[modelName]; ok {
+		return 0, err
+	}
+
+	info, err := fetchModelInfo(ctx, s.Client, modelName)
+	if err != nil {
+		if ctx.Err() == nil {
+			if s.inputTokenLimitErrs == nil {
+				s.inputTokenLimitErrs = make(map[string]error)
+			}
+			s.inputTokenLimitErrs[modelName] = err
+		}
+		return 0, err
+	}
+	if s.inputTokenLimits == nil {
+		s.inputTokenLimits = make(map[string]int)
+	}
+	s.inputTokenLimits[modelName] = int(info.InputTokenLimit)
+	return int(info.InputTokenLimit), nil
+}
+
+// estimateHistoryTokens approximates the number of tokens of the whole chat history.
+func (h *ChatHistory) estimateHistoryTokens() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return EstimateTokens(strings.Join(h.Messages, ""))
+}
+
+// compactHistory asks the AI for a summary of the conversation and compacts the chat history with it.
+// Unlike ":summarize", the summary is not printed, so it can also run before sending a message.
+//
+// Parameters:
+//
+//	ctx context.Context: The context for controlling the cancellation of the request.
+//	keep int: The number of most recent user and AI messages to keep.
+//
+// Returns:
+//
+//	CompactResult: The number of replaced messages and the estimated tokens before and after.
+//	error: An error if there is nothing to compact or the summary could not be generated.
+func (s *Session) compactHistory(ctx context.Context, keep int) (CompactResult, error) {
+	result := CompactResult{TokensBefore: s.ChatHistory.estimateHistoryTokens()}
+	stats := s.ChatHistory.GetMessageStats()
+	if stats.UserMessages+stats.AIMessages <= keep {
+		return result, errors.New(ErrorNothingToCompact)
+	}
+	if s.DryRun {
+		return result, errors.New(ErrorCompactDryRun)
+	}
+
+	// Note: The summary covers the full history, regardless of the history size in ChatConfig.
+	conversation := s.ChatHistory.GetHistory(&ChatConfig{HistorySize: math.MaxInt})
+	prompt := conversation + StringNewLine + promptTemplate(PromptTemplateSummarize)
+	summary, err := sendFanoutPrompt(ctx, s.ConfigureModelForSession(ctx), s.ChatHistory.SanitizeMessage(prompt))
+	if err != nil {
+		return result, err
+	}
+	if strings.TrimSpace(summary) == "" {
+		return result, errors.New(ErrorEmptySummary)
+	}
+
+	result.Removed = s.ChatHistory.Compact(SummaryPrefix+summary, keep)
+	result.TokensAfter = s.ChatHistory.estimateHistoryTokens()
+	s.saveHistory()
+	// The facts are extracted from the conversation before it was compacted.
+	s.extractMemoryFacts(ctx, conversation)
+	return result, nil
+}
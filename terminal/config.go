@@ -120,6 +120,23 @@ func WithMaxOutputTokens(maxOutputTokens int32) (ModelConfig, error) {
 	}, nil
 }
 
+// WithStopSequences creates a ModelConfig function to set the stop sequences
+// of a GenerativeModel. The AI stops generating output as soon as it produces
+// any of the given sequences; the sequence itself is not included in the response.
+//
+// Parameters:
+//
+//	sequences ...string: The stop sequences to set.
+//
+// Returns:
+//
+//	ModelConfig: A function that sets the stop sequences when applied to a model.
+func WithStopSequences(sequences ...string) ModelConfig {
+	return func(m *genai.GenerativeModel) {
+		m.StopSequences = sequences
+	}
+}
+
 // WithSafetyOptions creates a ModelConfig function to set the safety options
 // for a GenerativeModel. It allows the application of model-specific safety
 // settings based on the provided modelName, enabling fine-grained control over
@@ -0,0 +1,25 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+
+//go:build !unix && !windows
+// +build !unix,!windows
+
+package terminal
+
+import (
+	"context"
+	"errors"
+)
+
+// enableVirtualTerminal does nothing, as the output of platforms other than unix and Windows is not a console.
+func enableVirtualTerminal() {}
+
+// consoleWidth cannot measure the width on platforms other than unix and Windows (e.g., js/wasm or plan9),
+// so COLUMNS or DefaultTerminalWidth is used instead (see measureTerminalWidth).
+func consoleWidth() (int, error) {
+	return 0, errors.ErrUnsupported
+}
+
+// watchTerminalResize does nothing, as there is no resize signal to watch.
+func watchTerminalResize(ctx context.Context) {}
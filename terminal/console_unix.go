@@ -0,0 +1,49 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+
+//go:build unix
+// +build unix
+
+package terminal
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// enableVirtualTerminal does nothing, as terminals other than the Windows console process ANSI escape codes by default.
+func enableVirtualTerminal() {}
+
+// consoleWidth returns the width of the terminal the standard output is connected to.
+func consoleWidth() (int, error) {
+	size, err := unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ)
+	if err != nil {
+		return 0, err
+	}
+	return int(size.Col), nil
+}
+
+// watchTerminalResize measures the width of the terminal, and again whenever it is resized (SIGWINCH),
+// until the context is done.
+func watchTerminalResize(ctx context.Context) {
+	terminalColumns.Store(int32(measureTerminalWidth()))
+	resized := make(chan os.Signal, 1)
+	signal.Notify(resized, syscall.SIGWINCH)
+	go func() {
+		defer signal.Stop(resized)
+		for {
+			select {
+			case <-ctx.Done():
+				terminalColumns.Store(0)
+				return
+			case <-resized:
+				terminalColumns.Store(int32(measureTerminalWidth()))
+			}
+		}
+	}()
+}
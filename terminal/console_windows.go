@@ -0,0 +1,35 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+
+//go:build windows
+// +build windows
+
+package terminal
+
+import (
+	"context"
+
+	"github.com/H0llyW00dzZ/GoGenAI-Terminal-Chat/terminal/windows"
+)
+
+// enableVirtualTerminal enables the processing of ANSI escape codes on the Windows console (see the
+// windows package). If the console does not support escape codes (before Windows 10), the plain theme
+// is applied instead, and the escape codes that are part of the formatting itself (e.g., bold text)
+// are removed from the output.
+func enableVirtualTerminal() {
+	if err := windows.EnableVirtualTerminal(); err != nil {
+		logger.Debug(DebugNoVirtualTerminal, err)
+		colors = themes[PlainTheme]
+		stdout = &escapeStripWriter{output: stdout}
+	}
+}
+
+// consoleWidth returns the width of the visible part of the console the standard output is connected to.
+func consoleWidth() (int, error) {
+	return windows.ConsoleWidth()
+}
+
+// watchTerminalResize does nothing, as the Windows console has no resize signal.
+// The width is measured whenever it is needed instead.
+func watchTerminalResize(ctx context.Context) {}
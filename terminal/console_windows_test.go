@@ -0,0 +1,75 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+
+//go:build windows
+// +build windows
+
+package terminal
+
+import (
+	"os"
+	"testing"
+
+	"golang.org/x/sys/windows"
+)
+
+// restoreConsoleOutput restores the standard output, the output writer and the colors after a test.
+func restoreConsoleOutput(t *testing.T) {
+	savedFile, savedStdout, savedColors := os.Stdout, stdout, colors
+	t.Cleanup(func() {
+		os.Stdout, stdout, colors = savedFile, savedStdout, savedColors
+	})
+}
+
+func TestEnableVirtualTerminalLeavesRedirectedOutput(t *testing.T) {
+	restoreConsoleOutput(t)
+	file, err := os.CreateTemp(t.TempDir(), "output")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	os.Stdout = file
+	before, theme := stdout, colors
+
+	enableVirtualTerminal()
+
+	if stdout != before {
+		t.Errorf("the output of a file was replaced with %T", stdout)
+	}
+	if colors != theme {
+		t.Error("the theme of the output of a file was changed")
+	}
+}
+
+func TestEnableVirtualTerminalOnConsole(t *testing.T) {
+	restoreConsoleOutput(t)
+	console, err := os.OpenFile("CONOUT$", os.O_RDWR, 0)
+	if err != nil {
+		t.Skipf("no console is attached: %v", err)
+	}
+	defer console.Close()
+	handle := windows.Handle(console.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(handle, &mode); err != nil {
+		t.Skipf("the console mode is not available: %v", err)
+	}
+	defer windows.SetConsoleMode(handle, mode)
+	if err := windows.SetConsoleMode(handle, mode&^windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING); err != nil {
+		t.Skipf("the console mode cannot be changed: %v", err)
+	}
+	os.Stdout = console
+
+	enableVirtualTerminal()
+
+	var enabled uint32
+	if err := windows.GetConsoleMode(handle, &enabled); err != nil {
+		t.Fatal(err)
+	}
+	if enabled&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING == 0 {
+		// Consoles before Windows 10 cannot process escape codes, so they must be removed instead.
+		if _, ok := stdout.(*escapeStripWriter); !ok {
+			t.Errorf("escape codes are neither processed nor removed, the output is %T", stdout)
+		}
+	}
+}
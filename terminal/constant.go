@@ -11,6 +11,16 @@ const (
 	// GitHubAPIURL is the endpoint for the latest release information of the application.
 	GitHubAPIURL      = "https://api.github.com/repos/H0llyW00dzZ/GoGenAI-Terminal-Chat/releases/latest"
 	GitHubReleaseFUll = "https://api.github.com/repos/H0llyW00dzZ/GoGenAI-Terminal-Chat/releases/tags/%s"
+	// GitHubGistAPIURL is the endpoint for creating Gists, used by the ":share" command.
+	GitHubGistAPIURL    = "https://api.github.com/gists"
+	GitHubNewIssueURL   = "https://github.com/H0llyW00dzZ/GoGenAI-Terminal-Chat/issues/new"
+	GitHubAcceptJSON    = "application/vnd.github+json"
+	ContentTypeJSON     = "application/json"
+	HeaderAuthorization = "Authorization"
+	HeaderAccept        = "Accept"
+	HeaderContentType   = "Content-Type"
+	GoogleAPIKeyHeader  = "x-goog-api-key"
+	BearerPrefix        = "Bearer "
 	// CurrentVersion represents the current version of the application.
 	CurrentVersion = "v0.9.3"
 )
@@ -20,6 +30,9 @@ const (
 	SignalMessage                    = " Received an interrupt, shutting down gracefully..." // fix formatting ^C in linux/unix
 	RecoverGopher                    = "%s - %s - %sRecovered from panic:%s %s%v%s"
 	StackTracePanic                  = "\n%sStack Trace:\n%s%s"
+	EnvironmentPanic                 = "\n%sEnvironment:\n%s%s"
+	CrashReportWritten               = "\nA crash report was written to %s, please attach it when reporting this issue.\n"
+	ErrorFailedToWriteCrashReport    = "\nFailed to write the crash report: %v\n"
 	StackPossiblyTruncated           = "...stack trace possibly truncated...\n"
 	ObjectHighLevelString            = "%s %s"   // Catch High level string
 	ObjectHighLevelStringWithSpace   = "%s %s "  // Catch High level string with space
@@ -41,8 +54,17 @@ const (
 	GeminiProVision = "gemini-pro-vision"
 	GeminiProTuning = "gemini-1.0-pro-001"
 	GeminiProFlash  = "gemini-1.5-flash-latest"
+	// EmbeddingModelKeyword identifies embedding models (e.g., "embedding-001", "text-embedding-004") by name.
+	EmbeddingModelKeyword = "embedding"
+	// NotAvailable is displayed when a model does not report a value.
+	NotAvailable = "n/a"
 	// this may subject to changed in future for example can customize the delay
 	TypingDelay = 60 * time.Millisecond
+	// TypingEffectChar, TypingEffectWord and TypingEffectLine print the typing effect a character,
+	// a word or a line at a time (see TYPING_EFFECT).
+	TypingEffectChar = "char"
+	TypingEffectWord = "word"
+	TypingEffectLine = "line"
 	// this clearing chat history in secret storage
 	ChatHistoryClear = ColorHex95b806 + "All Chat history cleared." + ColorReset
 	// reset total token usage
@@ -99,22 +121,82 @@ const (
 		DoubleAsterisk + "%s" + DoubleAsterisk + " (high), " + DoubleAsterisk + "%s" + DoubleAsterisk + " (unspecified), " + DoubleAsterisk + "%s" + DoubleAsterisk + " (none).\n" +
 		DoubleAsterisk + "%s" + DoubleAsterisk + " <text> " + DoubleAsterisk + "%s" + DoubleAsterisk + " <target language>: Translate text to the specified language.\n" +
 		DoubleAsterisk + "%s" + DoubleAsterisk + " " + DoubleAsterisk + "%s" + DoubleAsterisk + " <number>: Generate a random string of the specified length.\n" +
-		DoubleAsterisk + "%s" + DoubleAsterisk + ": Summarize a current conversation\n\n" +
+		DoubleAsterisk + "%s" + DoubleAsterisk + ": Summarize a current conversation\n" +
+		DoubleAsterisk + "%s %s" + DoubleAsterisk + ": Show only the current summary, without the rest of the chat history.\n\n" +
 		DoubleAsterisk + "Note" + DoubleAsterisk + ": When you summarize a current conversation, it will be displayed at the top of the chat history.\n\n" +
-		DoubleAsterisk + "%s" + DoubleAsterisk + ": Switch the model for the current conversation.\n\n" +
-		DoubleAsterisk + "Note" + DoubleAsterisk + ": The current model-switching feature supports only the following models: " +
+		DoubleAsterisk + "%s" + DoubleAsterisk + " [model-name]: Switch the model for the current conversation. Without a model name, pick one from a numbered list of the available models.\n\n" +
+		DoubleAsterisk + "Note" + DoubleAsterisk + ": A typed model name must be one of the following models: " +
 		DoubleAsterisk + "%s" + DoubleAsterisk + ", " + DoubleAsterisk + "%s" + DoubleAsterisk + ", " + DoubleAsterisk + "%s" + DoubleAsterisk + "\n\n" +
 		DoubleAsterisk + "%s %s" + DoubleAsterisk + " " + DoubleAsterisk + "%s" + DoubleAsterisk + ": Show the chat history.\n" +
 		DoubleAsterisk + "%s" + DoubleAsterisk + " " + DoubleAsterisk + "%s" + DoubleAsterisk + ": Show the chat statistic.\n" +
 		DoubleAsterisk + "%s" + DoubleAsterisk + " " + DoubleAsterisk + "%s" + DoubleAsterisk + ": Clear all system summary messages from the chat history.\n" +
 		DoubleAsterisk + "%s" + DoubleAsterisk + " " + DoubleAsterisk + "%s" + DoubleAsterisk + ": Clear all chat history and reset the total token usage count if enabled.\n" +
 		DoubleAsterisk + "%s" + DoubleAsterisk + " <" + DoubleAsterisk + "model-name" + DoubleAsterisk + ">: Check the details of a specific AI model.\n" +
+		DoubleAsterisk + "%s" + DoubleAsterisk + " " + DoubleAsterisk + "%s" + DoubleAsterisk + ": Compare all available AI models in a table.\n" +
+		DoubleAsterisk + "%s" + DoubleAsterisk + ": Show the generation configuration of the current AI model.\n" +
+		DoubleAsterisk + "%s" + DoubleAsterisk + ", " + DoubleAsterisk + "%s" + DoubleAsterisk + ", " + DoubleAsterisk + "%s" + DoubleAsterisk + " or " + DoubleAsterisk + "%s" + DoubleAsterisk + " [value]: Show or set the temperature (0 to 2), top P (0 to 1), top K (1 or higher) or maximum output tokens of the session. The value " + DoubleAsterisk + "%s" + DoubleAsterisk + " restores the default.\n" +
+		DoubleAsterisk + "%s" + DoubleAsterisk + ": Show the retry policy of the session, " + DoubleAsterisk + "%s %s" + DoubleAsterisk + " <setting> <value> to change it (" + RetryKeyMaxRetries + ", " + RetryKeyBaseDelay + ", " + RetryKeyMaxDelay + ", " + RetryKeyJitter + " or " + RetryKeyStatusCodes + ", e.g. 429,500,503), or " + DoubleAsterisk + "%s %s" + DoubleAsterisk + " to go back to the config.\n" +
+		DoubleAsterisk + "%s" + DoubleAsterisk + " [text]: Show or set the persona, an instruction the AI follows during the whole session (e.g., \"Answer as a senior Go reviewer\"). The value " + DoubleAsterisk + "%s" + DoubleAsterisk + " removes it.\n" +
+		DoubleAsterisk + "%s" + DoubleAsterisk + ": Replay the chat history with the typing effect, without calling the AI.\n" +
+		DoubleAsterisk + "%s" + DoubleAsterisk + ": Export the sanitized chat transcript as Markdown (uploaded as a private Gist if GITHUB_TOKEN is set).\n" +
+		DoubleAsterisk + "%s %s" + DoubleAsterisk + ": Export the sanitized chat transcript as a standalone HTML file.\n" +
+		DoubleAsterisk + "%s %s" + DoubleAsterisk + " <path>: Export the sanitized chat transcript to the file, as HTML if it ends in .html, otherwise as Markdown.\n" +
+		DoubleAsterisk + "%s %s" + DoubleAsterisk + " <conversations.json> [number]: Import a ChatGPT export (the latest conversation, or the given one) into the chat history.\n" +
+		DoubleAsterisk + "%s %s" + DoubleAsterisk + " <MyActivity.json>: Import Gemini Apps activity from Google Takeout into the chat history.\n" +
+		DoubleAsterisk + "%s" + DoubleAsterisk + " <" + DoubleAsterisk + "path/file.go" + DoubleAsterisk + "> <prompt>: Send the prompt with the file content, then again whenever the file changes (at most once every 30 seconds). Press Ctrl+C to stop.\n" +
+		DoubleAsterisk + "%s" + DoubleAsterisk + " \"<cron>\" \"<prompt>\" [" + DoubleAsterisk + "%s" + DoubleAsterisk + " <file>]: Queue a prompt on a cron schedule (e.g., \"0 9 * * *\"), sending it like a typed prompt and optionally saving the response to a file.\n" +
+		DoubleAsterisk + "%s %s" + DoubleAsterisk + ": List the scheduled prompts.\n" +
+		DoubleAsterisk + "%s %s" + DoubleAsterisk + " <id>: Cancel a scheduled prompt.\n" +
+		DoubleAsterisk + "%s" + DoubleAsterisk + " <prompts.txt> [output.md]: Send every prompt in the file concurrently (one per line, or separated by \"---\" lines) and save the responses to a Markdown file.\n" +
+		DoubleAsterisk + "%s %s|%s" + DoubleAsterisk + ": Toggle dry-run mode, which shows what would be sent to the AI (model, token estimate, settings and content) without calling the API.\n" +
+		DoubleAsterisk + "%s" + DoubleAsterisk + " or " + DoubleAsterisk + "%s %s" + DoubleAsterisk + " <number>: Show or change the number of recent messages sent to the AI as context (twice as many are kept), dropping older messages right away.\n" +
+		DoubleAsterisk + "%s %s|%s" + DoubleAsterisk + ": Toggle quiet mode, which hides the separators, token counts, prompt feedback and automatic notices, but not the AI responses.\n" +
+		DoubleAsterisk + "%s %s" + DoubleAsterisk + " [number]: Show the most recent entries of the command audit log (enabled with AUDIT_LOG=true).\n" +
+		DoubleAsterisk + "%s" + DoubleAsterisk + " <text>: Search the chat history for messages containing the text, ignoring case.\n" +
+		DoubleAsterisk + "%s %s" + DoubleAsterisk + " <query>: Search the web with the engine of the web_search config section (or WEB_SEARCH) and summarize the results into the conversation.\n" +
+		DoubleAsterisk + "%s %s" + DoubleAsterisk + " <get|describe|logs> <args>: Run a read-only kubectl command and ask the AI to explain its output. Secrets are never read.\n" +
+		DoubleAsterisk + "%s %s" + DoubleAsterisk + " <container> [lines]: Read the most recent log lines of a container (500 by default) and ask the AI to diagnose the errors. " +
+		"Use " + DoubleAsterisk + "%s %s" + DoubleAsterisk + " <service> [lines] for a Docker Compose service.\n" +
+		DoubleAsterisk + "%s %s" + DoubleAsterisk + " [unit]: Read the most recent lines of the system journal (or syslog), optionally of a single unit, mask hostnames and IP addresses, and ask the AI to summarize the anomalies.\n" +
+		DoubleAsterisk + "%s" + DoubleAsterisk + " [<<MARKER]: Ask the AI to diagnose and fix the error (e.g., a stack trace or compiler output) in the clipboard, " +
+		"or pasted up to a MARKER line. The fix is shown as a diff, which " + DoubleAsterisk + "%s" + DoubleAsterisk + " can apply.\n" +
+		DoubleAsterisk + "%s" + DoubleAsterisk + " <path> [prompt]: Send a PNG, JPEG, WEBP or HEIC image with the prompt (by default, a request to describe it) to a vision model and show the answer.\n" +
+		DoubleAsterisk + "%s %s" + DoubleAsterisk + " <symbol>: Run go doc for the symbol (e.g., net/http.Client) and ask the AI to explain its documentation.\n" +
+		DoubleAsterisk + "%s %s %s" + DoubleAsterisk + " [packages and flags]: Run go test (on ./... by default) and ask the AI to explain the failures.\n" +
+		DoubleAsterisk + "%s" + DoubleAsterisk + " [number]: Summarize the conversation and replace all but the most recent messages (4 by default) with the summary, reporting the tokens saved.\n" +
+		DoubleAsterisk + "%s" + DoubleAsterisk + " <sessionA> <sessionB>: Compare two stored sessions message by message and show the messages removed from and added to the first one.\n" +
+		DoubleAsterisk + "%s %s" + DoubleAsterisk + " <name>: Save a copy of the chat history in memory under the name.\n" +
+		DoubleAsterisk + "%s %s" + DoubleAsterisk + " <name>: Go back to the saved copy of the chat history, e.g., to ask a question differently.\n" +
+		DoubleAsterisk + "%s" + DoubleAsterisk + ": List the saved checkpoints of this session.\n" +
+		DoubleAsterisk + "%s" + DoubleAsterisk + " <number> <prompt>: Ask a follow-up about message number <number> (as numbered by " + DoubleAsterisk + "%s" + DoubleAsterisk + "), sending only that message as context instead of the whole history.\n" +
+		DoubleAsterisk + "%s" + DoubleAsterisk + " <prompt>: Ask a one-off question that is not added to the chat history, the stored session, transcripts or the audit log.\n" +
+		DoubleAsterisk + "%s" + DoubleAsterisk + ": Show the session status: model, safety level, temperature, history usage, tokens used, uptime and storage path.\n" +
+		DoubleAsterisk + "%s" + DoubleAsterisk + " <file>: Apply the latest unified diff for the file from the AI responses, after showing it and asking for confirmation.\n" +
+		DoubleAsterisk + "%s" + DoubleAsterisk + " <fact>: Remember a fact (e.g., a preference) across sessions. The most recent facts are sent with every message.\n" +
+		DoubleAsterisk + "%s" + DoubleAsterisk + " or " + DoubleAsterisk + "%s %s" + DoubleAsterisk + ": List the remembered facts.\n" +
+		DoubleAsterisk + "%s %s" + DoubleAsterisk + " <id>: Forget a remembered fact.\n" +
+		DoubleAsterisk + "%s" + DoubleAsterisk + ": List the prompts used for the opening message and by commands, and whether they were customized.\n" +
+		DoubleAsterisk + "%s %s" + DoubleAsterisk + " <name> <text>: Replace a prompt (" + PromptTemplateContext + ", " + PromptTemplateSummarize + ", " + PromptTemplateTranslate + ", " + PromptTemplateShutdown + ", " + PromptTemplateExtractFacts + " or " + PromptTemplateWebSearch + ") and save it to the config file. The text must keep the %%s placeholders of the original, and may use the variables {{date}}, {{time}}, {{os}}, {{cwd}} and {{model}}.\n" +
+		DoubleAsterisk + "%s %s" + DoubleAsterisk + " <path> [path...]: Load a seed prompt from .txt or .md files, joined in order. It is added as a system message that stays at the top of the context, replacing the previous seed prompt.\n" +
+		DoubleAsterisk + "%s" + DoubleAsterisk + ": Run the setup wizard to change the API key, preferred model, safety level and theme.\n" +
+		DoubleAsterisk + "%s" + DoubleAsterisk + ": Prepare a bug report with the environment, recent errors and retry statistics, and a link to a pre-filled GitHub issue.\n" +
+		DoubleAsterisk + "%s %s" + DoubleAsterisk + ": Same as above, including the latest crash report file (see CRASH_REPORTS).\n" +
+		DoubleAsterisk + "%s" + DoubleAsterisk + ": Show the background workers (scheduler, input reader, signal handler), their state and how often they were restarted after a crash.\n" +
+		DoubleAsterisk + "%s %s|%s|%s" + DoubleAsterisk + ": Opt in to or out of anonymous telemetry of aggregate counters (messages sent, error classes, version), or show exactly what is sent.\n" +
+		DoubleAsterisk + "%s" + DoubleAsterisk + ": List the prompts typed while a response was in flight, which are waiting to be processed.\n" +
+		DoubleAsterisk + "%s %s" + DoubleAsterisk + " <number>: Remove a pending prompt from the queue.\n" +
+		DoubleAsterisk + "%s %s" + DoubleAsterisk + ": Remove all pending prompts from the queue.\n" +
+		DoubleAsterisk + "%s %s %s" + DoubleAsterisk + " <number>: Set the maximum number of output tokens for the current AI model.\n" +
+		DoubleAsterisk + "%s %s %s" + DoubleAsterisk + " \"<sequence>\": Add a stop sequence (up to 5), or " + DoubleAsterisk + "%s" + DoubleAsterisk + " to clear them.\n" +
+		DoubleAsterisk + "%s %s %s" + DoubleAsterisk + " <number>: Not available yet: the SDK has no generation seed, so reproducible responses cannot be requested.\n" +
 		DoubleAsterisk + "%s" + DoubleAsterisk + " " + DoubleAsterisk + "%s" + DoubleAsterisk + " <" + DoubleAsterisk + "path/file/data.txt" + DoubleAsterisk + "> or <" +
-		DoubleAsterisk + "data.txt" + DoubleAsterisk + ">: Counts a token from the specified file.\n\n" +
-		DoubleAsterisk + "Note" + DoubleAsterisk + ": The token count file feature supports multiple files simultaneously with the following extensions: " +
+		DoubleAsterisk + "data.txt" + DoubleAsterisk + ">: Counts a token from the specified file or directory. Press Ctrl+C to cancel a long batch.\n" +
+		DoubleAsterisk + "%s" + DoubleAsterisk + " " + DoubleAsterisk + "%s" + DoubleAsterisk + " <files>: Estimate tokens offline without calling the API. Results are approximate.\n" +
+		"Add " + DoubleAsterisk + "%s" + DoubleAsterisk + " json|csv [" + DoubleAsterisk + "%s" + DoubleAsterisk + " <file>] to either of them to print the count of each file and the total as JSON or CSV, or save them to the file.\n\n" +
+		DoubleAsterisk + "Note" + DoubleAsterisk + ": The token count file feature supports multiple files simultaneously with the following extensions by default: " +
 		dotMD + dotStringComma + dotTxt + dotStringComma + dotPng + dotStringComma +
 		dotJpg + dotStringComma + dotJpeg + dotStringComma + dotWebp + dotStringComma +
-		dotHeic + dotStringComma + dotHeif + ".\n" + "Also, note that .txt and .md files are currently only supported by gemini-pro.\n\n" +
+		dotHeic + dotStringComma + dotHeif + ".\n" + "More text file extensions can be allowed with the " + AllowedFileExtensionsEnv + " environment variable or the config file.\n" + "Also, note that .txt and .md files are currently only supported by gemini-pro.\n\n" +
 		DoubleAsterisk + "Additional Note" + DoubleAsterisk + ": There are no additional commands or HTML Markdown available " +
 		"because this is a terminal application and is limited.\n"
 	// TranslateCommandPrompt commands
@@ -149,10 +231,80 @@ const (
 	FileCommands        = ":file"
 	CheckModelCommands  = ":checkmodel"
 	SwitchModelCommands = ":switchmodel"
+	GenConfigCommand    = ":genconfig"
+	ConfigCommand       = ":config"
+	ReplayCommand       = ":replay"
+	ShareCommand        = ":share"
+	ImportCommand       = ":import"
+	WatchCommand        = ":watch"
+	ScheduleCommand     = ":schedule"
+	QueueCommand        = ":queue"
+	FanoutCommand       = ":fanout"
+	DryRunCommand       = ":dryrun"
+	AuditCommand        = ":audit"
+	SearchCommand       = ":search"
+	SetupCommand        = ":setup"
+	ReportCommand       = ":report"
+	TelemetryCommand    = ":telemetry"
+	WorkersCommand      = ":workers"
+	CompactCommand      = ":compact"
+	DiffCommand         = ":diff"
+	CheckpointCommand   = ":checkpoint"
+	QuoteCommand        = ":quote"
+	IncognitoCommand    = ":incognito"
+	PromptCommand       = ":prompt"
+	InfoCommand         = ":info"
+	ApplyDiffCommand    = ":applydiff"
+	RememberCommand     = ":remember"
+	MemoryCommand       = ":memory"
+	QuietCommand        = ":quiet"
+	HistoryCommand      = ":history"
+	K8sCommand          = ":k8s"
+	DockerCommand       = ":docker"
+	LogsCommand         = ":logs"
+	FixCommand          = ":fix"
+	GoCommand           = ":go"
+	ImageCommand        = ":image"
+	TemperatureCommand  = ":temperature"
+	TopPCommand         = ":topp"
+	TopKCommand         = ":topk"
+	MaxTokensCommand    = ":maxtokens"
+	ExportCommand       = ":export"
+	RetryPolicyCommand  = ":retrypolicy"
+	PersonaCommand      = ":persona"
 	PingCommand         = ":ping" // Currently marked as TODO
 	PrefixChar          = ":"
 	// List args
 	ChatHistoryArgs = "history"
+	AllArgs         = ":all"
+	SetArgs         = "set"
+	EstimateArgs    = ":estimate"
+	FormatArgs      = ":format"
+	SizeArgs        = ":size"
+	SummaryArgs     = "summary"
+	WebArgs         = ":web"
+	ExplainArgs     = "explain"
+	LogsArgs        = "logs"
+	ComposeArgs     = "compose"
+	SystemArgs      = ":system"
+	DocArgs         = "doc"
+	TestArgs        = "test"
+	GoExplainArgs   = ":explain"
+	HTMLArgs        = ":html"
+	CrashArgs       = ":crash"
+	ChatGPTArgs     = ":chatgpt"
+	GeminiArgs      = ":gemini"
+	OutputArgs      = ":output"
+	ListArgs        = ":list"
+	CancelArgs      = ":cancel"
+	On              = "on"
+	Off             = "off"
+	StatusArgs      = "status"
+	SaveArgs        = "save"
+	RestoreArgs     = "restore"
+	ListFactsArgs   = "list"
+	ForgetArgs      = "forget"
+	ResetArgs       = "reset"
 )
 
 // Defined List error message
@@ -182,7 +334,7 @@ const (
 	ErrorWhileTypingCommandArgs                     = "Invalid %s Command Arguments: %v"
 	ErrorPingFailed                                 = "Ping failed: %v"
 	ErrorUnrecognizedCommand                        = "Unrecognized command: %s"
-	ErrorUnrecognizedSubCommand                     = "Unrecognized %s command sub commands/args : %s"
+	ErrorUnrecognizedSubCommand                     = "Unrecognized %s command sub commands/args : %s (sub commands: %s)"
 	ErrorLowLevelCommand                            = "command cannot be empty"
 	ErrorUnknown                                    = "An error occurred: %v"
 	ErrorUnknownSafetyLevel                         = "Unknown safety level: %s"
@@ -198,27 +350,166 @@ const (
 	ErrorFailedToSendVersionCheckMessage            = "Failed to send version check message: %v"
 	ErrorFailedToSendVersionCheckMessageAfterReties = "Failed to send version check message after retries" // low level
 	ErrorFailedToSendTranslationMessage             = "Failed to send translation message: %v"
-	ErrorFailedToSendTranslationMessageAfterRetries = "Failed to send translation message after retries" // low level
-	ErrorFailedToApplyModelConfiguration            = "failed to apply model configuration"              // low level
-	ErrorMaxOutputTokenMustbe                       = "maxOutputTokens must be %d or higher, got %d"     // low level
+	ErrorFailedToSendTranslationMessageAfterRetries = "Failed to send translation message after retries"                  // low level
+	ErrorFailedToApplyModelConfiguration            = "failed to apply model configuration"                               // low level
+	ErrorMaxOutputTokenMustbe                       = "maxOutputTokens must be %d or higher, got %d"                      // low level
+	ErrorMaxOutputTokenExceedsLimit                 = "maxOutputTokens must not exceed %d for model %s, got %d"           // low level
+	ErrorInvalidConfigValue                         = "invalid value %q for %s"                                           // low level
+	ErrorTooManyStopSequences                       = "at most %d stop sequences are allowed, use \"none\" to clear them" // low level
+	ErrorGenerationParamRange                       = "%s must be between %g and %g, got %s"                              // low level
+	ErrorGenerationParamMin                         = "%s must be %d or higher, got %s"                                   // low level
+	ErrorSeedUnsupported                            = "%s is not supported by this SDK, so it cannot be sent to the AI"   // low level
+	ErrorFailedToApplyConfig                        = "Failed to apply config: %v"
 	ErrorFailedToSendSummarizeMessage               = "Failed To Send Summarize Message: %v"
 	ErrorFailedToSendSummarizeMessageAfterRetries   = "failed to send summarize message after retries" // low level
 	ErrorFailedToReadFile                           = "Failed to read the file at %s: %v"
+	ErrorFailedToParseAppConfig                     = "failed to parse config file %s: %v"                   // low level
+	ErrorUnknownRedactionProfile                    = "unknown redaction profile %q, available profiles: %s" // low level
+	ErrorInvalidSessionName                         = "invalid session name %q"                              // low level
+	ErrorInvalidSessionFile                         = "stored session %s is invalid: %v"                     // low level
+	ErrorSessionLocked                              = "session lock %s is held by process %d"                // low level
+	ErrorSetupAborted                               = "the input ended before the setup was complete"        // low level
+	ErrorLowLevelInvalidAPIKey                      = "the API key was rejected"                             // low level
+	ErrorFailedToLoadAppConfig                      = "Failed to load config, using defaults: %v"
+	ErrorInvalidHTTPConfig                          = "Invalid http config, using the default HTTP client: %v"
+	ErrorInvalidHTTPProxy                           = "invalid proxy URL %q: %v"                               // low level
+	ErrorInvalidTLSVersion                          = "unsupported min_tls_version %q, use \"1.2\" or \"1.3\"" // low level
+	ErrorNoCertificatesInCAFile                     = "no PEM certificates found in %s"                        // low level
+	ErrorInvalidRetryPolicy                         = "Invalid retry_policy config, using the default retry policy: %v"
+	ErrorNegativeRetryPolicy                        = "retry policy values must not be negative"       // low level
+	ErrorInvalidRetryJitter                         = "jitter must be between 0 and 1, got %v"         // low level
+	ErrorRetryMaxDelayTooShort                      = "max delay %v is shorter than the base delay %v" // low level
+	ErrorFailedToSetRetryPolicy                     = "Failed to change the retry policy: %v"
+	ErrorInvalidRetryStatusCode                     = "retryable status codes must be HTTP error codes (400 to 599), got %d" // low level
+	ErrorUnknownRetryPolicyKey                      = "unknown retry policy setting %q, expected one of: %s"                 // low level
+	ErrorPositiveRetryPolicyValue                   = "invalid value %q for %s: expected a positive number"                  // low level
+	ErrorInvalidRetryPolicyEnv                      = "invalid %s: %w"                                                       // low level
+	ErrorRetryBudgetExhausted                       = "Too many failed requests in a short time, so retries are paused. Requests are tried only once for the next %v"
+	ErrorLowLevelRetryBudgetExhausted               = "retry budget exhausted: %w" // low level
+	ErrorOperationTimedOut                          = "Operation %s cancelled after %v without a response. The API may be slow or unreachable: check your network, try again later, or run :report if it keeps happening"
+	ErrorFailedToCompact                            = "Failed to compact the chat history: %v"
+	ErrorNothingToCompact                           = "there are not enough messages to compact"                                          // low level
+	ErrorCompactDryRun                              = "compacting is not available in dry-run mode, since it needs a summary from the AI" // low level
+	ErrorEmptySummary                               = "the AI returned an empty summary"                                                  // low level
+	ErrorInvalidAutoCompactPercent                  = "invalid auto_compact_percent %q: expected a number from 0 to 100"                  // low level
+	ErrorInvalidTypingEffect                        = "invalid typing_effect %q: expected \"char\", \"word\" or \"line\""                 // low level
+	ErrorInvalidMemoryFacts                         = "invalid memory_facts %q: expected 0 or a positive number"                          // low level
+	ErrorInvalidCommandSafety                       = "invalid command_safety level %q for %s"                                            // low level
+	ErrorInvalidMemoryFile                          = "memory file %s is invalid: %v"                                                     // low level
+	ErrorFailedToLoadMemory                         = "Failed to load the remembered facts, they will not be sent: %v"
+	ErrorFailedToRemember                           = "Failed to remember the fact: %v"
+	ErrorFailedToForget                             = "Failed to forget the fact: %v"
+	ErrorFactNotFound                               = "there is no remembered fact #%d" // low level
+	ErrorFailedToExtractFacts                       = "Failed to extract facts about you from the conversation: %v"
+	ErrorInvalidTokenCountFormat                    = "Invalid token count format %q, expected \"json\" or \"csv\""
+	ErrorFailedToSaveTokenCounts                    = "Failed to save the token counts: %v"
+	ErrorInvalidPreflight                           = "invalid preflight %q: expected \"estimate\" or \"count\""
+	ErrorInvalidPreflightTokens                     = "invalid preflight_tokens %q: expected 0 or a positive number"
+	ErrorInlineCommandFailed                        = "Failed to run %s in the prompt, it was not sent: %v"
+	ErrorNotInlineCommand                           = "the %s command cannot be used in a prompt" // low level
+	ErrorFailedToIncludeFile                        = "Failed to include %s in the prompt, it was not sent: %v"
+	ErrorIncludedFileIsImage                        = "only text files can be included, %s is an image" // low level
+	ErrorInvalidIncludeURL                          = "only http and https URLs can be included"        // low level
+	ErrorIncludeURLStatus                           = "the server responded with %s"                    // low level
+	ErrorIncludeURLContentType                      = "pages of type %q cannot be included"             // low level
+	ErrorWebSearchDisabled                          = "Web search is not configured: set the engine in the web_search section of the config file, or WEB_SEARCH"
+	ErrorFailedToSearchWeb                          = "Failed to search the web: %v"
+	ErrorKubectlVerbNotAllowed                      = "Only read-only kubectl commands can be explained (get, describe or logs), not %q"
+	ErrorKubectlSecretsNotAllowed                   = "Secrets are never sent to the AI, so %q cannot be explained"
+	ErrorKubectlFlagNotAllowed                      = "The %s flag waits for changes, so its output cannot be explained"
+	ErrorFailedToRunKubectl                         = "Failed to run kubectl: %v"
+	ErrorFailedToReadLogs                           = "Failed to read the logs of %s: %v"
+	ErrorFailedToAnalyzeLogs                        = "Failed to analyze part %d of the logs: %v"
+	ErrorInvalidContainerName                       = "Invalid container or service name %q"
+	ErrorInvalidLogLines                            = "Invalid number of log lines %q, expected a positive number"
+	ErrorNoSystemLog                                = "journalctl is not available and no system log file can be read"              // low level
+	ErrorInvalidWebSearch                           = "invalid web_search engine %q: expected \"brave\", \"google\" or \"searxng\"" // low level
+	ErrorWebSearchRequires                          = "web_search with the %s engine requires %s"                                   // low level
+	ErrorIncludedFilesTooLarge                      = "The included files and pages are ~%d tokens, more than the model's input limit of %d tokens, so the prompt was not sent"
+	ErrorFailedToReadAuditLog                       = "Failed to read the audit log: %v"
+	ErrorFailedToReadErrorText                      = "Failed to read the error to fix: %v"
+	ErrorNoClipboardTool                            = "no clipboard tool found, install one of: %s" // low level
+	ErrorFailedToRunGo                              = "Failed to run go %s: %v"
+	ErrorFailedToLoadPromptFiles                    = "Failed to load the prompt files: %v"
+	ErrorInvalidPromptFile                          = "%s is not a .txt or .md file" // low level
+	ErrorEmptyPromptFiles                           = "the prompt files are empty"   // low level
+	ErrorFailedToBackupSession                      = "Failed to back up the chat history to %s: %v"
+	ErrorInvalidBackup                              = "invalid backup config: %s" // low level
+	ErrorFailedToAutoSummarize                      = "Failed to summarize the oldest messages in the background: %v"
+	ErrorFailedToSendImage                          = "Failed to send the image %s: %v"
+	ErrorInvalidAutoSummary                         = "invalid auto_summary config: %s"                        // low level
+	ErrorImageTooLarge                              = "the image is %d bytes, more than the limit of %d bytes" // low level
+	ErrorFailedToExportTranscript                   = "Failed to export the transcript to %s: %v"
+	ErrorInvalidRecorderMode                        = "unknown recorder mode %q, expected record or replay" // low level
+	ErrorNoRecordedInteraction                      = "no recorded interaction left for %s %s"              // low level
+	ErrorRecordedInteractionMismatch                = "the next recorded interaction is %s %s, not %s %s"   // low level
+	ErrorFailedToOpenSession                        = "Failed to open the stored session %q, the chat history will not be saved: %v"
+	ErrorNotAvailableInViewer                       = "The %s command is not available in viewer mode, nothing is sent to the API"
+	ErrorViewerCannotSend                           = "Viewer mode is read-only, messages are not sent to the API"
+	ErrorStoredSessionNotFound                      = "Stored session %q not found in %s"
+	ErrorFailedToDiffSessions                       = "Failed to compare the sessions %q and %q: %v"
+	ErrorFailedToRestoreCheckpoint                  = "Failed to restore the checkpoint: %v"
+	ErrorCheckpointNotFound                         = "checkpoint %q not found" // low level
+	ErrorFailedToSendQuote                          = "Failed to send the quoted prompt: %v"
+	ErrorInvalidQuoteNumber                         = "there is no message %d, the chat history has %d messages" // low level
+	ErrorFailedToSendIncognito                      = "Failed to send the incognito prompt: %v"
+	ErrorFailedToApplyDiff                          = "Failed to apply the diff: %v"
+	ErrorNoDiffForFile                              = "no diff for %s found in the AI responses"     // low level
+	ErrorDiffHunkMismatch                           = "hunk %d does not match the file near line %d" // low level
+	ErrorFailedToSetPromptTemplate                  = "Failed to set the prompt: %v"
+	ErrorUnknownPromptTemplate                      = "unknown prompt %q, expected one of: %s"                 // low level
+	ErrorInvalidPromptTemplate                      = "the prompt %q must contain exactly %d %%s placeholders" // low level
+	ErrorSetupFailed                                = "Setup failed, the config file was not changed: %v"
+	ErrorInvalidSetupChoice                         = "Invalid choice %q, please pick one of the listed options"
+	ErrorInvalidModelChoice                         = "Invalid choice %q, please pick a number from 1 to %d"
+	ErrorNoModelsToPick                             = "No available model can chat"
+	ErrorFailedToSaveTelemetry                      = "Failed to save the telemetry setting: %v"
+	ErrorTelemetryStatusCode                        = "telemetry endpoint returned status code %d" // low level
+	ErrorWorkerGaveUp                               = "The %s worker crashed again after %d restarts and was not restarted"
+	ErrorFailedToSaveSession                        = "Failed to save the stored session %q: %v"
 	ErrorFailedToCountTokens                        = "Failed to count tokens in the file at %s: %v"
+	ErrorTokenCountFallbackToEstimate               = "Failed to count tokens in the file at %s, using an offline estimate instead: %v"
+	ErrorFailedToShareTranscript                    = "Failed to share transcript: %v"
+	ErrorFailedToImportTranscript                   = "Failed to import transcript: %v"
+	ErrorInvalidImportFile                          = "invalid export file: %v"                                    // low level
+	ErrorImportNoConversation                       = "conversation %s not found, the export has %d conversations" // low level
+	ErrorImportEmpty                                = "the export contains no messages to import"                  // low level
+	ErrorImportCycle                                = "the conversation loops back to node %s"                     // low level
+	ErrorFailedToWatchFile                          = "Failed to watch file: %v"
+	ErrorTextFilesOnly                              = "only text files are supported, got %s"                 // low level
+	ErrorInvalidCronSpec                            = "invalid cron expression %q: expected 5 fields, got %d" // low level
+	ErrorInvalidCronField                           = "invalid cron %s field %q"                              // low level
+	ErrorCronFieldOutOfRange                        = "cron %s field %q is out of range %d-%d"                // low level
+	ErrorCronNeverFires                             = "cron expression %q never fires"                        // low level
+	ErrorInvalidScheduleID                          = "invalid schedule id %q"                                // low level
+	ErrorAIClientNotAvailable                       = "the AI client is not available"                        // low level
+	ErrorFailedToSchedulePrompt                     = "Failed to schedule prompt: %v"
+	ErrorScheduleNotFound                           = "No scheduled prompt with id %d"
+	ErrorScheduledPromptFailed                      = "Scheduled prompt #%d failed: %v"
+	ErrorQueuePositionNotFound                      = "No pending prompt at position %s"
+	ErrorFailedToRunFanout                          = "Failed to run fan-out: %v"
+	ErrorFanoutNoPrompts                            = "no prompts found in %s"                                           // low level
+	ErrorGistNonCreatedStatusCode                   = "github gist: received status code %d instead of 201"              // low level
+	ErrorInvalidChunkMaxTokens                      = "chunk max tokens must be greater than 0, got %d"                  // low level
+	ErrorInvalidChunkOverlap                        = "chunk overlap must be between 0 and %[2]d (exclusive), got %[1]d" // low level
 	ErrorUnrecognizedSubcommandForTokenCount        = "Unrecognized subcommand for token count: %s"
 	ErrorInvalidFileExtension                       = "Invalid file extension: %v"
 	ErrorFileTypeNotSupported                       = "file type not supported: only %s files are allowed." // Low level error
 	ErrorFailedToSendCommandToAI                    = "Failed to send command to AI: %v"
-	ErrorVariableImageFileTypeNotSupported          = "image file type not supported: only %s files are allowed." // Low level error
-	ErrorNoInputProvideForTokenCounting             = "no input provided for token counting"                      // low level error
+	ErrorVariableImageFileTypeNotSupported          = "image file type not supported: only %s files are allowed."               // Low level error
+	ErrorImageContentMismatch                       = "file %s has an image extension but its content is not a supported image" // low level
+	ErrorNoInputProvideForTokenCounting             = "no input provided for token counting"                                    // low level error
 	ErrorGopherEncounteredAnError                   = "Goroutine %d encountered an error: %w"
 	ErrorFailedToRetriveModelInfo                   = "Failed to retrieve model info: %v"
 	ErrorInvalidModelName                           = "Invalid model name: %s"
 	ErrorUnsupportedModelName                       = "unsupported model name: %s"
+	ErrorOutboundFilter                             = "Outbound filter: %v"
+	ErrorOutboundContentBlocked                     = "message blocked by outbound filter rule: %s" // low level
 
 	// List Error not because of this go codes, it literally google apis issue
 	// that so bad can't handle this a powerful terminal
 	Error500GoogleAPI    = "googleapi: Error 500:"
+	GoogleAPIErrorFormat = "googleapi: Error %d:"
 	ErrorGoogleInternal  = "Google Internal Error: %s"
 	ErrorGenAiReceiveNil = "received a nil option function" // low level
 	ErrorGenAI           = "GenAI Error: %v"
@@ -231,7 +522,6 @@ const (
 	ErrorInvalidLengthArgs            = "Invalid length argument: %v"          // high level
 	errorinvalidlengthArgs            = "invalid length argument: %v"          // low level
 	ErrorFailedtoGenerateRandomString = "Failed to generate random string: %v" // high level
-	errorfailedtogeneraterandomstring = "failed to generate random string: %v" // low level
 	// List Other Error not because of this go codes
 	// ErrorOtherAPI represents an error received from an external API server.
 	// It indicates non-client-related issues, such as server-side errors (e.g., HTTP 500 errors) indicate that so fucking bad hahaha.
@@ -251,23 +541,10 @@ const (
 	BinaryLeftSquareBracket = '['
 	BinaryAnsiSquenseChar   = 'm'
 	BinaryAnsiSquenseString = "m"
-	BinaryRegexAnsi         = `\x1b\[[0-9;]*m`
+	BinaryRegexAnsi         = `\x1b\[[0-9;]*m|\x1b\]8;[^\x1b]*\x1b\\`
 	CodeBlockRegex          = "```\\w+"
 	SanitizeTextAIResponse  = "\n---\n"
-	// This regex pattern will match:
-	//
-	// 1. An asterisk followed by a space (e.g., "* ")
-	//
-	// 2. Italic text surrounded by single asterisks (e.g., "*italic*")
-	//
-	// 3. A leading asterisk followed by space and italic text (e.g., "* *italic*")
-	//
-	// Ref: https://go.dev/play/p/3APEsqfgUV-
-	//
-	// It uses a non-greedy match for the italic text and optional groups.
-	ItalicTextRegex = `\*(\s\*\S.*?\S\*|\s|\S.*?\S\*)`
-	// TODO
-	StandaloneAsteriskAnsiRegexPattern = `(?m)(^|\s)\*(\s|$)`
+	HunkHeaderRegex         = `^@@ -(\d+)(?:,\d+)? \+(\d+)(?:,\d+)? @@`
 )
 
 // Defined List of Environment variables
@@ -281,16 +558,115 @@ const (
 		" command with parts: " +
 		// Better Readability use Custom HEX color
 		ColorHex95b806 + "%#v" + ColorReset
-	DEBUGRETRYPOLICY    = "Retry Policy Attempt %d: error occurred - %v"
-	DebugSwitchingModel = "Switching to AI model: " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset
-	ShowPromptFeedBack  = "SHOW_PROMPT_FEEDBACK"
-	PROMPTFEEDBACK      = "Rating for category " + ColorHex95b806 + "%s" + ColorReset + ": " +
+	DEBUGRETRYPOLICY              = "Retry Policy Attempt %d: error occurred - %v"
+	DebugSwitchingModel           = "Switching to AI model: " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset
+	DebugTokenCountCacheHit       = "Token count cache hit: " + ColorHex95b806 + "%d" + ColorReset + " tokens"
+	DebugTokenCountCacheNotSaved  = "Token count cache could not be saved to %s: %v"
+	DebugTokenCountCacheDiscarded = "Token count cache at %s is invalid and was discarded: %v"
+	DebugWatchRateLimited         = "Change in %s detected, waiting %v before sending it to the AI"
+	DebugWatchFailed              = "Watching %s reported an error: %v"
+	DebugAuditLogNotWritten       = "Audit log %s could not be written: %v"
+	DebugStaleSessionLock         = "Removing stale session lock %s left by process %d"
+	DebugSessionLockNotReleased   = "Session lock %s could not be released: %v"
+	DebugTelemetryNotSent         = "Telemetry was not sent: %v"
+	DebugTelemetrySent            = "Telemetry was sent to %s"
+	DebugInputTokenLimitUnknown   = "Skipping auto-compaction, the input token limit is unknown: %v"
+	DebugContextLimitUnknown      = "Sending the context untrimmed, the input token limit is unknown: %v"
+	DebugContextCountFailed       = "Context token count failed, using the offline estimate: %v"
+	DebugUnknownTheme             = "Unknown theme %q, using the default colors"
+	DebugImagePreviewFailed       = "Image %s could not be previewed: %v"
+	DebugContextCacheFailed       = "Model %s cannot cache the chat history, sending it in full: %v"
+	DebugContextCacheCreated      = "Cached the first %d messages of the chat history as %s"
+	DebugContextCacheNotDeleted   = "Cached content %s was not deleted: %v"
+	DebugContextCacheDropped      = "Not using cached content %s anymore after a failed request"
+	DebugCommandSafety            = "Using the %s safety level for %s"
+	DebugNoVirtualTerminal        = "The console cannot process ANSI escape codes, using the plain theme: %v"
+	DebugNoLineEditor             = "The terminal cannot be switched to line editing, reading the input as it is: %v"
+	DebugTerminalNotRestored      = "Failed to restore the terminal: %v"
+	DebugPreflightCountFailed     = "Preflight token count failed, using the offline estimate: %v"
+	DebugPreflightLimitUnknown    = "Preflight check without the model's limit, it is unknown: %v"
+	DebugSessionBackedUp          = "Backed up the chat history to %s"
+	DebugBackupNotRemoved         = "Failed to remove the old backup %s: %v"
+	ShowPromptFeedBack            = "SHOW_PROMPT_FEEDBACK"
+	PROMPTFEEDBACK                = "Rating for category " + ColorHex95b806 + "%s" + ColorReset + ": " +
 		ColorHex95b806 + "%s" + ColorReset
 	ShowTokenCount  = "SHOW_TOKEN_COUNT"
 	TokenCount      = ColorHex95b806 + "%d" + ColorReset + " tokens\n"
 	TotalTokenCount = "usage of this Session " + ColorHex95b806 + "%d" + ColorReset + " tokens"
 	// Note: This is separate from the main package and is used for the token counter. The token counter is external and not a part of the Gemini session.
 	APIKey = "API_KEY"
+	// EnableOutboundFilter enables the outbound content filter for user input when set to "true".
+	EnableOutboundFilter = "OUTBOUND_FILTER"
+	// AllowedFileExtensionsEnv is a comma-separated list of extra file extensions allowed for token counting (e.g., ".rst,.tex,.log").
+	AllowedFileExtensionsEnv = "ALLOWED_FILE_EXTENSIONS"
+	// AppConfigEnv overrides the location of the application config file.
+	AppConfigEnv = "GOGENAI_CONFIG"
+	// RedactionProfilesEnv is a comma-separated list of redaction profiles applied to exported transcripts (e.g., "pii,paths,hostnames").
+	RedactionProfilesEnv = "REDACTION_PROFILES"
+	// AuditLogEnv enables ("true") or disables ("false") the audit log of executed commands.
+	AuditLogEnv = "AUDIT_LOG"
+	// SessionNameEnv names the session whose chat history is stored on disk, enabling persistent storage.
+	SessionNameEnv = "SESSION_NAME"
+	// CrashReportsEnv enables ("true") writing a crash report file when a panic is recovered.
+	CrashReportsEnv = "CRASH_REPORTS"
+	// StatusBarEnv turns the status line above the input prompt off when set to "false".
+	StatusBarEnv = "STATUS_BAR"
+	// AutoCompactPercentEnv compacts the chat history once it exceeds this percentage of the model's input token limit.
+	AutoCompactPercentEnv = "AUTO_COMPACT_PERCENT"
+	// TypingEffectEnv prints responses a character ("char", the default), a word ("word") or a line ("line") at a time.
+	TypingEffectEnv = "TYPING_EFFECT"
+	// ContextCacheEnv enables ("true") caching a long chat history on the API, so it is not sent again with every message.
+	ContextCacheEnv = "CONTEXT_CACHE"
+	// MemoryFactsEnv is the number of most recently remembered facts sent with every message (see ":remember"); 0 disables them.
+	MemoryFactsEnv = "MEMORY_FACTS"
+	// MemoryExtractEnv enables ("true") remembering the durable facts about the user when the conversation is summarized or compacted.
+	MemoryExtractEnv = "MEMORY_EXTRACT"
+	// QuietEnv enables ("true") quiet mode on startup (see ":quiet").
+	QuietEnv = "QUIET"
+	// PreflightEnv checks the tokens of each prompt before it is sent: "estimate" (offline) or "count" (with the API).
+	PreflightEnv = "PREFLIGHT"
+	// PreflightTokensEnv is the number of tokens above which the preflight check warns, besides the model's input limit.
+	PreflightTokensEnv = "PREFLIGHT_TOKENS"
+	// WebSearchEnv is the engine of the web search: "brave", "google" or "searxng" (see the web_search config section).
+	WebSearchEnv = "WEB_SEARCH"
+	// WebSearchAPIKeyEnv is the key of the Brave or Google search API, instead of the one in the config file.
+	WebSearchAPIKeyEnv = "WEB_SEARCH_API_KEY"
+	// ModelNameEnv is the model of new sessions (e.g., "gemini-1.5-flash-latest"), instead of "model" in the config file.
+	ModelNameEnv = "MODEL_NAME"
+	// BackupDirEnv enables the automatic backups of the chat history to the directory (see the backup config section).
+	BackupDirEnv = "BACKUP_DIR"
+	// AutoSummaryMessagesEnv enables the background summary of the oldest messages once the chat history has more
+	// user and AI messages than this (see the auto_summary config section).
+	AutoSummaryMessagesEnv = "AUTO_SUMMARY_MESSAGES"
+	// SystemInstructionEnv is the persona of new sessions (see ":persona"), instead of "system_instruction" in the config file.
+	SystemInstructionEnv = "SYSTEM_INSTRUCTION"
+	// TelemetryEnv enables ("true") or disables ("false") the anonymous telemetry of aggregate counters.
+	TelemetryEnv = "TELEMETRY"
+	// TelemetryEndpointEnv is the URL the telemetry counters are sent to. Without it, nothing is sent.
+	TelemetryEndpointEnv = "TELEMETRY_ENDPOINT"
+	// RetryMaxRetriesEnv, RetryBaseDelayEnv, RetryMaxDelayEnv, RetryJitterEnv and RetryStatusCodesEnv set the values
+	// of the retry_policy config section of the same name, e.g. RETRY_STATUS_CODES="500,503".
+	RetryMaxRetriesEnv  = "RETRY_MAX_RETRIES"
+	RetryBaseDelayEnv   = "RETRY_BASE_DELAY_MS"
+	RetryMaxDelayEnv    = "RETRY_MAX_DELAY_MS"
+	RetryJitterEnv      = "RETRY_JITTER"
+	RetryStatusCodesEnv = "RETRY_STATUS_CODES"
+	// TermEnv and ColorTermEnv describe the terminal in the diagnostics.
+	TermEnv      = "TERM"
+	ColorTermEnv = "COLORTERM"
+	// HyperlinksEnv forces clickable links on ("true") or off ("false"). Otherwise, they are used if the terminal is known to support them.
+	HyperlinksEnv = "HYPERLINKS"
+	// TermProgramEnv, VTEVersionEnv, WTSessionEnv and KittyWindowIDEnv identify terminals that support clickable links.
+	TermProgramEnv   = "TERM_PROGRAM"
+	VTEVersionEnv    = "VTE_VERSION"
+	WTSessionEnv     = "WT_SESSION"
+	KittyWindowIDEnv = "KITTY_WINDOW_ID"
+	// ImagePreviewEnv forces the graphics protocol used for image previews: "kitty", "iterm", "sixel" or "none".
+	ImagePreviewEnv = "IMAGE_PREVIEW"
+	// ColumnsEnv is the width of the terminal used when it cannot be measured, e.g. when the output is piped.
+	ColumnsEnv = "COLUMNS"
+	// GitHubToken is an optional token with the "gist" scope used by ":share" to upload transcripts.
+	GitHubToken = "GITHUB_TOKEN"
 )
 
 // Defined Prefix System
@@ -307,23 +683,269 @@ const (
 	ShowChatHistory  = "Chat History:\n\n%s"
 	SummarizePrompt  = StripChars + "\nIn 200 words or less, provide a brief summary of the ongoing discussion.\n" +
 		"This summary will serve as a prompt for contextual reference in future interactions:\n\n"
+	ExtractFactsPrompt = StripChars + "\nList the durable facts about the user and their preferences from the discussion above " +
+		"that will still be useful in future discussions (e.g., \"prefers Go 1.22\", \"indents with tabs\", \"licenses projects under MIT\").\n" +
+		"Only include what the user stated or clearly implied, not facts about the topic. Write one short fact per line, starting with \"- \".\n" +
+		"If there are none, write NONE.\n\n"
+	WebSearchPrompt = StripChars + "\nSummarize the following web search results for \"%s\" for the discussion above, " +
+		"and name the URLs of the results you use:\n\n%s"
+	K8sExplainPrompt = StripChars + "\nExplain the output of \"kubectl %s\" below. Point out any problems, such as failing pods " +
+		"or warning events, and how to fix them:\n\n%s"
+	LogsDiagnosePrompt = StripChars + "\nDiagnose the errors and anomalies in the logs of %s below. Explain their likely causes and how to fix them. " +
+		"If there are none, say so briefly:\n\n%s"
+	LogsChunkPrompt = StripChars + "\nThis is part %d of %d of the logs of %s. List the errors and warnings in it, " +
+		"with their timestamps and likely causes. If there are none, write NONE:\n\n%s"
+	LogsFindingsPrompt = StripChars + "\nThe logs of %s were too long to send at once, so the errors were collected from each part below. " +
+		"Diagnose them: explain their likely causes and how to fix them:\n\n%s"
+	FixPrompt = StripChars + "\nBelow is an error, such as a stack trace or compiler output. Diagnose its cause, " +
+		"using the discussion above if it is relevant, and give the fix as a unified diff in a diff code block, " +
+		"with the path of each changed file in the --- and +++ headers:\n\n%s"
+	GoDocPrompt = StripChars + "\nExplain the Go documentation below, printed by \"go %s\". Describe what it is for and how to use it, " +
+		"with a short example:\n\n%s"
+	GoTestPrompt = StripChars + "\nExplain the output of \"go %s\" below. For each failing test or build error, explain its likely cause " +
+		"and how to fix it. If everything passed, say so briefly and point out anything unusual, such as skipped or slow tests:\n\n%s"
 
 	ListChatStats = statsEmoji + " List of Chat Statistics for This Session:\n\n" +
 		youNerd + " User messages: " + ColorHex95b806 + BoldText + "%d" + ResetBoldText + ColorReset + "\n" +
 		aiNerd + " AI messages: " + ColorHex95b806 + BoldText + "%d" + ResetBoldText + ColorReset + "\n" +
 		sysEmoji + " System messages: " + ColorHex95b806 + BoldText + "%d" + ResetBoldText + ColorReset
-	InfoTokenCountFile = "The file " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset +
+	InfoTokenCountProgress         = "[%d/%d] " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset + ": %d tokens (running total: " + ColorHex95b806 + BoldText + "%d" + ResetBoldText + ColorReset + ")"
+	InfoTokenCountEstimateProgress = "[%d/%d] " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset + ": ~%d tokens (estimate) (running total: " + ColorHex95b806 + BoldText + "%d" + ResetBoldText + ColorReset + ")"
+	InfoTokenCountFileEstimate     = "The file " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset +
+		" contains approximately " + ColorHex95b806 + BoldText + "%d" + ResetBoldText + ColorReset + " tokens (includes offline estimates)."
+	InfoTokenCountCancelled = "Token counting cancelled after %d of %d files"
+	InfoTokenCountSaved     = "Token counts saved to " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset
+	TokenCountSpinner       = "Counting tokens [%d/%d] %s"
+	InfoTokenCountFile      = "The file " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset +
 		" contains " + ColorHex95b806 + BoldText + "%d" + ResetBoldText + ColorReset + " tokens."
 	RetryingStupid500Error = "[Retry Policy] Retrying (" + ColorRed + "last error: %v" + ColorReset + ")" +
 		" attempt number " + ColorHex95b806 + BoldText + "%d" + ResetBoldText + ColorReset
 	// ModelFormat defines a template for displaying model information with color and bold formatting for placeholders.
 	ModelFormat = "Model Name: " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset + "\n" +
+		"Resource Name: " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset + "\n" +
+		"Base Model ID: " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset + "\n" +
 		"Version: " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset + "\n" +
 		"Description: " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset + "\n" +
 		"Supported Generation Methods: " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset + "\n" +
 		"Input Token Limit: " + ColorHex95b806 + BoldText + "%d" + ResetBoldText + ColorReset + "\n" +
-		"Output Token Limit: " + ColorHex95b806 + BoldText + "%d" + ResetBoldText + ColorReset
-	SwitchedModel = "Switched to model: " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset
+		"Output Token Limit: " + ColorHex95b806 + BoldText + "%d" + ResetBoldText + ColorReset + "\n" +
+		"Default Temperature: " + ColorHex95b806 + BoldText + "%g" + ResetBoldText + ColorReset + "\n" +
+		"Max Temperature: " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset + "\n" +
+		"Default Top P: " + ColorHex95b806 + BoldText + "%g" + ResetBoldText + ColorReset + "\n" +
+		"Default Top K: " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset
+	// GenerationConfigFormat defines a template for displaying the effective generation configuration.
+	GenerationConfigFormat = "Generation Config for: " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset + "\n" +
+		"Temperature: " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset + "\n" +
+		"Top P: " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset + "\n" +
+		"Top K: " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset + "\n" +
+		"Max Output Tokens: " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset + "\n" +
+		"Candidate Count: " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset + "\n" +
+		"Stop Sequences: " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset + "\n" +
+		"Seed: " + SeedUnsupported
+	ModelDefault                = "model default"
+	ConfigUpdated               = "Config %s set to %s"
+	InfoRetryPolicy             = "Retry policy: up to " + ColorHex95b806 + BoldText + "%d" + ResetBoldText + ColorReset + " attempts, delays from %v to %v with a jitter of %g, retried status codes: %s"
+	InfoRetryPolicyReset        = "The retry policy of the config is used again"
+	InfoGenerationParam         = "Config %s is " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset
+	InfoReplayStart             = "Replaying " + ColorHex95b806 + BoldText + "%d" + ResetBoldText + ColorReset + " messages (no API calls, press Ctrl+C to stop)"
+	InfoReplayEnd               = "Replay finished"
+	InfoReplayEmpty             = "There is no chat history to replay"
+	InfoReplayCancelled         = "Replay stopped after %d of %d messages"
+	InfoShareCompleted          = "Transcript shared: " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset
+	InfoShareEmpty              = "There is no chat history to share"
+	InfoTranscriptExported      = "Transcript of %d messages exported to " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset
+	InfoImportCompleted         = "Imported " + ColorHex95b806 + BoldText + "%d" + ResetBoldText + ColorReset + " messages from %s"
+	InfoWatchStarted            = "Watching " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset + " for changes (press Ctrl+C to stop)"
+	InfoWatchSending            = "Sending " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset + " to the AI"
+	InfoWatchStopped            = "Stopped watching %s"
+	InfoScheduleAdded           = "Scheduled prompt " + ColorHex95b806 + BoldText + "#%d" + ResetBoldText + ColorReset + ", next run at %s"
+	InfoScheduleCancelled       = "Cancelled scheduled prompt #%d"
+	InfoScheduleEmpty           = "There are no scheduled prompts"
+	InfoScheduleListHeader      = "Scheduled prompts:\n"
+	InfoScheduleListItem        = "  " + ColorHex95b806 + BoldText + "#%d" + ResetBoldText + ColorReset + " %q next at %s: %s%s\n"
+	InfoScheduleOutputFile      = " (saved to %s)"
+	InfoScheduledPromptDone     = "Scheduled prompt #%d finished, the response was added to the chat history: %s"
+	ScheduledOutputFormat       = "## %s\n\n> %s\n\n%s\n\n"
+	InfoPromptQueued            = "Queued as #%d, it will be sent after the current response (see :queue)"
+	InfoQueueEmpty              = "There are no pending prompts"
+	InfoQueueListHeader         = "Pending prompts:\n"
+	InfoQueueListItem           = "  " + ColorHex95b806 + BoldText + "#%d" + ResetBoldText + ColorReset + " %s\n"
+	InfoQueueRemoved            = "Removed pending prompt #%d"
+	InfoQueueCleared            = "Removed %d pending prompts"
+	FanoutSpinner               = "Sending %d prompts (up to %d at a time), press Ctrl+C to cancel"
+	InfoFanoutCompleted         = "Fan-out finished: " + ColorHex95b806 + BoldText + "%d" + ResetBoldText + ColorReset + " succeeded, %d failed, saved to " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset
+	FanoutMarkdownHeader        = "# Fan-out results\n\n_%d prompts sent to %s on %s_\n"
+	FanoutMarkdownResult        = "\n## Prompt %d\n\n> %s\n\n%s\n"
+	FanoutMarkdownError         = "**Error:** %v"
+	FanoutFileName              = "fanout-%s.md"
+	InfoDryRunStatus            = "Dry-run mode is " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset
+	InfoQuietStatus             = "Quiet mode is " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset
+	InfoHistorySize             = "History size: " + ColorHex95b806 + BoldText + "%d" + ResetBoldText + ColorReset + " messages are sent to the AI, up to %d are kept (%d now)"
+	InfoNoSummary               = "There is no summary yet. Use \"%s\" to summarize the conversation."
+	InfoHistorySizeSet          = "History size set to " + ColorHex95b806 + BoldText + "%d" + ResetBoldText + ColorReset + ", %d older messages were dropped"
+	DryRunHeader                = "Dry run, nothing was sent. Estimated input tokens: " + ColorHex95b806 + BoldText + "~%d" + ResetBoldText + ColorReset + "\nSafety settings:\n%s"
+	DryRunSafetySetting         = "  %v: %v"
+	DryRunContentHeader         = "Content:"
+	InfoAuditLogDisabled        = "The audit log is disabled, set AUDIT_LOG=true or \"audit_log\": true in the config file to enable it"
+	InfoAuditLogEmpty           = "The audit log is empty"
+	InfoSessionReadOnly         = "Session %q is in use by another instance (PID %d), its history is opened read-only and will not be saved"
+	InfoSessionLoaded           = "Loaded " + ColorHex95b806 + BoldText + "%d" + ResetBoldText + ColorReset + " messages from the stored session %q"
+	InfoViewerMode              = "Viewing the stored session %q (%d messages) in read-only mode. Nothing is sent to the API; type " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset + " to see the commands"
+	InfoViewerCommands          = "Commands available in viewer mode: %s"
+	DiagnosticFieldFormat       = "  %s: %s\n"
+	DiagnosticSettingFormat     = "%s=%s"
+	DiagnosticColorTermFormat   = " (COLORTERM=%s)"
+	DiagnosticVersion           = "Version"
+	DiagnosticPlatform          = "OS/Arch"
+	DiagnosticGoVersion         = "Go"
+	DiagnosticTerminal          = "Terminal"
+	DiagnosticModel             = "Model"
+	DiagnosticConfig            = "Config"
+	DiagnosticUnknown           = "unknown"
+	DiagnosticSet               = "set"
+	DiagnosticNotSet            = "not set"
+	DiagnosticAPIKey            = "api_key"
+	DiagnosticSessionName       = "session_name"
+	DiagnosticSafetyLevel       = "safety_level"
+	DiagnosticTheme             = "theme"
+	DiagnosticAuditLog          = "audit_log"
+	DiagnosticCrashReports      = "crash_reports"
+	DiagnosticTelemetry         = "telemetry"
+	DiagnosticRedactionProfiles = "redaction_profiles"
+	DiagnosticFileExtensions    = "allowed_file_extensions"
+	ReportEnvironmentSection    = "### Environment\n\n%s\n"
+	ReportErrorsSection         = "### Recent errors\n\n"
+	ReportErrorFormat           = "- %s: %s\n"
+	ReportRetrySection          = "\n### Retry statistics\n\n- Operations: %d\n- Retries: %d\n- Failed after all retries: %d\n- Non-retryable failures: %d\n- Failed without retrying (retry budget exhausted): %d\n"
+	ReportCrashSection          = "\n### Crash report\n\n"
+	ReportCrashFormat           = TripleBacktick + "\n%s\n" + TripleBacktick + "\n"
+	ReportNone                  = "None\n"
+	ReportTruncated             = "\n...(truncated)"
+	IssueFieldTemplate          = "template"
+	IssueFieldTitle             = "title"
+	IssueFieldOS                = "desktop-os"
+	IssueFieldVersion           = "application-version"
+	IssueFieldContext           = "additional-context"
+	IssueBugReportTemplate      = "bug_report.yml"
+	IssueBugReportTitle         = "[Bug]: "
+	InfoReportIssueURL          = "Review the report above, then open this link to create a pre-filled issue (nothing is sent until you submit it):\n%s"
+	InfoTelemetryStatus         = "Telemetry is " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset + ", endpoint: %s"
+	InfoTelemetrySaved          = "The telemetry setting was saved to %s"
+	InfoWorkerRestarting        = "Restarting the %s worker after a panic (restart %d/%d)"
+	InfoCompacting              = "Summarizing the conversation to compact the chat history..."
+	InfoCompacted               = "Replaced " + ColorHex95b806 + BoldText + "%d" + ResetBoldText + ColorReset + " messages with a summary. Estimated history tokens: ~%d -> ~%d (saved ~%d)"
+	WarningPreflightLimit       = "This prompt and the chat history are " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset + " tokens, more than the model's input limit of %d tokens"
+	WarningPreflightThreshold   = "This prompt and the chat history are " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset + " tokens, more than the preflight_tokens threshold of %d tokens"
+	PreflightConfirmation       = "Compact the chat history before sending? [y/N]: "
+	InlineShellConfirmation     = "Run %q and include its output in the prompt? [y/N]: "
+	InfoFileIncluded            = "Included %s in the prompt (~%d tokens)"
+	InfoWebSearching            = "Searching the web for %q..."
+	InfoWebSearchNoResults      = "The web search for %q found nothing"
+	InfoKubectlNoOutput         = "kubectl %s printed nothing to explain"
+	InfoNoLogs                  = "There are no logs of %s to analyze"
+	InfoAnalyzingLogs           = "The logs are long, analyzing them in %d parts..."
+	InfoPasteUntilMarker        = "Paste the error, then type %s on a line of its own:"
+	InfoNothingToFix            = "There is no error to fix in %s"
+	InfoGoNoOutput              = "go %s printed nothing to explain"
+	InfoSeedPromptLoaded        = "Loaded the seed prompt from %d files (~%d tokens), it stays at the top of the context"
+	InfoAutoCompacted           = "The chat history exceeded %d%% of the model's input limit, so %d older messages were replaced with a summary (~%d -> ~%d tokens)"
+	InfoContextTrimmed          = "The %d oldest messages were left out of the context to fit the model's input limit of %d tokens"
+	InfoAutoSummarized          = "The %d oldest messages were replaced with a pinned summary in the background (~%d -> ~%d tokens)"
+	WorkerTableHeader           = "WORKER\tSTATE\tRESTARTS\tFOR\tLAST PANIC"
+	WorkerTableRow              = "%s\t%s\t%d\t%s\t%s\n"
+	SessionInfoRow              = "%s\t%s\n"
+	SessionInfoModel            = "Model:"
+	SessionInfoSafety           = "Safety level:"
+	SessionInfoTemperature      = "Temperature:"
+	SessionInfoTempFormat       = "%.2f"
+	SessionInfoHistory          = "History:"
+	SessionInfoHistoryFormat    = "%d/%d messages (~%d tokens)"
+	SessionInfoTokens           = "Tokens used:"
+	SessionInfoUptime           = "Uptime:"
+	SessionInfoStorage          = "Storage:"
+	SessionInfoInMemory         = "in memory only (see SESSION_NAME)"
+	StatusBarFormat             = ColorHex95b806 + "%s ▸ %d tokens ▸ safety %s" + ColorReset
+	WorkerNoPanic               = "-"
+	WorkerRunning               = "running"
+	WorkerRestarting            = "restarting"
+	WorkerStopped               = "stopped"
+	WorkerFailed                = "failed"
+	WorkerScheduler             = "scheduler"
+	WorkerInputReader           = "input reader"
+	WorkerSignalHandler         = "signal handler"
+	InfoTelemetryPayload        = "Only these aggregate counters are sent when the session ends, never prompts, responses or file contents:\n%s"
+	TelemetryNoEndpoint         = "not set, nothing is sent (set TELEMETRY_ENDPOINT or \"telemetry_endpoint\" in the config file)"
+	TelemetryErrorServer        = "server_error"
+	TelemetryErrorQuota         = "quota"
+	TelemetryErrorPermission    = "permission"
+	TelemetryErrorNetwork       = "network"
+	TelemetryErrorCanceled      = "canceled"
+	TelemetryErrorOther         = "other"
+	TelemetryContentType        = "application/json"
+	SetupWelcome                = "Welcome to the %s setup! Press Enter to keep the default shown in brackets. You can run " + ColorHex95b806 + BoldText + ":setup" + ResetBoldText + ColorReset + " again at any time."
+	SetupAskAPIKey              = "Enter your Gemini API key (from https://aistudio.google.com/app/apikey): "
+	SetupValidatingAPIKey       = "Validating the API key..."
+	SetupAskModel               = "Preferred model"
+	SetupAskSafety              = "Safety level"
+	SetupAskTheme               = "Color theme"
+	SetupChoiceFormat           = "%s (%s) [%s]: "
+	SetupCompleted              = "Setup complete, the config was saved to %s"
+	InfoSearchNoMatches         = "No messages in the chat history contain %q"
+	InfoSearchHeader            = "Found %d messages containing %q:\n\n"
+	SearchResultFormat          = "[" + ColorHex95b806 + "%d" + ColorReset + "] %s\n"
+	InfoHistoryDiffHeader       = "Comparing %q with %q: %d shared, %d removed, %d added messages\n\n"
+	DiffRemovedFormat           = ColorRed + "- [%s #%d]" + ColorReset + " %s\n"
+	DiffAddedFormat             = ColorGreen + "+ [%s #%d]" + ColorReset + " %s\n"
+	InfoNoCheckpoints           = "No checkpoints saved yet. Use \":checkpoint save <name>\" to save one."
+	InfoCheckpointSaved         = "Saved the chat history as checkpoint %q"
+	InfoCheckpointRestored      = "Restored the chat history from checkpoint %q"
+	CheckpointFormat            = "%s: %d messages\n"
+	IncognitoArgsOmitted        = "(omitted)"
+	InfoPromptTemplateSaved     = "Saved the %q prompt to %s"
+	InfoDiffApplied             = "Applied %d hunks to %s"
+	InfoFactRemembered          = "Remembered fact #%d: %s"
+	InfoPersona                 = "Persona: %s"
+	InfoNoPersona               = "No persona set. Use \"%s <text>\" to set one."
+	InfoPersonaSet              = "Persona set, the AI follows it from the next message"
+	InfoPersonaCleared          = "Persona removed"
+	InfoFactAlreadyRemembered   = "Already remembered: %s"
+	InfoFactForgotten           = "Forgot fact #%d"
+	InfoNoFacts                 = "No facts remembered yet. Use \"%s <fact>\" to remember one."
+	InfoFactsExtracted          = "Remembered %d new facts about you from the conversation (see %s)"
+	MemoryFactListFormat        = "#%d %s (%s)\n"
+	InfoDiffNotApplied          = "The diff was not applied"
+	ApplyDiffConfirmation       = "Apply %d hunks to %s? [y/N]: "
+	YesShort                    = "y"
+	YesLong                     = "yes"
+	PromptTemplateFormat        = ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset + " (%s):\n%s\n\n"
+	PromptTemplateBuiltin       = "built-in"
+	PromptTemplateCustom        = "custom"
+	AuditEntryFormat            = "%s " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset + " %s -> %s (%d ms)\n"
+	AuditOutcomeOK              = "ok"
+	AuditOutcomeUnrecognized    = "unrecognized command"
+	AuditOutcomeEndedSession    = "ended session"
+	AuditOutcomeError           = "error: %v"
+	GistDescription             = "%s transcript"
+	TranscriptMarkdownHeader    = "# %s Transcript\n\n_Exported on %s_\n"
+	TranscriptMarkdownMessage   = "\n### %s\n\n%s\n"
+	TranscriptFileName          = "transcript-%s.md"
+	TranscriptHTMLFileName      = "transcript-%s.html"
+	TranscriptTimeFormat        = "20060102-150405"
+	SeedUnsupported             = "not supported by this SDK, responses cannot be made reproducible"
+	ListModelsHeader            = "Available models: " + ColorHex95b806 + BoldText + "%d" + ResetBoldText + ColorReset + "\n\n"
+	ModelTableHeader            = "MODEL\tVERSION\tINPUT LIMIT\tOUTPUT LIMIT\tMETHODS"
+	ModelTableRow               = "%s\t%s\t%d\t%d\t%s\n"
+	ModelResourcePrefix         = "models/"
+	PagerPrompt                 = ColorHex95b806 + "-- More (%d/%d) -- Enter to continue, q to quit --" + ColorReset
+	PagerQuit                   = "q"
+	DefaultPageSize             = 20
+	SwitchedModel               = "Switched to model: " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset
+	ModelPickerRow              = "%3d. %s%s\n"
+	ModelPickerCurrent          = " (current)"
+	ModelPickerPrompt           = "Pick a model by number, or press Enter to keep " + ColorHex95b806 + "%s" + ColorReset + ": "
+	ModelUnchanged              = "Kept model: " + ColorHex95b806 + BoldText + "%s" + ResetBoldText + ColorReset
+	GenerateContentMethod       = "generateContent"
+	OutboundContentMasked       = "Outbound filter masked content matching: " + ColorHex95b806 + "%s" + ColorReset
 )
 
 // Defined Tools
@@ -376,12 +998,31 @@ const (
 // Context RAM's labyrinth
 const (
 	ContextUserInvokeTranslateCommands = "Translating to %s: %s"
+	ContextUserInvokeWatchCommand      = "Watching file %[2]s: %[1]s"
 	SummaryPrefix                      = aiNerd + " 📝 📌 Summary of this discussion:\n\n"
+	PersonaPrefix                      = aiNerd + " 🎭 Persona:\n\n"
+	SeedPromptPrefix                   = aiNerd + " 🌱 Seed prompt:\n\n"
+	MemoryPrefix                       = aiNerd + " 🧠 Remembered facts about the user and their preferences:\n\n"
+	MemoryFactFormat                   = "- %s\n"
+	MemoryFactMarkers                  = "-*•0123456789. "
+)
+
+// Defined List of System Message Categories (see systemMessageRules)
+const (
+	SystemCategorySummary = "summary"
+	SystemCategoryPersona = "persona"
+	SystemCategorySeed    = "seed"
+	SystemCategoryNotice  = "notice"
 )
 
 // List RestfulAPI Error
 const (
 	Code500 = "500" // indicate that server so bad hahaha
+	Code429 = "429"
+	Code403 = "403"
+	// ResourceExhausted and PermissionDenied are the gRPC codes of the quota and permission errors.
+	ResourceExhausted = "ResourceExhausted"
+	PermissionDenied  = "PermissionDenied"
 )
 
 // dotFiles
@@ -391,6 +1032,8 @@ const (
 	// Also Using constants improves readability and maintainability over stupid hardcoding values.
 	dotMD          = ".md"
 	dotTxt         = ".txt"
+	dotHTML        = ".html"
+	dotHTM         = ".htm"
 	dotPng         = ".png"
 	dotJpg         = ".jpg"
 	dotJpeg        = ".jpeg"
@@ -399,6 +1042,7 @@ const (
 	dotHeif        = ".heif"
 	dotString      = "."
 	dotStringComma = ", "
+	commaString    = ","
 	oRString       = " or "
 )
 
@@ -430,6 +1074,10 @@ const (
 	ItalicText = "\x1B[3m"
 	// reset italic text formatting.
 	ResetItalicText = "\x1B[23m"
+	// hyperlink (OSC 8) with the URL and the label.
+	HyperlinkFormat = "\x1b]8;;%s\x1b\\%s\x1b]8;;\x1b\\"
+	// link label followed by its URL, for terminals without hyperlinks.
+	LinkFallbackFormat = "%s (%s)"
 )
 
 const (
@@ -441,6 +1089,97 @@ const (
 	SystemMessage
 )
 
+const (
+	// FilterAllow indicates that an outbound rule did not match.
+	FilterAllow FilterAction = iota
+	// FilterMask indicates that matching content should be replaced before sending.
+	FilterMask
+	// FilterBlock indicates that the whole message must not be sent.
+	FilterBlock
+)
+
+const (
+	// DiffUnchanged indicates a message that is in both sessions.
+	DiffUnchanged DiffOp = iota
+	// DiffRemoved indicates a message that is only in the first session.
+	DiffRemoved
+	// DiffAdded indicates a message that is only in the second session.
+	DiffAdded
+)
+
+// Defined List of Outbound Filter Rules
+//
+// Note: These patterns are intentionally conservative to keep false positives low.
+// Additional rules can be plugged in at runtime using OutboundFilter.AddRule.
+const (
+	RuleSecretKey        = "secret-key"
+	RuleEmail            = "email"
+	RuleCreditCard       = "credit-card"
+	RulePhoneNumber      = "phone-number"
+	RuleProfanity        = "profanity"
+	RuleURLHost          = "url-host"
+	RuleInternalHostname = "internal-hostname"
+	RuleIPAddress        = "ip-address"
+	RuleFilePath         = "file-path"
+	// Matches common API key and token formats (Google, GitHub, OpenAI, AWS, Slack) and PEM private keys.
+	RegexSecretKey   = `AIza[0-9A-Za-z_\-]{35}|gh[pousr]_[0-9A-Za-z]{36,}|sk-[0-9A-Za-z_\-]{20,}|AKIA[0-9A-Z]{16}|xox[baprs]-[0-9A-Za-z\-]{10,}|-----BEGIN [A-Z ]*PRIVATE KEY-----`
+	RegexEmail       = `[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`
+	RegexCreditCard  = `\b\d(?:[ \-]?\d){12,15}\b`
+	RegexPhoneNumber = `(?:\+\d{1,3}[ .\-]?)?\(?\b\d{3}\)?[ .\-]?\d{3}[ .\-]?\d{4}\b`
+	RegexProfanity   = `(?i)\b(?:fuck\w*|shit\w*|bitch\w*|bastard\w*|asshole\w*|dickhead\w*|motherfuck\w*)\b`
+	MaskSecretKey    = "[REDACTED]"
+	MaskEmail        = "[EMAIL]"
+	MaskCreditCard   = "[CARD NUMBER]"
+	MaskPhoneNumber  = "[PHONE]"
+	MaskProfanity    = "****"
+	// Matches the host of http(s) URLs; the scheme is kept by the mask.
+	RegexURLHost = `(https?://)[^/\s:?#]+`
+	// Matches hostnames on common private top-level domains (e.g., "db01.corp" or "nas.local").
+	RegexInternalHostname = `(?i)\b(?:[a-z0-9](?:[a-z0-9\-]{0,61}[a-z0-9])?\.)+(?:local|localdomain|internal|intranet|corp|lan|home)\b`
+	RegexIPAddress        = `\b(?:\d{1,3}\.){3}\d{1,3}\b`
+	// Matches absolute Unix paths with at least two segments, home-relative paths and Windows paths.
+	// The preceding character is kept by the mask, so URLs and fractions such as "1/2" are left alone.
+	RegexFilePath = `(^|[\s"'(=])(?:~?/[\w.\-]+(?:/[\w.\-]+)+|[A-Za-z]:\\[^\s"']+)`
+	MaskURLHost   = "${1}[HOST]"
+	MaskHostname  = "[HOST]"
+	MaskIPAddress = "[IP]"
+	MaskFilePath  = "${1}[PATH]"
+)
+
+// Defined List of Prompt Templates that can be overridden (see ":prompt")
+const (
+	PromptTemplateContext   = "context"
+	PromptTemplateSummarize = "summarize"
+	PromptTemplateTranslate = "translate"
+	PromptTemplateShutdown  = "shutdown"
+	PromptPlaceholder       = "%s"
+	// PromptTemplateExtractFacts asks for the durable facts about the user (see MEMORY_EXTRACT).
+	PromptTemplateExtractFacts = "extract_facts"
+	// PromptTemplateWebSearch asks for a summary of the results of ":search :web".
+	PromptTemplateWebSearch = "web_search"
+)
+
+// Defined List of Prompt Variables, written as {{name}} in a prompt (see expandPromptVariables)
+const (
+	PromptVariableDate       = "date"
+	PromptVariableTime       = "time"
+	PromptVariableOS         = "os"
+	PromptVariableCwd        = "cwd"
+	PromptVariableModel      = "model"
+	PromptVariableOpen       = "{{"
+	PromptVariableClose      = "}}"
+	PromptVariableTimeLayout = "15:04"
+	PromptVariableUnknown    = "unknown"
+)
+
+// Defined List of Redaction Profiles for exported transcripts
+const (
+	RedactionProfileSecrets   = "secrets"
+	RedactionProfilePII       = "pii"
+	RedactionProfileHostnames = "hostnames"
+	RedactionProfilePaths     = "paths"
+)
+
 // mime formatting
 const (
 	FormatJPEG = "jpeg"
@@ -450,7 +1189,510 @@ const (
 	FormatWEBP = "webp"
 )
 
+// application config file
+const (
+	AppConfigDir  = ".gogenai"
+	AppConfigFile = "config.json"
+	// TokenCountCacheFile is stored in the same directory as the config file.
+	TokenCountCacheFile = "tokencount_cache.json"
+	// MaxTokenCountCacheEntries limits the number of cached token counts.
+	MaxTokenCountCacheEntries = 1000
+	// AuditLogFile is stored in the same directory as the config file.
+	AuditLogFile = "audit.log"
+	// DefaultAuditShowEntries is the number of entries shown by ":audit :show" without a number.
+	DefaultAuditShowEntries = 20
+	// SessionsDir holds the stored sessions, in the same directory as the config file.
+	SessionsDir          = "sessions"
+	SessionFileExtension = ".json"
+	SessionLockExtension = ".lock"
+	// SessionStaleSuffix is appended to a stale lock, together with the PID of the instance taking it over.
+	SessionStaleSuffix   = ".stale"
+	SessionTempExtension = ".tmp"
+	// MemoryFile holds the remembered facts, in the same directory as the config file.
+	MemoryFile = "memory.json"
+	// DefaultMemoryFacts is the number of most recently remembered facts sent with every message, unless "memory_facts" is configured.
+	DefaultMemoryFacts = 10
+	// MaxRecentErrors is the number of error messages kept for ":report".
+	MaxRecentErrors = 10
+	// MaxReportCrashLength limits the crash report included in ":report :crash", since the issue is pre-filled through its URL.
+	MaxReportCrashLength = 3000
+	// MaxIssueURLLength keeps the pre-filled issue URL below the length that browsers and GitHub accept.
+	MaxIssueURLLength = 8000
+	// CrashReportsDir holds the crash reports, in the same directory as the config file.
+	CrashReportsDir     = "crashes"
+	CrashReportFileName = "crash-%s.txt"
+	// MaxWorkerRestarts is the number of times a background worker is restarted after a panic before it is given up.
+	MaxWorkerRestarts  = 5
+	WorkerRestartDelay = time.Second
+	// DefaultHTTPTimeout bounds the requests of the shared HTTP client, unless "timeout_seconds" is configured.
+	DefaultHTTPTimeout = 30 * time.Second
+	TLSVersion12       = "1.2"
+	TLSVersion13       = "1.3"
+	// DefaultMaxRetries, DefaultRetryBaseDelay, DefaultRetryMaxDelay and DefaultRetryJitter are the default retry policy.
+	DefaultMaxRetries     = 3
+	DefaultRetryBaseDelay = time.Second
+	DefaultRetryMaxDelay  = 30 * time.Second
+	DefaultRetryJitter    = 0.5
+	// DefaultRetryableStatusCode is the status code of the errors retried by default (Internal Server Error).
+	DefaultRetryableStatusCode = 500
+	// DefaultRetryBudget retries can be spent in a burst, and one is earned back every DefaultRetryBudgetRefill.
+	DefaultRetryBudget       = 10
+	DefaultRetryBudgetRefill = 10 * time.Second
+	// GitHubRequestTimeout bounds every request to the GitHub API, including reading the response.
+	GitHubRequestTimeout = 10 * time.Second
+	// DefaultCompactKeep is the number of recent messages kept by ":compact" without a number.
+	DefaultCompactKeep = 4
+	// ContextCountPercent is the share of the input token limit above which the tokens of the context are counted
+	// with the API; below it, the offline estimate is trusted (see fitContextHistory).
+	ContextCountPercent = 80
+	// MaxSystemNotices is the number of system messages without a category (e.g., version notices) kept in the history.
+	MaxSystemNotices = 3
+	// SummarizeTimeout, VersionCheckTimeout and TokenCountCommandTimeout bound the commands that wait for the network.
+	SummarizeTimeout         = 2 * time.Minute
+	VersionCheckTimeout      = 2 * time.Minute
+	TokenCountCommandTimeout = 10 * time.Minute
+	// ResponseTokenCountOperation names the token count of a response in the timeout message.
+	ResponseTokenCountOperation = "response token count"
+	// TokenCountTimeout bounds counting the tokens of a response, which is shown when SHOW_TOKEN_COUNT is enabled.
+	TokenCountTimeout = 30 * time.Second
+	// TelemetryTimeout bounds the telemetry request, so a slow endpoint never delays the exit.
+	TelemetryTimeout = 5 * time.Second
+	// DefaultTheme and PlainTheme are the available color themes (see themes).
+	DefaultTheme       = "default"
+	PlainTheme         = "plain"
+	SessionNamePattern = `^[A-Za-z0-9][A-Za-z0-9._-]*$`
+)
+
+// offline token estimation
+const (
+	// ApproxCharsPerToken is the average number of letters or digits per token in English-like text.
+	ApproxCharsPerToken = 4
+	// ApproxTokensPerImage is the fixed number of tokens the API counts for a single image.
+	ApproxTokensPerImage = 258
+)
+
+// chunking
+const (
+	DefaultChunkMaxTokens     = 2000
+	DefaultChunkOverlapTokens = 200
+)
+
+// cron
+const (
+	CronFieldMinute     = "minute"
+	CronFieldHour       = "hour"
+	CronFieldDayOfMonth = "day-of-month"
+	CronFieldMonth      = "month"
+	CronFieldDayOfWeek  = "day-of-week"
+	CronWildcard        = "*"
+	CronStep            = "/"
+	CronRange           = "-"
+	// CronMaxLookahead bounds the search for the next run; every valid schedule fires within it.
+	CronMaxLookahead = 5 * 366 * 24 * time.Hour
+)
+
+// fan-out
+const (
+	// FanoutMaxWorkers is the maximum number of fan-out prompts sent to the API at the same time.
+	FanoutMaxWorkers      = 4
+	FanoutPromptSeparator = "---"
+	MarkdownQuote         = "> "
+)
+
+// file watch
+const (
+	// WatchSettleDelay is how long a watched file must stay unchanged after a change before it is read.
+	WatchSettleDelay = time.Second
+	// WatchMinInterval is the minimum time between two sends of a watched file, to protect the API quota.
+	WatchMinInterval  = 30 * time.Second
+	WatchPromptFormat = "%s\n\nFile: %s\n" + TripleBacktick + "\n%s\n" + TripleBacktick
+)
+
+// spinner
+const (
+	SpinnerFormat   = "\r" + ColorHex95b806 + "%s" + ColorReset + " %s"
+	SpinnerInterval = 100 * time.Millisecond
+	// ClearLine moves the cursor to the beginning of the line and erases it.
+	ClearLine = "\r\033[K"
+)
+
+// Transcript import
+const (
+	ChatGPTRoleUser           = "user"
+	ChatGPTRoleAssistant      = "assistant"
+	GeminiTakeoutPromptPrefix = "Prompted "
+	HTMLBlockBreakRegex       = `(?i)<br\s*/?>|</(p|div|li|h[1-6]|pre)>`
+	HTMLTagRegex              = `<[^>]*>`
+)
+
+// HTML transcript export
+const (
+	CSSClassUser    = "user"
+	CSSClassAI      = "ai"
+	CSSClassSystem  = "system"
+	InlineCodeRegex = "`([^`\n]+)`"
+	BoldTextRegex   = `\*\*([^*\n]+)\*\*`
+	// TranscriptHTMLTemplate reproduces the terminal color scheme with inline CSS only, so the file works offline.
+	TranscriptHTMLTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} Transcript</title>
+<style>
+body { background: #0c0c0c; color: #cccccc; font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; margin: 0 auto; max-width: 960px; padding: 24px; }
+h1 { color: #95b806; font-size: 1.4em; }
+.exported { color: #808080; }
+.message { border-left: 3px solid #303030; margin: 16px 0; padding: 4px 12px; }
+.sender { font-weight: bold; margin-bottom: 6px; }
+.user .sender { color: #11f0f7; }
+.ai .sender { color: #95b806; }
+.system .sender { color: #ffff00; }
+.content { white-space: pre-wrap; word-wrap: break-word; }
+.content strong { color: #95b806; }
+code.inline { color: #ffff00; }
+pre { background: #1a1a1a; border: 1px solid #ff00ff; border-radius: 4px; overflow-x: auto; padding: 8px; white-space: pre; }
+pre code { color: #cccccc; }
+</style>
+</head>
+<body>
+<h1>{{.Title}} Transcript</h1>
+<p class="exported">Exported on {{.ExportedAt}}</p>
+{{range .Messages}}<div class="message {{.Class}}">
+<div class="sender">{{.Sender}}</div>
+<div class="content">{{.Content}}</div>
+</div>
+{{end}}</body>
+</html>
+`
+)
+
+// image magic bytes
+const (
+	MagicPNG  = "\x89PNG\r\n\x1a\n"
+	MagicJPEG = "\xff\xd8\xff"
+	MagicRIFF = "RIFF"
+	MagicWEBP = "WEBP"
+	MagicFtyp = "ftyp"
+	// imageHeaderSize is the number of leading bytes needed to detect every supported image format.
+	imageHeaderSize = 12
+)
+
 // model configuration
 const (
 	MinOutputTokens int32 = 20 // Define the minimum number of tokens as a constant
+	// Configuration keys for the ":config set" command
+	ConfigMaxTokens   = "maxtokens"
+	ConfigStop        = "stop"
+	ConfigSeed        = "seed"
+	ConfigTemperature = "temperature"
+	ConfigTopP        = "topp"
+	ConfigTopK        = "topk"
+	// DefaultTemperature is the temperature of new sessions, and MaxTemperature the highest one accepted by the API.
+	DefaultTemperature float32 = 0.9
+	MaxTemperature     float32 = 2
+	// MaxStopSequences is the maximum number of stop sequences accepted by the API.
+	MaxStopSequences = 5
+)
+
+// retry policy
+const (
+	// Settings of the ":retrypolicy" command, named like the values of the retry_policy config section.
+	RetryKeyMaxRetries  = "max_retries"
+	RetryKeyBaseDelay   = "base_delay_ms"
+	RetryKeyMaxDelay    = "max_delay_ms"
+	RetryKeyJitter      = "jitter"
+	RetryKeyStatusCodes = "retryable_status_codes"
+)
+
+// terminal image previews
+const (
+	ImageProtocolKitty = "kitty"
+	ImageProtocolITerm = "iterm"
+	ImageProtocolSixel = "sixel"
+	ImageProtocolNone  = "none"
+	// ImagePreviewFallback is printed instead of a preview on terminals without a graphics protocol.
+	ImagePreviewFallback = "🖼️  Image: %s\n"
+	// ImagePreviewMaxBytes is the size of the largest image file that is previewed.
+	ImagePreviewMaxBytes = 10 << 20
+	// ImagePreviewColumns is the width of kitty and iTerm2 previews in terminal columns.
+	ImagePreviewColumns = 40
+	// SixelMaxWidth and SixelMaxHeight bound the size of Sixel previews in pixels.
+	SixelMaxWidth  = 480
+	SixelMaxHeight = 360
+	// ImageReferenceTrimChars are stripped from both ends of a word before it is checked for an image path.
+	ImageReferenceTrimChars = "`'\"()[]<>,;:!?*"
+	// KittyChunkSize is the largest base64 payload sent in a single kitty graphics escape sequence.
+	KittyChunkSize = 4096
+	// Escape sequences of the graphics protocols
+	KittyImageFirstChunk = "\x1b_Ga=T,f=100,c=%d,m=%d;%s\x1b\\"
+	KittyImageNextChunk  = "\x1b_Gm=%d;%s\x1b\\"
+	ITermImageFormat     = "\x1b]1337;File=inline=1;size=%d;width=%d;preserveAspectRatio=1:%s\a"
+	SixelStart           = "\x1bPq"
+	SixelRasterFormat    = "\"1;1;%d;%d"
+	SixelColorFormat     = "#%d;2;%d;%d;%d"
+	SixelEnd             = "\x1b\\"
+)
+
+// unified diffs
+const (
+	DiffLanguage        = "diff"
+	PatchLanguage       = "patch"
+	DiffOldFilePrefix   = "--- "
+	DiffNewFilePrefix   = "+++ "
+	DiffGitPrefix       = "diff --git "
+	DiffIndexPrefix     = "index "
+	DiffHunkPrefix      = "@@"
+	DiffAddedPrefix     = "+"
+	DiffRemovedPrefix   = "-"
+	DiffLinePrefixes    = " +-"
+	DiffNoNewlinePrefix = "\\ "
+	DevNull             = "/dev/null"
+)
+
+// context caching
+const (
+	// ContextCacheMinTokens is the estimated size of the chat history from which it is cached.
+	// Smaller content is rejected by the API.
+	ContextCacheMinTokens = 32768
+	// ContextCacheTTL is how long a cache is kept by the API.
+	ContextCacheTTL = time.Hour
+	// ContextCacheRole is the role of the cached chat history.
+	ContextCacheRole = "user"
+)
+
+// terminal width
+const (
+	// DefaultTerminalWidth is the width of the terminal in columns when it cannot be measured and COLUMNS is not set.
+	DefaultTerminalWidth = 80
+	// SeparatorChar is repeated across the width of the terminal to separate the responses.
+	SeparatorChar = "-"
+)
+
+// token count formats
+const (
+	// TokenCountFormatJSON and TokenCountFormatCSV are the formats of ":tokencount ... :format".
+	TokenCountFormatJSON = "json"
+	TokenCountFormatCSV  = "csv"
+	// TokenCountCSVTotal labels the row with the total in the CSV format.
+	TokenCountCSVTotal = "total"
+)
+
+// preflight check
+const (
+	// PreflightEstimate and PreflightCount are the modes of the preflight check (see PreflightEnv).
+	PreflightEstimate = "estimate"
+	PreflightCount    = "count"
+)
+
+// inline commands
+const (
+	// InlineCommandOpen and InlineCommandClose enclose a command in a prompt, e.g. "!{cat main.go}".
+	InlineCommandOpen  = "!{"
+	InlineCommandClose = "}"
+	// InlineCommandTimeout is how long a shell command in a prompt may run.
+	InlineCommandTimeout = 30 * time.Second
+	// InlineCommandMaxOutput is the largest output of a command, in bytes, included in a prompt.
+	InlineCommandMaxOutput = 64 << 10
+	// InlineOutputTruncated is appended to the output of a command that was cut, e.g. at InlineCommandMaxOutput.
+	InlineOutputTruncated = "\n[output truncated]"
+)
+
+// file inclusion
+const (
+	// FileIncludePrefix starts a reference to a file whose content is included in a prompt, e.g. "@file:main.go".
+	FileIncludePrefix = "@file:"
+	// URLIncludePrefix starts a reference to a page whose text is included in a prompt, e.g. "@url:https://go.dev".
+	URLIncludePrefix = "@url:"
+	// FileIncludeFormat is how the path or URL and content of an included file or page appear in the prompt.
+	FileIncludeFormat = "%s:\n```\n%s\n```"
+	// URLFetchTimeout is how long fetching an included page may take.
+	URLFetchTimeout = 30 * time.Second
+	// URLMaxBytes is the largest part of an included page, in bytes, that is read.
+	URLMaxBytes = 2 << 20
+	// URLTokenBudget is the largest estimated number of tokens of the text of an included page.
+	URLTokenBudget = 8000
+	// URLCacheTTL is how long the text of a fetched page is reused.
+	URLCacheTTL = 15 * time.Minute
+	// HTMLSkippedRegex matches the comments, scripts, styles and other parts of a page that are not text.
+	HTMLSkippedRegex = `(?is)<!--.*?-->|<(?:head|script|style|noscript|template|svg)\b.*?</(?:head|script|style|noscript|template|svg)\s*>`
+	// URLTruncated is appended to the text of a page that was cut at URLMaxBytes or URLTokenBudget.
+	URLTruncated = "\n[page truncated]"
+)
+
+// web search
+const (
+	// WebSearchBrave, WebSearchGoogle and WebSearchSearXNG are the engines of the web search (see WebSearchConfig).
+	WebSearchBrave   = "brave"
+	WebSearchGoogle  = "google"
+	WebSearchSearXNG = "searxng"
+	// BraveSearchURL and GoogleSearchURL are the default endpoints of the Brave and Google search APIs.
+	BraveSearchURL  = "https://api.search.brave.com/res/v1/web/search"
+	GoogleSearchURL = "https://www.googleapis.com/customsearch/v1"
+	// BraveTokenHeader holds the key of the Brave search API.
+	BraveTokenHeader = "X-Subscription-Token"
+	// DefaultWebSearchResults is the number of results used when max_results is not set.
+	DefaultWebSearchResults = 5
+	// WebSearchTimeout is how long a web search may take.
+	WebSearchTimeout = 30 * time.Second
+	// WebSearchResultFormat is how each result appears in the summary prompt and the tool response.
+	WebSearchResultFormat = "%d. %s\n   %s\n   %s"
+	// WebSearchToolName and WebSearchQueryParam declare the function the AI can call to search the web.
+	WebSearchToolName   = "web_search"
+	WebSearchQueryParam = "query"
+	// WebSearchToolDescription tells the AI when to call the function.
+	WebSearchToolDescription = "Searches the web and returns the title, URL and snippet of the top results. " +
+		"Use it for recent events or facts you are not sure about."
+	// WebSearchMaxToolCalls bounds the rounds of function calls answered for one message.
+	WebSearchMaxToolCalls = 3
+)
+
+// kubernetes
+const (
+	// KubectlBinary is the kubectl executable run by ":k8s explain", looked up in PATH.
+	KubectlBinary = "kubectl"
+	// KubectlTimeout is how long a kubectl command may run.
+	KubectlTimeout = 60 * time.Second
+	// KubectlMaxOutput is the largest output of kubectl, in bytes, sent to the AI.
+	KubectlMaxOutput = 64 << 10
+)
+
+// log analysis
+const (
+	// DockerBinary is the docker executable run by ":docker", looked up in PATH.
+	DockerBinary = "docker"
+	// DefaultLogLines is the number of most recent log lines read when none is given.
+	DefaultLogLines = 500
+	// LogsTimeout is how long reading the logs may take.
+	LogsTimeout = 60 * time.Second
+	// LogsMaxOutput is the largest part of the logs, in bytes, that is analyzed.
+	LogsMaxOutput = 256 << 10
+	// LogsChunkMaxTokens is the size of the parts that long logs are analyzed in, one request each.
+	LogsChunkMaxTokens = 8000
+	// LogsFindingFormat is how the errors collected from each part of long logs are listed.
+	LogsFindingFormat = "Part %d:\n%s"
+	// DockerContainerSource and ComposeServiceSource name the logs in the prompts and messages.
+	DockerContainerSource = "the Docker container %q"
+	ComposeServiceSource  = "the Docker Compose service %q"
+	SystemLogSource       = "the system"
+	SystemUnitLogSource   = "the system unit %q"
+	// JournalctlBinary is the journalctl executable run by ":logs :system", looked up in PATH.
+	JournalctlBinary = "journalctl"
+	// RuleLocalHostname masks the name of this machine, which syslog puts on every line.
+	RuleLocalHostname = "local-hostname"
+)
+
+// error triage
+const (
+	// HeredocPrefix starts the marker of ":fix <<MARKER", which reads pasted lines up to the marker.
+	HeredocPrefix = "<<"
+	// ClipboardSource and PastedSource name where the error to fix was read from.
+	ClipboardSource = "the clipboard"
+	PastedSource    = "the pasted text"
+	// ClipboardTimeout is how long reading the clipboard may take.
+	ClipboardTimeout = 10 * time.Second
+	// FixMaxBytes is the largest part of the error, in bytes, sent to the AI.
+	FixMaxBytes = 64 << 10
+	// WaylandDisplayEnv is set in a Wayland session, where the clipboard is read with wl-paste.
+	WaylandDisplayEnv = "WAYLAND_DISPLAY"
+)
+
+// go tooling
+const (
+	// GoBinary is the go executable run by ":go", looked up in PATH.
+	GoBinary = "go"
+	// GoAllPackages is the pattern of the packages tested when ":go test :explain" names none.
+	GoAllPackages = "./..."
+	// GoDocTimeout and GoTestTimeout are how long go doc and go test may run.
+	GoDocTimeout  = 60 * time.Second
+	GoTestTimeout = 10 * time.Minute
+	// GoToolMaxOutput is the largest output of the go command, in bytes, sent to the AI.
+	GoToolMaxOutput = 64 << 10
+)
+
+// session backups
+const (
+	// DefaultBackupInterval and DefaultBackupKeep are used when interval_minutes and keep are not set.
+	DefaultBackupInterval = 5 * time.Minute
+	DefaultBackupKeep     = 10
+	// MemoryBackupName names the backups of a chat history that is not stored (see SESSION_NAME).
+	MemoryBackupName = "session"
+	// BackupTimeFormat is the time in the name of a backup file, e.g., "work-20240501-143000.json".
+	BackupTimeFormat = "20060102-150405"
+)
+
+// background summary
+const (
+	// DefaultAutoSummaryInterval is the time between two checks of the background summary when interval_minutes is not set.
+	DefaultAutoSummaryInterval = time.Minute
+	// DefaultAutoSummaryKeep is the number of most recent messages kept when keep is not set, more than ":compact" keeps,
+	// since the summary runs while the conversation goes on.
+	DefaultAutoSummaryKeep = 10
+	// AutoSummaryQueueLabel shows the background summary while it waits in the prompt queue.
+	AutoSummaryQueueLabel = "(summary of the oldest messages)"
+)
+
+// line editor
+const (
+	// CursorUpFormat, CursorDownFormat, CursorLeftFormat and CursorRightFormat move the cursor the given number of
+	// rows or columns.
+	CursorUpFormat    = "\033[%dA"
+	CursorDownFormat  = "\033[%dB"
+	CursorLeftFormat  = "\033[%dD"
+	CursorRightFormat = "\033[%dC"
+	// EraseToEndOfScreen erases the screen from the cursor to its end, including the rows of a wrapped line below it.
+	EraseToEndOfScreen = "\033[J"
+	// CursorPositionRequest asks the terminal for the position of the cursor, which it answers with
+	// "\033[<row>;<column>R" in the input. The answer is awaited for CursorPositionTimeout.
+	CursorPositionRequest = "\033[6n"
+	CursorPositionReport  = "%d;%d"
+	CursorPositionTimeout = 500 * time.Millisecond
+	// NextRow moves the cursor to the start of the next row.
+	NextRow = "\r\n"
+	// DumbTerminal is the TERM of terminals that cannot move the cursor, where the input is read as it is.
+	DumbTerminal = "dumb"
+	KeyCtrlA     = 0x01
+	KeyCtrlD     = 0x04
+	KeyCtrlE     = 0x05
+	KeyCtrlK     = 0x0b
+	KeyCtrlU     = 0x15
+	KeyBackspace = 0x08
+	KeyDelete    = 0x7f
+)
+
+// escape sequences
+const (
+	// MaxPendingEscapeBytes is the longest unterminated escape sequence held back by an escapeStripWriter
+	// before it is given up on, so a sequence that never ends cannot hold back the output.
+	MaxPendingEscapeBytes = 4096
+)
+
+// recorder
+const (
+	RecorderModeRecord = "record"
+	RecorderModeReplay = "replay"
+)
+
+// image input
+const (
+	// ImageMaxBytes is the largest image sent inline with a request.
+	ImageMaxBytes = 20 << 20
+	// ImageDefaultPrompt is sent with an image when ":image" has no prompt.
+	ImageDefaultPrompt = "Describe this image."
+	// ImageHistoryFormat is how an image and its prompt appear in the chat history, which only keeps the path.
+	ImageHistoryFormat = "[image: %s] %s"
+	// MultimodalModelPrefix identifies the models that accept images themselves, instead of GeminiProVision.
+	MultimodalModelPrefix = "gemini-1.5-"
+)
+
+// command errors
+const (
+	// The error codes of a CommandError, stable for programs that handle the failures of commands.
+	CommandErrorUnknownCommand    = "unknown_command"
+	CommandErrorUnknownSubcommand = "unknown_subcommand"
+	CommandErrorInvalidArgs       = "invalid_arguments"
+	CommandErrorNotAvailable      = "not_available"
+	CommandErrorFailed            = "command_failed"
+	// CommandErrorFormat is how the failure of a command is shown, with its error code.
+	CommandErrorFormat = "%v (error code: %s)"
 )
@@ -0,0 +1,125 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: The Gemini API keeps no server-side chat sessions, but it can cache content and refer to it by name (see genai.CachedContent).
+// With CONTEXT_CACHE enabled, a chat history that is long enough to be cached is cached once, and the following messages only send
+// what was added after it. The name is stored with a named session, so the cache is reused after a restart until it expires.
+// Models that cannot cache content, and histories below the minimum size of a cache, keep sending the full history.
+// The cache holds the messages at a fixed position in the chat history rather than the HistorySize messages sent as
+// context, which shift with every exchange: the context starts with the cached messages until the messages added after
+// them have grown to the size of a cache, and only then is a new cache created from the latest HistorySize messages.
+
+package terminal
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	genai "github.com/google/generative-ai-go/genai"
+)
+
+// contextCacheFor returns the name of a cached content that covers the start of the chat history sent as context,
+// together with the rest of the history, which still has to be sent. A new cache is created if there is none
+// that can be used, or if the history that is not cached has grown to the size of a cache itself.
+// It returns false if the history is sent in full.
+func (s *Session) contextCacheFor(ctx context.Context) (name, rest string, ok bool) {
+	if appConfig == nil || !appConfig.ContextCache || s.DryRun || s.historyTx != nil {
+		return "", "", false
+	}
+	modelName := s.activeModelName()
+	if modelName == s.contextCacheUnsupported {
+		return "", "", false
+	}
+
+	messages := s.ChatHistory.snapshot().messages
+	cache := s.contextCache
+	if !cache.covers(modelName, messages) ||
+		EstimateTokens(strings.Join(messages[cache.end():], "")) >= ContextCacheMinTokens {
+		start := max(0, len(messages)-s.ChatConfig.HistorySize) // The messages sent as context, see GetHistory
+		if EstimateTokens(strings.Join(messages[start:], "")) < ContextCacheMinTokens {
+			return "", "", false
+		}
+		var err error
+		if cache, err = s.createContextCache(ctx, modelName, messages, start); err != nil {
+			logger.Debug(DebugContextCacheFailed, modelName, err)
+			s.contextCacheUnsupported = modelName // Do not try again with this model
+			return "", "", false
+		}
+	}
+	return cache.Name, s.ChatHistory.buildHistoryString(messages[cache.end():]), true
+}
+
+// covers reports whether the cache can be used with the model for the messages of the chat history, which must
+// still hold the cached messages at their position. A nil cache covers nothing.
+func (c *ContextCache) covers(modelName string, messages []string) bool {
+	return c != nil && c.Model == modelName && time.Now().Before(c.ExpiresAt) &&
+		c.end() <= len(messages) && hashMessages(messages[c.Start:c.end()]) == c.Digest
+}
+
+// end returns the position in the chat history right after the last cached message.
+func (c *ContextCache) end() int {
+	return c.Start + c.Messages
+}
+
+// createContextCache caches the messages of the chat history from the start position on for the model.
+// The previous cache, if any, is deleted.
+func (s *Session) createContextCache(ctx context.Context, modelName string, messages []string, start int) (*ContextCache, error) {
+	messages = messages[start:]
+	created, err := s.Client.CreateCachedContent(ctx, &genai.CachedContent{
+		Model:      modelName,
+		Expiration: genai.ExpireTimeOrTTL{TTL: ContextCacheTTL},
+		Contents: []*genai.Content{{
+			Role:  ContextCacheRole,
+			Parts: []genai.Part{genai.Text(s.ChatHistory.buildHistoryString(messages))},
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cache := &ContextCache{
+		Name:      created.Name,
+		Model:     modelName,
+		Start:     start,
+		Messages:  len(messages),
+		Digest:    hashMessages(messages),
+		ExpiresAt: created.Expiration.ExpireTime,
+	}
+	if cache.ExpiresAt.IsZero() {
+		cache.ExpiresAt = time.Now().Add(ContextCacheTTL)
+	}
+	logger.Debug(DebugContextCacheCreated, len(messages), cache.Name)
+	s.deleteContextCache(ctx)
+	s.setContextCache(cache)
+	return cache, nil
+}
+
+// deleteContextCache deletes the current cache, if any. The cache would expire anyway, so failures are ignored.
+func (s *Session) deleteContextCache(ctx context.Context) {
+	if s.contextCache == nil {
+		return
+	}
+	if err := s.Client.DeleteCachedContent(ctx, s.contextCache.Name); err != nil {
+		logger.Debug(DebugContextCacheNotDeleted, s.contextCache.Name, err)
+	}
+	s.setContextCache(nil)
+}
+
+// setContextCache replaces the current cache, which is stored with a named session.
+func (s *Session) setContextCache(cache *ContextCache) {
+	s.contextCache = cache
+	if s.Store != nil {
+		s.Store.setContextCache(cache)
+	}
+}
+
+// hashMessages returns a digest of the messages.
+func hashMessages(messages []string) string {
+	data := make([][]byte, len(messages))
+	for i, message := range messages {
+		data[i] = []byte(message)
+	}
+	return hashSHA256(data...)
+}
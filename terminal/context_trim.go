@@ -0,0 +1,67 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: The context holds at most HistorySize messages, and only as many of them as fit the input token limit of
+// the model, so a long conversation does not fail with a request that is too large. The tokens are estimated offline
+// first, and only counted with the API once the estimate comes close to the limit.
+
+package terminal
+
+import (
+	"context"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+// fitContextHistory returns the chat history to send before the chat context, without the oldest chat messages
+// that would make the request exceed the input token limit of the model. The persona, the remembered facts and
+// the system messages are never left out. If the limit is unknown, the chat history is returned whole.
+func (s *Session) fitContextHistory(ctx context.Context, chatContext string) string {
+	history := s.contextChatHistory()
+	text := history.GetHistory(s.ChatConfig)
+	if s.DryRun {
+		return text
+	}
+	limit, err := s.inputTokenLimit(ctx)
+	if err != nil || limit <= 0 {
+		logger.Debug(DebugContextLimitUnknown, err)
+		return text
+	}
+
+	chatMsgs := history.contextChatMessages(s.ChatConfig)
+	fixed := s.expandedPersona() + s.memoryContext() + chatContext
+	drop := 0
+	for drop < len(chatMsgs) {
+		excess := s.countContextTokens(ctx, fixed+text, limit) - limit
+		if excess <= 0 {
+			break
+		}
+		// Leave out the oldest messages until their estimate covers the excess, then count again.
+		for excess > 0 && drop < len(chatMsgs) {
+			excess -= EstimateTokens(chatMsgs[drop])
+			drop++
+		}
+		text = history.getHistoryWithout(s.ChatConfig, drop)
+	}
+	if drop > 0 {
+		s.notify(InfoContextTrimmed, drop, limit)
+	}
+	return text
+}
+
+// countContextTokens returns the tokens of the context. While the offline estimate stays below ContextCountPercent
+// of the limit, the estimate is returned; closer to the limit, the tokens are counted with the client of the session,
+// falling back to the estimate if the API cannot be reached.
+func (s *Session) countContextTokens(ctx context.Context, text string, limit int) int {
+	estimate := EstimateTokens(text)
+	if estimate*100 < limit*ContextCountPercent {
+		return estimate
+	}
+	resp, err := s.Client.GenerativeModel(s.activeModelName()).CountTokens(ctx, genai.Text(text))
+	if err != nil {
+		logger.Debug(DebugContextCountFailed, err)
+		return estimate
+	}
+	return int(resp.TotalTokens)
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: AI output is untrusted. Escape sequences in it could clear the screen, move the cursor over earlier output,
+// change the window title or even write to the clipboard (OSC 52), so they are removed before anything is rendered.
+// The colors of the terminal are only ever added afterwards, by the renderer itself. The same applies to the responses
+// that are not rendered right away (see sendFanoutPrompt) and to the results of web searches.
+
+package terminal
+
+import (
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// stripControlSequences removes the terminal escape sequences and control characters from text, keeping newlines
+// and tabs. A carriage return is only kept as part of a line ending, so it cannot overwrite a line. Bytes that are
+// not valid UTF-8 are replaced with U+FFFD, since a stray 0x9b is read as CSI by terminals in 8-bit mode.
+func stripControlSequences(text string) string {
+	if !strings.ContainsFunc(text, isControlRune) && utf8.ValidString(text) {
+		return text
+	}
+	var result strings.Builder
+	result.Grow(len(text))
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case r == BinaryAnsiChar:
+			i += escapeSequenceLength(text[i:])
+			continue
+		case r == utf8.RuneError && size == 1:
+			result.WriteRune(utf8.RuneError)
+		case r == '\r' && strings.HasPrefix(text[i+size:], StringNewLine), !isControlRune(r):
+			result.WriteString(text[i : i+size])
+		}
+		i += size
+	}
+	return result.String()
+}
+
+// isControlRune reports whether r is a control character other than a newline or a tab.
+func isControlRune(r rune) bool {
+	return r != '\n' && r != '\t' && unicode.IsControl(r)
+}
+
+// escapeSequenceLength returns the length of the escape sequence at the start of text, which starts with ESC.
+// Of an unterminated sequence, only the introducer is counted, so the text after it stays visible.
+func escapeSequenceLength(text string) int {
+	if len(text) < 2 {
+		return len(text)
+	}
+	switch text[1] {
+	case BinaryLeftSquareBracket:
+		// CSI: parameter and intermediate bytes, ended by a final byte.
+		for i := 2; i < len(text); i++ {
+			switch c := text[i]; {
+			case c >= 0x40 && c <= 0x7e:
+				return i + 1
+			case c < 0x20 || c > 0x3f:
+				return 2
+			}
+		}
+	case ']', 'P', 'X', '^', '_':
+		// OSC, DCS, SOS, PM and APC: a string ended by BEL or ST (ESC \).
+		for i := 2; i < len(text); i++ {
+			switch {
+			case text[i] == '\a':
+				return i + 1
+			case text[i] == BinaryAnsiChar:
+				if i+1 < len(text) && text[i+1] == '\\' {
+					return i + 2
+				}
+				return 2
+			}
+		}
+	default:
+		// Any other sequence is ESC followed by a single character.
+		_, size := utf8.DecodeRuneInString(text[1:])
+		return 1 + size
+	}
+	return 2
+}
+
+// escapeSequenceEnded reports whether text, which starts with ESC, holds the whole escape sequence, so it can be
+// removed without looking at what follows it.
+func escapeSequenceEnded(text string) bool {
+	if len(text) < 2 {
+		return false
+	}
+	switch text[1] {
+	case BinaryLeftSquareBracket:
+		for i := 2; i < len(text); i++ {
+			if c := text[i]; c < 0x20 || c > 0x3f {
+				return true
+			}
+		}
+		return false
+	case ']', 'P', 'X', '^', '_':
+		for i := 2; i < len(text); i++ {
+			if text[i] == '\a' || (text[i] == BinaryAnsiChar && i+1 < len(text)) {
+				return true
+			}
+		}
+		return false
+	}
+	return utf8.FullRuneInString(text[1:])
+}
+
+// stripEscapeSequences removes the terminal escape sequences from text. Unlike stripControlSequences, it keeps the
+// control characters, such as the carriage return the spinner redraws its line with.
+func stripEscapeSequences(text string) string {
+	if !strings.ContainsRune(text, BinaryAnsiChar) {
+		return text
+	}
+	var result strings.Builder
+	result.Grow(len(text))
+	for i := 0; i < len(text); {
+		if text[i] == BinaryAnsiChar {
+			i += escapeSequenceLength(text[i:])
+			continue
+		}
+		result.WriteByte(text[i])
+		i++
+	}
+	return result.String()
+}
+
+// Write writes data without its escape sequences. An escape sequence at the end of data that has not ended yet
+// is held back until the next write, unless it is longer than MaxPendingEscapeBytes.
+func (w *escapeStripWriter) Write(data []byte) (int, error) {
+	text := w.pending + string(data)
+	w.pending = ""
+	for i := 0; i < len(text); {
+		start := strings.IndexByte(text[i:], BinaryAnsiChar)
+		if start < 0 {
+			break
+		}
+		start += i
+		if !escapeSequenceEnded(text[start:]) && len(text)-start <= MaxPendingEscapeBytes {
+			text, w.pending = text[:start], text[start:]
+			break
+		}
+		i = start + escapeSequenceLength(text[start:])
+	}
+	if _, err := io.WriteString(w.output, stripEscapeSequences(text)); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+
+package terminal
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// controlSequenceSeeds are inputs with the escape sequences an AI response could carry, complete and cut short.
+var controlSequenceSeeds = []string{
+	"",
+	"plain text",
+	"line one\r\nline two\ttabbed",
+	"\x1b[2J\x1b[Hcleared",
+	"\x1b[31mred\x1b[0m",
+	"\x1b]0;title\a",
+	"\x1b]52;c;aGVsbG8=\x1b\\clipboard",
+	"\x1bP1$r\x1b\\",
+	"\x1b[",
+	"\x1b]8;;https://example.com",
+	"\x1b",
+	"\u009b2J",
+	"\xc2\x9b31m",
+	"\x9b31m",
+	"over\rwrite",
+	"**bold** `code` ```go\nfmt.Println(\"\x1b[1m\")\n```",
+}
+
+// FuzzStripControlSequences checks that no escape sequence introducer, ESC (0x1b) or CSI (0x9b), survives.
+func FuzzStripControlSequences(f *testing.F) {
+	for _, seed := range controlSequenceSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, text string) {
+		stripped := stripControlSequences(text)
+		if strings.ContainsRune(stripped, BinaryAnsiChar) || strings.ContainsRune(stripped, '\u009b') {
+			t.Fatalf("stripControlSequences(%q) = %q, which still holds an escape sequence", text, stripped)
+		}
+		// A 0x9b byte may only be part of a character, so a stray one never reaches the terminal.
+		if !utf8.ValidString(stripped) {
+			t.Fatalf("stripControlSequences(%q) = %q, which is not valid UTF-8", text, stripped)
+		}
+	})
+}
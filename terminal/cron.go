@@ -0,0 +1,145 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: This is a minimal parser for the standard 5-field cron syntax ("minute hour day-of-month month day-of-week").
+// It supports "*", numbers, lists ("1,15"), ranges ("1-5") and steps ("*/10", "0-30/5"), which covers the usual
+// schedules without pulling in a cron dependency. Names such as "MON" and macros such as "@daily" are not supported.
+
+package terminal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronField describes the allowed range of a cron field.
+type cronField struct {
+	name     string
+	min, max int
+}
+
+// cronFields lists the five cron fields in order.
+var cronFields = [5]cronField{
+	{CronFieldMinute, 0, 59},
+	{CronFieldHour, 0, 23},
+	{CronFieldDayOfMonth, 1, 31},
+	{CronFieldMonth, 1, 12},
+	// Note: Both 0 and 7 mean Sunday.
+	{CronFieldDayOfWeek, 0, 7},
+}
+
+// ParseCronSpec parses a 5-field cron expression, e.g., "0 9 * * *" for every day at 09:00.
+//
+// Returns:
+//
+//	*CronSchedule: The parsed schedule.
+//	error: An error if the expression does not have five fields or a field is invalid.
+func ParseCronSpec(spec string) (*CronSchedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf(ErrorInvalidCronSpec, spec, len(fields))
+	}
+
+	var sets [5]uint64
+	for i, field := range fields {
+		set, err := parseCronField(field, cronFields[i])
+		if err != nil {
+			return nil, err
+		}
+		sets[i] = set
+	}
+
+	// Fold Sunday as 7 into Sunday as 0.
+	if sets[4]&(1<<7) != 0 {
+		sets[4] = sets[4]&^(1<<7) | 1
+	}
+
+	return &CronSchedule{
+		minutes:     sets[0],
+		hours:       sets[1],
+		daysOfMonth: sets[2],
+		months:      sets[3],
+		daysOfWeek:  sets[4],
+		anyDayOfMon: fields[2] == CronWildcard,
+		anyDayOfWk:  fields[4] == CronWildcard,
+	}, nil
+}
+
+// parseCronField parses one comma-separated cron field into a bit set of the allowed values.
+func parseCronField(field string, spec cronField) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, commaString) {
+		rangePart, stepPart, hasStep := strings.Cut(part, CronStep)
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step <= 0 {
+				return 0, fmt.Errorf(ErrorInvalidCronField, spec.name, field)
+			}
+		}
+
+		start, end := spec.min, spec.max
+		if rangePart != CronWildcard {
+			low, high, isRange := strings.Cut(rangePart, CronRange)
+			var err error
+			if start, err = strconv.Atoi(low); err != nil {
+				return 0, fmt.Errorf(ErrorInvalidCronField, spec.name, field)
+			}
+			end = start
+			if isRange {
+				if end, err = strconv.Atoi(high); err != nil {
+					return 0, fmt.Errorf(ErrorInvalidCronField, spec.name, field)
+				}
+			} else if hasStep {
+				// "5/15" means every 15 starting at 5.
+				end = spec.max
+			}
+		}
+		if start < spec.min || end > spec.max || start > end {
+			return 0, fmt.Errorf(ErrorCronFieldOutOfRange, spec.name, field, spec.min, spec.max)
+		}
+
+		for value := start; value <= end; value += step {
+			set |= 1 << value
+		}
+	}
+	return set, nil
+}
+
+// Matches reports whether the schedule fires at the minute of t.
+//
+// As in standard cron, when both the day of month and the day of week are restricted,
+// the schedule fires when either of them matches.
+func (c *CronSchedule) Matches(t time.Time) bool {
+	if c.minutes&(1<<t.Minute()) == 0 || c.hours&(1<<t.Hour()) == 0 || c.months&(1<<int(t.Month())) == 0 {
+		return false
+	}
+
+	dayOfMonth := c.daysOfMonth&(1<<t.Day()) != 0
+	dayOfWeek := c.daysOfWeek&(1<<int(t.Weekday())) != 0
+	switch {
+	case c.anyDayOfMon && c.anyDayOfWk:
+		return true
+	case c.anyDayOfMon:
+		return dayOfWeek
+	case c.anyDayOfWk:
+		return dayOfMonth
+	default:
+		return dayOfMonth || dayOfWeek
+	}
+}
+
+// Next returns the first minute strictly after the given time at which the schedule fires.
+// It returns the zero time if the schedule never fires within CronMaxLookahead (e.g., "0 0 31 2 *").
+func (c *CronSchedule) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	for limit := t.Add(CronMaxLookahead); t.Before(limit); t = t.Add(time.Minute) {
+		if c.Matches(t) {
+			return t
+		}
+	}
+	return time.Time{}
+}
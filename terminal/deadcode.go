@@ -84,6 +84,20 @@ func ApplyBold(text string, delimiter string, color string) string {
 	return color + text + ColorReset
 }
 
+// ApplyFormatting applies text formatting based on the provided FormattingOptions.
+// If the delimiter is recognized, it applies the appropriate ANSI formatting codes.
+//
+// Deprecated: This method is no longer used, and was replaced by StreamColorizer.
+// Refer to Colorize for text formatting options.
+func ApplyFormatting(options FormattingOptions) string {
+	if formatCode, ok := options.Formatting[options.Delimiter]; ok {
+		return options.Color + formatCode +
+			options.Text + ResetBoldText +
+			ResetItalicText + ColorReset
+	}
+	return options.Color + options.Text + ColorReset
+}
+
 // HandleUnrecognizedCommand takes an unrecognized command and the current session,
 // constructs a prompt to inform the AI about the unrecognized command, and sends
 // this information to the AI service. This function is typically called when a user
@@ -109,7 +123,7 @@ func HandleUnrecognizedCommand(command string, session *Session, parts []string)
 	// Debug
 	logger.Debug(DEBUGEXECUTINGCMD, command, parts)
 	// Pass ContextPrompt
-	session.ChatHistory.AddMessage(AiNerd, ContextPrompt, session.ChatConfig)
+	session.ChatHistory.AddMessage(AiNerd, promptTemplate(PromptTemplateContext), session.ChatConfig)
 	// If the command is not recognized, inform the AI about the unrecognized command.
 	aiPrompt := fmt.Sprintf(ErrorUserAttemptUnrecognizedCommandPrompt, ApplicationName, command)
 	// Sanitize the message before sending it to the AI
@@ -175,7 +189,7 @@ var ASCIIColors = map[rune]string{
 // Deprecated: This method is no longer used, and was replaced by NewASCIIArtStyle().
 // NewASCIIArtStyle() should be used for visual separators.
 func PrintAnotherVisualSeparator() {
-	fmt.Println(colors.ColorCyan24Bit + StripChars + colors.ColorReset)
+	fmt.Println(colors.ColorCyan24Bit + separatorLine() + colors.ColorReset)
 }
 
 // ReplaceTripleBackticks replaces all occurrences of triple backticks with a placeholder.
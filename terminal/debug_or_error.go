@@ -8,14 +8,14 @@ package terminal
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"runtime"
 	"strings"
+	"time"
 )
 
-// NewDebugOrErrorLogger initializes a new DebugOrErrorLogger with a logger that writes
-// to os.Stderr with the standard log flags.
+// NewDebugOrErrorLogger initializes a new DebugOrErrorLogger that prints its messages
+// with the typing effect to the terminal output.
 //
 // Returns:
 //
@@ -23,7 +23,6 @@ import (
 func NewDebugOrErrorLogger() *DebugOrErrorLogger {
 	debugMode := os.Getenv(DebugMode) == "true" // Read the environment variable once
 	return &DebugOrErrorLogger{
-		logger:          log.New(os.Stderr, "", log.LstdFlags),
 		debugMode:       debugMode,
 		PrintTypingChat: PrintTypingChat,
 	}
@@ -53,7 +52,7 @@ func (l *DebugOrErrorLogger) Debug(format string, v ...interface{}) {
 		l.PrintTypingChat(builder.String(), TypingDelay)
 
 		// Print a newline after the message
-		printnewlineASCII()
+		printnewlineASCII(stdout)
 	}
 }
 
@@ -69,6 +68,8 @@ func (l *DebugOrErrorLogger) Error(format string, v ...interface{}) {
 
 	// Format the error message
 	message := fmt.Sprintf(format, v...)
+	// Keep the error for ":report".
+	errorHistory.Add(message)
 	builder.WriteString(colors.ColorRed)
 	builder.WriteString(message)
 	builder.WriteString(colors.ColorReset)
@@ -80,7 +81,7 @@ func (l *DebugOrErrorLogger) Error(format string, v ...interface{}) {
 	l.PrintTypingChat(builder.String(), TypingDelay)
 
 	// Print a newline after the message
-	printnewlineASCII()
+	printnewlineASCII(stdout)
 }
 
 // RecoverFromPanic should be deferred at the beginning of a function or goroutine
@@ -106,41 +107,62 @@ func (l *DebugOrErrorLogger) Error(format string, v ...interface{}) {
 // function or goroutine.
 func (l *DebugOrErrorLogger) RecoverFromPanic() {
 	if r := recover(); r != nil {
-		var builder strings.Builder
-		combinedStyle := MergeStyles(panicDetected)
-		text := "PD"
-		asciiArt, _ := ToASCIIArt(text, combinedStyle)
-		fmt.Println(asciiArt)
-		printnewlineASCII()
-		// Format the message for panic
-		// Include the application name and version in the panic log
-		builder.WriteString(fmt.Sprintf(
-			RecoverGopher,
-			ApplicationName,
-			CurrentVersion,
-			colors.ColorHex95b806,
-			colors.ColorReset,
-			colors.ColorRed,
-			r,
-			colors.ColorReset))
-
-		// Retrieve the stack trace
-		stack := make([]byte, 4096)           // Start with a 4KB buffer
-		length := runtime.Stack(stack, false) // Pass 'false' to get only the current goroutine's stack trace
-
-		// Check if the stack trace might be truncated
-		// Note: This should not happen for lower complexity. If the complexity is very high, this may occur.
-		if length == len(stack) {
-			builder.WriteString(fmt.Sprintf(StackPossiblyTruncated))
-		}
+		l.reportPanic(r)
+	}
+}
+
+// reportPanic prints the panic report for a recovered panic value and optionally writes it to a crash report file.
+// It must be called from the deferred function that recovered the panic, so the stack trace is still the panicking one.
+func (l *DebugOrErrorLogger) reportPanic(r any) {
+	var builder strings.Builder
+	combinedStyle := MergeStyles(panicDetected)
+	text := "PD"
+	asciiArt, _ := ToASCIIArt(text, combinedStyle)
+	fmt.Fprintln(stdout, asciiArt)
+	printnewlineASCII(stdout)
+	// Format the message for panic
+	// Include the application name and version in the panic log
+	builder.WriteString(fmt.Sprintf(
+		RecoverGopher,
+		ApplicationName,
+		CurrentVersion,
+		colors.ColorHex95b806,
+		colors.ColorReset,
+		colors.ColorRed,
+		r,
+		colors.ColorReset))
+
+	// Retrieve the stack trace
+	stack := make([]byte, 4096)           // Start with a 4KB buffer
+	length := runtime.Stack(stack, false) // Pass 'false' to get only the current goroutine's stack trace
+
+	// Check if the stack trace might be truncated
+	// Note: This should not happen for lower complexity. If the complexity is very high, this may occur.
+	if length == len(stack) {
+		builder.WriteString(fmt.Sprintf(StackPossiblyTruncated))
+	}
 
-		builder.WriteString(fmt.Sprintf(StackTracePanic,
-			colors.ColorHex95b806,
-			colors.ColorReset,
-			stack[:length]))
+	// Include the environment, so the report can be attached to an issue as is.
+	builder.WriteString(fmt.Sprintf(EnvironmentPanic,
+		colors.ColorHex95b806,
+		colors.ColorReset,
+		formatDiagnostics(environmentDiagnostics())))
 
-		// Output the message to the logger
-		l.PrintTypingChat(builder.String(), TypingDelay)
+	builder.WriteString(fmt.Sprintf(StackTracePanic,
+		colors.ColorHex95b806,
+		colors.ColorReset,
+		stack[:length]))
+
+	// Output the message to the logger
+	l.PrintTypingChat(builder.String(), TypingDelay)
+
+	// Optionally keep a copy of the report that can be attached to an issue.
+	if crashReportsEnabled() {
+		if path, err := writeCrashReport(builder.String(), time.Now()); err != nil {
+			fmt.Fprintf(stdout, ErrorFailedToWriteCrashReport, err)
+		} else {
+			fmt.Fprintf(stdout, CrashReportWritten, path)
+		}
 	}
 }
 
@@ -208,7 +230,7 @@ func (l *DebugOrErrorLogger) Info(format string, v ...interface{}) {
 	l.PrintTypingChat(builder.String(), TypingDelay)
 
 	// Print a newline after the message
-	printnewlineASCII()
+	printnewlineASCII(stdout)
 }
 
 // Any logs a general message without any colorization. It behaves like Println and allows for formatted messages.
@@ -228,5 +250,5 @@ func (l *DebugOrErrorLogger) Any(format string, v ...interface{}) {
 	l.PrintTypingChat(builder.String(), TypingDelay)
 
 	// Print a newline after the message
-	printnewlineASCII() // this a modern now instead of fmt hahaha
+	printnewlineASCII(stdout) // this a modern now instead of fmt hahaha
 }
@@ -0,0 +1,129 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: Diagnostics describe the environment without exposing user data: the config summary only reports
+// whether sensitive settings (e.g., the API key or the session name) are set, never their values,
+// and crash reports are passed through the secrets redaction profile before they are written.
+
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// environmentDiagnostics returns the environment details included in panic output and crash reports.
+func environmentDiagnostics() []DiagnosticField {
+	return []DiagnosticField{
+		{Name: DiagnosticVersion, Value: CurrentVersion},
+		{Name: DiagnosticPlatform, Value: runtime.GOOS + "/" + runtime.GOARCH},
+		{Name: DiagnosticGoVersion, Value: runtime.Version()},
+		{Name: DiagnosticTerminal, Value: terminalType()},
+		{Name: DiagnosticModel, Value: activeModelForDiagnostics()},
+		{Name: DiagnosticConfig, Value: configSummary()},
+	}
+}
+
+// formatDiagnostics formats the diagnostic fields, one per line.
+func formatDiagnostics(fields []DiagnosticField) string {
+	var builder strings.Builder
+	for _, field := range fields {
+		fmt.Fprintf(&builder, DiagnosticFieldFormat, field.Name, field.Value)
+	}
+	return builder.String()
+}
+
+// terminalType describes the terminal from the TERM and COLORTERM environment variables.
+func terminalType() string {
+	term := os.Getenv(TermEnv)
+	if term == "" {
+		term = DiagnosticUnknown
+	}
+	if colorTerm := os.Getenv(ColorTermEnv); colorTerm != "" {
+		term += fmt.Sprintf(DiagnosticColorTermFormat, colorTerm)
+	}
+	return term
+}
+
+// activeModelForDiagnostics returns the model of the running session, if any.
+func activeModelForDiagnostics() string {
+	session := activeSession.Load()
+	if session == nil {
+		return DiagnosticUnknown
+	}
+	if session.CurrentModelName != "" {
+		return session.CurrentModelName
+	}
+	return session.DefaultModelName
+}
+
+// configSummary summarizes the application config. Sensitive values are reported as set or not set only.
+func configSummary() string {
+	if appConfig == nil {
+		return DiagnosticUnknown
+	}
+	settings := []string{
+		fmt.Sprintf(DiagnosticSettingFormat, DiagnosticAPIKey, formatIsSet(appConfig.APIKey != "" || os.Getenv(APIKey) != "")),
+		fmt.Sprintf(DiagnosticSettingFormat, DiagnosticSessionName, formatIsSet(appConfig.SessionName != "")),
+		fmt.Sprintf(DiagnosticSettingFormat, DiagnosticSafetyLevel, valueOrNotSet(appConfig.SafetyLevel)),
+		fmt.Sprintf(DiagnosticSettingFormat, DiagnosticTheme, valueOrNotSet(appConfig.Theme)),
+		fmt.Sprintf(DiagnosticSettingFormat, DiagnosticAuditLog, strconv.FormatBool(appConfig.AuditLog)),
+		fmt.Sprintf(DiagnosticSettingFormat, DiagnosticCrashReports, strconv.FormatBool(appConfig.CrashReports)),
+		fmt.Sprintf(DiagnosticSettingFormat, DiagnosticTelemetry, strconv.FormatBool(appConfig.Telemetry)),
+		fmt.Sprintf(DiagnosticSettingFormat, DiagnosticRedactionProfiles, strings.Join(appConfig.RedactionProfiles, commaString)),
+		fmt.Sprintf(DiagnosticSettingFormat, DiagnosticFileExtensions, strings.Join(appConfig.AllowedFileExtensions, commaString)),
+		fmt.Sprintf(DiagnosticSettingFormat, DebugMode, strconv.FormatBool(os.Getenv(DebugMode) == "true")),
+		fmt.Sprintf(DiagnosticSettingFormat, EnableOutboundFilter, strconv.FormatBool(os.Getenv(EnableOutboundFilter) == "true")),
+	}
+	return strings.Join(settings, dotStringComma)
+}
+
+// formatIsSet returns the label used in place of a sensitive value.
+func formatIsSet(set bool) string {
+	if set {
+		return DiagnosticSet
+	}
+	return DiagnosticNotSet
+}
+
+// valueOrNotSet returns the value, or the not set label if it is empty.
+func valueOrNotSet(value string) string {
+	if value == "" {
+		return DiagnosticNotSet
+	}
+	return value
+}
+
+// crashReportsEnabled reports whether crash report files should be written.
+func crashReportsEnabled() bool {
+	return appConfig != nil && appConfig.CrashReports
+}
+
+// writeCrashReport writes the report, without ANSI colors and with secrets masked, to a timestamped file
+// in the "crashes" directory next to the config file. It returns the path of the written file.
+func writeCrashReport(report string, at time.Time) (string, error) {
+	configPath, err := AppConfigPath()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(filepath.Dir(configPath), CrashReportsDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	if ansiRegex != nil {
+		report = ansiRegex.ReplaceAllString(report, "")
+	}
+	if filter, err := NewRedactionFilter(RedactionProfileSecrets); err == nil {
+		report, _, _ = filter.Apply(report)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf(CrashReportFileName, at.Format(TranscriptTimeFormat)))
+	return path, os.WriteFile(path, []byte(report), 0600)
+}
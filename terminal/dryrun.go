@@ -0,0 +1,79 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: In dry-run mode every request that would reach the model is printed instead of being sent,
+// using the same model configuration the real request would use. The chat history is restored after each input,
+// so a dry run leaves no trace in the conversation.
+
+package terminal
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+// chatHistorySnapshot holds a copy of the chat history state, used to undo the changes made during a dry run.
+type chatHistorySnapshot struct {
+	messages           []string
+	hashes             map[string]int
+	userMessageCount   int
+	aiMessageCount     int
+	systemMessageCount int
+}
+
+// snapshot returns a copy of the current chat history state.
+func (h *ChatHistory) snapshot() chatHistorySnapshot {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return chatHistorySnapshot{
+		messages:           slices.Clone(h.Messages),
+		hashes:             maps.Clone(h.Hashes),
+		userMessageCount:   h.UserMessageCount,
+		aiMessageCount:     h.AIMessageCount,
+		systemMessageCount: h.SystemMessageCount,
+	}
+}
+
+// restore replaces the chat history state with the snapshot.
+func (h *ChatHistory) restore(snapshot chatHistorySnapshot) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.Messages = snapshot.messages
+	h.Hashes = snapshot.hashes
+	if h.Hashes == nil {
+		h.reindexHashes() // A snapshot without hashes (e.g., a stored session) is indexed again.
+	}
+	h.UserMessageCount = snapshot.userMessageCount
+	h.AIMessageCount = snapshot.aiMessageCount
+	h.SystemMessageCount = snapshot.systemMessageCount
+}
+
+// previewRequest prints what would be sent to the model: the model name, an offline token estimate,
+// the safety and generation settings, and the full content. Long content is paged.
+func (s *Session) previewRequest(model *genai.GenerativeModel, content string) {
+	var safety []string
+	for _, setting := range model.SafetySettings {
+		safety = append(safety, fmt.Sprintf(DryRunSafetySetting, setting.Category, setting.Threshold))
+	}
+
+	logger.Any(DryRunHeader, EstimateTokens(content), strings.Join(safety, StringNewLine))
+	DisplayGenerationConfig(s.activeModelName(), &model.GenerationConfig)
+	if model.SystemInstruction != nil {
+		logger.Any(InfoPersona, s.expandedPersona())
+	}
+	fmt.Fprintln(s.Output, DryRunContentHeader)
+	s.printPaged(content, DefaultPageSize)
+}
+
+// formatOnOff returns the label for the state of a mode, such as dry-run or quiet mode.
+func formatOnOff(enabled bool) string {
+	if enabled {
+		return On
+	}
+	return Off
+}
@@ -0,0 +1,37 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: Each session reads its own input and writes its own output (see SetIO), so a program may embed
+// several of them. The command registry and the messages of the logger are shared by all sessions; the
+// logger writes to the terminal output.
+
+package terminal
+
+import "io"
+
+// SetIO replaces the terminal as the input and output of the session, e.g., to drive it from another
+// Go program or from a test. Lines of user input are read from input, and everything the session prints
+// for the user is written to output. It must be called before the session is started, since the input
+// is read from then on.
+//
+// Parameters:
+//
+//	input io.Reader: The reader of the user input, read line by line.
+//	output io.Writer: The writer that receives the output of the session.
+func (s *Session) SetIO(input io.Reader, output io.Writer) {
+	s.Input = NewPromptQueue(input, output)
+	s.Output = output
+}
+
+// DefaultCommandRegistry returns the registry of the built-in commands, which handles the commands
+// of every session. Other programs may register their own commands in it.
+func DefaultCommandRegistry() *CommandRegistry {
+	return registry
+}
+
+// RenderResponse returns the text of an AI response as it is shown in the terminal, with its code blocks,
+// diffs, math and emphasis colorized. The typing effect is not applied.
+func RenderResponse(text string) string {
+	return renderAIContent(text)
+}
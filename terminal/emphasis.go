@@ -0,0 +1,187 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+
+package terminal
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// renderEmphasis renders *italic*, **bold** and ***bold italic*** Markdown text with ANSI codes,
+// including emphasis nested in other emphasis. Asterisks that do not open or close emphasis,
+// such as list bullets or "2 * 3", are left as they are.
+//
+// Fenced code blocks and inline code spans are never touched, so the colorization applied
+// afterwards still sees their delimiters. Emphasis does not span lines.
+//
+// Note: Delimiters are matched with the flanking rules of CommonMark, see
+// https://spec.commonmark.org/0.31.2/#emphasis-and-strong-emphasis
+func renderEmphasis(text string) string {
+	return mapProseLines(text, renderEmphasisLine)
+}
+
+// mapProseLines applies render to each line of text outside fenced code blocks.
+// The lines that open or close a code block are left as they are too.
+func mapProseLines(text string, render func(line string) string) string {
+	lines := strings.Split(text, StringNewLine)
+	inCodeBlock := false
+	for i, line := range lines {
+		if strings.Count(line, TripleBacktick)%2 == 1 {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if !inCodeBlock {
+			lines[i] = render(line)
+		}
+	}
+	return strings.Join(lines, StringNewLine)
+}
+
+// renderEmphasisLine renders the emphasis of a single line.
+func renderEmphasisLine(line string) string {
+	runs := scanEmphasisRuns(line)
+	if len(runs) == 0 {
+		return line
+	}
+	matchEmphasisRuns(runs)
+
+	var result strings.Builder
+	result.Grow(len(line) * 2) // Preallocate with an estimated size
+	last, depth := 0, 0
+	for _, run := range runs {
+		result.WriteString(line[last:run.start])
+		for _, n := range run.closes {
+			result.WriteString(emphasisCode(n, false))
+			if depth--; depth == 0 {
+				result.WriteString(ColorReset)
+			}
+		}
+		result.WriteString(strings.Repeat(SingleAsterisk, run.remaining))
+		// Openers were matched innermost first, so open the outermost first.
+		for i := len(run.opens) - 1; i >= 0; i-- {
+			result.WriteString(emphasisCode(run.opens[i], true))
+			depth++
+		}
+		last = run.end
+	}
+	result.WriteString(line[last:])
+	return result.String()
+}
+
+// scanEmphasisRuns finds the runs of asterisks in line, skipping inline code spans.
+func scanEmphasisRuns(line string) []emphasisRun {
+	var runs []emphasisRun
+	for i := 0; i < len(line); {
+		switch line[i] {
+		case SingleBacktick[0]:
+			i = skipCodeSpan(line, i)
+		case SingleAsterisk[0]:
+			end := i
+			for end < len(line) && line[end] == SingleAsterisk[0] {
+				end++
+			}
+			before, after := ' ', ' ' // The start and end of a line count as whitespace.
+			if i > 0 {
+				before, _ = utf8.DecodeLastRuneInString(line[:i])
+			}
+			if end < len(line) {
+				after, _ = utf8.DecodeRuneInString(line[end:])
+			}
+			runs = append(runs, emphasisRun{
+				start:     i,
+				end:       end,
+				remaining: end - i,
+				canOpen:   isLeftFlanking(before, after),
+				canClose:  isLeftFlanking(after, before),
+			})
+			i = end
+		default:
+			i++
+		}
+	}
+	return runs
+}
+
+// skipCodeSpan returns the offset just past the code span starting at i.
+// If the backticks are never closed, only the backticks themselves are skipped.
+func skipCodeSpan(line string, i int) int {
+	end := i
+	for end < len(line) && line[end] == SingleBacktick[0] {
+		end++
+	}
+	fence := line[i:end]
+	for j := end; j < len(line); {
+		k := strings.Index(line[j:], fence)
+		if k < 0 {
+			break
+		}
+		start := j + k
+		j = start + len(fence)
+		if j < len(line) && line[j] == SingleBacktick[0] {
+			// A longer run of backticks does not close the span.
+			for j < len(line) && line[j] == SingleBacktick[0] {
+				j++
+			}
+			continue
+		}
+		return j
+	}
+	return end
+}
+
+// isLeftFlanking reports whether a delimiter run between before and after is left-flanking.
+// Swapping the arguments reports whether it is right-flanking.
+func isLeftFlanking(before, after rune) bool {
+	if unicode.IsSpace(after) {
+		return false
+	}
+	return !isPunctuation(after) || unicode.IsSpace(before) || isPunctuation(before)
+}
+
+// isPunctuation reports whether r is punctuation as defined by CommonMark.
+func isPunctuation(r rune) bool {
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+// matchEmphasisRuns pairs closing runs with the nearest opening runs before them.
+// Two asterisks are used for bold text when both runs have them, otherwise one for italic text.
+func matchEmphasisRuns(runs []emphasisRun) {
+	var openers []int
+	for i := range runs {
+		closer := &runs[i]
+		for closer.canClose && closer.remaining > 0 && len(openers) > 0 {
+			opener := &runs[openers[len(openers)-1]]
+			n := 1
+			if opener.remaining >= 2 && closer.remaining >= 2 {
+				n = 2
+			}
+			opener.opens = append(opener.opens, n)
+			opener.remaining -= n
+			closer.closes = append(closer.closes, n)
+			closer.remaining -= n
+			if opener.remaining == 0 {
+				openers = openers[:len(openers)-1]
+			}
+		}
+		if closer.canOpen && closer.remaining > 0 {
+			openers = append(openers, i)
+		}
+	}
+}
+
+// emphasisCode returns the ANSI codes that open or close emphasis made of n asterisks.
+func emphasisCode(n int, open bool) string {
+	switch {
+	case n == 2 && open:
+		return BoldText + colors.ColorHex95b806
+	case n == 2:
+		return ResetBoldText
+	case open:
+		return colors.ColorHex95b806 + ItalicText
+	default:
+		return ResetItalicText
+	}
+}
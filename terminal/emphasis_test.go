@@ -0,0 +1,41 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+
+package terminal
+
+import "testing"
+
+func TestRenderEmphasis(t *testing.T) {
+	italic, endItalic := colors.ColorHex95b806+ItalicText, ResetItalicText
+	bold, endBold := BoldText+colors.ColorHex95b806, ResetBoldText
+
+	tests := []struct {
+		name, in, want string
+	}{
+		{"italic", "*italic*", italic + "italic" + endItalic + ColorReset},
+		{"bold", "**bold**", bold + "bold" + endBold + ColorReset},
+		{"bold italic", "***both***", italic + bold + "both" + endBold + endItalic + ColorReset},
+		{"in a sentence", "an *italic* word", "an " + italic + "italic" + endItalic + ColorReset + " word"},
+		{"arithmetic", "2 * 3 * 4", "2 * 3 * 4"},
+		{"arithmetic without spaces", "2*3", "2*3"},
+		{"bullet", "* bullet", "* bullet"},
+		{"nested bullet", "- * bullet", "- * bullet"},
+		{"snake case", "snake_case and __init__", "snake_case and __init__"},
+		{"code span", "`*not italic*`", "`*not italic*`"},
+		{"code span before emphasis", "`a*b` and *c*", "`a*b` and " + italic + "c" + endItalic + ColorReset},
+		{"double backtick code span", "``a ` *b*``", "``a ` *b*``"},
+		{"nested", "**a *b* c**", bold + "a " + italic + "b" + endItalic + " c" + endBold + ColorReset},
+		{"unclosed", "*open", "*open"},
+		{"across lines", "*one\ntwo*", "*one\ntwo*"},
+		{"code block", "```\n*code*\n```", "```\n*code*\n```"},
+		{"after code block", "```go\nx := 2 * y\n```\n*done*", "```go\nx := 2 * y\n```\n" + italic + "done" + endItalic + ColorReset},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderEmphasis(tt.in); got != tt.want {
+				t.Errorf("renderEmphasis(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: The clipboard is read with the tool of the platform through runTool, so no clipboard library is needed.
+// The fix is rendered like any other response, so its diff is colorized by renderDiffBlocks and can be applied
+// with ":applydiff".
+
+package terminal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// clipboardTools returns the commands that print the clipboard on this platform, in order of preference.
+// The Wayland tool is only tried in a Wayland session.
+func clipboardTools() [][]string {
+	switch runtime.GOOS {
+	case "darwin":
+		return [][]string{{"pbpaste"}}
+	case "windows":
+		return [][]string{{"powershell", "-NoProfile", "-Command", "Get-Clipboard -Raw"}}
+	}
+	var tools [][]string
+	if os.Getenv(WaylandDisplayEnv) != "" {
+		tools = append(tools, []string{"wl-paste", "--no-newline"})
+	}
+	return append(tools,
+		[]string{"xclip", "-selection", "clipboard", "-out"},
+		[]string{"xsel", "--clipboard", "--output"},
+	)
+}
+
+// readClipboard returns the text in the clipboard, read with the first clipboard tool found in PATH.
+func readClipboard(ctx context.Context) (string, error) {
+	tools := clipboardTools()
+	names := make([]string, 0, len(tools))
+	for _, tool := range tools {
+		if _, err := exec.LookPath(tool[0]); err != nil {
+			names = append(names, tool[0])
+			continue
+		}
+		return runTool(ctx, tool[0], tool[1:], FixMaxBytes)
+	}
+	return "", fmt.Errorf(ErrorNoClipboardTool, strings.Join(names, dotStringComma))
+}
+
+// readPastedLines reads the lines typed or pasted by the user up to a line that is only the marker, like a heredoc.
+func (s *Session) readPastedLines(marker string) (string, error) {
+	logger.Any(InfoPasteUntilMarker, marker)
+	var lines []string
+	for {
+		line, err := s.Input.ReadLine()
+		if err != nil {
+			return "", err
+		}
+		if strings.TrimSpace(line) == marker {
+			return strings.Join(lines, StringNewLine), nil
+		}
+		lines = append(lines, strings.TrimRight(line, "\r"))
+	}
+}
+
+// triageError asks the AI to diagnose the error read from source (e.g., "the clipboard") and to give the fix as a
+// unified diff. The error is inspected by the outbound filter first, and cut at FixMaxBytes.
+// The command and the answer are added to the chat history.
+func (s *Session) triageError(command, source, errorText string) (bool, error) {
+	errorText, ok := s.filterOutbound(errorText)
+	if !ok {
+		return false, nil
+	}
+	if strings.TrimSpace(errorText) == "" {
+		logger.Any(InfoNothingToFix, source)
+		return false, nil
+	}
+	errorText = truncateOutput([]byte(errorText), FixMaxBytes)
+	return executeCommand(s, command, func(string) string {
+		return fmt.Sprintf(FixPrompt, errorText)
+	})
+}
@@ -5,14 +5,21 @@
 package terminal
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"sort"
+	"strings"
+	"time"
+
+	genai "github.com/google/generative-ai-go/genai"
 )
 
 // checkVersionAndGetPrompt checks if the current version of the software is the latest and informs the user accordingly.
-func (c *handleCheckVersionCommand) checkVersionAndGetPrompt() (aiPrompt string, err error) {
+func (c *handleCheckVersionCommand) checkVersionAndGetPrompt(ctx context.Context) (aiPrompt string, err error) {
 	// Check if the current version is the latest.
-	isLatest, latestVersion, err := checkLatestVersionWithBackoff()
+	isLatest, latestVersion, err := checkLatestVersionWithBackoff(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -21,7 +28,7 @@ func (c *handleCheckVersionCommand) checkVersionAndGetPrompt() (aiPrompt string,
 		aiPrompt = fmt.Sprintf(YouAreusingLatest, VersionCommand, ApplicationName, CurrentVersion)
 	} else {
 		// Fetch and format the release information for the latest version.
-		aiPrompt, err = fetchAndFormatReleaseInfo(latestVersion)
+		aiPrompt, err = fetchAndFormatReleaseInfo(ctx, latestVersion)
 		if err != nil {
 			return "", err
 		}
@@ -45,7 +52,7 @@ func (cmd *handleClearCommand) clearChatHistory(session *Session) (bool, error)
 	// Print the message(s) with timestamp and typing effect
 	logger.Any(clearMessage) // simplify
 	// Added back the context prompt after clearing the chat history
-	session.ChatHistory.AddMessage(AiNerd, ContextPrompt, session.ChatConfig)
+	session.ChatHistory.AddMessage(AiNerd, promptTemplate(PromptTemplateContext), session.ChatConfig)
 	return false, nil // Continue the session
 }
 
@@ -53,7 +60,7 @@ func (cmd *handleClearCommand) clearChatHistory(session *Session) (bool, error)
 func (cmd *handleClearCommand) clearSummarizeHistory(session *Session) (bool, error) {
 	session.ChatHistory.ClearAllSystemMessages()
 	logger.Any(ChatSysSummaryMessages) // simplify
-	session.ChatHistory.AddMessage(AiNerd, ContextPrompt, session.ChatConfig)
+	session.ChatHistory.AddMessage(AiNerd, promptTemplate(PromptTemplateContext), session.ChatConfig)
 	return false, nil // Continue the session
 }
 
@@ -73,3 +80,134 @@ func (cmd *handleStatsCommand) showChatStats(session *Session) (bool, error) {
 
 	return false, nil // Continue the session without error.
 }
+
+// listAllModels retrieves every model available to the API key and prints a comparison table
+// using the pager, since the list is usually longer than a single screen.
+func (cmd *handleCheckModelCommand) listAllModels(session *Session) (bool, error) {
+	var models []*genai.ModelInfo
+	operation := RetryableOperation{
+		retryFunc: func() (bool, error) {
+			var err error
+			models, err = fetchAllModelInfo(session.Ctx, session.Client)
+			return err == nil, err
+		},
+	}
+
+	if _, err := operation.retryWithExponentialBackoff(standardAPIErrorHandler); err != nil {
+		return false, fmt.Errorf(ErrorFailedToRetriveModelInfo, err)
+	}
+
+	fprintPrefixWithTimeStamp(session.Output, SYSTEMPREFIX, "")
+	fmt.Fprintf(session.Output, ListModelsHeader, len(models))
+	session.printPaged(formatModelTable(models), DefaultPageSize)
+	return false, nil
+}
+
+// replayMessage renders a single chat history message to w the same way it was shown originally.
+func replayMessage(w io.Writer, message string) {
+	message = strings.TrimSpace(message)
+	switch {
+	case strings.HasPrefix(message, AiNerd):
+		content := strings.TrimSpace(strings.TrimPrefix(message, AiNerd))
+		printAIResponse(w, renderAIContent(content), false)
+		printImagePreviews(w, content)
+	case strings.HasPrefix(message, SYSTEMPREFIX):
+		printAIResponse(w, renderAIContent(strings.TrimSpace(strings.TrimPrefix(message, SYSTEMPREFIX))), true)
+	default:
+		fprintPrefixWithTimeStamp(w, YouNerd, "")
+		newTypingPrinter(w).Print(strings.TrimSpace(strings.TrimPrefix(message, YouNerd)), TypingDelay)
+	}
+	fmt.Fprintln(w)
+}
+
+// cancelSchedule handles ":schedule :cancel <id>".
+func (cmd *handleScheduleCommand) cancelSchedule(session *Session, parts []string) (bool, error) {
+	if len(parts) != 3 {
+		return false, invalidArgsError(ScheduleCommand, parts)
+	}
+
+	id, err := parseScheduleID(parts[2])
+	if err != nil {
+		return false, fmt.Errorf(ErrorFailedToSchedulePrompt, err)
+	}
+	if !session.Worker.RemoveSchedule(id) {
+		return false, fmt.Errorf(ErrorScheduleNotFound, id)
+	}
+
+	logger.Any(InfoScheduleCancelled, id)
+	return false, nil
+}
+
+// listSchedules prints the scheduled prompts.
+func listSchedules(schedules []ScheduledPrompt) {
+	if len(schedules) == 0 {
+		logger.Any(InfoScheduleEmpty)
+		return
+	}
+
+	var builder strings.Builder
+	builder.WriteString(InfoScheduleListHeader)
+	for _, scheduled := range schedules {
+		var output string
+		if scheduled.OutputFile != "" {
+			output = fmt.Sprintf(InfoScheduleOutputFile, scheduled.OutputFile)
+		}
+		fmt.Fprintf(&builder, InfoScheduleListItem, scheduled.ID, scheduled.Spec, scheduled.Next.Format(time.RFC1123), scheduled.Prompt, output)
+	}
+	logger.Any(strings.TrimSuffix(builder.String(), StringNewLine))
+}
+
+// readFanoutPrompts reads and parses the prompt file of ":fanout", passing every prompt through the outbound filter.
+// It returns nil prompts without an error if the filter blocked one of them.
+func readFanoutPrompts(session *Session, filePath string) ([]string, error) {
+	if err := verifyTextFile(filePath); err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf(ErrorFailedToReadFile, filePath, err)
+	}
+
+	prompts := ParseFanoutPrompts(string(content))
+	if len(prompts) == 0 {
+		return nil, fmt.Errorf(ErrorFanoutNoPrompts, filePath)
+	}
+	for i, prompt := range prompts {
+		filtered, ok := session.filterOutbound(prompt)
+		if !ok {
+			return nil, nil
+		}
+		prompts[i] = filtered
+	}
+	return prompts, nil
+}
+
+// searchHistory returns the chat history messages containing the query, ignoring case,
+// each prefixed with its position in the history.
+func searchHistory(history *ChatHistory, query string) []string {
+	query = strings.ToLower(query)
+	var matches []string
+	for i, message := range history.snapshot().messages {
+		if strings.Contains(strings.ToLower(message), query) {
+			matches = append(matches, fmt.Sprintf(SearchResultFormat, i+1, strings.TrimSpace(message)))
+		}
+	}
+	return matches
+}
+
+// viewerCommandList returns the sorted, comma-separated list of the commands available in viewer mode.
+func viewerCommandList() string {
+	commands := make([]string, 0, len(viewerCommands))
+	for command := range viewerCommands {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+	return strings.Join(commands, dotStringComma)
+}
+
+// printIssueReport prints the bug report for review, followed by the link to the pre-filled issue.
+func (s *Session) printIssueReport(includeCrash bool) {
+	report := BuildIssueReport(includeCrash)
+	s.printPaged(report, DefaultPageSize)
+	logger.Any(InfoReportIssueURL, IssueURL(report))
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: Fan-out prompts are independent of each other and of the chat history, so they can run concurrently.
+// The number of goroutines talking to the API at the same time is bounded by FanoutMaxWorkers to protect the quota.
+
+package terminal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+// ParseFanoutPrompts splits the content of a prompt file into prompts.
+// Prompts are separated by lines containing only "---"; without separators, each non-empty line is a prompt.
+func ParseFanoutPrompts(content string) []string {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", StringNewLine), StringNewLine)
+
+	hasSeparator := false
+	for _, line := range lines {
+		if strings.TrimSpace(line) == FanoutPromptSeparator {
+			hasSeparator = true
+			break
+		}
+	}
+
+	var prompts []string
+	if !hasSeparator {
+		for _, line := range lines {
+			if line = strings.TrimSpace(line); line != "" {
+				prompts = append(prompts, line)
+			}
+		}
+		return prompts
+	}
+
+	var block []string
+	flush := func() {
+		if prompt := strings.TrimSpace(strings.Join(block, StringNewLine)); prompt != "" {
+			prompts = append(prompts, prompt)
+		}
+		block = block[:0]
+	}
+	for _, line := range lines {
+		if strings.TrimSpace(line) == FanoutPromptSeparator {
+			flush()
+			continue
+		}
+		block = append(block, line)
+	}
+	flush()
+	return prompts
+}
+
+// runFanout sends the prompts to the model concurrently, with at most FanoutMaxWorkers requests in flight.
+// The results keep the order of the prompts. A failed prompt does not stop the others; its error is stored in its result.
+func (s *Session) runFanout(ctx context.Context, prompts []string) []FanoutResult {
+	model := s.ConfigureModelForSession(ctx)
+	results := make([]FanoutResult, len(prompts))
+
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, FanoutMaxWorkers)
+	for i, prompt := range prompts {
+		results[i].Prompt = prompt
+		wg.Add(1) // Increment the WaitGroup counter for each goroutine.
+		go func(index int, prompt string) {
+			defer wg.Done() // Decrement the counter when the goroutine completes.
+
+			// Wait for a free worker slot, unless the user cancels the fan-out.
+			select {
+			case workers <- struct{}{}:
+				defer func() { <-workers }()
+			case <-ctx.Done():
+				results[index].Err = ctx.Err()
+				return
+			}
+
+			results[index].Response, results[index].Err = sendFanoutPrompt(ctx, model, prompt)
+		}(i, prompt)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// sendFanoutPrompt sends a single prompt with the retry policy and returns the response text, without escape sequences.
+func sendFanoutPrompt(ctx context.Context, model *genai.GenerativeModel, prompt string) (string, error) {
+	var response strings.Builder
+	operation := RetryableOperation{
+		retryFunc: func() (bool, error) {
+			resp, err := model.GenerateContent(ctx, genai.Text(prompt))
+			if err != nil {
+				return false, err
+			}
+			response.Reset()
+			for _, cand := range resp.Candidates {
+				if cand.Content == nil {
+					continue
+				}
+				for _, part := range cand.Content.Parts {
+					fmt.Fprint(&response, part)
+				}
+			}
+			return true, nil
+		},
+	}
+	if _, err := operation.retryWithExponentialBackoff(standardAPIErrorHandler); err != nil {
+		return "", err
+	}
+	// The response may be printed later (e.g., a remembered fact), so it never carries escape sequences.
+	return stripControlSequences(response.String()), nil
+}
+
+// BuildFanoutMarkdown renders the fan-out results as a Markdown document.
+func BuildFanoutMarkdown(results []FanoutResult, modelName string, finishedAt time.Time) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, FanoutMarkdownHeader, len(results), modelName, finishedAt.Format(time.RFC1123))
+	for i, result := range results {
+		response := result.Response
+		if result.Err != nil {
+			response = fmt.Sprintf(FanoutMarkdownError, result.Err)
+		}
+		// Quote every line of multi-line prompts.
+		prompt := strings.ReplaceAll(result.Prompt, StringNewLine, StringNewLine+MarkdownQuote)
+		fmt.Fprintf(&builder, FanoutMarkdownResult, i+1, prompt, response)
+	}
+	return builder.String()
+}
+
+// writeFanoutOutput writes the fan-out results to the output file. An empty path uses a timestamped file name
+// in the current directory. It returns the path of the written file.
+func writeFanoutOutput(outputFile string, results []FanoutResult, modelName string, finishedAt time.Time) (string, error) {
+	if outputFile == "" {
+		outputFile = fmt.Sprintf(FanoutFileName, finishedAt.Format(TranscriptTimeFormat))
+	}
+	if err := os.WriteFile(outputFile, []byte(BuildFanoutMarkdown(results, modelName, finishedAt)), 0600); err != nil {
+		return "", err
+	}
+	return outputFile, nil
+}
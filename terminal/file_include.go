@@ -0,0 +1,119 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: Only the prompt that is sent contains the included files and pages. The chat history keeps the input as it
+// was typed, with the short references, so a large file does not crowd out the rest of the conversation; the AI sees
+// its content only in the response to that prompt.
+
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// includeReferences replaces each "@file:path" in the input with the path and content of the file, and each
+// "@url:https://..." with the URL and text of the page (see fetchIncludedPage).
+// The content is inspected by the outbound filter, and its estimated tokens are checked against the input token
+// limit of the model, if it is known.
+//
+// Returns:
+//
+//	string: The prompt to send, with the content of the files and pages.
+//	bool: Whether the prompt can be sent; false if a reference cannot be included or the content is too large.
+func (s *Session) includeReferences(input string) (string, bool) {
+	if !strings.Contains(input, FileIncludePrefix) && !strings.Contains(input, URLIncludePrefix) {
+		return input, true
+	}
+	var result strings.Builder
+	last, tokens := 0, 0
+	var included []func() // Reports the included references, once the prompt can be sent
+	for _, field := range fieldIndexes(input) {
+		start, end := field[0], field[1]
+		name, content, err := s.readReference(input[start:end])
+		if name == "" {
+			continue
+		}
+		if err != nil {
+			logger.Error(ErrorFailedToIncludeFile, name, err)
+			return "", false
+		}
+		content, ok := s.filterOutbound(content)
+		if !ok {
+			return "", false
+		}
+		referenceTokens := EstimateTokens(content)
+		tokens += referenceTokens
+		included = append(included, func() { s.notify(InfoFileIncluded, name, referenceTokens) })
+		result.WriteString(input[last:start])
+		fmt.Fprintf(&result, FileIncludeFormat, name, content)
+		last = end
+	}
+	if last == 0 {
+		return input, true
+	}
+	result.WriteString(input[last:])
+
+	ctx, done := s.beginTimedOperation(TokenCountTimeout)
+	defer done()
+	if limit, err := s.inputTokenLimit(ctx); err == nil && limit > 0 && tokens > limit {
+		logger.Error(ErrorIncludedFilesTooLarge, tokens, limit)
+		return "", false
+	}
+	for _, report := range included {
+		report()
+	}
+	return result.String(), true
+}
+
+// readReference returns the name and content of the file or page that the field refers to.
+// The name is empty if the field is not a reference.
+func (s *Session) readReference(field string) (name, content string, err error) {
+	if path, ok := strings.CutPrefix(field, FileIncludePrefix); ok {
+		content, err = readIncludedFile(path)
+		return path, content, err
+	}
+	if url, ok := strings.CutPrefix(field, URLIncludePrefix); ok {
+		content, err = s.fetchIncludedPage(url)
+		return url, content, err
+	}
+	return "", "", nil
+}
+
+// fieldIndexes returns the start and end offsets of the fields of text, which are separated by whitespace.
+func fieldIndexes(text string) [][2]int {
+	var fields [][2]int
+	start := -1
+	for i, r := range text {
+		switch {
+		case unicode.IsSpace(r) && start >= 0:
+			fields = append(fields, [2]int{start, i})
+			start = -1
+		case !unicode.IsSpace(r) && start < 0:
+			start = i
+		}
+	}
+	if start >= 0 {
+		fields = append(fields, [2]int{start, len(text)})
+	}
+	return fields
+}
+
+// readIncludedFile returns the content of a text file to include in a prompt, without trailing newlines.
+// Only files with an allowed extension can be included (see verifyFileExtension); images cannot.
+func readIncludedFile(path string) (string, error) {
+	if err := verifyFileExtension(path); err != nil {
+		return "", err
+	}
+	if hasImageFileExtension(path) {
+		return "", fmt.Errorf(ErrorIncludedFileIsImage, path)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
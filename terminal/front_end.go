@@ -6,6 +6,7 @@ package terminal
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
@@ -53,11 +54,16 @@ func FilterLanguageFromCodeBlock(text string) string {
 // The prefix parameter is appended to the timestamp and can be a log level, a descriptor,
 // or any other string that aids in categorizing or highlighting the message.
 func PrintPrefixWithTimeStamp(prefix, message string) {
+	fprintPrefixWithTimeStamp(stdout, prefix, message)
+}
+
+// fprintPrefixWithTimeStamp is like PrintPrefixWithTimeStamp, but prints to w (e.g., the output of a session).
+func fprintPrefixWithTimeStamp(w io.Writer, prefix, message string) {
 	currentTime := time.Now().Format(TimeFormat)
 	// Check if the first character is potentially an emoji or wide character.
 	if isFirstCharacterWide(prefix) {
 		// Add an extra space after the prefix to ensure separation in terminals that might not handle wide characters well.
-		fmt.Printf(ObjectHighLevelTripleString, currentTime, prefix, message)
+		fmt.Fprintf(w, ObjectHighLevelTripleString, currentTime, prefix, message)
 	}
 }
 
@@ -73,25 +79,25 @@ func isFirstCharacterWide(s string) bool {
 	return size > 1
 }
 
-// printPromptFeedback formats and prints the prompt feedback received from the AI.
-func printPromptFeedback(feedback *genai.PromptFeedback) {
-	printnewlineASCII() // this better new line instead of "\n" for front end hahaha
+// printPromptFeedback formats and prints the prompt feedback received from the AI to w.
+func printPromptFeedback(w io.Writer, feedback *genai.PromptFeedback) {
+	printnewlineASCII(w) // this better new line instead of "\n" for front end hahaha
 	if feedback == nil {
 		return
 	}
 	// Iterate over safety ratings and print them.
 	for i, rating := range feedback.SafetyRatings {
 		safetyPrefix := ShieldEmoji
-		PrintPrefixWithTimeStamp(safetyPrefix, "")
+		fprintPrefixWithTimeStamp(w, safetyPrefix, "")
 		promptFeedback := fmt.Sprintf(PROMPTFEEDBACK, rating.Category.String(), rating.Probability.String())
 		if i < len(feedback.SafetyRatings)-1 {
 			promptFeedback += StringNewLine
 		}
-		humanTyping := NewTypingPrinter()
+		humanTyping := newTypingPrinter(w)
 		humanTyping.Print(promptFeedback, TypingDelay)
 	}
 	// fix front end lmao
-	printVisualSeparator()
+	printVisualSeparator(w)
 }
 
 // printTokenCount prints the number of tokens used in the AI's response, including the chat history.
@@ -127,19 +133,25 @@ func (s *Session) printTokenCount(apiKey, aiResponse string, chatHistory ...stri
 		params.ModelName = modelName                             // Set the ModelName field
 	}
 
-	tokenCount, err := params.CountTokens() // Adjusted to use the CountTokens function directly
-	printnewlineASCII()                     // a better one, instead of "\n"
+	// Note: Ctrl+C cancels the token count instead of ending the session.
+	ctx, done := s.beginTimedOperation(TokenCountTimeout)
+	tokenCount, err := params.CountTokens(ctx) // Adjusted to use the CountTokens function directly
+	printnewlineASCII(s.Output)                // a better one, instead of "\n"
 	if err != nil {
-		handleTokenCountError(err)
+		if !reportTimeout(ctx, ResponseTokenCountOperation, TokenCountTimeout) {
+			handleTokenCountError(err)
+		}
+		done()
 		return
 	}
+	done()
 	// Print the current token count
-	printCurrentTokenCount(tokenCount)
+	printCurrentTokenCount(s.Output, tokenCount)
 	// Update and print the total token count
-	updateAndPrintTotalTokenCount(tokenCount)
+	updateAndPrintTotalTokenCount(s.Output, tokenCount)
 
 	// Visual separator for clarity in the output
-	printVisualSeparator()
+	printVisualSeparator(s.Output)
 }
 
 // handleTokenCountError handles errors that occur while counting tokens.
@@ -147,36 +159,75 @@ func handleTokenCountError(err error) {
 	logger.Error(ErrorCountingTokens, err)
 }
 
-// printCurrentTokenCount prints the number of tokens used in the AI's response.
-func printCurrentTokenCount(tokenCount int) {
+// printCurrentTokenCount prints the number of tokens used in the AI's response to w.
+func printCurrentTokenCount(w io.Writer, tokenCount int) {
 	tokenPrefix := TokenEmoji
 	tokenMSG := fmt.Sprintf(TokenCount, tokenCount)
-	humanTyping := NewTypingPrinter()
-	PrintPrefixWithTimeStamp(tokenPrefix, "")
+	humanTyping := newTypingPrinter(w)
+	fprintPrefixWithTimeStamp(w, tokenPrefix, "")
 	humanTyping.Print(tokenMSG, TypingDelay)
 }
 
-// updateAndPrintTotalTokenCount updates the total token count for the session and prints it.
-func updateAndPrintTotalTokenCount(tokenCount int) {
+// updateAndPrintTotalTokenCount updates the total token count for the session and prints it to w.
+func updateAndPrintTotalTokenCount(w io.Writer, tokenCount int) {
 	totalTokenCount += tokenCount // Assuming totalTokenCount is a global or package-level variable
 	tokenUsageMSG := fmt.Sprintf(TotalTokenCount, totalTokenCount)
-	humanTyping := NewTypingPrinter()
-	PrintPrefixWithTimeStamp(StatisticsEmoji, "")
+	humanTyping := newTypingPrinter(w)
+	fprintPrefixWithTimeStamp(w, StatisticsEmoji, "")
 	humanTyping.Print(tokenUsageMSG, TypingDelay)
 }
 
-// printVisualSeparator prints a visual separator to the standard output.
-func printVisualSeparator() {
-	text := "V"
-	asciiArt, _ := ToASCIIArt(text, stripStyle)
-	fmt.Println(asciiArt)
+// notify prints a non-essential system message, such as a notice about something done automatically,
+// unless quiet mode is enabled (see ":quiet").
+func (s *Session) notify(format string, v ...interface{}) {
+	if s != nil && s.Quiet {
+		return
+	}
+	logger.Any(format, v...)
 }
 
-// printnewlineASCII prints a newline character as an ASCII art visual separator to the standard output.
-func printnewlineASCII() {
+// printVisualSeparator prints a visual separator spanning the width of the terminal to the standard output.
+func printVisualSeparator(w io.Writer) {
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, BoldText+colors.ColorCyan24Bit+separatorLine()+ColorReset)
+}
+
+// printBanner prints the logo with the version and tips beside it. On terminals too narrow for both,
+// the tips are printed below the logo, and without the logo if it does not fit either.
+func printBanner(w io.Writer) {
+	logo, _ := ToASCIIArt("G", slantStyle)
+	info, _ := ToASCIIArt("V", slantStyle)
+	width := terminalWidth()
+	if width >= visibleWidth(logo)+visibleWidth(info) {
+		banner, _ := ToASCIIArt("GV", slantStyle)
+		fmt.Fprintln(w, banner)
+		return
+	}
+	if width >= visibleWidth(logo) {
+		// Drop the padding below the logo, added for the taller tips.
+		fmt.Fprintln(w, strings.TrimRight(logo, " "+StringNewLine)+StringNewLine)
+	}
+	started := false
+	for _, line := range strings.Split(info, StringNewLine) {
+		// The blank lines that align the tips with the logo are left out, and the others emptied,
+		// as they would wrap on narrow terminals.
+		blank := strings.TrimSpace(ansiRegex.ReplaceAllString(line, "")) == ""
+		if blank && !started {
+			continue
+		}
+		started = true
+		if blank {
+			line = ""
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// printnewlineASCII prints a newline character as an ASCII art visual separator to w.
+func printnewlineASCII(w io.Writer) {
 	text := "N"
 	asciiArt, _ := ToASCIIArt(text, newLine)
-	fmt.Println(asciiArt)
+	fmt.Fprintln(w, asciiArt)
 }
 
 // removeAIPrefix checks for and removes the AI prefix if it's present in the response.
@@ -190,6 +241,26 @@ func removeAIPrefix(content string) string {
 
 // colorizeResponse applies color to the response content.
 func colorizeResponse(content string) string {
+	options := responseColorizationOptions(content)
+	// Bold text is rendered by renderEmphasis beforehand, so any double asterisks left are literal.
+	options.ColorPairs = withoutColorPair(options.ColorPairs, DoubleAsterisk)
+	// Call the Colorize function with the options struct
+	return Colorize(options)
+}
+
+// withoutColorPair returns the color pairs without the pair of the given delimiter.
+func withoutColorPair(colorPairs []string, delimiter string) []string {
+	pairs := make([]string, 0, len(colorPairs))
+	for i := 0; i+1 < len(colorPairs); i += 2 {
+		if colorPairs[i] != delimiter {
+			pairs = append(pairs, colorPairs[i], colorPairs[i+1])
+		}
+	}
+	return pairs
+}
+
+// responseColorizationOptions returns the colorization options used for AI responses.
+func responseColorizationOptions(content string) ColorizationOptions {
 	// Define color pairs and delimiters for colorization
 	colorPairs := []string{
 		TripleBacktick, colors.ColorPurple24Bit,
@@ -206,75 +277,129 @@ func colorizeResponse(content string) string {
 	}
 
 	// Create an instance of ColorizationOptions with the necessary information
-	options := ColorizationOptions{
+	return ColorizationOptions{
 		Text:           content,
 		ColorPairs:     colorPairs,
 		KeepDelimiters: keepDelimiters,
 		Formatting:     formatting,
 	}
-
-	// Call the Colorize function with the options struct
-	return Colorize(options)
 }
 
-// handleSingleAsterisks applies color to text surrounded by single-character delimiters.
+// handleSingleAsterisks applies color to the bullets of list items starting with an asterisk.
 func handleSingleAsterisks(content string) string {
 	return SingleCharColorize(content, SingleAsterisk, colors.ColorCyan24Bit)
 }
 
-// handleSingleMinusSign applies color to text surrounded by single-character delimiters.
+// handleSingleMinusSign applies color to the bullets of list items starting with a minus sign.
 func handleSingleMinusSign(content string) string {
 	return SingleCharColorize(content, SingleMinusSign, colors.ColorCyan24Bit)
 }
 
-// printAIResponse prints the AI's response with a typing effect.
+// renderAIContent prepares raw AI content for display by filtering code block languages and applying colors.
+// It is shared by live responses and ":replay", so both look exactly the same.
+func renderAIContent(content string) string {
+	// Remove the escape sequences of the AI first; the only ones left afterwards are added by the renderer.
+	content = stripControlSequences(content)
+	// Colorize diffs while the language identifier still tells them apart,
+	// then filter out the language identifier from code blocks before any other processing
+	filteredContent := FilterLanguageFromCodeBlock(renderDiffBlocks(content))
+	// Render math, links, italic and bold text first, while code spans can still be told apart
+	colorized := colorizeResponse(ApplyItalic(renderHyperlinks(renderMath(filteredContent))))
+	colorized = handleSingleAsterisks(colorized)
+	return handleSingleMinusSign(colorized)
+}
+
+// printAIResponse prints the AI's response to w with a typing effect.
 //
 // Added a new parameter `isSystemMessage` to distinguish between AI and system messages.
 //
 // Note: By implementing it this way, the function becomes easier to maintain and more reusable.
-func printAIResponse(response string, isSystemMessage bool) {
+func printAIResponse(w io.Writer, response string, isSystemMessage bool) {
 	var prefix string
 	if isSystemMessage {
 		prefix = SYSTEMPREFIX // Use system prefix for system messages
 	} else {
 		prefix = AiNerd // Use AI prefix for AI messages
 	}
-	humanTyping := NewTypingPrinter()
-	PrintPrefixWithTimeStamp(prefix, "")
+	humanTyping := newTypingPrinter(w)
+	fprintPrefixWithTimeStamp(w, prefix, "")
 	humanTyping.Print(response, TypingDelay)
+	// Never leave a color or style of a malformed response (e.g., an unterminated code span) active.
+	fmt.Fprint(w, ColorReset)
 }
 
 // printResponseFooter prints the footer after the AI response and includes prompt feedback and token count if enabled.
 //
 // Note: this functionality are powerful, it won't break a current session of conversation hahaha.
 func (s *Session) printResponseFooter(resp *genai.GenerateContentResponse, aiResponse string) {
+	// Quiet mode hides the whole footer, but keeps the spacing after the response.
+	if s.Quiet {
+		printnewlineASCII(s.Output)
+		return
+	}
+
 	showPromptFeedback := os.Getenv(ShowPromptFeedBack) == "true"
 	showTokenCount := os.Getenv(ShowTokenCount) == "true"
 
 	// Print the footer separator
-	printVisualSeparator()
+	printVisualSeparator(s.Output)
 
 	// Print prompt feedback if enabled
 	if showPromptFeedback && resp.PromptFeedback != nil {
-		printPromptFeedback(resp.PromptFeedback)
+		printPromptFeedback(s.Output, resp.PromptFeedback)
 	}
 
 	// Print token count if enabled
 	if showTokenCount {
-		apiKey := os.Getenv(APIKey) // Retrieve the API_KEY from the environment
+		apiKey := ResolveAPIKey() // Retrieve the API_KEY from the environment or the config file
 		s.printTokenCount(apiKey, aiResponse)
 	}
 
 	// Print the closing footer separator
-	printnewlineASCII() // fix front end issue lmao
+	printnewlineASCII(s.Output) // fix front end issue lmao
 }
 
 // NewTypingPrinter creates a new instance of TypingPrinter with a default print function.
 // This constructor function is idiomatic in Go, providing a way to set up the struct
-// with default values, which in this case is the PrintTypingChat function.
+// with default values, which in this case is the typing effect configured with TYPING_EFFECT
+// (PrintTypingChat unless it is set).
 func NewTypingPrinter() *TypingPrinter {
+	return newTypingPrinter(stdout)
+}
+
+// newTypingPrinter is like NewTypingPrinter, but prints to w (e.g., the output of a session).
+func newTypingPrinter(w io.Writer) *TypingPrinter {
+	effect := ""
+	if appConfig != nil {
+		effect = appConfig.TypingEffect
+	}
+	return newTypingPrinterWithEffect(w, effect)
+}
+
+// NewTypingPrinterWithEffect creates a new instance of TypingPrinter that prints a character
+// (TypingEffectChar), a word (TypingEffectWord) or a line (TypingEffectLine) at a time.
+// An unknown effect falls back to a character at a time.
+func NewTypingPrinterWithEffect(effect string) *TypingPrinter {
+	return newTypingPrinterWithEffect(stdout, effect)
+}
+
+// newTypingPrinterWithEffect is like NewTypingPrinterWithEffect, but prints to w.
+func newTypingPrinterWithEffect(w io.Writer, effect string) *TypingPrinter {
+	printFunc := func(message string, delay time.Duration) {
+		fprintTypingChat(w, message, delay)
+	}
+	switch effect {
+	case TypingEffectWord:
+		printFunc = func(message string, delay time.Duration) {
+			printTypingChunks(w, splitWords(message), delay)
+		}
+	case TypingEffectLine:
+		printFunc = func(message string, delay time.Duration) {
+			printTypingChunks(w, strings.SplitAfter(message, StringNewLine), delay)
+		}
+	}
 	return &TypingPrinter{
-		PrintFunc: PrintTypingChat, // Set the default typing effect function.
+		PrintFunc: printFunc,
 	}
 }
 
@@ -0,0 +1,30 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+
+package terminal
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// FuzzRenderAIContent checks that rendering never panics, and that the only escape sequences in the rendered content
+// are the complete ones added by the renderer: once they are removed, no ESC (0x1b) or CSI (0x9b) is left.
+func FuzzRenderAIContent(f *testing.F) {
+	for _, seed := range controlSequenceSeeds {
+		f.Add(seed)
+	}
+	f.Add("*italic* **bold** ***both*** 2 * 3 * 4\n- * bullet\n`snake_case`")
+	f.Add("```diff\n- old\n+ new\n```\n[link](https://example.com) $x^2$")
+	f.Fuzz(func(t *testing.T, content string) {
+		visible := stripEscapeSequences(renderAIContent(content))
+		if strings.ContainsRune(visible, BinaryAnsiChar) || strings.ContainsRune(visible, '\u009b') {
+			t.Fatalf("renderAIContent(%q) leaves %q after removing its escape sequences", content, visible)
+		}
+		if !utf8.ValidString(visible) {
+			t.Fatalf("renderAIContent(%q) renders %q, which is not valid UTF-8", content, visible)
+		}
+	})
+}
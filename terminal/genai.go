@@ -8,9 +8,10 @@ import (
 	"bufio"
 	"context"
 	"fmt"
-	"os"
+	"io"
 	"strings"
 	"time"
+	"unicode"
 
 	genai "github.com/google/generative-ai-go/genai"
 )
@@ -30,8 +31,13 @@ import (
 // For instance, when a Gopher completes a task or job and transitions to a resting state,
 // this function can print a message with a typing effect to visually represent the Gopher's "sleeping" activities.
 func PrintTypingChat(message string, delay time.Duration) {
+	fprintTypingChat(stdout, message, delay)
+}
+
+// fprintTypingChat is like PrintTypingChat, but prints the message to w (e.g., the output of a session).
+func fprintTypingChat(w io.Writer, message string, delay time.Duration) {
 	// Note: Improve a human typing effect.
-	writer := bufio.NewWriter(os.Stdout) // Create a buffered writer
+	writer := bufio.NewWriter(w) // Create a buffered writer
 
 	for _, char := range message {
 		// Additional Note: This improvement eliminates the use of fmt + animated characters, enhancing smoothness, especially with 100+ messages.
@@ -41,10 +47,55 @@ func PrintTypingChat(message string, delay time.Duration) {
 		time.Sleep(delay)                // Sleep for the desired delay
 	}
 
-	printnewlineASCII()
+	printnewlineASCII(w)
 	writer.Flush() // Make sure to flush any remaining output
 }
 
+// PrintTypingWords is like PrintTypingChat, but prints the message a word at a time,
+// waiting for delay after each word. This keeps the typing effect while long responses
+// are printed many times faster.
+func PrintTypingWords(message string, delay time.Duration) {
+	printTypingChunks(stdout, splitWords(message), delay)
+}
+
+// PrintTypingLines is like PrintTypingChat, but prints the message a line at a time,
+// waiting for delay after each line.
+func PrintTypingLines(message string, delay time.Duration) {
+	printTypingChunks(stdout, strings.SplitAfter(message, StringNewLine), delay)
+}
+
+// printTypingChunks prints the chunks to w one after another, waiting for delay after each of them.
+func printTypingChunks(w io.Writer, chunks []string, delay time.Duration) {
+	writer := bufio.NewWriter(w)
+	for _, chunk := range chunks {
+		if chunk == "" {
+			continue
+		}
+		writer.WriteString(chunk)
+		writer.Flush()
+		time.Sleep(delay)
+	}
+
+	printnewlineASCII(w)
+	writer.Flush()
+}
+
+// splitWords splits text into words, each followed by the whitespace after it.
+// Joining the words gives back the text.
+func splitWords(text string) []string {
+	var words []string
+	start, inSpace := 0, false
+	for i, r := range text {
+		isSpace := unicode.IsSpace(r)
+		if inSpace && !isSpace {
+			words = append(words, text[start:i])
+			start = i
+		}
+		inSpace = isSpace
+	}
+	return append(words, text[start:])
+}
+
 // ConfigureModelForSession prepares and configures a generative AI model for use in a chat session.
 // It applies safety settings from the session to the model and sets additional configuration options
 // such as temperature. This function is essential for ensuring that the AI model behaves according
@@ -65,14 +116,7 @@ func PrintTypingChat(message string, delay time.Duration) {
 // safety settings are applied. The modelName parameter allows for model-specific configuration,
 // enabling more granular control over the behavior and safety of different AI models.
 func (s *Session) ConfigureModelForSession(ctx context.Context) *genai.GenerativeModel {
-	// Use the default model name
-	modelName := s.DefaultModelName
-	if s.CurrentModelName != "" {
-		// Note: This refactoring makes the code easier to maintain and less prone to bugs, compared to stupid complex and convoluted approaches.
-		modelName = s.CurrentModelName // Override with the current model name if set
-		// Log the model change
-		logger.Debug(DebugSwitchingModel, modelName)
-	}
+	modelName := s.activeModelName()
 	// Initialize the model with the specific AI model identifier.
 	model := s.Client.GenerativeModel(modelName)
 
@@ -82,14 +126,38 @@ func (s *Session) ConfigureModelForSession(ctx context.Context) *genai.Generativ
 	}
 	s.SafetySettings.ApplyToModel(model, modelName)
 
-	// Set additional configuration options, such as the temperature, to control the creativity
+	// Apply generation settings, such as the temperature, to control the creativity
 	// and randomness of the AI's responses.
-	tempOption := WithTemperature(0.9)
-	ApplyOptions(model, tempOption)
+	if s.GenerationSettings == nil {
+		s.GenerationSettings = DefaultGenerationSettings()
+	}
+	s.GenerationSettings.ApplyToModel(model)
+
+	// Give the AI the persona of the session, so it behaves consistently across the whole session.
+	s.applySystemInstruction(model, modelName)
+
+	// Let the AI search the web, if the web search tool is enabled.
+	if webSearchToolEnabled() {
+		model.Tools = []*genai.Tool{webSearchTool()}
+	}
 
 	return model
 }
 
+// activeModelName returns the name of the model used by the session.
+// The current model name takes precedence over the default model name if it is set.
+func (s *Session) activeModelName() string {
+	// Use the default model name
+	modelName := s.DefaultModelName
+	if s.CurrentModelName != "" {
+		// Note: This refactoring makes the code easier to maintain and less prone to bugs, compared to stupid complex and convoluted approaches.
+		modelName = s.CurrentModelName // Override with the current model name if set
+		// Log the model change
+		logger.Debug(DebugSwitchingModel, modelName)
+	}
+	return modelName
+}
+
 // SendMessage sends a chat message to the generative AI model and retrieves the response.
 // It constructs a chat session using the provided `genai.Client`, which is used to communicate
 // with the AI service. The function simulates a chat interaction by sending the chat context,
@@ -112,11 +180,28 @@ func (s *Session) ConfigureModelForSession(ctx context.Context) *genai.Generativ
 // specified by the session's ChatConfig, to the generative AI model. It then calls `printResponse` to process
 // and print the AI's response. The final AI response is returned as a concatenated string of all parts from the AI response.
 func (s *Session) SendMessage(ctx context.Context, client *genai.Client, chatContext string) (string, error) {
+	return s.sendExchange(ctx, "", chatContext)
+}
+
+// sendExchange sends the chat context with the relevant chat history, like SendMessage. Once the response
+// has arrived, userMessage (if any) and the response are added to the chat history together, so a request
+// that fails or is retried never leaves a user message without its response behind.
+func (s *Session) sendExchange(ctx context.Context, userMessage, chatContext string) (string, error) {
 	// Get the generative model from the client
 	model := s.ConfigureModelForSession(ctx) // Simplify 🤪
 
-	// Retrieve the relevant chat history using ChatConfig
-	chatHistory := s.ChatHistory.GetHistory(s.ChatConfig)
+	// Retrieve the relevant chat history using ChatConfig, as much of it as fits the input token limit.
+	var chatHistory string
+	// If the start of the chat history is cached, only the rest of it is sent.
+	if cacheName, rest, ok := s.contextCacheFor(ctx); ok {
+		model.CachedContentName = cacheName
+		// Note: The API does not accept tools or a system instruction together with cached content.
+		model.Tools = nil
+		model.SystemInstruction = nil
+		chatHistory = rest
+	} else {
+		chatHistory = s.fitContextHistory(ctx, chatContext)
+	}
 
 	// Form the full context by appending the new message to the chat history
 	fullContext := chatContext
@@ -124,19 +209,82 @@ func (s *Session) SendMessage(ctx context.Context, client *genai.Client, chatCon
 		// Append the new message to the chat history to form the full context
 		fullContext = chatHistory + StringNewLine + chatContext
 	}
+	// The remembered facts come first, independent of the chat history.
+	if memory := s.memoryContext(); memory != "" {
+		fullContext = memory + StringNewLine + fullContext
+	}
+
+	aiResponse, err := s.sendFullContext(ctx, model, fullContext, userMessage)
+	if err != nil && model.CachedContentName != "" {
+		// The cache may have expired or been deleted, so a retry sends the full history.
+		logger.Debug(DebugContextCacheDropped, model.CachedContentName)
+		s.setContextCache(nil)
+	}
+	return aiResponse, err
+}
+
+// sendFullContext sends the full context, after the persona of the session, to the model and prints the response.
+// Only after a successful round trip are userMessage (if any) and the response added to the chat history (see recordExchange).
+// In dry-run mode, the request is only previewed.
+func (s *Session) sendFullContext(ctx context.Context, model *genai.GenerativeModel, fullContext, userMessage string) (string, error) {
+	// The persona comes before everything else, unless the model already has it as its system instruction.
+	if persona := s.personaContext(model); persona != "" {
+		fullContext = persona + StringNewLine + fullContext
+	}
+
+	// In dry-run mode, show the request instead of sending it.
+	if s.DryRun {
+		s.previewRequest(model, fullContext)
+		return "", nil
+	}
 
 	// Start a new chat session with the model
 	cs := model.StartChat()
 
 	// Send the full context to the AI and get the response
 	resp, err := cs.SendMessage(ctx, genai.Text(fullContext))
+	if err == nil {
+		// Answer the web searches the AI asks for before it responds, if the tool is enabled.
+		resp, err = s.answerToolCalls(ctx, cs, resp)
+	}
 	if err != nil {
 		logger.Error(ErrorFailedTosendmessagesToAI, err)
 		return "", err
 	}
 
+	telemetry.RecordMessageSent()
+
 	// Process the AI's response using the Session's method
-	return s.printResponse(resp), nil
+	aiResponse := s.printResponse(resp)
+	s.recordExchange(userMessage, resp)
+	return aiResponse, nil
+}
+
+// recordExchange adds the user message (if any) and the parts of the AI response to the chat history,
+// unless it is incognito. It is called exactly once per successful round trip.
+// The messages are added in a single transaction, or in the transaction of the command being executed.
+func (s *Session) recordExchange(userMessage string, resp *genai.GenerateContentResponse) {
+	if s.incognito {
+		return
+	}
+	tx := s.historyTx
+	if tx == nil {
+		tx = s.ChatHistory.Begin()
+		defer tx.Commit()
+	}
+	if userMessage != "" {
+		tx.AddMessage(YouNerd, userMessage, s.ChatConfig)
+	}
+	for _, cand := range resp.Candidates {
+		if cand.Content == nil {
+			continue
+		}
+		for _, part := range cand.Content.Parts {
+			// Note: The function removeAIPrefix is invoked here to prevent the occurrence of duplicate AIPrefix entries in ChatHistory (known as RAM's labyrinth),
+			// which could lead to confusion.
+			tx.AddMessage(AiNerd, removeAIPrefix(fmt.Sprint(part)), s.ChatConfig)
+		}
+	}
 }
 
 // SendDummyMessage verifies the validity of the API key by sending a dummy message.
@@ -151,7 +299,8 @@ func (s *Session) SendMessage(ctx context.Context, client *genai.Client, chatCon
 //	An error if sending the dummy message fails.
 func SendDummyMessage(client *genai.Client) (bool, error) {
 	// Initialize a dummy chat session or use an appropriate lightweight method.
-	model := client.GenerativeModel(GeminiPro)
+	// Use the configured model, so the key is verified against the model the session will use.
+	model := client.GenerativeModel(currentModelName())
 	// Configure the model with options.
 	// Apply the configurations to the model.
 	// Note: This a testing in live production by sending a Dummy messages lmao
@@ -203,25 +352,18 @@ func (s *Session) printResponse(resp *genai.GenerateContentResponse) string {
 			for _, part := range cand.Content.Parts {
 				content := fmt.Sprint(part)
 
-				// Note: The function removeAIPrefix is invoked here to prevent the occurrence of duplicate AIPrefix entries in ChatHistory (known as RAM's labyrinth),
-				// which could lead to confusion.
 				// Remove the AI prefix from the content.
+				// Note: The response is added to the chat history by recordExchange once it has been printed.
 				content = removeAIPrefix(content)
 
-				// Store the original AI response in the chat history (known as RAM's labyrinth)
-				s.ChatHistory.AddMessage(AiNerd, content, s.ChatConfig)
-
 				// Process the AI response for display
-				// Filter out the language identifier from code blocks before any other processing
-				filteredContent := FilterLanguageFromCodeBlock(content)
-				colorized := colorizeResponse(filteredContent)
-				colorized = handleSingleAsterisks(colorized)
-				colorized = handleSingleMinusSign(colorized)
+				colorized := renderAIContent(content)
 
 				// Display the processed AI response
 				// Note: "false" indicate that AI Prefix not System Prefix
 				// This how I like Go, unlike other language that sometimes not accurate about boolean lmao
-				printAIResponse(colorized, false)
+				printAIResponse(s.Output, colorized, false)
+				printImagePreviews(s.Output, content)
 				aiResponse += colorized
 			}
 		}
@@ -254,7 +396,8 @@ func sanitizeAIResponse(response string) string {
 
 // sendToAIWithoutDisplay sends a message to the AI, processes the response, and updates the chat history without displaying the response.
 //
-// Note: This function is currently unused, but it will be employed for automated summarization in the future.
+// Note: This function is currently unused. The automated summarization uses compactHistory instead (see runAutoSummary),
+// since the summary replaces the oldest messages rather than being added to the chat history.
 func (s *Session) sendToAIWithoutDisplay(ctx context.Context, chatContext string) error {
 	// Use the default model name
 	model := s.ConfigureModelForSession(ctx)
@@ -282,13 +425,8 @@ func (s *Session) sendMessageAndProcessResponse(ctx context.Context, model *gena
 
 	// Process the AI's response and add it to the chat history
 	aiResponse := s.processAIResponse(resp)
-	sanitizedMessage := s.ChatHistory.SanitizeMessage(aiPrompt)
-	formattedResponse := fmt.Sprintf(ObjectHighLevelString, SYSTEMPREFIX, aiResponse)
-	if !s.ChatHistory.handleSystemMessage(sanitizedMessage, formattedResponse, s.ChatHistory.hashMessage(aiResponse)) {
-		// If it was not a system message or no existing system message was found to replace,
-		// add the new system message to the chat history.
-		s.ChatHistory.AddMessage(SYSTEMPREFIX, aiResponse, s.ChatConfig)
-	}
+	// System messages replace older ones of the same category (see addSystemMessage).
+	s.ChatHistory.AddMessage(SYSTEMPREFIX, aiResponse, s.ChatConfig)
 
 	return nil
 }
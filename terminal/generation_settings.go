@@ -0,0 +1,192 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: Generation settings follow the same pattern as safety settings. New user-adjustable parameters
+// are added to the generationOptions map in init.go without touching the command handler itself.
+
+package terminal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	genai "github.com/google/generative-ai-go/genai"
+)
+
+// DefaultGenerationSettings returns a GenerationSettings instance with the default
+// parameters used by the chat session. MaxOutputTokens is left at zero, which means
+// the model's own default output limit is used.
+func DefaultGenerationSettings() *GenerationSettings {
+	return &GenerationSettings{
+		Temperature: DefaultTemperature,
+	}
+}
+
+// ApplyToModel applies the generation settings to a given generative AI model.
+// Parameters that are not set are left untouched so the model defaults apply.
+func (g *GenerationSettings) ApplyToModel(model *genai.GenerativeModel) {
+	options := []ModelConfig{WithTemperature(g.Temperature)}
+	if g.MaxOutputTokens > 0 {
+		maxTokensOption, err := WithMaxOutputTokens(g.MaxOutputTokens)
+		if err != nil {
+			// Note: This should not happen, since the value has already been validated by setMaxOutputTokens.
+			logger.Error(ErrorFailedToApplyConfig, err)
+		} else {
+			options = append(options, maxTokensOption)
+		}
+	}
+	if g.TopP != nil {
+		options = append(options, WithTopP(*g.TopP))
+	}
+	if g.TopK != nil {
+		options = append(options, WithTopK(*g.TopK))
+	}
+	if len(g.StopSequences) > 0 {
+		options = append(options, WithStopSequences(g.StopSequences...))
+	}
+	ApplyOptions(model, options...)
+}
+
+// setMaxOutputTokens parses and validates the requested maximum number of output tokens
+// and stores it in the session's generation settings.
+//
+// The value must be at least MinOutputTokens and must not exceed the OutputTokenLimit
+// reported by the currently active model. The special value "none" restores the model default.
+func setMaxOutputTokens(session *Session, value string) error {
+	if value == None {
+		session.GenerationSettings.MaxOutputTokens = 0
+		return nil
+	}
+	maxOutputTokens, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return fmt.Errorf(ErrorInvalidConfigValue, value, ConfigMaxTokens)
+	}
+
+	modelName := session.activeModelName()
+	info, err := fetchModelInfo(session.Ctx, session.Client, modelName)
+	if err != nil {
+		return fmt.Errorf(ErrorFailedToRetriveModelInfo, err)
+	}
+
+	if err = validateMaxOutputTokens(int32(maxOutputTokens), info.OutputTokenLimit, modelName); err != nil {
+		return err
+	}
+
+	session.GenerationSettings.MaxOutputTokens = int32(maxOutputTokens)
+	return nil
+}
+
+// validateMaxOutputTokens checks the maximum number of output tokens against the
+// minimum supported by this application and the limit of the given model.
+func validateMaxOutputTokens(maxOutputTokens, outputTokenLimit int32, modelName string) error {
+	if maxOutputTokens < MinOutputTokens {
+		return fmt.Errorf(ErrorMaxOutputTokenMustbe, MinOutputTokens, maxOutputTokens)
+	}
+	if outputTokenLimit > 0 && maxOutputTokens > outputTokenLimit {
+		return fmt.Errorf(ErrorMaxOutputTokenExceedsLimit, outputTokenLimit, modelName, maxOutputTokens)
+	}
+	return nil
+}
+
+// setTemperature stores the temperature in the session's generation settings.
+// The value must be between 0 and MaxTemperature, and the special value "none" restores DefaultTemperature.
+func setTemperature(session *Session, value string) error {
+	if value == None {
+		session.GenerationSettings.Temperature = DefaultTemperature
+		return nil
+	}
+	temperature, err := strconv.ParseFloat(value, 32)
+	if err != nil {
+		return fmt.Errorf(ErrorInvalidConfigValue, value, ConfigTemperature)
+	}
+	if temperature < 0 || temperature > float64(MaxTemperature) {
+		return fmt.Errorf(ErrorGenerationParamRange, ConfigTemperature, 0.0, MaxTemperature, value)
+	}
+	session.GenerationSettings.Temperature = float32(temperature)
+	return nil
+}
+
+// setTopP stores the top P in the session's generation settings.
+// The value must be between 0 and 1, and the special value "none" restores the model default.
+func setTopP(session *Session, value string) error {
+	if value == None {
+		session.GenerationSettings.TopP = nil
+		return nil
+	}
+	topP, err := strconv.ParseFloat(value, 32)
+	if err != nil {
+		return fmt.Errorf(ErrorInvalidConfigValue, value, ConfigTopP)
+	}
+	if topP < 0 || topP > 1 {
+		return fmt.Errorf(ErrorGenerationParamRange, ConfigTopP, 0.0, 1.0, value)
+	}
+	p := float32(topP)
+	session.GenerationSettings.TopP = &p
+	return nil
+}
+
+// setTopK stores the top K in the session's generation settings.
+// The value must be 1 or higher, and the special value "none" restores the model default.
+func setTopK(session *Session, value string) error {
+	if value == None {
+		session.GenerationSettings.TopK = nil
+		return nil
+	}
+	topK, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return fmt.Errorf(ErrorInvalidConfigValue, value, ConfigTopK)
+	}
+	if topK < 1 {
+		return fmt.Errorf(ErrorGenerationParamMin, ConfigTopK, 1, value)
+	}
+	k := int32(topK)
+	session.GenerationSettings.TopK = &k
+	return nil
+}
+
+// generationParamValue returns the value of the generation parameter of the key as it is shown to the user.
+func (g *GenerationSettings) generationParamValue(key string) string {
+	switch key {
+	case ConfigTemperature:
+		return formatConfigFloat(&g.Temperature)
+	case ConfigTopP:
+		return formatConfigFloat(g.TopP)
+	case ConfigTopK:
+		return formatConfigInt(g.TopK)
+	case ConfigMaxTokens:
+		if g.MaxOutputTokens > 0 {
+			return formatConfigInt(&g.MaxOutputTokens)
+		}
+	}
+	return ModelDefault
+}
+
+// setStopSequence adds a stop sequence to the session's generation settings.
+// Surrounding double quotes are removed, so both stop "END" and stop END are accepted.
+// The special value "none" removes all stop sequences.
+func setStopSequence(session *Session, value string) error {
+	sequence := strings.Trim(value, `"`)
+	if sequence == "" {
+		return fmt.Errorf(ErrorInvalidConfigValue, value, ConfigStop)
+	}
+	if sequence == None {
+		session.GenerationSettings.StopSequences = nil
+		return nil
+	}
+	if len(session.GenerationSettings.StopSequences) >= MaxStopSequences {
+		return fmt.Errorf(ErrorTooManyStopSequences, MaxStopSequences)
+	}
+	session.GenerationSettings.StopSequences = append(session.GenerationSettings.StopSequences, sequence)
+	return nil
+}
+
+// setSeed rejects the generation seed.
+//
+// Note: The generative-ai-go SDK used by this application does not expose a seed parameter yet, so a seed could not
+// be sent to the AI. It is rejected rather than kept, so nobody relies on responses that are not reproducible.
+// Once the SDK supports it, the seed can be stored in GenerationSettings and applied in ApplyToModel.
+func setSeed(session *Session, value string) error {
+	return fmt.Errorf(ErrorSeedUnsupported, ConfigSeed)
+}
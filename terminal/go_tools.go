@@ -0,0 +1,37 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: ":go doc" and ":go test :explain" run the go command directly, without a shell, in the current directory.
+// Failing tests are not an error of the command: their output is what the AI explains.
+
+package terminal
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+)
+
+// goTestArgs returns the arguments of go test for the packages and flags given to ":go test :explain".
+// Without any, the tests of all packages in the current module are run.
+func goTestArgs(args []string) []string {
+	if len(args) == 0 {
+		return []string{TestArgs, GoAllPackages}
+	}
+	return append([]string{TestArgs}, args...)
+}
+
+// runGoCommand runs the go command with args and returns its combined output, cut at GoToolMaxOutput.
+// For go test, a non-zero exit status is not an error, since it reports failing tests with it.
+func runGoCommand(ctx context.Context, args []string) (string, error) {
+	if args[0] != TestArgs {
+		return runTool(ctx, GoBinary, args, GoToolMaxOutput)
+	}
+	output, err := exec.CommandContext(ctx, GoBinary, args...).CombinedOutput()
+	var exitErr *exec.ExitError
+	if err != nil && (ctx.Err() != nil || !errors.As(err, &exitErr)) {
+		return "", err
+	}
+	return truncateOutput(output, GoToolMaxOutput), nil
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: The diff compares whole messages rather than lines, using the longest common subsequence,
+// so after forking a session the shared prefix is skipped and only the diverging messages are shown.
+// Both sessions are read without taking their locks, so a session that is currently in use can still be compared.
+
+package terminal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffMessages compares two message lists and returns the edit script that turns a into b.
+// Messages that are in both lists, in the same order, are marked as unchanged.
+func diffMessages(a, b []string) []MessageDiff {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	diff := make([]MessageDiff, 0, max(len(a), len(b)))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			diff = append(diff, MessageDiff{Op: DiffUnchanged, Message: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, MessageDiff{Op: DiffRemoved, Message: a[i]})
+			i++
+		default:
+			diff = append(diff, MessageDiff{Op: DiffAdded, Message: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		diff = append(diff, MessageDiff{Op: DiffRemoved, Message: a[i]})
+	}
+	for ; j < len(b); j++ {
+		diff = append(diff, MessageDiff{Op: DiffAdded, Message: b[j]})
+	}
+	return diff
+}
+
+// diffStoredSessions loads two stored sessions and compares their chat histories.
+//
+// Parameters:
+//
+//	nameA string: The name of the session to compare from.
+//	nameB string: The name of the session to compare to.
+//
+// Returns:
+//
+//	[]MessageDiff: The messages removed from, added to and shared by both sessions.
+//	error: An error if one of the sessions cannot be loaded.
+func diffStoredSessions(nameA, nameB string) ([]MessageDiff, error) {
+	historyA, _, err := loadViewerHistory(nameA)
+	if err != nil {
+		return nil, err
+	}
+	historyB, _, err := loadViewerHistory(nameB)
+	if err != nil {
+		return nil, err
+	}
+	return diffMessages(historyA.snapshot().messages, historyB.snapshot().messages), nil
+}
+
+// formatHistoryDiff formats the removed and added messages of the diff, numbered by their position in each session.
+// Unchanged messages are only counted, since they are usually the shared history before a fork.
+func formatHistoryDiff(nameA, nameB string, diff []MessageDiff) string {
+	var builder strings.Builder
+	var added, removed, unchanged int
+	indexA, indexB := 0, 0
+	for _, entry := range diff {
+		message := strings.TrimSpace(entry.Message)
+		switch entry.Op {
+		case DiffRemoved:
+			indexA++
+			removed++
+			builder.WriteString(fmt.Sprintf(DiffRemovedFormat, nameA, indexA, message))
+		case DiffAdded:
+			indexB++
+			added++
+			builder.WriteString(fmt.Sprintf(DiffAddedFormat, nameB, indexB, message))
+		default:
+			indexA++
+			indexB++
+			unchanged++
+		}
+	}
+	return fmt.Sprintf(InfoHistoryDiffHeader, nameA, nameB, unchanged, removed, added) + builder.String()
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: This is the API for other Go programs that read the sessions stored by this tool (see SESSION_NAME) or their
+// backups, without running the binary. The file is only read: the session lock is not taken, so a session can be read
+// while an instance is writing it, and what is read is the state of the last save.
+
+package terminal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// OpenHistory reads the stored session file at path, e.g., "sessions/work.json" next to the config file.
+//
+// Example:
+//
+//	history, err := terminal.OpenHistory(path)
+//	if err != nil {
+//		return err
+//	}
+//	history.Each(func(i int, message terminal.HistoryMessage) bool {
+//		fmt.Println(message.Type == terminal.AIMessage, message.Text)
+//		return true
+//	})
+func OpenHistory(path string) (*StoredHistory, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var stored StoredSession
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf(ErrorInvalidSessionFile, path, err)
+	}
+
+	history := &StoredHistory{messages: make([]HistoryMessage, 0, len(stored.Messages))}
+	if savedAt, err := time.Parse(time.RFC3339, stored.SavedAt); err == nil {
+		history.SavedAt = savedAt
+	}
+	for _, message := range stored.Messages {
+		history.messages = append(history.messages, parseHistoryMessage(message))
+	}
+	return history, nil
+}
+
+// parseHistoryMessage splits a message of the chat history into its type and text.
+func parseHistoryMessage(message string) HistoryMessage {
+	message = strings.TrimSpace(ansiRegex.ReplaceAllString(message, ""))
+	messageType := DetermineMessageType(message)
+	for _, prefix := range []string{SYSTEMPREFIX, AiNerd, YouNerd} {
+		if text, ok := strings.CutPrefix(message, prefix); ok {
+			return HistoryMessage{Type: messageType, Text: strings.TrimSpace(text)}
+		}
+	}
+	return HistoryMessage{Type: messageType, Text: message}
+}
+
+// Len returns the number of messages.
+func (h *StoredHistory) Len() int {
+	return len(h.messages)
+}
+
+// Messages returns a copy of the messages, oldest first.
+func (h *StoredHistory) Messages() []HistoryMessage {
+	return slices.Clone(h.messages)
+}
+
+// Each calls fn for each message, oldest first, until fn returns false.
+func (h *StoredHistory) Each(fn func(i int, message HistoryMessage) bool) {
+	for i, message := range h.messages {
+		if !fn(i, message) {
+			return
+		}
+	}
+}
+
+// Stats counts the messages of each type. The counts are computed from the messages,
+// rather than taken from the file, which may have been written by an older version.
+func (h *StoredHistory) Stats() MessageStats {
+	var stats MessageStats
+	for _, message := range h.messages {
+		switch message.Type {
+		case UserMessage:
+			stats.UserMessages++
+		case AIMessage:
+			stats.AIMessages++
+		case SystemMessage:
+			stats.SystemMessages++
+		}
+	}
+	return stats
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: A transaction applies all of its changes at once, under a single lock of the chat history, so concurrent
+// readers never see half of a multi-step change (e.g., a user message without its response), and a change that
+// fails halfway is simply rolled back instead of being undone message by message.
+
+package terminal
+
+// Begin starts a transaction on the chat history. Its changes are only applied by Commit.
+func (h *ChatHistory) Begin() *HistoryTransaction {
+	return &HistoryTransaction{history: h}
+}
+
+// AddMessage adds a message to the chat history when the transaction is committed (see ChatHistory.AddMessage).
+func (tx *HistoryTransaction) AddMessage(user string, text string, config *ChatConfig) {
+	tx.changes = append(tx.changes, func(h *ChatHistory) {
+		h.addMessage(user, text, config)
+	})
+}
+
+// ClearAllSystemMessages removes all system messages from the chat history when the transaction is committed.
+func (tx *HistoryTransaction) ClearAllSystemMessages() {
+	tx.changes = append(tx.changes, (*ChatHistory).clearAllSystemMessages)
+}
+
+// GetHistory returns the chat history as it will be once the transaction is committed (see ChatHistory.GetHistory).
+func (tx *HistoryTransaction) GetHistory(config *ChatConfig) string {
+	return tx.preview().GetHistory(config)
+}
+
+// preview returns the chat history as it will be once the transaction is committed. Without changes,
+// it is the chat history itself.
+func (tx *HistoryTransaction) preview() *ChatHistory {
+	if len(tx.changes) == 0 {
+		return tx.history
+	}
+	preview := &ChatHistory{}
+	preview.restore(tx.history.snapshot())
+	for _, change := range tx.changes {
+		change(preview)
+	}
+	return preview
+}
+
+// Commit applies the changes of the transaction to the chat history.
+// It does nothing if the transaction was already committed or rolled back.
+func (tx *HistoryTransaction) Commit() {
+	if tx.done {
+		return
+	}
+	tx.done = true
+
+	tx.history.mu.Lock()
+	defer tx.history.mu.Unlock()
+	for _, change := range tx.changes {
+		change(tx.history)
+	}
+	tx.changes = nil
+}
+
+// Rollback discards the changes of the transaction. It does nothing after Commit,
+// so it can be deferred right after Begin.
+func (tx *HistoryTransaction) Rollback() {
+	tx.done = true
+	tx.changes = nil
+}
+
+// withHistoryTransaction runs fn with a transaction that collects the chat history changes of a command,
+// including the exchanges sent while it runs (see recordExchange). The changes are committed if fn succeeds,
+// and rolled back if it returns an error.
+func (s *Session) withHistoryTransaction(fn func(tx *HistoryTransaction) error) error {
+	tx := s.ChatHistory.Begin()
+	s.historyTx = tx
+	defer func() {
+		s.historyTx = nil
+		tx.Rollback()
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	tx.Commit()
+	return nil
+}
+
+// recordMessage adds a message to the chat history, as part of the transaction of the command being executed
+// if there is one.
+func (s *Session) recordMessage(user, text string) {
+	if s.historyTx != nil {
+		s.historyTx.AddMessage(user, text, s.ChatConfig)
+		return
+	}
+	s.ChatHistory.AddMessage(user, text, s.ChatConfig)
+}
+
+// contextChatHistory returns the chat history to send as context, including the pending changes
+// of the command being executed.
+func (s *Session) contextChatHistory() *ChatHistory {
+	if s.historyTx != nil {
+		return s.historyTx.preview()
+	}
+	return s.ChatHistory
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: The HTML export is a single standalone file. The terminal color scheme is reproduced with inline CSS,
+// and no external stylesheet or script is loaded, so the file opens offline and runs no third-party code.
+
+package terminal
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// transcriptHTMLTemplate is parsed once; the template text lives in constant.go with the other templates.
+var transcriptHTMLTemplate = template.Must(template.New("transcript").Parse(TranscriptHTMLTemplate))
+
+// inlineCodeRegex and boldTextRegex match the inline Markdown the terminal colorizes (`code` and **bold**).
+var (
+	inlineCodeRegex = regexp.MustCompile(InlineCodeRegex)
+	boldTextRegex   = regexp.MustCompile(BoldTextRegex)
+)
+
+// BuildTranscriptHTML renders chat history messages as a sanitized, standalone HTML document.
+func BuildTranscriptHTML(messages []string, exportedAt time.Time) (string, error) {
+	entries := sanitizeTranscript(messages)
+	data := struct {
+		Title      string
+		ExportedAt string
+		Messages   []transcriptHTMLMessage
+	}{
+		Title:      ApplicationName,
+		ExportedAt: exportedAt.Format(time.RFC1123),
+		Messages:   make([]transcriptHTMLMessage, 0, len(entries)),
+	}
+
+	for _, entry := range entries {
+		data.Messages = append(data.Messages, transcriptHTMLMessage{
+			Class:   transcriptSenderClass(entry.Prefix),
+			Sender:  strings.TrimSuffix(entry.Prefix, PrefixChar),
+			Content: renderTranscriptHTML(entry.Text),
+		})
+	}
+
+	var builder strings.Builder
+	if err := transcriptHTMLTemplate.Execute(&builder, data); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
+
+// exportTranscriptHTML writes the HTML transcript to a file in the current directory and returns its path.
+func exportTranscriptHTML(messages []string, exportedAt time.Time) (string, error) {
+	document, err := BuildTranscriptHTML(messages, exportedAt)
+	if err != nil {
+		return "", err
+	}
+	filename := fmt.Sprintf(TranscriptHTMLFileName, exportedAt.Format(TranscriptTimeFormat))
+	if err := os.WriteFile(filename, []byte(document), 0600); err != nil {
+		return "", err
+	}
+	return filename, nil
+}
+
+// transcriptSenderClass returns the CSS class used to color a sender.
+func transcriptSenderClass(prefix string) string {
+	switch prefix {
+	case AiNerd:
+		return CSSClassAI
+	case SYSTEMPREFIX:
+		return CSSClassSystem
+	default:
+		return CSSClassUser
+	}
+}
+
+// renderTranscriptHTML converts message text into escaped HTML.
+// Fenced code blocks become <pre><code> elements tagged with their language,
+// while inline code and bold text get the same colors as in the terminal.
+func renderTranscriptHTML(text string) template.HTML {
+	var builder strings.Builder
+	segments := strings.Split(text, TripleBacktick)
+	for i, segment := range segments {
+		// Odd segments are inside a fenced code block, unless the closing fence is missing.
+		if i%2 == 1 && i < len(segments)-1 {
+			language, code, _ := strings.Cut(segment, StringNewLine)
+			builder.WriteString(`<pre><code`)
+			if language = strings.TrimSpace(language); language != "" {
+				builder.WriteString(` class="language-` + html.EscapeString(language) + `"`)
+			}
+			builder.WriteString(`>` + html.EscapeString(code) + `</code></pre>`)
+			continue
+		}
+		if i%2 == 1 {
+			segment = TripleBacktick + segment
+		}
+		escaped := html.EscapeString(segment)
+		escaped = inlineCodeRegex.ReplaceAllString(escaped, `<code class="inline">$1</code>`)
+		escaped = boldTextRegex.ReplaceAllString(escaped, `<strong>$1</strong>`)
+		builder.WriteString(escaped)
+	}
+	// Note: The content has been escaped above, so it is safe to mark it as HTML.
+	return template.HTML(builder.String())
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: Every HTTP request of the terminal (GitHub releases, Gists and telemetry) goes through the shared client,
+// so connections are reused and the "http" section of the config file (proxy, TLS and keep-alive settings) applies everywhere.
+// The standard HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are honored when no proxy is configured.
+// The Gemini API uses its own gRPC connection and is not affected.
+
+package terminal
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// NewHTTPClient creates an HTTP client from the config. A nil config uses the defaults.
+//
+// Parameters:
+//
+//	config *HTTPConfig: The proxy, TLS, keep-alive and timeout settings.
+//
+// Returns:
+//
+//	*http.Client: The configured client.
+//	error: An error if the proxy URL, the minimum TLS version or the CA file is invalid.
+func NewHTTPClient(config *HTTPConfig) (*http.Client, error) {
+	if config == nil {
+		config = &HTTPConfig{}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.Proxy != "" {
+		proxyURL, err := url.Parse(config.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf(ErrorInvalidHTTPProxy, config.Proxy, err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+	transport.DisableKeepAlives = config.DisableKeepAlives
+	if config.IdleConnTimeoutSeconds > 0 {
+		transport.IdleConnTimeout = time.Duration(config.IdleConnTimeoutSeconds) * time.Second
+	}
+	if config.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+	}
+
+	timeout := DefaultHTTPTimeout
+	if config.TimeoutSeconds > 0 {
+		timeout = time.Duration(config.TimeoutSeconds) * time.Second
+	}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+	}, nil
+}
+
+// newTLSConfig returns the TLS settings of the client. TLS 1.2 is the minimum unless TLS 1.3 is required.
+func newTLSConfig(config *HTTPConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	switch config.MinTLSVersion {
+	case "", TLSVersion12:
+	case TLSVersion13:
+		tlsConfig.MinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf(ErrorInvalidTLSVersion, config.MinTLSVersion)
+	}
+
+	if config.CAFile == "" {
+		return tlsConfig, nil
+	}
+	// Note: The extra certificates are added to the system pool, e.g., for a corporate proxy that intercepts TLS.
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	pem, err := os.ReadFile(config.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf(ErrorFailedToReadFile, config.CAFile, err)
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf(ErrorNoCertificatesInCAFile, config.CAFile)
+	}
+	tlsConfig.RootCAs = pool
+	return tlsConfig, nil
+}
+
+// defaultHTTPClient creates the shared HTTP client from the application config.
+// An invalid "http" section is reported and the defaults are used instead, so the terminal still works.
+func defaultHTTPClient() *http.Client {
+	client, err := NewHTTPClient(appConfig.HTTP)
+	if err != nil {
+		logger.Error(ErrorInvalidHTTPConfig, err)
+		client, _ = NewHTTPClient(nil)
+	}
+	return client
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// hyperlinkTermPrograms lists the values of TERM_PROGRAM of terminals that support OSC 8 hyperlinks.
+var hyperlinkTermPrograms = []string{"iTerm.app", "WezTerm", "vscode", "Hyper", "ghostty"}
+
+// supportsHyperlinks reports whether links should be rendered as OSC 8 hyperlinks.
+// HYPERLINKS=true or HYPERLINKS=false takes precedence over the detection of the terminal.
+func supportsHyperlinks() bool {
+	switch os.Getenv(HyperlinksEnv) {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if os.Getenv(WTSessionEnv) != "" || os.Getenv(KittyWindowIDEnv) != "" {
+		return true
+	}
+	if slices.Contains(hyperlinkTermPrograms, os.Getenv(TermProgramEnv)) {
+		return true
+	}
+	// VTE based terminals (e.g., GNOME Terminal) support hyperlinks since 0.50.
+	if vte, err := strconv.Atoi(os.Getenv(VTEVersionEnv)); err == nil && vte >= 5000 {
+		return true
+	}
+	term := os.Getenv(TermEnv)
+	return strings.Contains(term, "kitty") || strings.HasPrefix(term, "foot") || term == "alacritty"
+}
+
+// renderHyperlinks makes the URLs and Markdown links ("[label](url)") in text clickable.
+// On terminals that support it, each link is emitted as an OSC 8 hyperlink. Otherwise, plain URLs are
+// left as they are and Markdown links are shown as the label followed by the URL in parentheses.
+//
+// Links in fenced code blocks and inline code spans are left as they are.
+func renderHyperlinks(text string) string {
+	osc8 := supportsHyperlinks()
+	return mapProseLines(text, func(line string) string {
+		return renderHyperlinksLine(line, osc8)
+	})
+}
+
+// renderHyperlinksLine renders the links of a single line.
+func renderHyperlinksLine(line string, osc8 bool) string {
+	var result strings.Builder
+	last := 0
+	for i := 0; i < len(line); {
+		switch {
+		case line[i] == SingleBacktick[0]:
+			i = skipCodeSpan(line, i)
+			continue
+		case line[i] == '[':
+			if label, url, end, ok := parseMarkdownLink(line, i); ok {
+				result.WriteString(line[last:i])
+				result.WriteString(formatLink(label, url, osc8))
+				i, last = end, end
+				continue
+			}
+		case strings.HasPrefix(line[i:], "http://") || strings.HasPrefix(line[i:], "https://"):
+			if before, _ := utf8.DecodeLastRuneInString(line[:i]); i == 0 || !unicode.IsLetter(before) && !unicode.IsDigit(before) {
+				end := i + urlLength(line[i:])
+				result.WriteString(line[last:i])
+				result.WriteString(formatLink(line[i:end], line[i:end], osc8))
+				i, last = end, end
+				continue
+			}
+		}
+		i++
+	}
+	if last == 0 {
+		return line
+	}
+	result.WriteString(line[last:])
+	return result.String()
+}
+
+// parseMarkdownLink parses a Markdown link "[label](url)" starting at i.
+// Only http and https URLs are accepted. It returns the offset just past the link.
+//
+// Note: The label cannot contain '[', and the URL cannot contain '[' or whitespace, so the search stops at the
+// next '[' in both cases. This keeps rendering a line with many unclosed brackets linear.
+func parseMarkdownLink(line string, i int) (label, url string, end int, ok bool) {
+	closing := strings.IndexAny(line[i+1:], "[]")
+	if closing <= 0 || line[i+1+closing] != ']' {
+		return "", "", 0, false
+	}
+	label = line[i+1 : i+1+closing]
+	rest := line[i+1+closing+1:]
+	if !strings.HasPrefix(rest, "(") {
+		return "", "", 0, false
+	}
+	paren := strings.IndexFunc(rest, func(r rune) bool { return r == ')' || r == '[' || unicode.IsSpace(r) })
+	if paren < 0 || rest[paren] != ')' {
+		return "", "", 0, false
+	}
+	url = rest[1:paren]
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return "", "", 0, false
+	}
+	return label, url, i + 1 + closing + 1 + paren + 1, true
+}
+
+// urlLength returns the length of the URL at the start of text. Trailing punctuation that
+// most likely ends the sentence, and a closing parenthesis without an opening one, are excluded.
+func urlLength(text string) int {
+	end := strings.IndexFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune("<>\"`", r)
+	})
+	if end < 0 {
+		end = len(text)
+	}
+	for end > 0 {
+		last := text[end-1]
+		if strings.IndexByte(".,;:!?'*_", last) >= 0 ||
+			last == ')' && strings.Count(text[:end], "(") < strings.Count(text[:end], ")") {
+			end--
+			continue
+		}
+		break
+	}
+	return end
+}
+
+// formatLink returns the link as an OSC 8 hyperlink, or as plain text if osc8 is false.
+func formatLink(label, url string, osc8 bool) string {
+	switch {
+	case osc8:
+		return fmt.Sprintf(HyperlinkFormat, url, label)
+	case label == url:
+		return url
+	default:
+		return fmt.Sprintf(LinkFallbackFormat, label, url)
+	}
+}
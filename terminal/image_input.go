@@ -0,0 +1,92 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: The image is sent in a single request, together with the recent chat history as text, since GeminiProVision
+// does not support multi-turn chats. Only the path of the image is added to the chat history, not its data.
+
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	genai "github.com/google/generative-ai-go/genai"
+)
+
+// visionModelName returns the model that answers about images: the model of the session if it accepts images
+// itself, otherwise GeminiProVision.
+func visionModelName(modelName string) string {
+	if strings.HasPrefix(modelName, MultimodalModelPrefix) {
+		return modelName
+	}
+	return GeminiProVision
+}
+
+// readImageInput reads the image at path and returns its data and format.
+// The content must be a supported image (see verifyImageFileExtension) of at most ImageMaxBytes.
+func readImageInput(path string) ([]byte, string, error) {
+	if err := verifyImageFileExtension(path); err != nil {
+		return nil, "", err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, "", err
+	}
+	if info.Size() > ImageMaxBytes {
+		return nil, "", fmt.Errorf(ErrorImageTooLarge, info.Size(), ImageMaxBytes)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, detectImageFormat(data), nil
+}
+
+// sendImage sends the image at path and the prompt to the vision model, with the safety settings of the session,
+// and prints the answer with the typing effect. The prompt is inspected by the outbound filter first.
+// The prompt, with the path of the image, and the answer are added to the chat history.
+func (s *Session) sendImage(path, prompt string) error {
+	data, format, err := readImageInput(path)
+	if err != nil {
+		return err
+	}
+	prompt, ok := s.filterOutbound(prompt)
+	if !ok {
+		return nil // The filter already informed the user
+	}
+
+	modelName := visionModelName(s.activeModelName())
+	model := s.Client.GenerativeModel(modelName)
+	s.SafetySettings.ApplyToModel(model, modelName)
+	s.GenerationSettings.ApplyToModel(model)
+	s.applySystemInstruction(model, modelName)
+
+	fullContext := prompt
+	if chatHistory := s.ChatHistory.GetHistory(s.ChatConfig); len(chatHistory) > 0 {
+		fullContext = chatHistory + StringNewLine + prompt
+	}
+	if persona := s.personaContext(model); persona != "" {
+		fullContext = persona + StringNewLine + fullContext
+	}
+	if s.DryRun {
+		s.previewRequest(model, fmt.Sprintf(ImageHistoryFormat, path, fullContext))
+		return nil
+	}
+
+	operation := RetryableOperation{
+		retryFunc: func() (bool, error) {
+			resp, err := model.GenerateContent(s.Ctx, genai.ImageData(format, data), genai.Text(fullContext))
+			if err != nil {
+				return false, err
+			}
+			telemetry.RecordMessageSent()
+			s.printResponse(resp)
+			s.recordExchange(fmt.Sprintf(ImageHistoryFormat, path, prompt), resp)
+			return true, nil
+		},
+	}
+	_, err = operation.retryWithExponentialBackoff(standardAPIErrorHandler)
+	return err
+}
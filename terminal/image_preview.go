@@ -0,0 +1,250 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+
+package terminal
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	_ "image/jpeg" // Register the JPEG decoder
+	"image/png"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// printImagePreviews previews the local image files referenced in text, either as a path
+// (e.g., "chart.png") or as a Markdown image (e.g., "![chart](chart.png)").
+// The preview uses the graphics protocol of the terminal (see imageProtocol); if there is none,
+// or the image cannot be previewed, the path of the image is printed instead.
+//
+// Note: Previews are written directly instead of with the typing effect, which would take far too long for image data.
+func printImagePreviews(w io.Writer, text string) {
+	references := imageReferences(text)
+	if len(references) == 0 {
+		return
+	}
+	protocol := imageProtocol()
+	for _, path := range references {
+		preview, err := encodeImagePreview(path, protocol)
+		if err != nil {
+			logger.Debug(DebugImagePreviewFailed, path, err)
+		}
+		if preview == "" {
+			fmt.Fprintf(w, ImagePreviewFallback, path)
+			continue
+		}
+		fmt.Fprintln(w, preview)
+	}
+}
+
+// imageReferences returns the local image files referenced in text, in order and without duplicates.
+// Only existing files whose content is a supported image are returned.
+func imageReferences(text string) []string {
+	var references []string
+	for _, word := range strings.Fields(text) {
+		// For a Markdown image, only the part in parentheses is the path.
+		if i := strings.Index(word, "]("); i >= 0 {
+			word = word[i+2:]
+		}
+		path := strings.TrimRight(strings.Trim(word, ImageReferenceTrimChars), ".")
+		if strings.Contains(path, "://") || !hasImageFileExtension(path) || slices.Contains(references, path) {
+			continue
+		}
+		if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() || info.Size() > ImagePreviewMaxBytes {
+			continue
+		}
+		if verifyImageFileExtension(path) == nil {
+			references = append(references, path)
+		}
+	}
+	return references
+}
+
+// imageProtocol returns the graphics protocol supported by the terminal, or ImageProtocolNone.
+// IMAGE_PREVIEW takes precedence over the detection of the terminal.
+func imageProtocol() string {
+	switch protocol := os.Getenv(ImagePreviewEnv); protocol {
+	case ImageProtocolKitty, ImageProtocolITerm, ImageProtocolSixel, ImageProtocolNone:
+		return protocol
+	case "false":
+		return ImageProtocolNone
+	}
+	term, termProgram := os.Getenv(TermEnv), os.Getenv(TermProgramEnv)
+	switch {
+	case os.Getenv(KittyWindowIDEnv) != "" || strings.Contains(term, "kitty") || termProgram == "ghostty":
+		return ImageProtocolKitty
+	case termProgram == "iTerm.app" || termProgram == "WezTerm":
+		return ImageProtocolITerm
+	case strings.Contains(term, "sixel") || strings.HasPrefix(term, "foot") || term == "mlterm":
+		return ImageProtocolSixel
+	default:
+		return ImageProtocolNone
+	}
+}
+
+// encodeImagePreview returns the escape sequences that show the image at path with protocol.
+// It returns an empty string for ImageProtocolNone.
+func encodeImagePreview(path, protocol string) (string, error) {
+	if protocol == ImageProtocolNone {
+		return "", nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	switch protocol {
+	case ImageProtocolITerm:
+		// iTerm2 decodes the image itself, so any format it supports is sent as is.
+		return fmt.Sprintf(ITermImageFormat, len(data), ImagePreviewColumns, base64.StdEncoding.EncodeToString(data)), nil
+	case ImageProtocolKitty:
+		pngData, err := toPNG(data)
+		if err != nil {
+			return "", err
+		}
+		return encodeKittyImage(pngData), nil
+	default:
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return "", err
+		}
+		return encodeSixel(img), nil
+	}
+}
+
+// toPNG returns the image data as PNG, converting it if needed.
+// Only PNG and JPEG images can be converted.
+func toPNG(data []byte) ([]byte, error) {
+	if detectImageFormat(data) == FormatPNG {
+		return data, nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeKittyImage returns the kitty graphics protocol escape sequences that show the PNG image.
+// The base64 payload is split into chunks, as required by the protocol.
+func encodeKittyImage(pngData []byte) string {
+	payload := base64.StdEncoding.EncodeToString(pngData)
+	var result strings.Builder
+	for first := true; first || payload != ""; first = false {
+		chunk := payload[:min(len(payload), KittyChunkSize)]
+		payload = payload[len(chunk):]
+		more := 0
+		if payload != "" {
+			more = 1
+		}
+		if first {
+			fmt.Fprintf(&result, KittyImageFirstChunk, ImagePreviewColumns, more, chunk)
+		} else {
+			fmt.Fprintf(&result, KittyImageNextChunk, more, chunk)
+		}
+	}
+	return result.String()
+}
+
+// encodeSixel returns the image as Sixel graphics, scaled to fit SixelMaxWidth and SixelMaxHeight
+// and dithered to the web-safe palette.
+func encodeSixel(img image.Image) string {
+	img = scaleImage(img, SixelMaxWidth, SixelMaxHeight)
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	paletted := image.NewPaletted(image.Rect(0, 0, width, height), palette.WebSafe)
+	draw.FloydSteinberg.Draw(paletted, paletted.Bounds(), img, bounds.Min)
+
+	var result strings.Builder
+	result.WriteString(SixelStart)
+	fmt.Fprintf(&result, SixelRasterFormat, width, height)
+	for i, c := range paletted.Palette {
+		// Sixel colors are given in percent.
+		r, g, b, _ := c.RGBA()
+		fmt.Fprintf(&result, SixelColorFormat, i, r*100/0xffff, g*100/0xffff, b*100/0xffff)
+	}
+
+	// Each band is six pixel rows high, drawn once for every color it uses.
+	for top := 0; top < height; top += 6 {
+		var used [256]bool
+		for y := top; y < min(top+6, height); y++ {
+			for x := 0; x < width; x++ {
+				used[paletted.ColorIndexAt(x, y)] = true
+			}
+		}
+		first := true
+		for index := range paletted.Palette {
+			if !used[index] {
+				continue
+			}
+			if !first {
+				result.WriteByte('$') // Return to the start of the band
+			}
+			first = false
+			fmt.Fprintf(&result, "#%d", index)
+			writeSixelRow(&result, paletted, top, uint8(index))
+		}
+		result.WriteByte('-') // Move to the next band
+	}
+	result.WriteString(SixelEnd)
+	return result.String()
+}
+
+// writeSixelRow writes the pixels of one color in the band starting at top, run-length encoded.
+func writeSixelRow(result *strings.Builder, paletted *image.Paletted, top int, index uint8) {
+	width, height := paletted.Bounds().Dx(), paletted.Bounds().Dy()
+	sixelAt := func(x int) byte {
+		var bits byte
+		for dy := 0; dy < 6 && top+dy < height; dy++ {
+			if paletted.ColorIndexAt(x, top+dy) == index {
+				bits |= 1 << dy
+			}
+		}
+		return '?' + bits
+	}
+	for x := 0; x < width; {
+		sixel := sixelAt(x)
+		run := 1
+		for x+run < width && sixelAt(x+run) == sixel {
+			run++
+		}
+		if run > 3 {
+			fmt.Fprintf(result, "!%d%c", run, sixel)
+		} else {
+			result.WriteString(strings.Repeat(string(sixel), run))
+		}
+		x += run
+	}
+}
+
+// scaleImage scales img down with nearest-neighbor sampling to fit within maxWidth and maxHeight,
+// keeping its aspect ratio. Images that already fit are returned as they are.
+func scaleImage(img image.Image, maxWidth, maxHeight int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxWidth && height <= maxHeight {
+		return img
+	}
+	newWidth, newHeight := maxWidth, height*maxWidth/width
+	if newHeight > maxHeight {
+		newWidth, newHeight = width*maxHeight/height, maxHeight
+	}
+	newWidth, newHeight = max(newWidth, 1), max(newHeight, 1)
+	scaled := image.NewRGBA(image.Rect(0, 0, newWidth, newHeight))
+	for y := 0; y < newHeight; y++ {
+		for x := 0; x < newWidth; x++ {
+			scaled.Set(x, y, img.At(bounds.Min.X+x*width/newWidth, bounds.Min.Y+y*height/newHeight))
+		}
+	}
+	return scaled
+}
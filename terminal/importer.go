@@ -0,0 +1,185 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: The importers convert web UI exports into TranscriptEntry values, the same neutral format used by ":share",
+// so adding another source only needs a new parser registered in the importParsers map.
+
+package terminal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// htmlBlockBreakRegex and htmlTagRegex turn the HTML of Takeout responses into plain text.
+var (
+	htmlBlockBreakRegex = regexp.MustCompile(HTMLBlockBreakRegex)
+	htmlTagRegex        = regexp.MustCompile(HTMLTagRegex)
+)
+
+// ParseChatGPTExport parses a ChatGPT "conversations.json" export and returns the messages of one conversation.
+//
+// Parameters:
+//
+//	data []byte: The content of conversations.json.
+//	selector string: The 1-based index of the conversation in the export. An empty selector picks the
+//	                 most recently updated conversation.
+//
+// Returns:
+//
+//	[]TranscriptEntry: The user and assistant messages in chronological order.
+//	error: An error if the export cannot be parsed or the conversation does not exist.
+func ParseChatGPTExport(data []byte, selector string) ([]TranscriptEntry, error) {
+	var conversations []ChatGPTConversation
+	if err := json.Unmarshal(data, &conversations); err != nil {
+		return nil, fmt.Errorf(ErrorInvalidImportFile, err)
+	}
+	if len(conversations) == 0 {
+		return nil, errors.New(ErrorImportEmpty)
+	}
+
+	conversation, err := selectChatGPTConversation(conversations, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	// Walk from the current node back to the root, then reverse to get chronological order.
+	// Note: Only the active branch is followed, so regenerated answers that were discarded are not imported.
+	// A corrupted export may link a node back to itself or to a descendant, which would otherwise never end.
+	var entries []TranscriptEntry
+	visited := make(map[string]bool)
+	for nodeID := conversation.CurrentNode; nodeID != ""; {
+		if visited[nodeID] {
+			return nil, fmt.Errorf(ErrorImportCycle, nodeID)
+		}
+		visited[nodeID] = true
+		node, ok := conversation.Mapping[nodeID]
+		if !ok {
+			break
+		}
+		if entry, ok := chatGPTNodeEntry(node); ok {
+			entries = append(entries, entry)
+		}
+		nodeID = node.Parent
+	}
+	slices.Reverse(entries)
+	return entries, nil
+}
+
+// selectChatGPTConversation picks a conversation by 1-based index, or the most recently updated one.
+func selectChatGPTConversation(conversations []ChatGPTConversation, selector string) (*ChatGPTConversation, error) {
+	if selector == "" {
+		latest := 0
+		for i, conversation := range conversations {
+			if conversation.UpdateTime > conversations[latest].UpdateTime {
+				latest = i
+			}
+		}
+		return &conversations[latest], nil
+	}
+
+	index, err := strconv.Atoi(selector)
+	if err != nil || index < 1 || index > len(conversations) {
+		return nil, fmt.Errorf(ErrorImportNoConversation, selector, len(conversations))
+	}
+	return &conversations[index-1], nil
+}
+
+// chatGPTNodeEntry converts a ChatGPT mapping node into a transcript entry.
+// System, tool and empty messages are skipped.
+func chatGPTNodeEntry(node ChatGPTNode) (TranscriptEntry, bool) {
+	if node.Message == nil {
+		return TranscriptEntry{}, false
+	}
+
+	var prefix string
+	switch node.Message.Author.Role {
+	case ChatGPTRoleUser:
+		prefix = YouNerd
+	case ChatGPTRoleAssistant:
+		prefix = AiNerd
+	default:
+		return TranscriptEntry{}, false
+	}
+
+	// Note: Parts can also be objects (e.g., uploaded images); only text parts are imported.
+	var texts []string
+	for _, part := range node.Message.Content.Parts {
+		var text string
+		if json.Unmarshal(part, &text) == nil && strings.TrimSpace(text) != "" {
+			texts = append(texts, text)
+		}
+	}
+	if len(texts) == 0 {
+		return TranscriptEntry{}, false
+	}
+	return TranscriptEntry{Prefix: prefix, Text: strings.Join(texts, StringNewLine)}, true
+}
+
+// ParseGeminiTakeout parses the Gemini Apps activity file from Google Takeout ("MyActivity.json").
+// Each activity holds one prompt and, usually, the response as HTML. The selector is not used,
+// since Takeout stores a flat activity list instead of separate conversations.
+//
+// Returns:
+//
+//	[]TranscriptEntry: The prompts and responses in chronological order.
+//	error: An error if the file cannot be parsed or contains no prompts.
+func ParseGeminiTakeout(data []byte, selector string) ([]TranscriptEntry, error) {
+	var activities []GeminiTakeoutActivity
+	if err := json.Unmarshal(data, &activities); err != nil {
+		return nil, fmt.Errorf(ErrorInvalidImportFile, err)
+	}
+
+	var entries []TranscriptEntry
+	// Note: Takeout lists the newest activity first.
+	for i := len(activities) - 1; i >= 0; i-- {
+		activity := activities[i]
+		prompt, ok := strings.CutPrefix(activity.Title, GeminiTakeoutPromptPrefix)
+		if !ok || strings.TrimSpace(prompt) == "" {
+			continue
+		}
+		entries = append(entries, TranscriptEntry{Prefix: YouNerd, Text: strings.TrimSpace(prompt)})
+		for _, item := range activity.SafeHTMLItem {
+			if response := htmlToText(item.HTML); response != "" {
+				entries = append(entries, TranscriptEntry{Prefix: AiNerd, Text: response})
+			}
+		}
+	}
+	if len(entries) == 0 {
+		return nil, errors.New(ErrorImportEmpty)
+	}
+	return entries, nil
+}
+
+// htmlToText converts the simple HTML used in Takeout responses into plain text.
+func htmlToText(content string) string {
+	content = htmlBlockBreakRegex.ReplaceAllString(content, StringNewLine)
+	content = htmlTagRegex.ReplaceAllString(content, "")
+	return strings.TrimSpace(html.UnescapeString(content))
+}
+
+// importTranscript reads an export file with the given parser and appends its messages to the chat history.
+// It returns the number of imported messages.
+func importTranscript(session *Session, parse ImportParser, filePath, selector string) (int, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return 0, fmt.Errorf(ErrorFailedToReadFile, filePath, err)
+	}
+
+	entries, err := parse(data, selector)
+	if err != nil {
+		return 0, err
+	}
+	for _, entry := range entries {
+		session.ChatHistory.AddMessage(entry.Prefix, entry.Text, session.ChatConfig)
+	}
+	return len(entries), nil
+}
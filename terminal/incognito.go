@@ -0,0 +1,32 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: An incognito prompt still sees the recent chat history as context, but neither the prompt nor the response
+// is added to it, so they never reach the stored session, the shared or exported transcripts, or later requests.
+// The audit log only records that the command was used, never the prompt.
+
+package terminal
+
+// sendIncognito sends the prompt with the recent chat history as context and prints the response,
+// without adding the prompt or the response to the chat history.
+func (s *Session) sendIncognito(prompt string) error {
+	s.incognito = true
+	defer func() { s.incognito = false }()
+
+	fullContext := prompt
+	if chatHistory := s.ChatHistory.GetHistory(s.ChatConfig); len(chatHistory) > 0 {
+		fullContext = chatHistory + StringNewLine + prompt
+	}
+	if memory := s.memoryContext(); memory != "" {
+		fullContext = memory + StringNewLine + fullContext
+	}
+	operation := RetryableOperation{
+		retryFunc: func() (bool, error) {
+			_, err := s.sendFullContext(s.Ctx, s.ConfigureModelForSession(s.Ctx), fullContext, "")
+			return err == nil, err
+		},
+	}
+	_, err := operation.retryWithExponentialBackoff(standardAPIErrorHandler)
+	return err
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: The session info only reads local state, so it is available in viewer mode and never calls the API.
+// The history tokens are an offline estimate (see EstimateTokens), while the tokens used are the ones counted
+// for the responses of this session (see updateAndPrintTotalTokenCount).
+
+package terminal
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// formatSessionInfo formats the status block of ":info": the model and its settings, the history usage,
+// the tokens used, the uptime and where the history is stored.
+func (s *Session) formatSessionInfo(now time.Time) string {
+	stats := s.ChatHistory.GetMessageStats()
+	messages := stats.UserMessages + stats.AIMessages + stats.SystemMessages
+	storage := SessionInfoInMemory
+	if s.Store != nil {
+		storage = s.Store.path
+	}
+
+	var builder strings.Builder
+	table := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(table, SessionInfoRow, SessionInfoModel, currentModelName())
+	fmt.Fprintf(table, SessionInfoRow, SessionInfoSafety, s.SafetySettings.Level())
+	fmt.Fprintf(table, SessionInfoRow, SessionInfoTemperature, fmt.Sprintf(SessionInfoTempFormat, s.GenerationSettings.Temperature))
+	fmt.Fprintf(table, SessionInfoRow, SessionInfoHistory, fmt.Sprintf(SessionInfoHistoryFormat,
+		messages, s.ChatConfig.HistorySize, s.ChatHistory.estimateHistoryTokens()))
+	fmt.Fprintf(table, SessionInfoRow, SessionInfoTokens, fmt.Sprint(totalTokenCount))
+	fmt.Fprintf(table, SessionInfoRow, SessionInfoUptime, now.Sub(s.StartedAt).Round(time.Second).String())
+	fmt.Fprintf(table, SessionInfoRow, SessionInfoStorage, storage)
+	table.Flush()
+	return builder.String()
+}
@@ -5,11 +5,16 @@
 package terminal
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+	"sync/atomic"
 )
 
 // apiKey holds the API key used for authenticating requests to the generative
@@ -24,6 +29,64 @@ var apiKey string
 // logger is a package-level variable that can be used throughout the terminal package.
 var logger *DebugOrErrorLogger
 
+// appConfig holds the application config loaded from the config file and environment variables.
+var appConfig *AppConfig
+
+// viewerCommands lists the commands available in viewer mode (see NewViewerSession).
+// They only read the chat history or the local state, so none of them calls the API.
+var viewerCommands = map[string]bool{
+	QuitCommand:      true,
+	ShortQuitCommand: true,
+	HelpCommand:      true,
+	ShortHelpCommand: true,
+	ChatCommands:     true,
+	StatsCommand:     true,
+	SearchCommand:    true,
+	DiffCommand:      true,
+	InfoCommand:      true,
+	ReplayCommand:    true,
+	ShareCommand:     true,
+	ExportCommand:    true,
+	AuditCommand:     true,
+	QueueCommand:     true,
+	ReportCommand:    true,
+	ShowCommands:     true,
+}
+
+// activeSession is the running session, used by the panic diagnostics to report the active model.
+var activeSession atomic.Pointer[Session]
+
+// errorHistory keeps the most recent error messages for the ":report" command.
+var errorHistory = NewErrorHistory(MaxRecentErrors)
+
+// retryStats counts the retries of API requests for the ":report" command.
+var retryStats RetryStats
+
+// telemetry counts the aggregate events reported by the opt-in telemetry (see sendTelemetry).
+var telemetry = NewTelemetry()
+
+// supervisor restarts the background goroutines when they panic (see ":workers").
+var supervisor = NewSupervisor()
+
+// auditLog records executed commands when enabled in the config (nil when disabled).
+var auditLog *AuditLog
+
+// stdinQueue is the only reader of the terminal input (see PromptQueue), and the input of new sessions.
+var stdinQueue = NewPromptQueue(os.Stdin, stdout)
+
+// stdout is the terminal output: the output of new sessions and of the messages of the logger.
+// It is only replaced while the package is initialized (see enableVirtualTerminal).
+var stdout io.Writer = os.Stdout
+
+// httpClient is the shared HTTP client, configured by the "http" section of the config file (see NewHTTPClient).
+var httpClient *http.Client
+
+// retryPolicy controls the retries of failed requests, configured by the "retry_policy" section of the config file.
+var retryPolicy = DefaultRetryPolicy()
+
+// tokenCountCache holds token counts of previously counted files.
+var tokenCountCache *TokenCountCache
+
 // this is a package-level variable that holds the command registry.
 // Caution is advised: if you're not familiar with these practices, improper handling in this "CommandRegistry" could lead to frequent panics 24/7 🤪.
 var registry *CommandRegistry
@@ -44,6 +107,13 @@ var colors = ANSIColorCodes{
 	ColorReset:       ColorReset,
 }
 
+// themes maps the theme names to their color codes. The plain theme is meant for terminals
+// that do not render ANSI colors well; it only affects the colors taken from the colors variable.
+var themes = map[string]ANSIColorCodes{
+	DefaultTheme: colors,
+	PlainTheme:   {},
+}
+
 // ansichar
 var ansichar = BinaryAnsiChars{
 	BinaryAnsiChar:          BinaryAnsiChar,
@@ -70,8 +140,6 @@ var totalTokenCount int = 0
 // Note: Removing Struct now, this a `Go` not a `Rust`
 var ansiRegex *regexp.Regexp
 
-var italicAnsiRegex *regexp.Regexp
-
 // filterCodeBlock is a compiled regular expression that is used to identify and
 // remove language identifiers from Markdown code blocks. A Markdown code block is
 // typically indicated by triple backticks (```) followed by an optional language
@@ -86,6 +154,51 @@ var italicAnsiRegex *regexp.Regexp
 // recompiling it with each use.
 var filterCodeBlock *regexp.Regexp
 
+// hunkHeaderRegex matches a hunk header of a unified diff, such as "@@ -12,7 +12,8 @@ func main() {".
+var hunkHeaderRegex *regexp.Regexp
+
+// scalable generationOptions maps configuration keys to their corresponding setter functions and validity.
+var generationOptions = map[string]GenerationOption{
+	ConfigMaxTokens: {
+		Setter: setMaxOutputTokens,
+		Valid:  true,
+	},
+	ConfigStop: {
+		Setter: setStopSequence,
+		Valid:  true,
+	},
+	ConfigSeed: {
+		Setter: setSeed,
+		Valid:  true,
+	},
+	ConfigTemperature: {
+		Setter: setTemperature,
+		Valid:  true,
+	},
+	ConfigTopP: {
+		Setter: setTopP,
+		Valid:  true,
+	},
+	ConfigTopK: {
+		Setter: setTopK,
+		Valid:  true,
+	},
+}
+
+// importParsers maps the ":import" subcommands to the parsers of their export formats.
+var importParsers = map[string]ImportParser{
+	ChatGPTArgs: ParseChatGPTExport,
+	GeminiArgs:  ParseGeminiTakeout,
+}
+
+// redactionProfiles lists the redaction profiles in the order their rules are applied.
+var redactionProfiles = []RedactionProfile{
+	{Name: RedactionProfileSecrets, Rules: secretsRedactionRules},
+	{Name: RedactionProfilePII, Rules: piiRedactionRules},
+	{Name: RedactionProfileHostnames, Rules: hostnameRedactionRules},
+	{Name: RedactionProfilePaths, Rules: pathRedactionRules},
+}
+
 // scalable safetyOptions maps safety level strings to their corresponding setter functions and validity.
 var safetyOptions = map[string]SafetyOption{
 	Low: {
@@ -110,47 +223,61 @@ var safetyOptions = map[string]SafetyOption{
 	},
 }
 
+// spinnerFrames holds the animation frames used by Spinner.
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
 // scalable a global variable for the ASCII style.
 var slantStyle = NewASCIIArtStyle()
-var stripStyle = NewASCIIArtStyle()
 var newLine = NewASCIIArtStyle()
 var panicDetected = NewASCIIArtStyle()
 
 var dynamicErrorFileTypeNotSupported = ErrorFileTypeNotSupported
 
+// verifyTextFile checks that the file has an allowed text file extension. It is used by commands that send
+// the file content as text (e.g., ":watch" and ":fanout"), where images are not supported.
+func verifyTextFile(filePath string) error {
+	if hasImageFileExtension(filePath) {
+		return fmt.Errorf(ErrorTextFilesOnly, filePath)
+	}
+	return verifyFileExtension(filePath)
+}
+
 // helper function
 //
 // verifyFileExtension checks if the file has an allowed extension.
+// Images are always allowed since they are detected by content; text file extensions come from the application config.
 func verifyFileExtension(filePath string) error {
-	allowedExtensions := map[string]bool{
-		// Note: Feel free to submit a pull request or issues if you want to add support for other file types
-		dotMD:   true,
-		dotTxt:  true,
-		dotPng:  true,
-		dotJpg:  true,
-		dotJpeg: true,
-		dotHeic: true,
-		dotHeif: true,
-		dotWebp: true,
+	// Note: Feel free to submit a pull request or issues if you want to add support for other file types,
+	// or simply allow them via the ALLOWED_FILE_EXTENSIONS environment variable or the config file.
+	fileExt := strings.ToLower(filepath.Ext(filePath))
+	if appConfig.IsAllowedFileExtension(fileExt) || hasImageFileExtension(filePath) {
+		return nil
 	}
 
-	// Extract the file extension and check if it's allowed.
-	fileExt := strings.ToLower(filepath.Ext(filePath))
-	if _, allowed := allowedExtensions[fileExt]; !allowed {
-		// Create a slice to hold the allowed extensions for the error message.
-		allowedExts := []string{}
-		for ext, isAllowed := range allowedExtensions {
-			if isAllowed {
-				// Add the extension without the dot for a cleaner error message.
-				allowedExts = append(allowedExts, strings.TrimPrefix(ext, dotString))
-			}
-		}
-		// Join the allowed extensions with commas and an "or" before the last one.
-		allowedExtsStr := strings.Join(allowedExts[:len(allowedExts)-1], dotStringComma) + oRString + allowedExts[len(allowedExts)-1]
-		return fmt.Errorf(dynamicErrorFileTypeNotSupported, allowedExtsStr)
+	// Create a slice to hold the allowed extensions for the error message.
+	allowedExts := []string{}
+	for _, ext := range slices.Concat(appConfig.AllowedFileExtensions, []string{dotPng, dotJpg, dotJpeg, dotHeic, dotHeif, dotWebp}) {
+		// Add the extension without the dot for a cleaner error message.
+		allowedExts = append(allowedExts, strings.TrimPrefix(ext, dotString))
 	}
+	// Join the allowed extensions with commas and an "or" before the last one.
+	allowedExtsStr := strings.Join(allowedExts[:len(allowedExts)-1], dotStringComma) + oRString + allowedExts[len(allowedExts)-1]
+	return fmt.Errorf(dynamicErrorFileTypeNotSupported, allowedExtsStr)
+}
 
-	return nil
+// supportedImageFormats lists the image formats recognized by detectImageFormat, used in error messages.
+var supportedImageFormats = []string{FormatPNG, FormatJPEG, FormatWEBP, FormatHEIC}
+
+// heifBrands maps the ISO base media file brands found after "ftyp" to their image format.
+var heifBrands = map[string]string{
+	"heic": FormatHEIC,
+	"heix": FormatHEIC,
+	"hevc": FormatHEIC,
+	"hevx": FormatHEIC,
+	"heim": FormatHEIC,
+	"heis": FormatHEIC,
+	"mif1": FormatHEIF,
+	"msf1": FormatHEIF,
 }
 
 // Dynamic ErrorImageFileTypeNotSupported is a format string for the error message when an unsupported file type is encountered.
@@ -158,54 +285,87 @@ var dynamicErrorImageFileTypeNotSupported = ErrorVariableImageFileTypeNotSupport
 
 // helper function
 //
-// verifyImageFileExtension checks if the image file has an allowed extension.
+// verifyImageFileExtension checks if the file is a supported image.
+// Despite its name, the decision is made by sniffing the file's magic bytes (see detectImageFormat)
+// rather than trusting the extension, so misnamed files are handled correctly.
+//
+// Returns:
+//
+//	error: An error if the file cannot be read or its content is not a supported image format.
 func verifyImageFileExtension(filePath string) error {
-	allowedExtensions := map[string]bool{
-		dotPng:  true,
-		dotJpg:  true,
-		dotJpeg: true,
-		dotHeic: true,
-		dotHeif: true,
-		dotWebp: true,
+	header, err := readFileHeader(filePath)
+	if err != nil {
+		return err
 	}
-
-	// Extract the file extension and check if it's allowed.
-	fileExt := strings.ToLower(filepath.Ext(filePath))
-	if _, allowed := allowedExtensions[fileExt]; !allowed {
-		// Create a slice to hold the allowed extensions for the error message.
-		allowedExts := []string{}
-		for ext, isAllowed := range allowedExtensions {
-			if isAllowed {
-				// Add the extension without the dot for a cleaner error message.
-				allowedExts = append(allowedExts, strings.TrimPrefix(ext, dotString))
-			}
-		}
-		// Join the allowed extensions with commas and an "or" before the last one.
-		allowedExtsStr := strings.Join(allowedExts[:len(allowedExts)-1], dotStringComma) + oRString + allowedExts[len(allowedExts)-1]
-		return fmt.Errorf(dynamicErrorImageFileTypeNotSupported, allowedExtsStr)
+	if detectImageFormat(header) == "" {
+		// Join the supported formats with commas and an "or" before the last one.
+		allowedFormatsStr := strings.Join(supportedImageFormats[:len(supportedImageFormats)-1], dotStringComma) + oRString + supportedImageFormats[len(supportedImageFormats)-1]
+		return fmt.Errorf(dynamicErrorImageFileTypeNotSupported, allowedFormatsStr)
 	}
-
 	return nil
 }
 
-// getImageFormat returns the image format based on the file extension.
+// getImageFormat returns the image format based on the file's magic bytes.
+// It returns an empty string if the file cannot be read or is not a supported image.
 func getImageFormat(filePath string) string {
-	extToFormat := map[string]string{
-		dotJpg:  FormatJPEG,
-		dotJpeg: FormatJPEG,
-		dotPng:  FormatPNG,
-		dotHeic: FormatHEIC,
-		dotHeif: FormatHEIC,
-		dotWebp: FormatWEBP,
+	header, err := readFileHeader(filePath)
+	if err != nil {
+		return ""
+	}
+	return detectImageFormat(header)
+}
+
+// hasImageFileExtension reports whether the file extension claims the file is an image.
+// It is only used to give an accurate error when the content does not match the extension.
+func hasImageFileExtension(filePath string) bool {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case dotPng, dotJpg, dotJpeg, dotHeic, dotHeif, dotWebp:
+		return true
+	default:
+		return false
+	}
+}
+
+// readFileHeader reads up to imageHeaderSize bytes from the beginning of a file.
+// Files shorter than imageHeaderSize are returned as-is.
+func readFileHeader(filePath string) ([]byte, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
 	}
+	defer file.Close()
 
-	// Extract the file extension in lowercase.
-	ext := strings.ToLower(filepath.Ext(filePath))
-	// Lookup the image format based on file extension.
-	if format, ok := extToFormat[ext]; ok {
-		return format
+	header := make([]byte, imageHeaderSize)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	return header[:n], nil
+}
+
+// detectImageFormat identifies the image format from the leading bytes of a file.
+//
+// Supported signatures:
+//
+//	PNG:       89 50 4E 47 0D 0A 1A 0A
+//	JPEG:      FF D8 FF
+//	WEBP:      "RIFF" <size> "WEBP"
+//	HEIC/HEIF: <size> "ftyp" <brand>, where brand is one of heifBrands
+//
+// It returns an empty string if the header does not match any supported format.
+func detectImageFormat(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, []byte(MagicPNG)):
+		return FormatPNG
+	case bytes.HasPrefix(header, []byte(MagicJPEG)):
+		return FormatJPEG
+	case len(header) >= 12 && string(header[0:4]) == MagicRIFF && string(header[8:12]) == MagicWEBP:
+		return FormatWEBP
+	case len(header) >= 12 && string(header[4:8]) == MagicFtyp:
+		return heifBrands[string(header[8:12])]
+	default:
+		return ""
 	}
-	return ""
 }
 
 // Helper Function
@@ -227,10 +387,24 @@ var supportedModels map[string]bool
 func init() {
 	// Initialize the logger when the package is imported.
 	logger = NewDebugOrErrorLogger()
+	// Load the application config, falling back to the defaults if it is broken.
+	appConfig = loadAppConfigOrDefault()
+	// Apply the color theme before the styles below capture the colors.
+	applyTheme(appConfig.Theme)
+	// Enable the ANSI escape codes on the Windows console, falling back to the plain theme if it cannot process them.
+	enableVirtualTerminal()
+	// Create the shared HTTP client with the proxy, TLS and keep-alive settings from the config.
+	httpClient = defaultHTTPClient()
+	// Apply the retry policy from the config.
+	retryPolicy = defaultRetryPolicy()
+	// Load the token count cache stored next to the config file.
+	tokenCountCache = defaultTokenCountCache()
+	// Open the audit log, if enabled.
+	auditLog = defaultAuditLog()
 	// Compile the ANSI color code regular expression pattern.
 	ansiRegex = regexp.MustCompile(BinaryRegexAnsi)
 	filterCodeBlock = regexp.MustCompile(CodeBlockRegex)
-	italicAnsiRegex = regexp.MustCompile(ItalicTextRegex)
+	hunkHeaderRegex = regexp.MustCompile(HunkHeaderRegex)
 
 	// Initialize the command registry.
 	// Note: This NewCommandRegistry offers excellent scalability. For Example: You can easily add numerous commands without impacting
@@ -243,7 +417,7 @@ func init() {
 	registry.Register(VersionCommand, &handleCheckVersionCommand{})
 	registry.Register(HelpCommand, &handleHelpCommand{})
 	registry.Register(ShortHelpCommand, &handleHelpCommand{})
-	registry.Register(AITranslateCommand, &handleAITranslateCommand{})
+	registry.RegisterSpec(AITranslateCommand, freeArgsSpec(&handleAITranslateCommand{}))
 	registry.Register(SummarizeCommands, &handleSummarizeCommand{})
 	// Assume handleClearCommand is capable of handling subcommands for ":clear"
 	clearCommandHandler := &handleClearCommand{}
@@ -276,13 +450,168 @@ func init() {
 	tokenCountCommandHandler := &handleTokeCountingCommand{}
 	registry.Register(TokenCountCommands, tokenCountCommandHandler)
 	registry.RegisterSubcommand(TokenCountCommands, FileCommands, tokenCountCommandHandler)
+	registry.RegisterSubcommand(TokenCountCommands, EstimateArgs, tokenCountCommandHandler)
 	// Register the check models command and its handler.
 	checkModelCommandHandler := &handleCheckModelCommand{}
-	registry.Register(CheckModelCommands, checkModelCommandHandler)
+	registry.RegisterSpec(CheckModelCommands, freeArgsSpec(checkModelCommandHandler))
 	// Register the switch models command and its handler.
-	registry.Register(SwitchModelCommands, &handleSwitchModelCommand{})
-
-	//TODO: Will add more commands here, example: :help, :about, :credits, :k8s, syncing AI With Go Routines (Known as Gopher hahaha) etc.
+	registry.RegisterSpec(SwitchModelCommands, freeArgsSpec(&handleSwitchModelCommand{}))
+	// Register the generation config command and its handler.
+	registry.Register(GenConfigCommand, &handleGenConfigCommand{})
+	// Register the generation parameter commands, which share a handler for their keys.
+	registry.RegisterSpec(TemperatureCommand, freeArgsSpec(&handleGenerationParamCommand{key: ConfigTemperature}))
+	registry.RegisterSpec(TopPCommand, freeArgsSpec(&handleGenerationParamCommand{key: ConfigTopP}))
+	registry.RegisterSpec(TopKCommand, freeArgsSpec(&handleGenerationParamCommand{key: ConfigTopK}))
+	registry.RegisterSpec(MaxTokensCommand, freeArgsSpec(&handleGenerationParamCommand{key: ConfigMaxTokens}))
+	// Register the retry policy command and its handler.
+	retryPolicyCommandHandler := &handleRetryPolicyCommand{}
+	registry.Register(RetryPolicyCommand, retryPolicyCommandHandler)
+	registry.RegisterSubcommand(RetryPolicyCommand, SetArgs, retryPolicyCommandHandler)
+	registry.RegisterSubcommand(RetryPolicyCommand, ResetArgs, retryPolicyCommandHandler)
+	// Register the persona command and its handler.
+	registry.RegisterSpec(PersonaCommand, freeArgsSpec(&handlePersonaCommand{}))
+	// Register the replay command and its handler.
+	registry.Register(ReplayCommand, &handleReplayCommand{})
+	// Register the share command and its handler.
+	shareCommandHandler := &handleShareCommand{}
+	registry.Register(ShareCommand, shareCommandHandler)
+	registry.RegisterSubcommand(ShareCommand, HTMLArgs, shareCommandHandler)
+	// Register the export command and its handler.
+	exportCommandHandler := &handleExportCommand{}
+	registry.Register(ExportCommand, exportCommandHandler)
+	registry.RegisterSubcommand(ExportCommand, FileCommands, exportCommandHandler)
+	// Register the import command and its handler.
+	importCommandHandler := &handleImportCommand{}
+	registry.Register(ImportCommand, importCommandHandler)
+	registry.RegisterSubcommand(ImportCommand, ChatGPTArgs, importCommandHandler)
+	registry.RegisterSubcommand(ImportCommand, GeminiArgs, importCommandHandler)
+	// Register the watch command and its handler.
+	registry.RegisterSpec(WatchCommand, freeArgsSpec(&handleWatchCommand{}))
+	// Register the schedule command and its handler.
+	scheduleCommandHandler := &handleScheduleCommand{}
+	registry.RegisterSpec(ScheduleCommand, freeArgsSpec(scheduleCommandHandler))
+	registry.RegisterSubcommand(ScheduleCommand, ListArgs, scheduleCommandHandler)
+	registry.RegisterSubcommand(ScheduleCommand, CancelArgs, scheduleCommandHandler)
+	// Register the fanout command and its handler.
+	registry.RegisterSpec(FanoutCommand, freeArgsSpec(&handleFanoutCommand{}))
+	// Register the dryrun command and its handler.
+	dryRunCommandHandler := &handleDryRunCommand{}
+	registry.Register(DryRunCommand, dryRunCommandHandler)
+	registry.RegisterSubcommand(DryRunCommand, On, dryRunCommandHandler)
+	registry.RegisterSubcommand(DryRunCommand, Off, dryRunCommandHandler)
+	// Register the show command and its handler.
+	showCommandHandler := &handleShowCommand{}
+	registry.Register(ShowCommands, showCommandHandler)
+	registry.RegisterSubcommand(ShowCommands, SummaryArgs, showCommandHandler)
+	// Register the history command and its handler.
+	historyCommandHandler := &handleHistoryCommand{}
+	registry.Register(HistoryCommand, historyCommandHandler)
+	registry.RegisterSubcommand(HistoryCommand, SizeArgs, historyCommandHandler)
+	// Register the quiet command and its handler.
+	quietCommandHandler := &handleQuietCommand{}
+	registry.Register(QuietCommand, quietCommandHandler)
+	registry.RegisterSubcommand(QuietCommand, On, quietCommandHandler)
+	registry.RegisterSubcommand(QuietCommand, Off, quietCommandHandler)
+	// Register the k8s command and its handler.
+	k8sCommandHandler := &handleK8sCommand{}
+	registry.Register(K8sCommand, k8sCommandHandler)
+	registry.RegisterSubcommand(K8sCommand, ExplainArgs, k8sCommandHandler)
+	// Register the docker command and its handler.
+	dockerCommandHandler := &handleDockerCommand{}
+	registry.Register(DockerCommand, dockerCommandHandler)
+	registry.RegisterSubcommand(DockerCommand, LogsArgs, dockerCommandHandler)
+	registry.RegisterSubcommand(DockerCommand, ComposeArgs, dockerCommandHandler)
+	// Register the logs command and its handler.
+	logsCommandHandler := &handleLogsCommand{}
+	registry.Register(LogsCommand, logsCommandHandler)
+	registry.RegisterSubcommand(LogsCommand, SystemArgs, logsCommandHandler)
+	// Register the fix command and its handler.
+	registry.RegisterSpec(FixCommand, freeArgsSpec(&handleFixCommand{}))
+	// Register the image command and its handler.
+	registry.RegisterSpec(ImageCommand, freeArgsSpec(&handleImageCommand{}))
+	// Register the go command and its handler.
+	goCommandHandler := &handleGoCommand{}
+	registry.Register(GoCommand, goCommandHandler)
+	registry.RegisterSubcommand(GoCommand, DocArgs, goCommandHandler)
+	registry.RegisterSubcommand(GoCommand, TestArgs, goCommandHandler)
+	// Register the report command and its handler.
+	reportCommandHandler := &reportshitFunctionthatTooComplexCommand{}
+	registry.Register(ReportCommand, reportCommandHandler)
+	registry.RegisterSubcommand(ReportCommand, CrashArgs, reportCommandHandler)
+
+	// Register the compact command and its handler.
+	registry.RegisterSpec(CompactCommand, freeArgsSpec(&handleCompactCommand{}))
+
+	// Register the workers command and its handler.
+	registry.Register(WorkersCommand, &handleWorkersCommand{})
+
+	// Register the telemetry command and its handler.
+	telemetryCommandHandler := &handleTelemetryCommand{}
+	registry.Register(TelemetryCommand, telemetryCommandHandler)
+	registry.RegisterSubcommand(TelemetryCommand, On, telemetryCommandHandler)
+	registry.RegisterSubcommand(TelemetryCommand, Off, telemetryCommandHandler)
+	registry.RegisterSubcommand(TelemetryCommand, StatusArgs, telemetryCommandHandler)
+	// Register the setup command and its handler.
+	registry.Register(SetupCommand, &handleSetupCommand{})
+	// Register the search command and its handler.
+	searchCommandHandler := &handleSearchCommand{}
+	registry.RegisterSpec(SearchCommand, freeArgsSpec(searchCommandHandler))
+	registry.RegisterSubcommand(SearchCommand, WebArgs, searchCommandHandler)
+
+	// Register the diff command and its handler.
+	registry.RegisterSpec(DiffCommand, freeArgsSpec(&handleDiffCommand{}))
+
+	// Register the quote command and its handler.
+	registry.RegisterSpec(QuoteCommand, freeArgsSpec(&handleQuoteCommand{}))
+
+	// Register the incognito command and its handler.
+	registry.RegisterSpec(IncognitoCommand, freeArgsSpec(&handleIncognitoCommand{}))
+
+	// Register the info command and its handler.
+	registry.Register(InfoCommand, &handleInfoCommand{})
+
+	// Register the applydiff command and its handler.
+	registry.RegisterSpec(ApplyDiffCommand, freeArgsSpec(&handleApplyDiffCommand{}))
+
+	// Register the remember command and its handler.
+	registry.RegisterSpec(RememberCommand, freeArgsSpec(&handleRememberCommand{}))
+
+	// Register the memory command and its subcommands.
+	memoryCommandHandler := &handleMemoryCommand{}
+	registry.Register(MemoryCommand, memoryCommandHandler)
+	registry.RegisterSubcommand(MemoryCommand, ListFactsArgs, memoryCommandHandler)
+	registry.RegisterSubcommand(MemoryCommand, ForgetArgs, memoryCommandHandler)
+
+	// Register the prompt command and its subcommands.
+	promptCommandHandler := &handlePromptCommand{}
+	registry.Register(PromptCommand, promptCommandHandler)
+	registry.RegisterSubcommand(PromptCommand, SetArgs, promptCommandHandler)
+	registry.RegisterSubcommand(PromptCommand, FileCommands, &handlePromptfileCommand{})
+
+	// Register the checkpoint command and its subcommands.
+	checkpointCommandHandler := &handleCheckpointCommand{}
+	registry.Register(CheckpointCommand, checkpointCommandHandler)
+	registry.RegisterSubcommand(CheckpointCommand, SaveArgs, checkpointCommandHandler)
+	registry.RegisterSubcommand(CheckpointCommand, RestoreArgs, checkpointCommandHandler)
+	// Register the audit command and its handler.
+	auditCommandHandler := &handleAuditCommand{}
+	registry.Register(AuditCommand, auditCommandHandler)
+	registry.RegisterSubcommand(AuditCommand, ShowCommands, auditCommandHandler)
+	// Record every command in the audit log.
+	if auditLog != nil {
+		registry.AddHook(auditLog.Record)
+	}
+	// Register the queue command and its handler.
+	queueCommandHandler := &handleQueueCommand{}
+	registry.Register(QueueCommand, queueCommandHandler)
+	registry.RegisterSubcommand(QueueCommand, CancelArgs, queueCommandHandler)
+	registry.RegisterSubcommand(QueueCommand, ClearCommand, queueCommandHandler)
+	// Register the config command and its handler.
+	configCommandHandler := &handleConfigCommand{}
+	registry.Register(ConfigCommand, configCommandHandler)
+	registry.RegisterSubcommand(ConfigCommand, SetArgs, configCommandHandler)
+
+	//TODO: Will add more commands here, example: :help, :about, :credits, syncing AI With Go Routines (Known as Gopher hahaha) etc.
 	// Note: In python, I don't think so it's possible hahaahaha, also I am using prefix ":" instead of "/" is respect to git and command line, fuck prefix "/" which is confusing for command line
 
 	// ASCII Scalable mode 🤪
@@ -306,13 +635,6 @@ func init() {
 		eMpty,
 		// Note: This utilizes a struct for color definitions to ensure consistency. This is important for compatibility with operating systems that may not handle ANSI colors properly.
 	}, BoldText+colors.ColorCyan24Bit)
-	// Initialize the text style patterns for 'V'.
-	// Note: Although it may appear that patterns are duplicated, they are, in fact, distinct. This structure ensures scalability that powered by Go 🤪.
-	stripStyle.AddChar(V, []string{
-		eMpty, // this better unlike hardcoded "\n" lmao.
-		StripChars,
-		// Note: This utilizes a struct for color definitions to ensure consistency. This is important for compatibility with operating systems that may not handle ANSI colors properly.
-	}, BoldText+colors.ColorCyan24Bit)
 	newLine.AddChar(N, []string{
 		eMpty, // this better unlike hardcoded "\n" lmao.
 	}, colors.ColorReset)
@@ -0,0 +1,116 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: A command in a prompt is either a command of this application, which must implement InlineCommand,
+// or a shell command. Shell commands run only after the user has confirmed them, because the prompt may have been
+// pasted from elsewhere.
+
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// expandInlineCommands replaces each command in the input, such as "!{cat main.go}" or "!{:cryptorand :length 8}",
+// with its output. A "!{" without a closing "}" is left as it is.
+//
+// Returns:
+//
+//	string: The input with the output of its commands.
+//	bool: Whether the input can be sent; false if a command failed or was declined.
+func (s *Session) expandInlineCommands(input string) (string, bool) {
+	if !strings.Contains(input, InlineCommandOpen) {
+		return input, true
+	}
+	var result strings.Builder
+	for {
+		start := strings.Index(input, InlineCommandOpen)
+		if start < 0 {
+			break
+		}
+		end := strings.Index(input[start:], InlineCommandClose)
+		if end < 0 {
+			break
+		}
+		command := strings.TrimSpace(input[start+len(InlineCommandOpen) : start+end])
+		output, ok := s.runInlineCommand(command)
+		if !ok {
+			return "", false
+		}
+		result.WriteString(input[:start])
+		result.WriteString(output)
+		input = input[start+end+len(InlineCommandClose):]
+	}
+	result.WriteString(input)
+	return result.String(), true
+}
+
+// runInlineCommand returns the output of a command in a prompt.
+// It returns false if the command failed, or the user declined to run the shell command.
+func (s *Session) runInlineCommand(command string) (string, bool) {
+	parts := splitQuotedArgs(command)
+	if len(parts) == 0 {
+		return "", true
+	}
+	if strings.HasPrefix(command, PrefixChar) {
+		handler, ok := registry.handler(parts[0]).(InlineCommand)
+		if !ok {
+			logger.Error(ErrorInlineCommandFailed, command, fmt.Errorf(ErrorNotInlineCommand, parts[0]))
+			return "", false
+		}
+		// Note: The safety settings of the command apply to the prompt, just as when it runs on its own.
+		defer s.useCommandSafety(parts[0])()
+		output, err := handler.Inline(s, parts)
+		if err != nil {
+			logger.Error(ErrorInlineCommandFailed, command, err)
+			return "", false
+		}
+		return output, true
+	}
+
+	if !s.confirm(fmt.Sprintf(InlineShellConfirmation, command)) {
+		return "", false
+	}
+	ctx, done := s.beginTimedOperation(InlineCommandTimeout)
+	defer done()
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+	output, err := runTool(ctx, shell, []string{flag, command}, InlineCommandMaxOutput)
+	if err != nil {
+		if !reportTimeout(ctx, command, InlineCommandTimeout) {
+			logger.Error(ErrorInlineCommandFailed, command, err)
+		}
+		return "", false
+	}
+	return output, true
+}
+
+// runTool runs the executable with the arguments, without a shell, and returns its combined output cut at maxBytes.
+// If it fails, the error includes the output, which usually explains why.
+func runTool(ctx context.Context, binary string, args []string, maxBytes int) (string, error) {
+	output, err := exec.CommandContext(ctx, binary, args...).CombinedOutput()
+	if err != nil {
+		if trimmed := bytes.TrimSpace(output); len(trimmed) > 0 {
+			return "", fmt.Errorf("%w: %s", err, trimmed)
+		}
+		return "", err
+	}
+	return truncateOutput(output, maxBytes), nil
+}
+
+// truncateOutput returns the output of a command without trailing newlines, cut at maxBytes.
+func truncateOutput(output []byte, maxBytes int) string {
+	output = bytes.TrimRight(output, "\r\n")
+	if len(output) > maxBytes {
+		return string(output[:maxBytes]) + InlineOutputTruncated
+	}
+	return string(output)
+}
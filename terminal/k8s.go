@@ -0,0 +1,43 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: ":k8s explain" runs kubectl directly, without a shell, so its arguments cannot chain other commands.
+// Only verbs that read the cluster are allowed, and secrets are refused, since the output is sent to the AI.
+
+package terminal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// kubectlVerbs lists the kubectl verbs that ":k8s explain" runs. They never change the cluster.
+var kubectlVerbs = map[string]bool{
+	"get":      true,
+	"describe": true,
+	"logs":     true,
+}
+
+// kubectlWaitingFlags lists the flags that keep kubectl running until it is stopped.
+var kubectlWaitingFlags = []string{"-f", "--follow", "-w", "--watch", "--watch-only"}
+
+// checkKubectlArgs checks that the kubectl arguments start with an allowed verb, do not read secrets,
+// and do not wait for changes.
+func checkKubectlArgs(args []string) error {
+	if !kubectlVerbs[args[0]] {
+		return fmt.Errorf(ErrorKubectlVerbNotAllowed, args[0])
+	}
+	for _, arg := range args[1:] {
+		if strings.Contains(strings.ToLower(arg), "secret") {
+			return fmt.Errorf(ErrorKubectlSecretsNotAllowed, arg)
+		}
+		flag, _, _ := strings.Cut(arg, "=")
+		for _, waiting := range kubectlWaitingFlags {
+			if flag == waiting {
+				return fmt.Errorf(ErrorKubectlFlagNotAllowed, flag)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,400 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: The line editor switches the terminal to non-canonical mode without echo for as long as the program runs,
+// so it echoes what is typed itself. Signals are still generated by the terminal, so Ctrl+C works as before.
+// The terminal is restored by the cleanup of the session (see PromptQueue.RestoreTerminal).
+
+//go:build unix
+// +build unix
+
+package terminal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/H0llyW00dzZ/GoGenAI-Terminal-Chat/terminal/linux_or_unix"
+	"golang.org/x/sys/unix"
+)
+
+// lineEditor reads the lines typed on a terminal with line editing: the left and right arrow keys move the cursor,
+// the up and down arrow keys recall the previous inputs, and Ctrl+A and Ctrl+E jump to the start and the end.
+type lineEditor struct {
+	reader  *bufio.Reader
+	output  io.Writer
+	fd      int
+	history *linux_or_unix.InputHistoryManager
+	// pending holds the keys that were typed ahead of the answer to a cursor position request (see startColumn).
+	pending []rune
+	// noCursorPosition is set once the terminal did not answer a cursor position request, so it is not asked again.
+	noCursorPosition bool
+	// mu protects saved, the state of the terminal before the editor changed it, or nil once it is restored.
+	mu    sync.Mutex
+	saved *unix.Termios
+}
+
+// newLineReader returns a line editor if both the input and the output are a terminal that can move the cursor,
+// and reads the input as it is otherwise (e.g., piped input or the input set by SetIO).
+func newLineReader(input io.Reader, output io.Writer) inputLineReader {
+	reader := bufio.NewReader(input)
+	file, ok := input.(*os.File)
+	outputFile, isFile := output.(*os.File)
+	if !ok || !isFile || os.Getenv(TermEnv) == DumbTerminal || !isTerminal(int(outputFile.Fd())) {
+		return &bufferedLineReader{reader: reader}
+	}
+	fd := int(file.Fd())
+	saved, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		return &bufferedLineReader{reader: reader}
+	}
+
+	raw := *saved
+	raw.Lflag &^= unix.ICANON | unix.ECHO
+	raw.Cc[unix.VMIN] = 1
+	raw.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, ioctlSetTermios, &raw); err != nil {
+		logger.Debug(DebugNoLineEditor, err)
+		return &bufferedLineReader{reader: reader}
+	}
+	return &lineEditor{
+		reader:  reader,
+		output:  output,
+		fd:      fd,
+		history: linux_or_unix.NewInputHistoryManager(),
+		saved:   saved,
+	}
+}
+
+// isTerminal reports whether the file descriptor is a terminal.
+func isTerminal(fd int) bool {
+	_, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	return err == nil
+}
+
+// ReadLine reads the next line, echoing and editing it as it is typed. A line that is not blank is added to the
+// input history. Ctrl+D on an empty line ends the input, like in canonical mode.
+//
+// Note: The positions on the terminal are counted in columns from the start of the row the line starts on, so a line
+// longer than the terminal is wide wraps across rows, and wide characters (e.g., CJK) take two columns.
+func (e *lineEditor) ReadLine() (string, error) {
+	var line []rune
+	cursor := 0
+	start := -1 // The column the line starts at, asked once the first key is typed.
+	for {
+		r, err := e.readRune()
+		if err != nil {
+			return "", err
+		}
+		if start < 0 {
+			start = e.startColumn()
+		}
+		width := terminalWidth()
+		from := columnOf(line, cursor, start, width)
+		switch r {
+		case '\r', '\n':
+			end := columnOf(line, len(line), start, width)
+			moveCursor(e.output, from, end, width)
+			if end <= start || end%width != 0 {
+				fmt.Fprintln(e.output) // Otherwise, the cursor is already on the next row (see wrapAtRowEnd).
+			}
+			text := string(line)
+			if strings.TrimSpace(text) != "" {
+				e.history.Add(text)
+			}
+			return text, nil
+		case KeyCtrlD:
+			if len(line) == 0 {
+				fmt.Fprintln(e.output)
+				return "", io.EOF
+			}
+			if cursor < len(line) {
+				line = append(line[:cursor], line[cursor+1:]...)
+			}
+		case KeyBackspace, KeyDelete:
+			if cursor > 0 {
+				line = append(line[:cursor-1], line[cursor:]...)
+				cursor--
+			}
+		case KeyCtrlA:
+			cursor = 0
+		case KeyCtrlE:
+			cursor = len(line)
+		case KeyCtrlK:
+			line = line[:cursor]
+		case KeyCtrlU:
+			line = append([]rune{}, line[cursor:]...)
+			cursor = 0
+		case BinaryAnsiChar:
+			line, cursor = e.handleEscapeSequence(line, cursor)
+		default:
+			if r == '\t' {
+				r = ' '
+			}
+			if r < ' ' {
+				continue // Other control characters are ignored.
+			}
+			line = append(line[:cursor], append([]rune{r}, line[cursor:]...)...)
+			cursor++
+			if cursor == len(line) {
+				// Typing at the end of the line only needs the new character to be printed.
+				fmt.Fprint(e.output, string(r))
+				wrapAtRowEnd(e.output, columnOf(line, cursor, start, width), start, width)
+				continue
+			}
+		}
+		e.redraw(line, from, cursor, start, width)
+	}
+}
+
+// readRune returns the next key, starting with the keys that were typed ahead of a cursor position answer.
+func (e *lineEditor) readRune() (rune, error) {
+	if len(e.pending) > 0 {
+		r := e.pending[0]
+		e.pending = e.pending[1:]
+		return r, nil
+	}
+	r, _, err := e.reader.ReadRune()
+	return r, err
+}
+
+// startColumn asks the terminal for the column of the cursor, where the line being typed starts, since the prompt
+// before it is printed by the session. The keys typed ahead of the answer are kept for ReadLine. If the terminal does
+// not answer within CursorPositionTimeout, the line is assumed to start at the first column.
+func (e *lineEditor) startColumn() int {
+	if e.noCursorPosition {
+		return 0
+	}
+	io.WriteString(e.output, CursorPositionRequest)
+	var typed []rune
+	defer func() { e.pending = append(e.pending, typed...) }()
+	for {
+		r, ok := e.readRuneWithin(CursorPositionTimeout)
+		if !ok {
+			e.noCursorPosition = true
+			return 0
+		}
+		if r != BinaryAnsiChar {
+			typed = append(typed, r)
+			continue
+		}
+
+		// The escape sequence is either the answer or a key typed ahead of it (e.g., an arrow key).
+		sequence, ok := e.readEscapeSequence()
+		if ok && len(sequence) > 2 && sequence[0] == BinaryLeftSquareBracket && sequence[len(sequence)-1] == 'R' {
+			var row, column int
+			if n, _ := fmt.Sscanf(string(sequence[1:len(sequence)-1]), CursorPositionReport, &row, &column); n == 2 {
+				return column - 1
+			}
+		}
+		typed = append(append(typed, r), sequence...)
+		if !ok {
+			e.noCursorPosition = true
+			return 0
+		}
+	}
+}
+
+// readEscapeSequence reads the rest of an escape sequence after its escape character, waiting for each rune for at
+// most CursorPositionTimeout. It returns false with the runes read so far if the sequence was cut short.
+func (e *lineEditor) readEscapeSequence() ([]rune, bool) {
+	introducer, ok := e.readRuneWithin(CursorPositionTimeout)
+	if !ok {
+		return nil, false
+	}
+	sequence := []rune{introducer}
+	switch introducer {
+	case BinaryLeftSquareBracket:
+		// A control sequence ends with its final byte.
+		for !isFinalByte(sequence[len(sequence)-1]) || len(sequence) == 1 {
+			r, ok := e.readRuneWithin(CursorPositionTimeout)
+			if !ok {
+				return sequence, false
+			}
+			sequence = append(sequence, r)
+		}
+	case 'O':
+		// A function key (e.g., Home) has a single rune after the introducer.
+		r, ok := e.readRuneWithin(CursorPositionTimeout)
+		if !ok {
+			return sequence, false
+		}
+		sequence = append(sequence, r)
+	}
+	return sequence, true
+}
+
+// readRuneWithin reads the next rune from the terminal, waiting for at most the timeout. It returns false if
+// nothing was typed in time or the input failed.
+func (e *lineEditor) readRuneWithin(timeout time.Duration) (rune, bool) {
+	if e.reader.Buffered() == 0 {
+		fds := []unix.PollFd{{Fd: int32(e.fd), Events: unix.POLLIN}}
+		n, err := unix.Poll(fds, int(timeout.Milliseconds()))
+		for err == unix.EINTR {
+			n, err = unix.Poll(fds, int(timeout.Milliseconds()))
+		}
+		if err != nil || n == 0 {
+			return 0, false
+		}
+	}
+	r, _, err := e.reader.ReadRune()
+	return r, err == nil
+}
+
+// isFinalByte reports whether the rune ends a control sequence.
+func isFinalByte(r rune) bool {
+	return r >= 0x40 && r <= 0x7e
+}
+
+// handleEscapeSequence reads the rest of an escape sequence, such as an arrow key, and applies it to the line.
+// Unknown sequences are read and ignored.
+func (e *lineEditor) handleEscapeSequence(line []rune, cursor int) ([]rune, int) {
+	introducer, err := e.readRune()
+	if err != nil || (introducer != BinaryLeftSquareBracket && introducer != 'O') {
+		return line, cursor
+	}
+	var params strings.Builder
+	final := rune(0)
+	for final == 0 {
+		c, err := e.readRune()
+		if err != nil {
+			return line, cursor
+		}
+		if isFinalByte(c) {
+			final = c
+		} else {
+			params.WriteRune(c)
+		}
+	}
+
+	switch {
+	case final == 'A':
+		return e.recall(line, true)
+	case final == 'B':
+		return e.recall(line, false)
+	case final == 'C':
+		return line, min(cursor+1, len(line))
+	case final == 'D':
+		return line, max(cursor-1, 0)
+	case final == 'H', final == '~' && (params.String() == "1" || params.String() == "7"):
+		return line, 0
+	case final == 'F', final == '~' && (params.String() == "4" || params.String() == "8"):
+		return line, len(line)
+	case final == '~' && params.String() == "3" && cursor < len(line):
+		return append(line[:cursor], line[cursor+1:]...), cursor
+	}
+	return line, cursor
+}
+
+// recall replaces the line with the previous (or next) input from the history, with the cursor at its end.
+// The line being typed is kept, and comes back after the most recent input.
+func (e *lineEditor) recall(line []rune, previous bool) ([]rune, int) {
+	history := e.history
+	switch {
+	case previous && history.CurrentIndex < len(history.History)-1:
+		if history.CurrentIndex < 0 {
+			history.UpdateCurrentInput(string(line))
+		}
+		line = []rune(history.Previous())
+	case !previous && history.CurrentIndex >= 0:
+		line = []rune(history.Next())
+		if history.CurrentIndex < 0 {
+			line = []rune(history.CurrentInput)
+		}
+	}
+	return line, len(line)
+}
+
+// redraw prints the line again from its start, after moving the cursor there from its previous column (see columnOf).
+// What is left of the previous line is erased, also on the rows below it, and the cursor is moved to its new position.
+func (e *lineEditor) redraw(line []rune, from, cursor, start, width int) {
+	var builder strings.Builder
+	moveCursor(&builder, from, start, width)
+	builder.WriteString(string(line))
+	end := columnOf(line, len(line), start, width)
+	wrapAtRowEnd(&builder, end, start, width)
+	builder.WriteString(EraseToEndOfScreen)
+	moveCursor(&builder, end, columnOf(line, cursor, start, width), width)
+	io.WriteString(e.output, builder.String())
+}
+
+// moveCursor writes the control sequences that move the cursor from one column of the line to another one
+// (see columnOf), across the rows of the terminal the line wraps over.
+func moveCursor(w io.Writer, from, to, width int) {
+	switch rows := to/width - from/width; {
+	case rows < 0:
+		fmt.Fprintf(w, CursorUpFormat, -rows)
+	case rows > 0:
+		fmt.Fprintf(w, CursorDownFormat, rows)
+	}
+	switch columns := to%width - from%width; {
+	case columns < 0:
+		fmt.Fprintf(w, CursorLeftFormat, -columns)
+	case columns > 0:
+		fmt.Fprintf(w, CursorRightFormat, columns)
+	}
+}
+
+// columnOf returns the column of the cursor before the rune at the index of the line (or after its end), counted from
+// the start of the row the line starts on, which starts at the start column. The row is the column divided by the
+// width of the terminal. A wide character that does not fit at the end of a row is wrapped to the next row.
+func columnOf(line []rune, index, start, width int) int {
+	column := start
+	for i, r := range line {
+		w := runeWidth(r)
+		if column%width+w > width {
+			column += width - column%width
+		}
+		if i == index {
+			return column
+		}
+		column += w
+	}
+	return column
+}
+
+// wrapAtRowEnd moves the cursor to the start of the next row if the line ends right at the end of a row. The terminal
+// keeps the cursor on the last column of that row until the next character is printed, where columnOf would not
+// find it.
+func wrapAtRowEnd(w io.Writer, end, start, width int) {
+	if end > start && end%width == 0 {
+		io.WriteString(w, NextRow)
+	}
+}
+
+// runeWidth returns the number of columns the terminal uses for the rune: none for combining marks, two for wide
+// characters (e.g., CJK and most emoji), and one otherwise.
+func runeWidth(r rune) int {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case r >= 0xff61 && r <= 0xffdc: // Halfwidth Katakana and Hangul
+		return 1
+	case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul),
+		r >= 0xff01 && r <= 0xff60, r >= 0xffe0 && r <= 0xffe6, // Fullwidth forms
+		r >= 0x1f300 && r <= 0x1faff: // Emoji and pictographs
+		return 2
+	}
+	return 1
+}
+
+// Restore switches the terminal back to the mode it was in before the line editor changed it.
+// It is safe to call more than once.
+func (e *lineEditor) Restore() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.saved == nil {
+		return
+	}
+	if err := unix.IoctlSetTermios(e.fd, ioctlSetTermios, e.saved); err != nil {
+		logger.Debug(DebugTerminalNotRestored, err)
+	}
+	e.saved = nil
+}
@@ -0,0 +1,19 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+
+//go:build !unix && !windows
+// +build !unix,!windows
+
+package terminal
+
+import (
+	"bufio"
+	"io"
+)
+
+// newLineReader reads the input as it is, since platforms other than unix and Windows (e.g., js/wasm or plan9)
+// have no terminal that the line editor can switch to non-canonical mode.
+func newLineReader(input io.Reader, output io.Writer) inputLineReader {
+	return &bufferedLineReader{reader: bufio.NewReader(input)}
+}
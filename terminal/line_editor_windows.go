@@ -0,0 +1,19 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+
+//go:build windows
+// +build windows
+
+package terminal
+
+import (
+	"bufio"
+	"io"
+)
+
+// newLineReader reads the input as it is. The Windows console edits the line being typed itself, and recalls
+// the previous inputs with the arrow keys, so no line editor is needed.
+func newLineReader(input io.Reader, output io.Writer) inputLineReader {
+	return &bufferedLineReader{reader: bufio.NewReader(input)}
+}
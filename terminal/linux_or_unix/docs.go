@@ -27,8 +27,8 @@
 //  previousInput := manager.Previous() // retrieves "second command"
 //  nextInput := manager.Next()         // retrieves ""
 
-// Note that the package is built only on non-Windows platforms. If you need similar
-// functionality for Windows, you should create a corresponding package that handles
-// input history according to Windows terminal behavior.
+// Note that the package is built only on non-Windows platforms. On Windows, the console
+// recalls the previous inputs with the arrow keys itself; its counterpart, the windows
+// package, prepares the console for escape codes instead.
 
 package linux_or_unix
@@ -56,7 +56,7 @@ func (m *InputHistoryManager) UpdateCurrentInput(input string) {
 // NewInputHistoryManager creates and returns a new instance of InputHistoryManager.
 // It initializes the history with an empty slice and sets the current index to -1,
 // indicating that no history entry is currently selected.
-// TODO: Used it for Arrow Up and Down to retrieve previous and next input.
+// It is used by the line editor of the terminal package for the Up and Down arrow keys.
 func NewInputHistoryManager() *InputHistoryManager {
 	return &InputHistoryManager{
 		History:      make([]string, 0),
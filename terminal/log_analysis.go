@@ -0,0 +1,163 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: Logs are read with runTool, without a shell, and long logs are split with ChunkText. The errors of each part are
+// collected without the chat history first, so only the final diagnosis and the command are added to the history.
+
+package terminal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// syslogFiles lists the system log files read when journalctl is not available, in order of preference.
+var syslogFiles = []string{"/var/log/syslog", "/var/log/messages"}
+
+// analyzeLogs asks the AI to diagnose the errors in the logs of source (e.g., "container web").
+// The logs are inspected by the outbound filter first. Logs longer than LogsChunkMaxTokens are analyzed part by part,
+// and the AI then diagnoses the errors collected from all parts.
+func (s *Session) analyzeLogs(command, source, logs string) (bool, error) {
+	logs, ok := s.filterOutbound(logs)
+	if !ok {
+		return false, nil
+	}
+	if strings.TrimSpace(logs) == "" {
+		logger.Any(InfoNoLogs, source)
+		return false, nil
+	}
+	chunks, err := ChunkText(logs, ChunkOptions{MaxTokens: LogsChunkMaxTokens, OverlapTokens: DefaultChunkOverlapTokens})
+	if err != nil {
+		return false, err
+	}
+	if len(chunks) == 1 {
+		return executeCommand(s, command, func(string) string {
+			return fmt.Sprintf(LogsDiagnosePrompt, source, logs)
+		})
+	}
+
+	s.notify(InfoAnalyzingLogs, len(chunks))
+	findings := make([]string, 0, len(chunks))
+	for i, chunk := range chunks {
+		ctx, done := s.beginTimedOperation(SummarizeTimeout)
+		finding, err := sendFanoutPrompt(ctx, s.ConfigureModelForSession(ctx), fmt.Sprintf(LogsChunkPrompt, i+1, len(chunks), source, chunk.Text))
+		done()
+		if err != nil {
+			return false, deadlineError(ctx, command, SummarizeTimeout, fmt.Errorf(ErrorFailedToAnalyzeLogs, i+1, err))
+		}
+		findings = append(findings, fmt.Sprintf(LogsFindingFormat, i+1, strings.TrimSpace(finding)))
+	}
+	return executeCommand(s, command, func(string) string {
+		return fmt.Sprintf(LogsFindingsPrompt, source, strings.Join(findings, StringNewLine+StringNewLine))
+	})
+}
+
+// parseLogLines parses the optional number of log lines of a command, DefaultLogLines if it is empty.
+func parseLogLines(arg string) (int, error) {
+	if arg == "" {
+		return DefaultLogLines, nil
+	}
+	lines, err := strconv.Atoi(arg)
+	if err != nil || lines <= 0 {
+		return 0, fmt.Errorf(ErrorInvalidLogLines, arg)
+	}
+	return lines, nil
+}
+
+// dockerLogsArgs returns the docker arguments that read the most recent log lines of the container, or of the
+// Docker Compose service for ComposeArgs, from ":docker logs <container> [lines]" or ":docker compose <service> [lines]".
+func dockerLogsArgs(parts []string) ([]string, error) {
+	name := parts[2]
+	// A name starting with a dash would be read as a flag of docker.
+	if strings.HasPrefix(name, "-") {
+		return nil, fmt.Errorf(ErrorInvalidContainerName, name)
+	}
+	lineArg := ""
+	if len(parts) > 3 {
+		lineArg = parts[3]
+	}
+	lines, err := parseLogLines(lineArg)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{LogsArgs, "--tail", strconv.Itoa(lines), "--timestamps", name}
+	if parts[1] == ComposeArgs {
+		args = append([]string{ComposeArgs, LogsArgs, "--no-color"}, args[1:]...)
+	}
+	return args, nil
+}
+
+// readSystemLogs returns the most recent lines of the system journal, or of the unit if it is not empty.
+// Without journalctl, they are read from the end of a syslog file, keeping the lines that mention the unit.
+func readSystemLogs(ctx context.Context, unit string) (string, error) {
+	if _, err := exec.LookPath(JournalctlBinary); err == nil {
+		// Note: The unit is part of its flag, so it is never read as a flag of its own.
+		args := []string{"--no-pager", "--quiet", "--output=short-iso", "--lines=" + strconv.Itoa(DefaultLogLines)}
+		if unit != "" {
+			args = append(args, "--unit="+unit)
+		}
+		return runTool(ctx, JournalctlBinary, args, LogsMaxOutput)
+	}
+
+	for _, path := range syslogFiles {
+		content, err := readFileTail(path, LogsMaxOutput)
+		if err != nil {
+			continue
+		}
+		lines := strings.Split(strings.TrimRight(content, StringNewLine), StringNewLine)
+		if name := strings.TrimSuffix(unit, ".service"); name != "" {
+			lines = slices.DeleteFunc(lines, func(line string) bool { return !strings.Contains(line, name) })
+		}
+		return strings.Join(lines[max(0, len(lines)-DefaultLogLines):], StringNewLine), nil
+	}
+	return "", errors.New(ErrorNoSystemLog)
+}
+
+// readFileTail returns the last maxBytes of the file, starting at a full line.
+func readFileTail(path string, maxBytes int64) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	offset := max(0, info.Size()-maxBytes)
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return "", err
+	}
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+	text := string(content)
+	if offset > 0 {
+		// The first line was most likely cut.
+		_, text, _ = strings.Cut(text, StringNewLine)
+	}
+	return text, nil
+}
+
+// systemLogRedactionFilter returns the redaction filter of system logs, which masks hostnames and IP addresses
+// (see hostnameRedactionRules) and the name of this machine.
+func systemLogRedactionFilter() *OutboundFilter {
+	filter, _ := NewRedactionFilter(RedactionProfileHostnames)
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		// Syslog usually shows the short name of the machine, without its domain.
+		short, _, _ := strings.Cut(hostname, ".")
+		pattern := `\b(?:` + regexp.QuoteMeta(hostname) + `|` + regexp.QuoteMeta(short) + `)\b`
+		filter.AddRule(NewRegexRule(RuleLocalHostname, pattern, FilterMask, MaskHostname))
+	}
+	return filter
+}
@@ -0,0 +1,312 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+
+package terminal
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// latexSymbols maps LaTeX commands to their Unicode characters.
+var latexSymbols = map[string]string{
+	// Greek letters
+	"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ε", "varepsilon": "ε",
+	"zeta": "ζ", "eta": "η", "theta": "θ", "vartheta": "ϑ", "iota": "ι", "kappa": "κ",
+	"lambda": "λ", "mu": "μ", "nu": "ν", "xi": "ξ", "pi": "π", "rho": "ρ", "sigma": "σ",
+	"tau": "τ", "upsilon": "υ", "phi": "φ", "varphi": "φ", "chi": "χ", "psi": "ψ", "omega": "ω",
+	"Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ", "Xi": "Ξ", "Pi": "Π",
+	"Sigma": "Σ", "Upsilon": "Υ", "Phi": "Φ", "Psi": "Ψ", "Omega": "Ω",
+	// Operators and relations
+	"times": "×", "cdot": "⋅", "div": "÷", "pm": "±", "mp": "∓", "ast": "∗", "circ": "∘",
+	"leq": "≤", "le": "≤", "geq": "≥", "ge": "≥", "neq": "≠", "ne": "≠", "approx": "≈",
+	"equiv": "≡", "sim": "∼", "simeq": "≃", "propto": "∝", "ll": "≪", "gg": "≫",
+	"sum": "∑", "prod": "∏", "int": "∫", "oint": "∮", "partial": "∂", "nabla": "∇",
+	"infty": "∞", "degree": "°", "prime": "′",
+	// Sets and logic
+	"in": "∈", "notin": "∉", "ni": "∋", "subset": "⊂", "subseteq": "⊆", "supset": "⊃",
+	"supseteq": "⊇", "cup": "∪", "cap": "∩", "emptyset": "∅", "varnothing": "∅",
+	"forall": "∀", "exists": "∃", "neg": "¬", "land": "∧", "wedge": "∧", "lor": "∨", "vee": "∨",
+	// Arrows
+	"to": "→", "rightarrow": "→", "leftarrow": "←", "gets": "←", "leftrightarrow": "↔",
+	"Rightarrow": "⇒", "implies": "⇒", "Leftarrow": "⇐", "Leftrightarrow": "⇔", "iff": "⇔",
+	"mapsto": "↦", "uparrow": "↑", "downarrow": "↓",
+	// Dots and spacing
+	"ldots": "…", "dots": "…", "cdots": "⋯", "vdots": "⋮", "ddots": "⋱",
+	",": " ", ";": " ", ":": " ", "!": "", " ": " ", "quad": " ", "qquad": "  ",
+	// Escaped characters
+	"{": "{", "}": "}", "%": "%", "$": "$", "&": "&", "#": "#", "_": "_",
+	"|": "‖", "lvert": "|", "rvert": "|", "langle": "⟨", "rangle": "⟩",
+	// Sizing commands have no Unicode equivalent and are dropped.
+	"left": "", "right": "", "big": "", "Big": "", "bigg": "", "Bigg": "", "displaystyle": "",
+}
+
+// latexTextCommands lists the LaTeX commands whose argument is rendered as it is.
+var latexTextCommands = map[string]bool{
+	"text": true, "textrm": true, "textbf": true, "textit": true, "mathrm": true, "mathbf": true,
+	"mathit": true, "mathsf": true, "mathtt": true, "operatorname": true, "boldsymbol": true,
+}
+
+// blackboardLetters maps letters to their blackboard bold form, as in \mathbb{R}.
+var blackboardLetters = map[rune]rune{
+	'C': 'ℂ', 'N': 'ℕ', 'P': 'ℙ', 'Q': 'ℚ', 'R': 'ℝ', 'Z': 'ℤ',
+}
+
+// superscripts maps characters to their superscript form.
+var superscripts = map[rune]rune{
+	'0': '⁰', '1': '¹', '2': '²', '3': '³', '4': '⁴', '5': '⁵', '6': '⁶', '7': '⁷', '8': '⁸', '9': '⁹',
+	'+': '⁺', '-': '⁻', '=': '⁼', '(': '⁽', ')': '⁾', '′': '′', '∗': '*', '*': '*', 'T': 'ᵀ',
+	'a': 'ᵃ', 'b': 'ᵇ', 'c': 'ᶜ', 'd': 'ᵈ', 'e': 'ᵉ', 'f': 'ᶠ', 'g': 'ᵍ', 'h': 'ʰ', 'i': 'ⁱ',
+	'j': 'ʲ', 'k': 'ᵏ', 'l': 'ˡ', 'm': 'ᵐ', 'n': 'ⁿ', 'o': 'ᵒ', 'p': 'ᵖ', 'r': 'ʳ', 's': 'ˢ',
+	't': 'ᵗ', 'u': 'ᵘ', 'v': 'ᵛ', 'w': 'ʷ', 'x': 'ˣ', 'y': 'ʸ', 'z': 'ᶻ',
+}
+
+// subscripts maps characters to their subscript form.
+var subscripts = map[rune]rune{
+	'0': '₀', '1': '₁', '2': '₂', '3': '₃', '4': '₄', '5': '₅', '6': '₆', '7': '₇', '8': '₈', '9': '₉',
+	'+': '₊', '-': '₋', '=': '₌', '(': '₍', ')': '₎',
+	'a': 'ₐ', 'e': 'ₑ', 'h': 'ₕ', 'i': 'ᵢ', 'j': 'ⱼ', 'k': 'ₖ', 'l': 'ₗ', 'm': 'ₘ', 'n': 'ₙ',
+	'o': 'ₒ', 'p': 'ₚ', 'r': 'ᵣ', 's': 'ₛ', 't': 'ₜ', 'u': 'ᵤ', 'v': 'ᵥ', 'x': 'ₓ',
+}
+
+// vulgarFractions maps simple fractions to their single character form.
+var vulgarFractions = map[string]string{
+	"1/2": "½", "1/3": "⅓", "2/3": "⅔", "1/4": "¼", "3/4": "¾", "1/5": "⅕", "2/5": "⅖",
+	"3/5": "⅗", "4/5": "⅘", "1/6": "⅙", "5/6": "⅚", "1/8": "⅛", "3/8": "⅜", "5/8": "⅝", "7/8": "⅞",
+}
+
+// renderMath replaces LaTeX math in text with a Unicode approximation, so formulas are readable
+// in the terminal. Inline math is written as $...$ or $$...$$ on a single line; display math is
+// a block of lines between lines that only contain $$.
+//
+// A dollar sign only opens inline math if it is followed by a non-space character, and only closes
+// it if it follows a non-space character and is not followed by a digit, so amounts like "$5 and $10"
+// are left as they are. Math in code blocks and code spans is never touched.
+func renderMath(text string) string {
+	lines := strings.Split(text, StringNewLine)
+	result := make([]string, 0, len(lines))
+	inCodeBlock := false
+	displayStart := -1 // Index in lines of the $$ line that opened a display block, if any
+	for i, line := range lines {
+		switch {
+		case strings.Count(line, TripleBacktick)%2 == 1:
+			inCodeBlock = !inCodeBlock
+			result = append(result, line)
+		case inCodeBlock:
+			result = append(result, line)
+		case strings.TrimSpace(line) == "$$":
+			if displayStart < 0 {
+				displayStart = i
+			} else {
+				displayStart = -1
+			}
+		case displayStart >= 0:
+			result = append(result, latexToUnicode(line))
+		default:
+			result = append(result, renderMathLine(line))
+		}
+	}
+	if displayStart >= 0 {
+		// The display block was never closed, so it was not math after all.
+		result = append(result[:len(result)-(len(lines)-displayStart-1)], lines[displayStart:]...)
+	}
+	return strings.Join(result, StringNewLine)
+}
+
+// renderMathLine renders the inline math of a single line.
+func renderMathLine(line string) string {
+	var result strings.Builder
+	last := 0
+	for i := 0; i < len(line); {
+		switch {
+		case line[i] == SingleBacktick[0]:
+			i = skipCodeSpan(line, i)
+			continue
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '$':
+			// An escaped dollar sign never opens math and is shown without the backslash.
+			result.WriteString(line[last:i])
+			i += 2
+			last = i - 1
+			continue
+		case strings.HasPrefix(line[i:], "$$"):
+			if end := strings.Index(line[i+2:], "$$"); end > 0 {
+				result.WriteString(line[last:i])
+				result.WriteString(latexToUnicode(line[i+2 : i+2+end]))
+				i = i + 2 + end + 2
+				last = i
+				continue
+			}
+		case line[i] == '$':
+			if end := closingDollar(line, i); end > 0 {
+				result.WriteString(line[last:i])
+				result.WriteString(latexToUnicode(line[i+1 : end]))
+				i = end + 1
+				last = i
+				continue
+			}
+		}
+		i++
+	}
+	if last == 0 {
+		return line
+	}
+	result.WriteString(line[last:])
+	return result.String()
+}
+
+// closingDollar returns the offset of the dollar sign that closes the inline math opened at i, or -1.
+func closingDollar(line string, i int) int {
+	if i+1 >= len(line) || line[i+1] == ' ' || line[i+1] == '$' {
+		return -1
+	}
+	for j := i + 2; j < len(line); j++ {
+		if line[j] != '$' || line[j-1] == ' ' || line[j-1] == '\\' {
+			continue
+		}
+		if j+1 < len(line) && line[j+1] >= '0' && line[j+1] <= '9' {
+			continue
+		}
+		return j
+	}
+	return -1
+}
+
+// latexToUnicode converts a LaTeX math expression to a Unicode approximation.
+// Unknown commands are kept as they are.
+func latexToUnicode(expr string) string {
+	var result strings.Builder
+	for i := 0; i < len(expr); {
+		switch c := expr[i]; c {
+		case '\\':
+			name, end := readLatexCommand(expr, i)
+			i = end
+			result.WriteString(renderLatexCommand(name, expr, &i))
+		case '^', '_':
+			var arg string
+			arg, i = readLatexGroup(expr, i+1)
+			if c == '^' {
+				result.WriteString(toScript(latexToUnicode(arg), superscripts, "^"))
+			} else {
+				result.WriteString(toScript(latexToUnicode(arg), subscripts, "_"))
+			}
+		case '{', '}':
+			i++ // Grouping braces are not shown.
+		default:
+			_, size := utf8.DecodeRuneInString(expr[i:])
+			result.WriteString(expr[i : i+size])
+			i += size
+		}
+	}
+	return result.String()
+}
+
+// renderLatexCommand renders the command name, reading its arguments from expr at *i.
+func renderLatexCommand(name, expr string, i *int) string {
+	switch {
+	case name == "frac" || name == "dfrac" || name == "tfrac":
+		var numerator, denominator string
+		numerator, *i = readLatexGroup(expr, *i)
+		denominator, *i = readLatexGroup(expr, *i)
+		return formatFraction(latexToUnicode(numerator), latexToUnicode(denominator))
+	case name == "sqrt":
+		var arg string
+		arg, *i = readLatexGroup(expr, *i)
+		return "√" + parenthesize(latexToUnicode(arg))
+	case name == "mathbb":
+		var arg string
+		arg, *i = readLatexGroup(expr, *i)
+		return strings.Map(func(r rune) rune {
+			if bb, ok := blackboardLetters[r]; ok {
+				return bb
+			}
+			return r
+		}, arg)
+	case latexTextCommands[name]:
+		var arg string
+		arg, *i = readLatexGroup(expr, *i)
+		return latexToUnicode(arg)
+	}
+	if symbol, ok := latexSymbols[name]; ok {
+		return symbol
+	}
+	return "\\" + name
+}
+
+// readLatexCommand reads the name of the command whose backslash is at i.
+// It returns the name and the offset just past it.
+func readLatexCommand(expr string, i int) (string, int) {
+	end := i + 1
+	for end < len(expr) && unicode.IsLetter(rune(expr[end])) {
+		end++
+	}
+	if end == i+1 && end < len(expr) {
+		end++ // A single non-letter, as in "\," or "\{"
+	}
+	return expr[i+1 : end], end
+}
+
+// readLatexGroup reads the argument starting at i: a group in braces, a command or a single character.
+// It returns the argument and the offset just past it.
+func readLatexGroup(expr string, i int) (string, int) {
+	for i < len(expr) && expr[i] == ' ' {
+		i++
+	}
+	if i >= len(expr) {
+		return "", i
+	}
+	switch expr[i] {
+	case '{':
+		depth := 0
+		for j := i; j < len(expr); j++ {
+			switch expr[j] {
+			case '{':
+				depth++
+			case '}':
+				if depth--; depth == 0 {
+					return expr[i+1 : j], j + 1
+				}
+			}
+		}
+		return expr[i+1:], len(expr)
+	case '\\':
+		_, end := readLatexCommand(expr, i)
+		return expr[i:end], end
+	}
+	_, size := utf8.DecodeRuneInString(expr[i:])
+	return expr[i : i+size], i + size
+}
+
+// toScript converts text to superscript or subscript characters. If a character has no such
+// form, the text is written after the marker instead, in parentheses if it is longer than one character.
+func toScript(text string, script map[rune]rune, marker string) string {
+	var result strings.Builder
+	for _, r := range text {
+		s, ok := script[r]
+		if !ok && utf8.RuneCountInString(text) > 1 {
+			return marker + "(" + text + ")"
+		}
+		if !ok {
+			return marker + text
+		}
+		result.WriteRune(s)
+	}
+	return result.String()
+}
+
+// formatFraction renders a fraction, using a single character for common fractions like ½.
+func formatFraction(numerator, denominator string) string {
+	if fraction, ok := vulgarFractions[numerator+"/"+denominator]; ok {
+		return fraction
+	}
+	return parenthesize(numerator) + "/" + parenthesize(denominator)
+}
+
+// parenthesize wraps text in parentheses if it contains spaces or operators.
+func parenthesize(text string) string {
+	if !strings.ContainsAny(text, " +-=/⋅×÷±,") {
+		return text
+	}
+	return "(" + text + ")"
+}
@@ -0,0 +1,216 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: Remembered facts are a long-term memory shared by all sessions. They are stored in "memory.json" next to the config file
+// and, unlike the chat history, never trimmed: the most recent facts (MEMORY_FACTS, 10 by default) are sent as a system message
+// before the chat history with every message, so they survive the rolling history and are never stored in a session.
+// With MEMORY_EXTRACT enabled, summarizing or compacting the conversation also extracts the durable facts about the user from it.
+
+package terminal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// memoryPath returns the location of the memory file, next to the application config file.
+func memoryPath() (string, error) {
+	configPath, err := AppConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configPath), MemoryFile), nil
+}
+
+// LoadMemory reads the remembered facts from the file at path. A missing file is an empty memory.
+func LoadMemory(path string) (*Memory, error) {
+	memory := &Memory{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return memory, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, memory); err != nil {
+		return nil, fmt.Errorf(ErrorInvalidMemoryFile, path, err)
+	}
+	return memory, nil
+}
+
+// Save writes the remembered facts to the file at path. The file is replaced atomically,
+// so a crash while saving never loses the facts that were already remembered.
+func (m *Memory) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmpPath := path + SessionTempExtension
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// Remember adds the fact to the memory and returns it. A fact that is already remembered, ignoring case,
+// is returned as it is instead of being added again, in which case added is false.
+func (m *Memory) Remember(text string) (fact MemoryFact, added bool) {
+	for _, fact := range m.Facts {
+		if strings.EqualFold(fact.Text, text) {
+			return fact, false
+		}
+	}
+	m.NextID++
+	fact = MemoryFact{ID: m.NextID, Text: text, CreatedAt: time.Now()}
+	m.Facts = append(m.Facts, fact)
+	return fact, true
+}
+
+// Forget removes the fact with the given ID from the memory. It reports whether the fact was found.
+func (m *Memory) Forget(id int) bool {
+	i := slices.IndexFunc(m.Facts, func(fact MemoryFact) bool { return fact.ID == id })
+	if i < 0 {
+		return false
+	}
+	m.Facts = slices.Delete(m.Facts, i, i+1)
+	return true
+}
+
+// recent returns the n most recently remembered facts, oldest first.
+func (m *Memory) recent(n int) []MemoryFact {
+	return m.Facts[max(0, len(m.Facts)-n):]
+}
+
+// loadMemory loads the remembered facts into the session. Failures are reported but never end the session,
+// which then continues without them.
+func (s *Session) loadMemory() {
+	path, err := memoryPath()
+	if err == nil {
+		s.memory, err = LoadMemory(path)
+	}
+	if err != nil {
+		logger.Error(ErrorFailedToLoadMemory, err)
+	}
+}
+
+// remember adds the facts to the memory file and to the session, and returns the facts that were not remembered yet.
+func (s *Session) remember(texts ...string) ([]MemoryFact, error) {
+	var added []MemoryFact
+	err := s.updateMemory(func(memory *Memory) error {
+		for _, text := range texts {
+			if fact, ok := memory.Remember(text); ok {
+				added = append(added, fact)
+			}
+		}
+		return nil
+	})
+	return added, err
+}
+
+// forget removes the fact with the given ID from the memory file and from the session.
+func (s *Session) forget(id int) error {
+	return s.updateMemory(func(memory *Memory) error {
+		if !memory.Forget(id) {
+			return fmt.Errorf(ErrorFactNotFound, id)
+		}
+		return nil
+	})
+}
+
+// updateMemory changes the memory file with update and saves it, unless update returns an error.
+// The file is read again first, so the changes made by another running instance are kept.
+func (s *Session) updateMemory(update func(memory *Memory) error) error {
+	path, err := memoryPath()
+	if err != nil {
+		return err
+	}
+	memory, err := LoadMemory(path)
+	if err != nil {
+		return err
+	}
+	if err := update(memory); err != nil {
+		return err
+	}
+	if err := memory.Save(path); err != nil {
+		return err
+	}
+	s.memory = memory
+	return nil
+}
+
+// extractMemoryFacts asks the AI for the durable facts about the user and their preferences in the conversation,
+// and remembers them. It does nothing unless MEMORY_EXTRACT is enabled. Since it runs after the conversation was
+// summarized, failures are only reported and never fail the summary.
+func (s *Session) extractMemoryFacts(ctx context.Context, conversation string) {
+	if appConfig == nil || !appConfig.ExtractMemoryFacts || s.DryRun {
+		return
+	}
+	prompt := conversation + StringNewLine + promptTemplate(PromptTemplateExtractFacts)
+	response, err := sendFanoutPrompt(ctx, s.ConfigureModelForSession(ctx), s.ChatHistory.SanitizeMessage(prompt))
+	if err != nil {
+		logger.Error(ErrorFailedToExtractFacts, err)
+		return
+	}
+	added, err := s.remember(parseMemoryFacts(response)...)
+	if err != nil {
+		logger.Error(ErrorFailedToExtractFacts, err)
+		return
+	}
+	if len(added) > 0 {
+		s.notify(InfoFactsExtracted, len(added), MemoryCommand)
+	}
+}
+
+// parseMemoryFacts returns the facts listed in the response of the AI, one per line.
+// Only list items are facts, so a response of "NONE" or an introduction has none.
+func parseMemoryFacts(response string) []string {
+	var facts []string
+	for _, line := range strings.Split(response, StringNewLine) {
+		line = strings.TrimSpace(line)
+		fact := strings.TrimSpace(strings.TrimLeft(line, MemoryFactMarkers))
+		if fact == "" || fact == line {
+			continue
+		}
+		facts = append(facts, fact)
+	}
+	return facts
+}
+
+// formatMemoryFacts lists the remembered facts with their IDs and when they were remembered.
+func formatMemoryFacts(facts []MemoryFact) string {
+	var builder strings.Builder
+	for _, fact := range facts {
+		fmt.Fprintf(&builder, MemoryFactListFormat, fact.ID, fact.Text, fact.CreatedAt.Format(time.DateOnly))
+	}
+	return builder.String()
+}
+
+// memoryContext returns the system message with the most recent remembered facts that is sent before the chat
+// history, or an empty string if there are none or remembered facts are disabled (MEMORY_FACTS=0).
+func (s *Session) memoryContext() string {
+	if s.memory == nil || appConfig == nil || appConfig.MemoryFacts <= 0 {
+		return ""
+	}
+	facts := s.memory.recent(appConfig.MemoryFacts)
+	if len(facts) == 0 {
+		return ""
+	}
+	var builder strings.Builder
+	builder.WriteString(MemoryPrefix)
+	for _, fact := range facts {
+		fmt.Fprintf(&builder, MemoryFactFormat, fact.Text)
+	}
+	return fmt.Sprintf(ObjectHighLevelStringWithNewLine, SYSTEMPREFIX, builder.String())
+}
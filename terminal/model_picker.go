@@ -0,0 +1,90 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: The picker lists the models available to the API key (see fetchAllModelInfo) instead of the supportedModels
+// map, so models released after this version can be picked too. A typed model name is still checked against the map.
+
+package terminal
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	genai "github.com/google/generative-ai-go/genai"
+)
+
+// chatModelNames returns the sorted names of the models that can chat, i.e., that support generating content.
+func chatModelNames(models []*genai.ModelInfo) []string {
+	var names []string
+	for _, info := range models {
+		if slices.Contains(info.SupportedGenerationMethods, GenerateContentMethod) {
+			names = append(names, strings.TrimPrefix(info.Name, ModelResourcePrefix))
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// pickModel lists the models available to the API key with numbers, and switches the session to the model
+// the user picks. An empty answer keeps the current model.
+func (cmd *handleSwitchModelCommand) pickModel(session *Session) (bool, error) {
+	var models []*genai.ModelInfo
+	operation := RetryableOperation{
+		retryFunc: func() (bool, error) {
+			var err error
+			models, err = fetchAllModelInfo(session.Ctx, session.Client)
+			return err == nil, err
+		},
+	}
+	if _, err := operation.retryWithExponentialBackoff(standardAPIErrorHandler); err != nil {
+		return false, fmt.Errorf(ErrorFailedToRetriveModelInfo, err)
+	}
+	names := chatModelNames(models)
+	if len(names) == 0 {
+		return false, fmt.Errorf(ErrorNoModelsToPick)
+	}
+
+	current := session.activeModelName()
+	fprintPrefixWithTimeStamp(session.Output, SYSTEMPREFIX, "")
+	fmt.Fprintf(session.Output, ListModelsHeader, len(names))
+	for i, name := range names {
+		marker := ""
+		if name == current {
+			marker = ModelPickerCurrent
+		}
+		fmt.Fprintf(session.Output, ModelPickerRow, i+1, name, marker)
+	}
+
+	name, err := session.askModelChoice(names, current)
+	if err != nil || name == current {
+		logger.Any(ModelUnchanged, current)
+		return false, nil // Continue the session.
+	}
+	session.CurrentModelName = name
+	logger.Any(SwitchedModel, name)
+	return false, nil // Continue the session.
+}
+
+// askModelChoice asks for the number of a model until the answer is one of the listed numbers, and returns
+// the name of that model. An empty answer, or the end of the input, returns the current model.
+func (s *Session) askModelChoice(names []string, current string) (string, error) {
+	for {
+		fprintPrefixWithTimeStamp(s.Output, SYSTEMPREFIX, "")
+		fmt.Fprintf(s.Output, ModelPickerPrompt, current)
+		answer, err := s.Input.ReadLine()
+		if err != nil {
+			return current, err
+		}
+		answer = strings.TrimSpace(answer)
+		if answer == "" {
+			return current, nil
+		}
+		if number, err := strconv.Atoi(answer); err == nil && number >= 1 && number <= len(names) {
+			return names[number-1], nil
+		}
+		logger.Error(ErrorInvalidModelChoice, answer, len(names))
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: The outbound filter complements SafetySettings. SafetySettings only filter what the model sends back,
+// while this filter inspects what the user is about to send before it ever leaves the machine.
+
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// NewOutboundFilter creates an OutboundFilter with the given rules.
+// The filter is only active when enabled is true; otherwise Apply returns the input unchanged.
+//
+// Parameters:
+//
+//	enabled bool: Whether the filter should inspect outbound content.
+//	rules ...OutboundRule: A variadic number of rules evaluated in the order they are provided.
+//
+// Returns:
+//
+//	*OutboundFilter: A pointer to the newly created OutboundFilter.
+func NewOutboundFilter(enabled bool, rules ...OutboundRule) *OutboundFilter {
+	return &OutboundFilter{
+		Enabled: enabled,
+		rules:   rules,
+	}
+}
+
+// DefaultOutboundFilter creates an OutboundFilter populated with DefaultOutboundRules.
+// It is enabled only when the OUTBOUND_FILTER environment variable is set to "true".
+func DefaultOutboundFilter() *OutboundFilter {
+	enabled := os.Getenv(EnableOutboundFilter) == "true"
+	return NewOutboundFilter(enabled, DefaultOutboundRules()...)
+}
+
+// DefaultOutboundRules returns the built-in set of outbound rules.
+// Secrets are blocked outright, while personal data and profanity are masked so the
+// rest of the prompt can still be sent.
+func DefaultOutboundRules() []OutboundRule {
+	return []OutboundRule{
+		NewRegexRule(RuleSecretKey, RegexSecretKey, FilterBlock, ""),
+		NewRegexRule(RuleEmail, RegexEmail, FilterMask, MaskEmail),
+		NewRegexRule(RuleCreditCard, RegexCreditCard, FilterMask, MaskCreditCard),
+		NewRegexRule(RulePhoneNumber, RegexPhoneNumber, FilterMask, MaskPhoneNumber),
+		NewRegexRule(RuleProfanity, RegexProfanity, FilterMask, MaskProfanity),
+	}
+}
+
+// AddRule appends a rule to the filter. Rules are evaluated in insertion order.
+func (f *OutboundFilter) AddRule(rule OutboundRule) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = append(f.rules, rule)
+}
+
+// Rules returns a copy of the rules currently registered in the filter.
+func (f *OutboundFilter) Rules() []OutboundRule {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return append([]OutboundRule(nil), f.rules...)
+}
+
+// Apply runs every rule against the text and returns the filtered result.
+//
+// Parameters:
+//
+//	text string: The outbound text (e.g., user input) to inspect.
+//
+// Returns:
+//
+//	string: The text with masked segments replaced.
+//	[]string: The names of the rules that masked content.
+//	error: A non-nil error if any rule blocked the text. The returned text must not be sent in that case.
+func (f *OutboundFilter) Apply(text string) (string, []string, error) {
+	if f == nil || !f.Enabled {
+		return text, nil, nil
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	var masked []string
+	for _, rule := range f.rules {
+		filtered, action := rule.Apply(text)
+		switch action {
+		case FilterBlock:
+			return "", masked, fmt.Errorf(ErrorOutboundContentBlocked, rule.Name())
+		case FilterMask:
+			masked = append(masked, rule.Name())
+			text = filtered
+		}
+	}
+	return text, masked, nil
+}
+
+// NewRegexRule creates a regular-expression-based OutboundRule.
+//
+// Parameters:
+//
+//	name string: A short identifier shown to the user when the rule triggers.
+//	pattern string: The regular expression to match. It is compiled once here.
+//	action FilterAction: Whether matches should be masked or the whole text blocked.
+//	replacement string: The text used in place of each match when masking.
+//
+// Returns:
+//
+//	*RegexRule: A pointer to the newly created rule.
+func NewRegexRule(name, pattern string, action FilterAction, replacement string) *RegexRule {
+	return &RegexRule{
+		RuleName:    name,
+		Pattern:     regexp.MustCompile(pattern),
+		Action:      action,
+		Replacement: replacement,
+	}
+}
+
+// Name returns the identifier of the rule.
+func (r *RegexRule) Name() string {
+	return r.RuleName
+}
+
+// Apply checks the text against the rule pattern. It returns FilterAllow when nothing matched.
+func (r *RegexRule) Apply(text string) (string, FilterAction) {
+	if !r.Pattern.MatchString(text) {
+		return text, FilterAllow
+	}
+	if r.Action == FilterBlock {
+		return text, FilterBlock
+	}
+	return r.Pattern.ReplaceAllString(text, r.Replacement), FilterMask
+}
+
+// filterOutbound applies the session's outbound filter to the given text and informs
+// the user if something was masked or blocked.
+// It returns the text that is safe to send and false if the text must not be sent.
+func (s *Session) filterOutbound(text string) (string, bool) {
+	filtered, masked, err := s.OutboundFilter.Apply(text)
+	if err != nil {
+		logger.Error(ErrorOutboundFilter, err)
+		return "", false
+	}
+	if len(masked) > 0 {
+		logger.Any(OutboundContentMasked, strings.Join(masked, dotStringComma))
+	}
+	return filtered, true
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+
+package terminal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// printPaged prints long output to the output of the session one page at a time, similar to "more".
+// After each page it waits for the user to press Enter to continue or "q" to stop.
+// Output that fits into a single page is printed directly without prompting.
+//
+// Parameters:
+//
+//	text string: The text to be displayed.
+//	pageSize int: The number of lines per page. Values below 1 fall back to DefaultPageSize.
+func (s *Session) printPaged(text string, pageSize int) {
+	if pageSize < 1 {
+		pageSize = DefaultPageSize
+	}
+	lines := strings.Split(strings.TrimRight(text, StringNewLine), StringNewLine)
+
+	for start := 0; start < len(lines); start += pageSize {
+		end := min(start+pageSize, len(lines))
+		fmt.Fprintln(s.Output, strings.Join(lines[start:end], StringNewLine))
+		if end == len(lines) {
+			return
+		}
+		fmt.Fprintf(s.Output, PagerPrompt, end, len(lines))
+		answer, err := s.Input.ReadLine()
+		if err != nil || strings.EqualFold(strings.TrimSpace(answer), PagerQuit) {
+			return
+		}
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: The persona is sent as the system instruction of the model, which only the Gemini 1.5 models accept.
+// For the other models, and with cached content (which does not accept a system instruction of its own), it is
+// sent as a system message before the conversation instead. Either way, it is not part of the chat history, so it
+// is never summarized, compacted or stored.
+
+package terminal
+
+import (
+	"fmt"
+	"strings"
+
+	genai "github.com/google/generative-ai-go/genai"
+)
+
+// supportsSystemInstruction reports whether the model accepts a system instruction.
+func supportsSystemInstruction(modelName string) bool {
+	return strings.HasPrefix(modelName, MultimodalModelPrefix)
+}
+
+// applySystemInstruction sets the persona of the session as the system instruction of the model, if it has one
+// and the model accepts it.
+func (s *Session) applySystemInstruction(model *genai.GenerativeModel, modelName string) {
+	if s.SystemInstruction == "" || !supportsSystemInstruction(modelName) {
+		return
+	}
+	model.SystemInstruction = genai.NewUserContent(genai.Text(s.expandedPersona()))
+}
+
+// personaContext returns the persona of the session as a system message for the models that were not given
+// it as their system instruction (see applySystemInstruction), or "" if there is nothing to send.
+func (s *Session) personaContext(model *genai.GenerativeModel) string {
+	if s.SystemInstruction == "" || model.SystemInstruction != nil {
+		return ""
+	}
+	return fmt.Sprintf(ObjectHighLevelStringWithNewLine, SYSTEMPREFIX, PersonaPrefix+s.expandedPersona())
+}
+
+// expandedPersona returns the persona of the session with its prompt variables (e.g., {{date}}) replaced by their
+// current values, since it is sent again with every message.
+func (s *Session) expandedPersona() string {
+	return expandPromptVariables(s.SystemInstruction, false)
+}
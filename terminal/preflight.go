@@ -0,0 +1,72 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+
+package terminal
+
+import (
+	"context"
+	"fmt"
+)
+
+// preflight counts the tokens of the chat history and the input before they are sent (see "preflight"), and warns
+// if they exceed "preflight_tokens" or the input token limit of the model. The user is then offered to compact
+// the chat history first; either way, the input is sent afterwards.
+func (s *Session) preflight(input string) {
+	if appConfig.Preflight == "" || s.DryRun {
+		return
+	}
+
+	// Note: The count and the compaction have their own deadlines, so the time spent answering is not counted.
+	ctx, done := s.beginTimedOperation(TokenCountTimeout)
+	tokens, estimated, limit := s.preflightTokens(ctx, input)
+	done()
+	count := fmt.Sprint(tokens)
+	if estimated {
+		count = "~" + count
+	}
+	switch {
+	case limit > 0 && tokens > limit:
+		logger.Any(WarningPreflightLimit, count, limit)
+	case appConfig.PreflightTokens > 0 && tokens > appConfig.PreflightTokens:
+		logger.Any(WarningPreflightThreshold, count, appConfig.PreflightTokens)
+	default:
+		return
+	}
+	if !s.confirm(PreflightConfirmation) {
+		return
+	}
+
+	ctx, done = s.beginTimedOperation(SummarizeTimeout)
+	defer done()
+	result, err := s.compactHistory(ctx, DefaultCompactKeep)
+	if err != nil {
+		if !reportTimeout(ctx, CompactCommand, SummarizeTimeout) {
+			logger.Error(ErrorFailedToCompact, err)
+		}
+		return
+	}
+	logger.Any(InfoCompacted, result.Removed, result.TokensBefore, result.TokensAfter, result.TokensBefore-result.TokensAfter)
+}
+
+// preflightTokens returns the tokens of the chat history and the input, whether they are an offline estimate,
+// and the input token limit of the current model (0 if it is unknown). With PreflightCount, the tokens are
+// counted with the API, falling back to the offline estimate if the API cannot be reached.
+func (s *Session) preflightTokens(ctx context.Context, input string) (tokens int, estimated bool, limit int) {
+	text := s.memoryContext() + s.ChatHistory.GetHistory(s.ChatConfig) + input
+	tokens, estimated = EstimateTokens(text), true
+	if appConfig.Preflight == PreflightCount {
+		params := TokenCountParams{APIKey: ResolveAPIKey(), ModelName: s.activeModelName(), Input: text}
+		if count, err := params.CountTokens(ctx); err == nil {
+			tokens, estimated = count, false
+		} else {
+			logger.Debug(DebugPreflightCountFailed, err)
+		}
+	}
+
+	limit, err := s.inputTokenLimit(ctx)
+	if err != nil {
+		logger.Debug(DebugPreflightLimitUnknown, err)
+	}
+	return tokens, estimated, limit
+}
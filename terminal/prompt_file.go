@@ -0,0 +1,48 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: A seed prompt is a system message of its own category (see systemMessageRules), so it is sent before the
+// conversation like the summary, and a new seed prompt replaces the previous one without removing the summary.
+
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// readPromptFiles reads the prompt files and returns their contents in order, separated by blank lines.
+// Only .txt and .md files are read, and empty files are skipped.
+func readPromptFiles(paths []string) (string, error) {
+	contents := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if ext := strings.ToLower(filepath.Ext(path)); ext != dotTxt && ext != dotMD {
+			return "", fmt.Errorf(ErrorInvalidPromptFile, path)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		if text := strings.TrimSpace(string(content)); text != "" {
+			contents = append(contents, text)
+		}
+	}
+	if len(contents) == 0 {
+		return "", fmt.Errorf(ErrorEmptyPromptFiles)
+	}
+	return strings.Join(contents, StringNewLine+StringNewLine), nil
+}
+
+// seedPrompt adds the prompt to the chat history as the seed prompt, after it is inspected by the outbound filter.
+// It reports whether the prompt was added.
+func (s *Session) seedPrompt(prompt string) bool {
+	prompt, ok := s.filterOutbound(prompt)
+	if !ok {
+		return false
+	}
+	s.recordMessage(SYSTEMPREFIX, SeedPromptPrefix+prompt)
+	return true
+}
@@ -0,0 +1,302 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: The prompt queue is the only reader of the standard input. Lines typed while a response is in flight
+// are queued and processed in order afterwards, instead of being lost or blocking the terminal.
+// Anything else that needs a line from the user (e.g., the pager) must use ReadLine instead of reading os.Stdin.
+// The background worker never changes the session itself: it submits its work to the queue, which the main loop
+// handles between prompts like a typed line.
+
+package terminal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// NewPromptQueue creates an empty prompt queue. The queue starts reading the input on the first use.
+// If both the input and the output are a terminal, the lines are edited as they are typed and echoed to the output.
+func NewPromptQueue(input io.Reader, output io.Writer) *PromptQueue {
+	return &PromptQueue{
+		input:  input,
+		output: output,
+		ready:  make(chan struct{}, 1),
+	}
+}
+
+// start launches the goroutine that reads the input line by line. It is safe to call more than once.
+func (q *PromptQueue) start() {
+	q.startOnce.Do(func() {
+		// Note: The reader is kept across restarts, so no buffered input is lost if the loop panics.
+		reader := newLineReader(q.input, q.output)
+		q.mu.Lock()
+		q.reader = reader
+		q.mu.Unlock()
+		supervisor.Go(WorkerInputReader, func() {
+			q.readLoop(reader)
+		})
+	})
+}
+
+// RestoreTerminal gives the terminal back in the state it was found in, if the line editor changed it.
+// It must be called before the program exits.
+func (q *PromptQueue) RestoreTerminal() {
+	q.mu.Lock()
+	reader := q.reader
+	q.mu.Unlock()
+	if reader != nil {
+		reader.Restore()
+	}
+}
+
+// readLoop reads lines until the input fails and routes each line to a waiting ReadLine call,
+// the interceptor, or the queue.
+func (q *PromptQueue) readLoop(reader inputLineReader) {
+	for {
+		line, err := reader.ReadLine()
+		if err != nil {
+			q.mu.Lock()
+			q.err = err
+			if q.direct != nil {
+				// Wake up a waiting ReadLine call; there is nothing more to read.
+				close(q.direct)
+				q.direct = nil
+			}
+			q.mu.Unlock()
+			q.signal()
+			return
+		}
+		q.route(strings.TrimSpace(line))
+	}
+}
+
+// route delivers a line that was read from the input.
+func (q *PromptQueue) route(line string) {
+	q.mu.Lock()
+	if q.direct != nil {
+		// A ReadLine call (e.g., the pager) is waiting for this line.
+		q.direct <- line
+		q.direct = nil
+		q.mu.Unlock()
+		return
+	}
+	busy, intercept := q.busy, q.intercept
+	q.mu.Unlock()
+
+	// While a prompt is in flight, queue management commands run immediately instead of waiting in line.
+	if busy && intercept != nil && intercept(line) {
+		return
+	}
+
+	q.mu.Lock()
+	q.pending = append(q.pending, queuedLine{text: line})
+	position := len(q.pending)
+	q.mu.Unlock()
+	q.signal()
+
+	if busy {
+		logger.Any(InfoPromptQueued, position)
+	}
+}
+
+// signal wakes up a waiting Next call without blocking.
+func (q *PromptQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// Next returns the next input line, waiting until one is available or the context is cancelled.
+// The queued result reports whether the line was typed ahead while a previous prompt was in flight.
+func (q *PromptQueue) Next(ctx context.Context) (line string, queued bool, err error) {
+	next, queued, err := q.next(ctx)
+	return next.text, queued, err
+}
+
+// next is Next for the main loop, which also gets the handler of a line submitted by the background worker.
+func (q *PromptQueue) next(ctx context.Context) (line queuedLine, queued bool, err error) {
+	q.start()
+	queued = q.Len() > 0
+	for {
+		q.mu.Lock()
+		if len(q.pending) > 0 {
+			line = q.pending[0]
+			q.pending = q.pending[1:]
+			q.mu.Unlock()
+			return line, queued, nil
+		}
+		if q.err != nil {
+			err = q.err
+			q.mu.Unlock()
+			return queuedLine{}, false, err
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.ready:
+		case <-ctx.Done():
+			return queuedLine{}, false, ctx.Err()
+		}
+	}
+}
+
+// Submit queues work of the background worker, which the main loop runs with handle once the lines before it
+// are handled. The text shows the work in ":queue". Work with the same text that is still pending is not
+// queued again, and Submit returns false.
+func (q *PromptQueue) Submit(text string, handle func(s *Session) bool) bool {
+	q.mu.Lock()
+	for _, line := range q.pending {
+		if line.handle != nil && line.text == text {
+			q.mu.Unlock()
+			return false
+		}
+	}
+	q.pending = append(q.pending, queuedLine{text: text, handle: handle})
+	q.mu.Unlock()
+	q.signal()
+	return true
+}
+
+// ReadLine waits for the next line typed by the user and returns it directly, bypassing the queue.
+// It is meant for short interactive answers, such as the pager's "press Enter to continue".
+func (q *PromptQueue) ReadLine() (string, error) {
+	q.start()
+	direct := make(chan string, 1)
+	q.mu.Lock()
+	if q.err != nil {
+		err := q.err
+		q.mu.Unlock()
+		return "", err
+	}
+	q.direct = direct
+	q.mu.Unlock()
+
+	line, ok := <-direct
+	if !ok {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		return "", q.err
+	}
+	return line, nil
+}
+
+// SetBusy marks whether a prompt is in flight. Lines typed while busy are reported as queued.
+func (q *PromptQueue) SetBusy(busy bool) {
+	q.mu.Lock()
+	q.busy = busy
+	q.mu.Unlock()
+}
+
+// SetInterceptor sets the function that may handle a line immediately while busy.
+// It returns true if the line was handled and must not be queued.
+func (q *PromptQueue) SetInterceptor(intercept func(line string) bool) {
+	q.mu.Lock()
+	q.intercept = intercept
+	q.mu.Unlock()
+}
+
+// Len returns the number of pending lines.
+func (q *PromptQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// Pending returns a copy of the pending lines in order.
+func (q *PromptQueue) Pending() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	lines := make([]string, 0, len(q.pending))
+	for _, line := range q.pending {
+		lines = append(lines, line.text)
+	}
+	return lines
+}
+
+// Remove removes the pending line at the 1-based position. It returns false if there is no such line.
+func (q *PromptQueue) Remove(position int) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if position < 1 || position > len(q.pending) {
+		return false
+	}
+	q.pending = append(q.pending[:position-1], q.pending[position:]...)
+	return true
+}
+
+// Clear removes all pending lines and returns how many were removed.
+func (q *PromptQueue) Clear() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	removed := len(q.pending)
+	q.pending = nil
+	return removed
+}
+
+// runCommand runs a ":queue" command against the queue: it lists the pending lines, removes the one at a position
+// (":queue :cancel <number>"), or removes all of them (":queue :clear"). It only takes the lock of the queue, so it
+// is safe to run while a response is in flight.
+func (q *PromptQueue) runCommand(parts []string) error {
+	switch {
+	case len(parts) == 1:
+		q.printPending()
+	case len(parts) == 3 && parts[1] == CancelArgs:
+		position, err := strconv.Atoi(parts[2])
+		if err != nil || !q.Remove(position) {
+			return fmt.Errorf(ErrorQueuePositionNotFound, parts[2])
+		}
+		logger.Any(InfoQueueRemoved, position)
+	case len(parts) == 2 && parts[1] == ClearCommand:
+		logger.Any(InfoQueueCleared, q.Clear())
+	default:
+		return invalidArgsError(QueueCommand, parts)
+	}
+	return nil
+}
+
+// printPending prints the pending lines with their positions.
+func (q *PromptQueue) printPending() {
+	pending := q.Pending()
+	if len(pending) == 0 {
+		logger.Any(InfoQueueEmpty)
+		return
+	}
+
+	var builder strings.Builder
+	builder.WriteString(InfoQueueListHeader)
+	for i, prompt := range pending {
+		fmt.Fprintf(&builder, InfoQueueListItem, i+1, prompt)
+	}
+	logger.Any(strings.TrimSuffix(builder.String(), StringNewLine))
+}
+
+// interceptQueueCommand runs ":queue" commands immediately, so pending prompts can be inspected
+// and cancelled while a response is still in flight.
+//
+// Note: This runs on the reader goroutine, at the same time as the input being handled by the main loop,
+// so the command goes straight to the queue instead of through the command registry, which may change the
+// session (e.g., the safety settings of a command) and runs the command hooks.
+func (s *Session) interceptQueueCommand(line string) bool {
+	parts := strings.Fields(line)
+	if len(parts) == 0 || parts[0] != QueueCommand {
+		return false
+	}
+	fmt.Fprintln(s.Output) // Add newline, like before any other command
+	if err := s.Input.runCommand(parts); err != nil {
+		reportCommandError(asCommandError(QueueCommand, err))
+	}
+	return true
+}
+
+// ReadLine reads the next line, including its line ending.
+func (r *bufferedLineReader) ReadLine() (string, error) {
+	return r.reader.ReadString(byte(nl.NewLineChars))
+}
+
+// Restore does nothing, as the terminal is left as it is.
+func (r *bufferedLineReader) Restore() {}
@@ -0,0 +1,111 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: The built-in prompts can be overridden by name in the "prompts" section of the config file, or with ":prompt set",
+// which writes the same section. An override must keep the placeholders (%s) of the built-in prompt, in the same order,
+// since the command fills them in (e.g., the text and the target language of ":aitranslate").
+
+package terminal
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// defaultPromptTemplates are the built-in prompts that can be overridden, by name.
+var defaultPromptTemplates = map[string]string{
+	PromptTemplateContext:   ContextPrompt,
+	PromptTemplateSummarize: SummarizePrompt,
+	PromptTemplateTranslate: AITranslateCommandPrompt,
+	PromptTemplateShutdown:  ContextPromptShutdown,
+	// Note: The facts prompt is only used with MEMORY_EXTRACT enabled.
+	PromptTemplateExtractFacts: ExtractFactsPrompt,
+	PromptTemplateWebSearch:    WebSearchPrompt,
+}
+
+// promptTemplate returns the prompt with the given name, as overridden in the config, or the built-in one,
+// with its variables (e.g., {{date}}) expanded.
+func promptTemplate(name string) string {
+	// Note: Prompts with placeholders are formatted afterwards, so the values of the variables must be escaped.
+	formatted := strings.Contains(defaultPromptTemplates[name], PromptPlaceholder)
+	return expandPromptVariables(rawPromptTemplate(name), formatted)
+}
+
+// rawPromptTemplate returns the prompt with the given name as written, without expanding its variables.
+func rawPromptTemplate(name string) string {
+	if appConfig != nil {
+		if template, ok := appConfig.Prompts[name]; ok && template != "" {
+			return template
+		}
+	}
+	return defaultPromptTemplates[name]
+}
+
+// promptTemplateNames returns the sorted names of the prompts that can be overridden.
+func promptTemplateNames() []string {
+	names := make([]string, 0, len(defaultPromptTemplates))
+	for name := range defaultPromptTemplates {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// validatePromptTemplate checks that the prompt can be overridden and has as many placeholders as the built-in one.
+func validatePromptTemplate(name, template string) error {
+	builtin, ok := defaultPromptTemplates[name]
+	if !ok {
+		return fmt.Errorf(ErrorUnknownPromptTemplate, name, strings.Join(promptTemplateNames(), dotStringComma))
+	}
+	if want := strings.Count(builtin, PromptPlaceholder); strings.Count(template, PromptPlaceholder) != want {
+		return fmt.Errorf(ErrorInvalidPromptTemplate, name, want)
+	}
+	return nil
+}
+
+// validatePromptTemplates checks every prompt override of the config.
+func validatePromptTemplates(templates map[string]string) error {
+	for name, template := range templates {
+		if err := validatePromptTemplate(name, template); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setPromptTemplate overrides the prompt and saves it to the config file, so it is kept for the next sessions.
+func setPromptTemplate(name, template string) error {
+	if err := validatePromptTemplate(name, template); err != nil {
+		return err
+	}
+	path, err := updateAppConfigFile(func(config *AppConfig) {
+		if config.Prompts == nil {
+			config.Prompts = make(map[string]string)
+		}
+		config.Prompts[name] = template
+	})
+	if err != nil {
+		return err
+	}
+	if appConfig.Prompts == nil {
+		appConfig.Prompts = make(map[string]string)
+	}
+	appConfig.Prompts[name] = template
+	logger.Any(InfoPromptTemplateSaved, name, path)
+	return nil
+}
+
+// formatPromptTemplates lists the current prompts and whether they are built-in or overridden.
+func formatPromptTemplates() string {
+	var builder strings.Builder
+	for _, name := range promptTemplateNames() {
+		source := PromptTemplateBuiltin
+		if template, ok := appConfig.Prompts[name]; ok && template != "" {
+			source = PromptTemplateCustom
+		}
+		builder.WriteString(fmt.Sprintf(PromptTemplateFormat, name, source, rawPromptTemplate(name)))
+	}
+	return builder.String()
+}
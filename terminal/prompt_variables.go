@@ -0,0 +1,65 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: Prompt variables are a deliberately small template engine: a fixed set of {{name}} variables replaced by their
+// current values, without conditionals or loops, so a prompt can never fail to render.
+// Unknown variables are left as they are, which keeps prompts that contain braces for other reasons intact.
+// Every prompt that can be customized goes through expandPromptVariables (see promptTemplate), and so does the persona
+// (see expandedPersona).
+
+package terminal
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// promptVariables returns the current value of each prompt variable.
+func promptVariables() map[string]string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		cwd = PromptVariableUnknown
+	}
+	now := time.Now()
+	return map[string]string{
+		PromptVariableDate:  now.Format(time.DateOnly),
+		PromptVariableTime:  now.Format(PromptVariableTimeLayout),
+		PromptVariableOS:    runtime.GOOS,
+		PromptVariableCwd:   cwd,
+		PromptVariableModel: currentModelName(),
+	}
+}
+
+// expandPromptVariables replaces the variables in the text, such as {{date}} or {{model}}, with their current values.
+// If the text is formatted afterwards (see promptTemplate), the percent signs in the values are escaped.
+func expandPromptVariables(text string, formatted bool) string {
+	if !strings.Contains(text, PromptVariableOpen) {
+		return text
+	}
+	variables := promptVariables()
+	pairs := make([]string, 0, 2*len(variables))
+	for name, value := range variables {
+		if formatted {
+			value = strings.ReplaceAll(value, "%", "%%")
+		}
+		pairs = append(pairs, PromptVariableOpen+name+PromptVariableClose, value)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// currentModelName returns the model of the running session, or the configured default before a session has started.
+func currentModelName() string {
+	if session := activeSession.Load(); session != nil {
+		if session.CurrentModelName != "" {
+			return session.CurrentModelName
+		}
+		return session.DefaultModelName
+	}
+	if appConfig != nil && appConfig.Model != "" {
+		return appConfig.Model
+	}
+	return GeminiPro
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: A quoted prompt is sent with only the quoted message as context, instead of the recent chat history,
+// which keeps follow-ups on an earlier message focused and cheap. The prompt and the response are still added
+// to the chat history, so the conversation can continue normally afterwards.
+
+package terminal
+
+import (
+	"fmt"
+)
+
+// quotedContext returns the message at the 1-based position in the chat history, as numbered by ":search".
+func (h *ChatHistory) quotedContext(number int) (string, error) {
+	messages := h.snapshot().messages
+	if number < 1 || number > len(messages) {
+		return "", fmt.Errorf(ErrorInvalidQuoteNumber, number, len(messages))
+	}
+	return messages[number-1], nil
+}
+
+// sendQuote sends the prompt to the AI with only the quoted message as context.
+//
+// Parameters:
+//
+//	number int: The 1-based position of the quoted message in the chat history.
+//	prompt string: The follow-up prompt about the quoted message.
+//
+// Returns:
+//
+//	error: An error if the message does not exist or the prompt could not be sent.
+func (s *Session) sendQuote(number int, prompt string) error {
+	quoted, err := s.ChatHistory.quotedContext(number)
+	if err != nil {
+		return err
+	}
+	fullContext := quoted + StringNewLine + prompt
+	operation := RetryableOperation{
+		retryFunc: func() (bool, error) {
+			_, err := s.sendFullContext(s.Ctx, s.ConfigureModelForSession(s.Ctx), fullContext, prompt)
+			return err == nil, err
+		},
+	}
+	_, err = operation.retryWithExponentialBackoff(standardAPIErrorHandler)
+	return err
+}
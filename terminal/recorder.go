@@ -0,0 +1,176 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: The Gemini client talks to the API over REST, so its HTTP requests can be recorded and replayed by an
+// http.RoundTripper. Interactions are replayed in the order they were recorded, which keeps retries deterministic:
+// a recorded server error is answered again before the successful response that followed it.
+// The API key is sent in a header that is never recorded, and secrets in the bodies are masked.
+
+package terminal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"google.golang.org/api/option"
+)
+
+// NewRecorder creates a Recorder for the fixture file at path.
+//
+// Parameters:
+//
+//	path string: The fixture file that is written when recording and read when replaying.
+//	mode string: RecorderModeRecord to call the API and record the interactions, or RecorderModeReplay
+//	             to answer the requests with the recorded interactions, without calling the API.
+//	apiKey string: The API key used when recording. It is not needed to replay.
+//
+// Returns:
+//
+//	*Recorder: The recorder, whose ClientOptions are given to genai.NewClient.
+//	error: An error if the mode is unknown or the fixture file cannot be read.
+func NewRecorder(path, mode, apiKey string) (*Recorder, error) {
+	// Note: The secrets profile is always valid, so the error can be ignored.
+	filter, _ := NewRedactionFilter(RedactionProfileSecrets)
+	recorder := &Recorder{
+		path:   path,
+		mode:   mode,
+		apiKey: apiKey,
+		filter: filter,
+	}
+	switch mode {
+	case RecorderModeRecord:
+		return recorder, nil
+	case RecorderModeReplay:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &recorder.interactions); err != nil {
+			return nil, err
+		}
+		return recorder, nil
+	default:
+		return nil, fmt.Errorf(ErrorInvalidRecorderMode, mode)
+	}
+}
+
+// ClientOptions returns the options that send the requests of a genai.Client through the recorder.
+// They also authenticate the client, so no other auth option is needed.
+func (r *Recorder) ClientOptions() []option.ClientOption {
+	apiKey := r.apiKey
+	if apiKey == "" {
+		// Note: The cache client of genai does not use the HTTP client, but cannot be created without a key.
+		apiKey = RecorderModeReplay
+	}
+	return []option.ClientOption{option.WithHTTPClient(&http.Client{Transport: r}), option.WithAPIKey(apiKey)}
+}
+
+// RoundTrip records or replays a request, depending on the mode of the recorder.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.mode == RecorderModeReplay {
+		return r.replay(req)
+	}
+	return r.record(req)
+}
+
+// record sends the request to the API with the API key and records it with its response.
+func (r *Recorder) record(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil {
+		var err error
+		if requestBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+	// Note: The request is cloned, since a RoundTripper must not modify the request it is given.
+	outgoing := req.Clone(req.Context())
+	outgoing.Body = io.NopCloser(bytes.NewReader(requestBody))
+	outgoing.Header.Set(GoogleAPIKeyHeader, r.apiKey)
+
+	resp, err := http.DefaultTransport.RoundTrip(outgoing)
+	if err != nil {
+		return nil, err
+	}
+	responseBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, RecordedInteraction{
+		Method:       req.Method,
+		Path:         req.URL.Path,
+		RequestBody:  r.redact(requestBody),
+		Status:       resp.StatusCode,
+		ContentType:  resp.Header.Get(HeaderContentType),
+		ResponseBody: r.redact(responseBody),
+	})
+	return resp, nil
+}
+
+// replay answers the request with the next recorded interaction, which must have the same method and path.
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.next >= len(r.interactions) {
+		return nil, fmt.Errorf(ErrorNoRecordedInteraction, req.Method, req.URL.Path)
+	}
+	interaction := r.interactions[r.next]
+	if interaction.Method != req.Method || interaction.Path != req.URL.Path {
+		return nil, fmt.Errorf(ErrorRecordedInteractionMismatch, interaction.Method, interaction.Path, req.Method, req.URL.Path)
+	}
+	r.next++
+
+	header := make(http.Header)
+	if interaction.ContentType != "" {
+		header.Set(HeaderContentType, interaction.ContentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Status, http.StatusText(interaction.Status)),
+		StatusCode:    interaction.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(interaction.ResponseBody))),
+		ContentLength: int64(len(interaction.ResponseBody)),
+		Request:       req,
+	}, nil
+}
+
+// redact returns the body with the secrets masked.
+func (r *Recorder) redact(body []byte) string {
+	// Note: The redaction filter only masks, so it never returns an error.
+	redacted, _, _ := r.filter.Apply(string(body))
+	return redacted
+}
+
+// Save writes the recorded interactions to the fixture file. It does nothing when replaying.
+func (r *Recorder) Save() error {
+	if r.mode != RecorderModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, data, 0600)
+}
+
+// Remaining returns the number of recorded interactions that have not been replayed yet.
+func (r *Recorder) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.interactions) - r.next
+}
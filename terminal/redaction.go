@@ -0,0 +1,101 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: Redaction profiles reuse the OutboundFilter and its regex rules, but always mask instead of block,
+// so a transcript can still be exported after sensitive parts are removed.
+
+package terminal
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// NewRedactionFilter creates an always-enabled OutboundFilter that masks the data covered by the given profiles.
+// The secrets profile is always applied, even if it is not listed, so secrets never leave the machine in an export.
+//
+// Parameters:
+//
+//	profiles ...string: The names of the redaction profiles, e.g., "pii" or "paths" (see redactionProfiles).
+//
+// Returns:
+//
+//	*OutboundFilter: The redaction filter.
+//	error: An error if a profile name is unknown.
+func NewRedactionFilter(profiles ...string) (*OutboundFilter, error) {
+	if err := validateRedactionProfiles(profiles); err != nil {
+		return nil, err
+	}
+
+	// Note: The rules are added in the order of redactionProfiles, not in the order of the names,
+	// since URL hosts must be masked before their paths are mistaken for file paths.
+	var rules []OutboundRule
+	for _, profile := range redactionProfiles {
+		if profile.Name == RedactionProfileSecrets || slices.Contains(profiles, profile.Name) {
+			rules = append(rules, profile.Rules()...)
+		}
+	}
+	return NewOutboundFilter(true, rules...), nil
+}
+
+// validateRedactionProfiles checks that every profile name is known.
+func validateRedactionProfiles(profiles []string) error {
+	known := make([]string, 0, len(redactionProfiles))
+	for _, profile := range redactionProfiles {
+		known = append(known, profile.Name)
+	}
+	for _, name := range profiles {
+		if !slices.Contains(known, name) {
+			return fmt.Errorf(ErrorUnknownRedactionProfile, name, strings.Join(known, dotStringComma))
+		}
+	}
+	return nil
+}
+
+// transcriptRedactionFilter returns the redaction filter for exported transcripts, using the profiles
+// from the application config.
+func transcriptRedactionFilter() *OutboundFilter {
+	filter, err := NewRedactionFilter(appConfig.RedactionProfiles...)
+	if err != nil {
+		// Note: The profiles are validated when the config is loaded, so this only happens if the config was changed
+		// at runtime. Fall back to the defaults rather than exporting without redaction.
+		logger.Error(ErrorFailedToLoadAppConfig, err)
+		filter, _ = NewRedactionFilter(DefaultAppConfig().RedactionProfiles...)
+	}
+	return filter
+}
+
+// secretsRedactionRules masks API keys, tokens and private keys.
+func secretsRedactionRules() []OutboundRule {
+	return []OutboundRule{
+		NewRegexRule(RuleSecretKey, RegexSecretKey, FilterMask, MaskSecretKey),
+	}
+}
+
+// piiRedactionRules masks personal data.
+func piiRedactionRules() []OutboundRule {
+	return []OutboundRule{
+		NewRegexRule(RuleEmail, RegexEmail, FilterMask, MaskEmail),
+		NewRegexRule(RuleCreditCard, RegexCreditCard, FilterMask, MaskCreditCard),
+		NewRegexRule(RulePhoneNumber, RegexPhoneNumber, FilterMask, MaskPhoneNumber),
+	}
+}
+
+// hostnameRedactionRules masks URL hosts, internal hostnames and IP addresses.
+// Public domains outside of URLs are kept, since they are usually harmless (e.g., "github.com" in a sentence).
+func hostnameRedactionRules() []OutboundRule {
+	return []OutboundRule{
+		NewRegexRule(RuleURLHost, RegexURLHost, FilterMask, MaskURLHost),
+		NewRegexRule(RuleInternalHostname, RegexInternalHostname, FilterMask, MaskHostname),
+		NewRegexRule(RuleIPAddress, RegexIPAddress, FilterMask, MaskIPAddress),
+	}
+}
+
+// pathRedactionRules masks absolute file paths, which often contain user and project names.
+func pathRedactionRules() []OutboundRule {
+	return []OutboundRule{
+		NewRegexRule(RuleFilePath, RegexFilePath, FilterMask, MaskFilePath),
+	}
+}
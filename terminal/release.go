@@ -5,6 +5,7 @@
 package terminal
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -17,6 +18,7 @@ import (
 //
 // Parameters:
 //
+//	ctx context.Context: The context for controlling the cancellation of the request.
 //	currentVersion string: The version string of the currently running application.
 //
 // Returns:
@@ -24,14 +26,9 @@ import (
 //	isLatest bool: A boolean indicating if the current version is the latest available.
 //	latestVersion string: The tag name of the latest release, if newer than current; otherwise, an empty string.
 //	err error: An error if the request fails or if there is an issue parsing the response.
-func CheckLatestVersion(currentVersion string) (isLatest bool, latestVersion string, err error) {
-	// Create an HTTP client with a timeout to prevent hanging requests.
-	client := &http.Client{
-		Timeout: time.Second * 10,
-	}
-
+func CheckLatestVersion(ctx context.Context, currentVersion string) (isLatest bool, latestVersion string, err error) {
 	// Perform an HTTP GET request to the GitHub API URL.
-	resp, err := client.Get(GitHubAPIURL)
+	resp, err := getGitHubAPI(ctx, GitHubAPIURL)
 	if err != nil {
 		// Log and return the error if the HTTP request fails.
 		logger.Error(ErrorFailedToFetchReleaseInfo, GitHubAPIURL, err)
@@ -68,17 +65,18 @@ func CheckLatestVersion(currentVersion string) (isLatest bool, latestVersion str
 //
 // Parameters:
 //
+//	ctx context.Context: The context for controlling the cancellation of the request.
 //	tagName: The name of the tag for which release information is requested.
 //
 // Returns:
 //
 //	error: An error if the request fails or if there is an issue parsing the response.
-func (r *GitHubRelease) GetFullReleaseInfo(tagName string) error {
+func (r *GitHubRelease) GetFullReleaseInfo(ctx context.Context, tagName string) error {
 	// Construct the full URL to the GitHub API for the given tag name.
 	releaseURL := fmt.Sprintf(GitHubReleaseFUll, tagName)
 
 	// Perform an HTTP GET request to the constructed URL.
-	resp, err := http.Get(releaseURL)
+	resp, err := getGitHubAPI(ctx, releaseURL)
 	if err != nil {
 		// Log and return the error if the HTTP request fails.
 		logger.Error(ErrorFailedToFetchReleaseInfo, tagName, err)
@@ -106,22 +104,54 @@ func (r *GitHubRelease) GetFullReleaseInfo(tagName string) error {
 	return nil
 }
 
+// getGitHubAPI performs a GET request to the GitHub API. The request is bounded by GitHubRequestTimeout
+// and is cancelled along with the context, so Ctrl+C and shutdown never wait for a slow response.
+func getGitHubAPI(ctx context.Context, url string) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, GitHubRequestTimeout)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	req.Header.Set(HeaderAccept, GitHubAcceptJSON)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// Note: The timeout also covers reading the body, so it is released when the body is closed.
+	resp.Body = &cancelOnCloseBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// Close closes the response body and releases the request context.
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
 // checkLatestVersionWithBackoff wraps the CheckLatestVersion call with retry logic.
 // It attempts to determine if the current version is the latest and retries on failure
 // with exponential backoff.
 //
+// Parameters:
+//
+//	ctx context.Context: The context for controlling the cancellation of the requests.
+//
 // Returns:
 //
 //	isLatest bool: A boolean indicating if the current version is the latest available.
 //	latestVersion string: The tag name of the latest release, if newer than current; otherwise, an empty string.
 //	err error: An error if the request fails after retries or if there is an issue parsing the response.
-func checkLatestVersionWithBackoff() (isLatest bool, latestVersion string, err error) {
+func checkLatestVersionWithBackoff(ctx context.Context) (isLatest bool, latestVersion string, err error) {
 	// Define a retryable operation with a function that checks the latest version.
 	operation := RetryableOperation{
 		retryFunc: func() (bool, error) {
 			// Call CheckLatestVersion to compare the current version with the latest release.
 			var err error
-			isLatest, latestVersion, err = CheckLatestVersion(CurrentVersion)
+			isLatest, latestVersion, err = CheckLatestVersion(ctx, CurrentVersion)
 			// The operation is successful if there is no error.
 			return err == nil, err
 		},
@@ -144,15 +174,16 @@ func checkLatestVersionWithBackoff() (isLatest bool, latestVersion string, err e
 //
 // Parameters:
 //
+//	ctx context.Context: The context for controlling the cancellation of the requests.
 //	latestVersion string: The tag name of the latest release.
 //
 // Returns:
 //
 //	aiPrompt string: A formatted string containing release information.
 //	err error: An error if the request fails after retries or if there is an issue with formatting.
-func fetchAndFormatReleaseInfo(latestVersion string) (aiPrompt string, err error) {
+func fetchAndFormatReleaseInfo(ctx context.Context, latestVersion string) (aiPrompt string, err error) {
 	// Fetch the release information with retries in case of transient errors.
-	releaseInfo, err := fetchReleaseWithBackoff(latestVersion)
+	releaseInfo, err := fetchReleaseWithBackoff(ctx, latestVersion)
 	if err != nil {
 		return "", err
 	}
@@ -174,20 +205,21 @@ func fetchAndFormatReleaseInfo(latestVersion string) (aiPrompt string, err error
 //
 // Parameters:
 //
+//	ctx context.Context: The context for controlling the cancellation of the requests.
 //	latestVersion string: The tag name of the latest release.
 //
 // Returns:
 //
 //	releaseInfo *GitHubRelease: A pointer to the GitHubRelease struct containing the release information.
 //	err error: An error if the request fails after retries or if there is an issue parsing the response.
-func fetchReleaseWithBackoff(tagName string) (*GitHubRelease, error) {
+func fetchReleaseWithBackoff(ctx context.Context, tagName string) (*GitHubRelease, error) {
 	releaseInfo := &GitHubRelease{}
 
 	// Define a retryable operation with a function that fetches the release information.
 	operation := RetryableOperation{
 		retryFunc: func() (retry bool, err error) {
 			// Call GetFullReleaseInfo to fetch detailed release information for the given tag name.
-			err = releaseInfo.GetFullReleaseInfo(tagName)
+			err = releaseInfo.GetFullReleaseInfo(ctx, tagName)
 			// The operation is successful if there is no error.
 			return err == nil, err
 		},
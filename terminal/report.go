@@ -0,0 +1,130 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: The report pre-fills the repository's bug report issue form through its URL, so nothing is uploaded
+// until the user reviews the issue in the browser and submits it. The gathered details are passed through
+// the transcript redaction profiles first, since error messages can contain file paths or personal data.
+
+package terminal
+
+import (
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+// NewErrorHistory creates an ErrorHistory that keeps the most recent limit errors.
+func NewErrorHistory(limit int) *ErrorHistory {
+	return &ErrorHistory{limit: limit}
+}
+
+// Add records an error message, dropping the oldest one when the history is full.
+func (h *ErrorHistory) Add(message string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = append(h.entries, RecordedError{Time: time.Now(), Message: message})
+	if len(h.entries) > h.limit {
+		h.entries = h.entries[len(h.entries)-h.limit:]
+	}
+}
+
+// Entries returns a copy of the recorded errors, oldest first.
+func (h *ErrorHistory) Entries() []RecordedError {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return slices.Clone(h.entries)
+}
+
+// BuildIssueReport gathers the environment, the recent errors and the retry statistics,
+// and optionally the latest crash report, as the "additional context" of a bug report.
+//
+// Parameters:
+//
+//	includeCrash bool: Whether to include the latest crash report file, if there is one.
+//
+// Returns:
+//
+//	string: The report in Markdown, with sensitive data redacted.
+func BuildIssueReport(includeCrash bool) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, ReportEnvironmentSection, formatDiagnostics(environmentDiagnostics()))
+
+	builder.WriteString(ReportErrorsSection)
+	recent := errorHistory.Entries()
+	if len(recent) == 0 {
+		builder.WriteString(ReportNone)
+	}
+	for _, recorded := range recent {
+		fmt.Fprintf(&builder, ReportErrorFormat, recorded.Time.Format(time.RFC3339), recorded.Message)
+	}
+
+	fmt.Fprintf(&builder, ReportRetrySection,
+		retryStats.Operations.Load(), retryStats.Retries.Load(), retryStats.Exhausted.Load(), retryStats.NonRetryable.Load(),
+		retryStats.OverBudget.Load())
+
+	if includeCrash {
+		builder.WriteString(ReportCrashSection)
+		_, content, err := latestCrashReport()
+		if err != nil {
+			builder.WriteString(ReportNone)
+		} else {
+			if len(content) > MaxReportCrashLength {
+				content = strings.ToValidUTF8(content[:MaxReportCrashLength], "") + ReportTruncated
+			}
+			fmt.Fprintf(&builder, ReportCrashFormat, content)
+		}
+	}
+
+	// Note: Error messages may contain colors, which are not rendered in an issue.
+	report, _, _ := transcriptRedactionFilter().Apply(ansiRegex.ReplaceAllString(builder.String(), ""))
+	return report
+}
+
+// IssueURL returns the URL of a new bug report with the report pre-filled.
+// The report is shortened if the URL would be too long for the browser.
+func IssueURL(report string) string {
+	values := url.Values{}
+	values.Set(IssueFieldTemplate, IssueBugReportTemplate)
+	values.Set(IssueFieldTitle, IssueBugReportTitle)
+	values.Set(IssueFieldOS, runtime.GOOS+"/"+runtime.GOARCH)
+	values.Set(IssueFieldVersion, CurrentVersion)
+	values.Set(IssueFieldContext, report)
+
+	issueURL := GitHubNewIssueURL + "?" + values.Encode()
+	for len(issueURL) > MaxIssueURLLength && len(report) > 0 {
+		report = strings.ToValidUTF8(report[:len(report)*3/4], "")
+		values.Set(IssueFieldContext, report+ReportTruncated)
+		issueURL = GitHubNewIssueURL + "?" + values.Encode()
+	}
+	return issueURL
+}
+
+// latestCrashReport returns the path and content of the most recent crash report file.
+func latestCrashReport() (string, string, error) {
+	configPath, err := AppConfigPath()
+	if err != nil {
+		return "", "", err
+	}
+	// Note: The file names contain a sortable timestamp, so the last match is the latest report.
+	matches, err := filepath.Glob(filepath.Join(filepath.Dir(configPath), CrashReportsDir, fmt.Sprintf(CrashReportFileName, "*")))
+	if err != nil {
+		return "", "", err
+	}
+	if len(matches) == 0 {
+		return "", "", fs.ErrNotExist
+	}
+	slices.Sort(matches)
+	path := matches[len(matches)-1]
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", err
+	}
+	return path, string(data), nil
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: The retry budget is shared by every operation of a session (sending messages, summarizing, checking the version,
+// counting tokens, ...), so a flaky network does not make each command burn its own full retry cycle.
+// Once the budget is spent, operations are attempted only once until retries refill over time.
+
+package terminal
+
+import (
+	"math"
+	"time"
+)
+
+// NewRetryBudget creates a full retry budget.
+//
+// Parameters:
+//
+//	capacity int: The maximum number of retries that can be spent in a burst.
+//	refill time.Duration: The time it takes to earn back one retry.
+func NewRetryBudget(capacity int, refill time.Duration) *RetryBudget {
+	return &RetryBudget{
+		capacity: float64(capacity),
+		tokens:   float64(capacity),
+		refill:   refill,
+		last:     time.Now(),
+	}
+}
+
+// TrySpend spends one retry if the budget allows it. It reports false, along with the time until
+// the next retry is available, when the budget is exhausted.
+func (b *RetryBudget) TrySpend(now time.Time) (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refillLocked(now)
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) * float64(b.refill))
+}
+
+// Remaining returns the number of retries that can currently be spent.
+func (b *RetryBudget) Remaining(now time.Time) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refillLocked(now)
+	return int(b.tokens)
+}
+
+// refillLocked adds the retries earned since the last update. The caller must hold the lock.
+func (b *RetryBudget) refillLocked(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 && b.refill > 0 {
+		b.tokens = math.Min(b.capacity, b.tokens+float64(elapsed)/float64(b.refill))
+	}
+	b.last = now
+}
+
+// currentRetryBudget returns the retry budget of the running session, or nil before a session has started
+// (e.g., while validating the API key), in which case retries are not limited.
+func currentRetryBudget() *RetryBudget {
+	if session := activeSession.Load(); session != nil {
+		return session.RetryBudget
+	}
+	return nil
+}
@@ -5,22 +5,213 @@
 package terminal
 
 import (
+	"errors"
 	"fmt"
-	"math"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
+
+	"google.golang.org/api/googleapi"
 )
 
+// retryPolicyKeys lists the settings of the retry policy that can be changed with ":retrypolicy"
+// and the RETRY_* environment variables, in the order they are shown.
+var retryPolicyKeys = []string{RetryKeyMaxRetries, RetryKeyBaseDelay, RetryKeyMaxDelay, RetryKeyJitter, RetryKeyStatusCodes}
+
+// retryPolicyEnvs maps the settings of the retry policy to the environment variables that set them.
+var retryPolicyEnvs = map[string]string{
+	RetryKeyMaxRetries:  RetryMaxRetriesEnv,
+	RetryKeyBaseDelay:   RetryBaseDelayEnv,
+	RetryKeyMaxDelay:    RetryMaxDelayEnv,
+	RetryKeyJitter:      RetryJitterEnv,
+	RetryKeyStatusCodes: RetryStatusCodesEnv,
+}
+
+// DefaultRetryPolicy returns the retry policy used when the config file does not change it.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries:   DefaultMaxRetries,
+		BaseDelay:    DefaultRetryBaseDelay,
+		MaxDelay:     DefaultRetryMaxDelay,
+		Jitter:       DefaultRetryJitter,
+		Budget:       DefaultRetryBudget,
+		BudgetRefill: DefaultRetryBudgetRefill,
+		// Note: Other errors, such as 429 Too Many Requests, are only retried if configured.
+		RetryableStatusCodes: []int{DefaultRetryableStatusCode},
+	}
+}
+
+// NewRetryPolicy applies the "retry_policy" section of the config file to the default policy.
+//
+// Parameters:
+//
+//	config *RetryPolicyConfig: The configured values. A nil config returns the default policy.
+//
+// Returns:
+//
+//	RetryPolicy: The resulting policy.
+//	error: An error if a value is out of range.
+func NewRetryPolicy(config *RetryPolicyConfig) (RetryPolicy, error) {
+	return applyRetryPolicyConfig(DefaultRetryPolicy(), config)
+}
+
+// applyRetryPolicyConfig applies the values set in config to the policy, and returns the policy unchanged
+// if a value is out of range.
+func applyRetryPolicyConfig(base RetryPolicy, config *RetryPolicyConfig) (RetryPolicy, error) {
+	policy := base
+	if config == nil {
+		return policy, nil
+	}
+	if config.MaxRetries < 0 || config.BaseDelayMs < 0 || config.MaxDelayMs < 0 || config.Budget < 0 || config.BudgetRefillMs < 0 {
+		return base, fmt.Errorf(ErrorNegativeRetryPolicy)
+	}
+	if config.MaxRetries > 0 {
+		policy.MaxRetries = config.MaxRetries
+	}
+	if config.BaseDelayMs > 0 {
+		policy.BaseDelay = time.Duration(config.BaseDelayMs) * time.Millisecond
+	}
+	if config.MaxDelayMs > 0 {
+		policy.MaxDelay = time.Duration(config.MaxDelayMs) * time.Millisecond
+	}
+	if config.Budget > 0 {
+		policy.Budget = config.Budget
+	}
+	if config.BudgetRefillMs > 0 {
+		policy.BudgetRefill = time.Duration(config.BudgetRefillMs) * time.Millisecond
+	}
+	if config.Jitter != nil {
+		if *config.Jitter < 0 || *config.Jitter > 1 {
+			return base, fmt.Errorf(ErrorInvalidRetryJitter, *config.Jitter)
+		}
+		policy.Jitter = *config.Jitter
+	}
+	for _, code := range config.RetryableStatusCodes {
+		if code < 400 || code > 599 {
+			return base, fmt.Errorf(ErrorInvalidRetryStatusCode, code)
+		}
+	}
+	if len(config.RetryableStatusCodes) > 0 {
+		policy.RetryableStatusCodes = slices.Clone(config.RetryableStatusCodes)
+	}
+	if policy.MaxDelay < policy.BaseDelay {
+		return base, fmt.Errorf(ErrorRetryMaxDelayTooShort, policy.MaxDelay, policy.BaseDelay)
+	}
+	return policy, nil
+}
+
+// setValue parses the value of a setting of the retry policy (see retryPolicyKeys) into the config.
+// Delays are in milliseconds, and the status codes are separated by commas.
+func (c *RetryPolicyConfig) setValue(key, value string) error {
+	value = strings.TrimSpace(value)
+	switch key {
+	case RetryKeyMaxRetries, RetryKeyBaseDelay, RetryKeyMaxDelay:
+		number, err := strconv.Atoi(value)
+		if err != nil || number <= 0 {
+			return fmt.Errorf(ErrorPositiveRetryPolicyValue, value, key)
+		}
+		switch key {
+		case RetryKeyMaxRetries:
+			c.MaxRetries = number
+		case RetryKeyBaseDelay:
+			c.BaseDelayMs = number
+		default:
+			c.MaxDelayMs = number
+		}
+	case RetryKeyJitter:
+		jitter, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf(ErrorInvalidConfigValue, value, key)
+		}
+		c.Jitter = &jitter
+	case RetryKeyStatusCodes:
+		c.RetryableStatusCodes = nil
+		for _, field := range strings.Split(value, commaString) {
+			code, err := strconv.Atoi(strings.TrimSpace(field))
+			if err != nil {
+				return fmt.Errorf(ErrorInvalidConfigValue, value, key)
+			}
+			c.RetryableStatusCodes = append(c.RetryableStatusCodes, code)
+		}
+	default:
+		return fmt.Errorf(ErrorUnknownRetryPolicyKey, key, strings.Join(retryPolicyKeys, dotStringComma))
+	}
+	return nil
+}
+
+// applyRetryPolicyEnv sets the values of the retry_policy config section from the RETRY_* environment variables.
+func applyRetryPolicyEnv(config *AppConfig) error {
+	for _, key := range retryPolicyKeys {
+		env := retryPolicyEnvs[key]
+		value := os.Getenv(env)
+		if value == "" {
+			continue
+		}
+		if config.RetryPolicy == nil {
+			config.RetryPolicy = &RetryPolicyConfig{}
+		}
+		if err := config.RetryPolicy.setValue(key, value); err != nil {
+			return fmt.Errorf(ErrorInvalidRetryPolicyEnv, env, err)
+		}
+	}
+	return nil
+}
+
+// currentRetryPolicy returns the retry policy of the active session, or the policy of the config
+// if there is no session yet (e.g., while the API key is checked).
+func currentRetryPolicy() RetryPolicy {
+	if session := activeSession.Load(); session != nil {
+		return session.RetryPolicy
+	}
+	return retryPolicy
+}
+
+// formatStatusCodes returns the status codes separated by commas.
+func formatStatusCodes(codes []int) string {
+	names := make([]string, 0, len(codes))
+	for _, code := range codes {
+		names = append(names, strconv.Itoa(code))
+	}
+	return strings.Join(names, dotStringComma)
+}
+
+// defaultRetryPolicy creates the retry policy from the application config.
+// An invalid "retry_policy" section is reported and the defaults are used instead.
+func defaultRetryPolicy() RetryPolicy {
+	policy, err := NewRetryPolicy(appConfig.RetryPolicy)
+	if err != nil {
+		logger.Error(ErrorInvalidRetryPolicy, err)
+	}
+	return policy
+}
+
+// Delay returns how long to wait after the given failed attempt (starting at 0).
+// The exponential delay is capped at MaxDelay, then up to Jitter of it is randomly removed.
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := p.MaxDelay
+	// Note: Beyond 62 doublings the shift overflows, and the cap has long been reached anyway.
+	if attempt < 62 && p.BaseDelay<<attempt > 0 && p.BaseDelay<<attempt < p.MaxDelay {
+		delay = p.BaseDelay << attempt
+	}
+	if p.Jitter > 0 {
+		delay -= time.Duration(rand.Float64() * p.Jitter * float64(delay))
+	}
+	return delay
+}
+
 // retryWithExponentialBackoff attempts to execute the RetryableFunc with a retry policy.
-// It applies exponential backoff between retries and logs an error if the maximum number of retries is reached.
+// It applies exponential backoff with jitter between retries (see RetryPolicy) and logs an error if the maximum number of retries is reached.
 //
 // Note: this a powerful retry policy, unlike that shitty complex go codes
 func (op *RetryableOperation) retryWithExponentialBackoff(handleError ErrorHandlerFunc) (bool, error) {
-	const maxRetries = 3
-	baseDelay := time.Second
+	policy := currentRetryPolicy()
 	var lastErr error // Variable to store the last error encountered
+	retryStats.Operations.Add(1)
 
-	for attempt := 0; attempt < maxRetries; attempt++ {
+	for attempt := 0; attempt < policy.MaxRetries; attempt++ {
 		success, err := op.retryFunc()
 		if err == nil {
 			return success, nil
@@ -32,13 +223,24 @@ func (op *RetryableOperation) retryWithExponentialBackoff(handleError ErrorHandl
 
 		// Use the provided error handler to check if we should retry.
 		if handleError(err) {
-			delay := baseDelay * time.Duration(math.Pow(2, float64(attempt)))
-			time.Sleep(delay)
+			// Fail fast when the session has spent its retries, instead of waiting through a full retry cycle.
+			if budget := currentRetryBudget(); budget != nil {
+				if ok, wait := budget.TrySpend(time.Now()); !ok {
+					retryStats.OverBudget.Add(1)
+					telemetry.RecordError(err)
+					logger.Error(ErrorRetryBudgetExhausted, wait.Round(time.Second))
+					return false, fmt.Errorf(ErrorLowLevelRetryBudgetExhausted, err)
+				}
+			}
+			retryStats.Retries.Add(1)
+			time.Sleep(policy.Delay(attempt))
 			// Log the retry attempt number and the last error message
-			logger.Any(RetryingStupid500Error, lastErr, attempt+1)
+			activeSession.Load().notify(RetryingStupid500Error, lastErr, attempt+1)
 			continue // Retry the request
 		} else {
 			// Non-retryable error or max retries exceeded
+			retryStats.NonRetryable.Add(1)
+			telemetry.RecordError(err)
 			logger.Error(ErrorNonretryableerror, err)
 			return false, err
 		}
@@ -46,18 +248,36 @@ func (op *RetryableOperation) retryWithExponentialBackoff(handleError ErrorHandl
 
 	// If this point is reached, retries have been exhausted without success.
 	// Use the last error encountered in the final error message.
+	retryStats.Exhausted.Add(1)
+	telemetry.RecordError(lastErr)
 	err := fmt.Errorf(ErrorLowLevelMaximumRetries, lastErr)
 	return false, err
 }
 
 // standardAPIErrorHandler is the standard error handling strategy for API errors.
+// Errors with a status code of the retry policy (by default, 500) are retried.
 func standardAPIErrorHandler(err error) bool {
-	// Error 500 Google Api
-	return strings.Contains(err.Error(), Error500GoogleAPI)
+	codes := currentRetryPolicy().RetryableStatusCodes
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return slices.Contains(codes, apiErr.Code)
+	}
+	// The error may only keep the message of the API error, e.g. "googleapi: Error 500: ...".
+	for _, code := range codes {
+		if strings.Contains(err.Error(), fmt.Sprintf(GoogleAPIErrorFormat, code)) {
+			return true
+		}
+	}
+	return false
 }
 
 // standardOtherAPIErrorHandler is the standard error handling strategy for API errors.
+// The errors of other APIs (e.g., GitHub) only mention the status code, so it is looked for in the message.
 func standardOtherAPIErrorHandler(err error) bool {
-	// Error 500 Other Api
-	return strings.Contains(err.Error(), Code500)
+	for _, code := range currentRetryPolicy().RetryableStatusCodes {
+		if strings.Contains(err.Error(), strconv.Itoa(code)) {
+			return true
+		}
+	}
+	return false
 }
@@ -8,6 +8,9 @@
 package terminal
 
 import (
+	"fmt"
+	"strings"
+
 	genai "github.com/google/generative-ai-go/genai"
 )
 
@@ -85,6 +88,23 @@ func (s *SafetySettings) SetNoneSafety() {
 	s.DerogatoryThershold = genai.HarmBlockNone
 }
 
+// Level returns the name of the safety level the settings correspond to (see the ":safety" command).
+// All categories share the same threshold, so the threshold of dangerous content is representative.
+func (s *SafetySettings) Level() string {
+	switch s.DangerousContentThreshold {
+	case genai.HarmBlockLowAndAbove:
+		return Low
+	case genai.HarmBlockOnlyHigh:
+		return High
+	case genai.HarmBlockUnspecified:
+		return Unspecified
+	case genai.HarmBlockNone:
+		return None
+	default:
+		return Default
+	}
+}
+
 // ApplyToModel applies the configured safety settings to a given generative AI model.
 // This method updates the model's safety settings to match the thresholds specified
 // in the SafetySettings instance, affecting how the model filters generated content.
@@ -175,3 +195,48 @@ func (s *SafetySettings) ApplyToModel(model *genai.GenerativeModel, modelName st
 		}
 	}
 }
+
+// normalizeCommandSafety returns the "command_safety" option with the command prefix added where it is missing,
+// so commands can be configured with or without it.
+func normalizeCommandSafety(commandSafety map[string]string) map[string]string {
+	if commandSafety == nil {
+		return nil
+	}
+	normalized := make(map[string]string, len(commandSafety))
+	for command, level := range commandSafety {
+		command = strings.ToLower(strings.TrimSpace(command))
+		if !strings.HasPrefix(command, PrefixChar) {
+			command = PrefixChar + command
+		}
+		normalized[command] = strings.ToLower(strings.TrimSpace(level))
+	}
+	return normalized
+}
+
+// validateCommandSafety checks that every command of the "command_safety" option uses a known safety level.
+func validateCommandSafety(commandSafety map[string]string) error {
+	for command, level := range commandSafety {
+		if option, ok := safetyOptions[level]; !ok || !option.Valid {
+			return fmt.Errorf(ErrorInvalidCommandSafety, level, command)
+		}
+	}
+	return nil
+}
+
+// useCommandSafety applies the safety level configured for the command in "command_safety", if any.
+// It returns a function that restores the safety settings the session had before.
+func (s *Session) useCommandSafety(name string) (restore func()) {
+	// Note: The ":safety" command is never overridden, as it changes the settings of the session itself.
+	if appConfig == nil || s.SafetySettings == nil || name == SafetyCommand {
+		return func() {}
+	}
+	level := appConfig.CommandSafety[name]
+	option, ok := safetyOptions[level]
+	if !ok || !option.Valid {
+		return func() {}
+	}
+	previous := *s.SafetySettings
+	option.Setter(s.SafetySettings)
+	logger.Debug(DebugCommandSafety, level, name)
+	return func() { *s.SafetySettings = previous }
+}
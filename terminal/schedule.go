@@ -0,0 +1,132 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: Scheduled prompts live only for the lifetime of the session. The ChatWorker goroutine only submits
+// them to the prompt queue when they are due; the main loop sends them between prompts, like a typed line,
+// and the responses are printed, stored in the chat history and, optionally, appended to a file.
+
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// AddSchedule registers a prompt to run on the given cron schedule and returns it.
+// If outputFile is not empty, each response is also appended to that file.
+func (cw *ChatWorker) AddSchedule(spec, prompt, outputFile string, now time.Time) (*ScheduledPrompt, error) {
+	schedule, err := ParseCronSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	next := schedule.Next(now)
+	if next.IsZero() {
+		return nil, fmt.Errorf(ErrorCronNeverFires, spec)
+	}
+
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+	cw.nextID++
+	scheduled := &ScheduledPrompt{
+		ID:         cw.nextID,
+		Spec:       spec,
+		Prompt:     prompt,
+		OutputFile: outputFile,
+		Next:       next,
+		schedule:   schedule,
+	}
+	cw.schedules = append(cw.schedules, scheduled)
+	return scheduled, nil
+}
+
+// RemoveSchedule removes a scheduled prompt by ID. It returns false if no such prompt exists.
+func (cw *ChatWorker) RemoveSchedule(id int) bool {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+	for i, scheduled := range cw.schedules {
+		if scheduled.ID == id {
+			cw.schedules = append(cw.schedules[:i], cw.schedules[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Schedules returns a copy of the scheduled prompts.
+func (cw *ChatWorker) Schedules() []ScheduledPrompt {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+	schedules := make([]ScheduledPrompt, 0, len(cw.schedules))
+	for _, scheduled := range cw.schedules {
+		schedules = append(schedules, *scheduled)
+	}
+	return schedules
+}
+
+// dueSchedules returns the prompts due at the given time and advances them to their next run.
+func (cw *ChatWorker) dueSchedules(now time.Time) []ScheduledPrompt {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+	var due []ScheduledPrompt
+	for _, scheduled := range cw.schedules {
+		if now.Before(scheduled.Next) {
+			continue
+		}
+		due = append(due, *scheduled)
+		// Note: Runs missed while the machine was asleep are skipped instead of being replayed.
+		scheduled.Next = scheduled.schedule.Next(now)
+	}
+	return due
+}
+
+// runDueSchedules submits every scheduled prompt that is due to the main loop, where it is sent like a typed line.
+func (cw *ChatWorker) runDueSchedules(now time.Time) {
+	for _, scheduled := range cw.dueSchedules(now) {
+		cw.session.Input.Submit(scheduled.Prompt, func(s *Session) bool {
+			return s.runScheduledPrompt(scheduled, now)
+		})
+	}
+}
+
+// runScheduledPrompt sends a scheduled prompt to the AI like a typed prompt, with the persona, memory and
+// context trimming of handleUserInput. If requested, the response is also appended to the output file.
+// It returns true if the session should end.
+func (s *Session) runScheduledPrompt(scheduled ScheduledPrompt, now time.Time) bool {
+	response, end := s.sendUserInput(scheduled.Prompt)
+	if end || response == "" {
+		return end // Nothing was sent, e.g. in dry-run mode.
+	}
+	if scheduled.OutputFile != "" {
+		if err := appendScheduledOutput(scheduled.OutputFile, scheduled.Prompt, response, now); err != nil {
+			logger.Error(ErrorScheduledPromptFailed, scheduled.ID, err)
+			return false // Continue the session.
+		}
+	}
+	logger.Any(InfoScheduledPromptDone, scheduled.ID, scheduled.Prompt)
+	return false // Continue the session.
+}
+
+// appendScheduledOutput appends a scheduled response to the output file, creating it if needed.
+func appendScheduledOutput(filePath, prompt, aiResponse string, now time.Time) error {
+	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(file, ScheduledOutputFormat, now.Format(time.RFC1123), prompt, aiResponse); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// parseScheduleID parses the ID argument of ":schedule :cancel".
+func parseScheduleID(arg string) (int, error) {
+	id, err := strconv.Atoi(arg)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf(ErrorInvalidScheduleID, arg)
+	}
+	return id, nil
+}
@@ -5,13 +5,14 @@
 package terminal
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
-	"strings"
 	"syscall"
+	"time"
 
 	"github.com/google/generative-ai-go/genai"
 	"google.golang.org/api/option"
@@ -28,13 +29,8 @@ import (
 //
 //	*Session: A pointer to the newly created Session object.
 func NewSession(apiKey string) *Session {
-	// Initialize ChatConfig with default values.
-	chatConfig := DefaultChatConfig()
-	ctx, cancel := context.WithCancel(context.Background())
-
-	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
+	client, err := genai.NewClient(context.Background(), option.WithAPIKey(apiKey))
 	if err != nil {
-		cancel()
 		logger.Error(ErrorFailedToCreateNewAiClient, err)
 		return nil
 	}
@@ -51,23 +47,97 @@ func NewSession(apiKey string) *Session {
 
 	// Handle the result of the retry operation.
 	if err != nil || !valid {
-		cancel()
+		client.Close()
 		logger.Error(ErrorFailedToStartSession, err)
 		return nil
 	}
-	// Note: This doesn't use a storage system like a database or file system to keep the chat history, nor does it use a JSON structure (as a front-end might) for sending request to Google AI.
+	return NewSessionWithClient(client)
+}
+
+// NewSessionWithClient creates a new chat session that uses the given AI client, without validating its API key.
+// It lets other Go programs embed the chat with a client they configured themselves (see SetIO).
+//
+// Parameters:
+//
+//	client *genai.Client: The generative AI client used by the session.
+//
+// Returns:
+//
+//	*Session: A pointer to the newly created Session object.
+func NewSessionWithClient(client *genai.Client) *Session {
+	// Initialize ChatConfig with default values.
+	chatConfig := DefaultChatConfig()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Note: By default this doesn't use a storage system like a database or file system to keep the chat history (see SESSION_NAME for the opt-in storage), nor does it use a JSON structure (as a front-end might) for sending request to Google AI.
 	// So if you're wondering where this is all stored, it's in a place you won't find—somewhere in the RAM's labyrinth, hahaha!
 	// Initialize the ChatHistory here instead of using an empty struct
 	chatHistory := NewChatHistory() // Hash RAM's labyrinth, hahaha!
-	return &Session{
-		Client:           client,
-		ChatHistory:      chatHistory, // Store the pointer to ChatHistory in RAM's labyrinth
-		ChatConfig:       chatConfig,  // Initialize ChatConfig
-		SafetySettings:   DefaultSafetySettings(),
-		DefaultModelName: GeminiPro, // Set the default model name
-		Ctx:              ctx,
-		Cancel:           cancel,
+	session := &Session{
+		Client:             client,
+		ChatHistory:        chatHistory, // Store the pointer to ChatHistory in RAM's labyrinth
+		ChatConfig:         chatConfig,  // Initialize ChatConfig
+		SafetySettings:     DefaultSafetySettings(),
+		GenerationSettings: DefaultGenerationSettings(),
+		OutboundFilter:     DefaultOutboundFilter(), // Optional, enabled via OUTBOUND_FILTER
+		DefaultModelName:   GeminiPro,               // Set the default model name
+		Ctx:                ctx,
+		Cancel:             cancel,
+		StartedAt:          time.Now(),
+		Quiet:              appConfig.Quiet,
+		Input:              stdinQueue,
+		Output:             stdout,
+	}
+	session.Worker = NewChatWorker(session)
+	session.RetryPolicy = retryPolicy
+	session.RetryBudget = NewRetryBudget(retryPolicy.Budget, retryPolicy.BudgetRefill)
+	// Apply the preferred model and safety level from the config file.
+	session.applyAppConfig(appConfig)
+	// Restore the stored chat history, if persistent storage is enabled.
+	session.Store = defaultSessionStore()
+	session.loadHistory()
+	// Load the remembered facts, which are sent with every message.
+	session.loadMemory()
+	return session
+}
+
+// NewViewerSession creates a read-only session that browses a stored conversation.
+// It needs no API key: there is no AI client, user input is never sent, and only the commands
+// listed in viewerCommands are available. The stored session is never modified.
+//
+// Parameters:
+//
+//	name string: The name of the stored session (see SESSION_NAME).
+//
+// Returns:
+//
+//	*Session: A pointer to the viewer session, or nil if the stored session cannot be loaded.
+func NewViewerSession(name string) *Session {
+	history, store, err := loadViewerHistory(name)
+	if err != nil {
+		logger.Error(ErrorFailedToStartSession, err)
+		return nil
 	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	session := &Session{
+		ChatHistory:        history,
+		ChatConfig:         DefaultChatConfig(),
+		SafetySettings:     DefaultSafetySettings(),
+		GenerationSettings: DefaultGenerationSettings(),
+		OutboundFilter:     DefaultOutboundFilter(),
+		DefaultModelName:   GeminiPro,
+		Store:              store,
+		ViewOnly:           true,
+		Ctx:                ctx,
+		Cancel:             cancel,
+		StartedAt:          time.Now(),
+		Quiet:              appConfig.Quiet,
+		Input:              stdinQueue,
+		Output:             stdout,
+	}
+	session.Worker = NewChatWorker(session)
+	return session
 }
 
 // Start begins the chat session, managing user input and AI responses.
@@ -78,32 +148,34 @@ func NewSession(apiKey string) *Session {
 // It ensures resources are cleaned up properly on exit by deferring the cancellation of the session's context
 // and the closure of the AI client.
 func (s *Session) Start() {
-	// Merge styles before using.
-	combinedStyle := MergeStyles(slantStyle)
-	text := "GV"
-	asciiArt, _ := ToASCIIArt(text, combinedStyle)
-	fmt.Println(asciiArt)
+	// Keep the separators as wide as the terminal, even after it is resized.
+	watchTerminalResize(s.Ctx)
+	printBanner(s.Output)
 	// Note: This is securely managed by the Gopher Officer, which handles the session and is linked to the `processInput` function.
 	// Additionally, the Gopher Officer may occasionally sleep during the session's lifecycle and will wake up when needed.
 	defer s.cleanup()
+	// Let the panic diagnostics report the active model.
+	activeSession.Store(s)
 	// This Automated Spawn another Goroutine Officer (Known as Gopher Officer) to handle signal
 	s.setupSignalHandling()
-
-	// Simulate AI starting the conversation by Gopher Nerd
-	// This is a prompt context as the starting point for AI to start the conversation
-	humanTyping := NewTypingPrinter()
-	PrintPrefixWithTimeStamp(AiNerd, "")
-	humanTyping.Print(ContextPrompt, TypingDelay)
-	printnewlineASCII() // Ensure there's a newline after the AI's initial message
-
-	// Add AI's initial message to chat history
-	s.ChatHistory.AddMessage(AiNerd, ContextPrompt, s.ChatConfig)
+	// Start the background worker; it stops when the session context is cancelled.
+	s.Worker.Start(s.Ctx)
+	// Let ":queue" commands run immediately while a prompt is in flight.
+	s.Input.SetInterceptor(s.interceptQueueCommand)
+
+	// A viewer session only browses the stored history, so the AI does not start a conversation.
+	if s.ViewOnly {
+		stats := s.ChatHistory.GetMessageStats()
+		logger.Any(InfoViewerMode, s.Store.name, stats.UserMessages+stats.AIMessages+stats.SystemMessages, HelpCommand)
+	} else {
+		s.greet()
+	}
 
 	// Main loop for processing user input
 	for {
 		select {
 		case <-s.Ctx.Done():
-			fmt.Println(ContextCancel)
+			fmt.Fprintln(s.Output, ContextCancel)
 			return
 		default:
 			done := s.processInput()
@@ -114,6 +186,19 @@ func (s *Session) Start() {
 	}
 }
 
+// greet simulates the AI starting the conversation and adds its initial message to the chat history.
+func (s *Session) greet() {
+	// Simulate AI starting the conversation by Gopher Nerd
+	// This is a prompt context as the starting point for AI to start the conversation
+	humanTyping := newTypingPrinter(s.Output)
+	fprintPrefixWithTimeStamp(s.Output, AiNerd, "")
+	humanTyping.Print(promptTemplate(PromptTemplateContext), TypingDelay)
+	printnewlineASCII(s.Output) // Ensure there's a newline after the AI's initial message
+
+	// Add AI's initial message to chat history
+	s.ChatHistory.AddMessage(AiNerd, promptTemplate(PromptTemplateContext), s.ChatConfig)
+}
+
 // setupSignalHandling configures the handling of interrupt signals to ensure graceful
 // shutdown of the session. It listens for SIGINT and SIGTERM signals.
 func (s *Session) setupSignalHandling() {
@@ -122,57 +207,185 @@ func (s *Session) setupSignalHandling() {
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
 	// Gopher Officer to handle graceful shutdown, and monitoring other signal in linux/unix or windows.
-	go func() {
+	supervisor.Go(WorkerSignalHandler, func() {
 		for {
 			sig := <-sigChan // Block until a signal is received
 			switch sig {
 			case syscall.SIGINT, syscall.SIGTERM:
+				// Ctrl+C during a cancellable operation (e.g., a token counting batch) only stops that operation.
+				if sig == syscall.SIGINT && s.cancelOperation() {
+					continue
+				}
 				// Perform cleanup and exit only on SIGINT and SIGTERM.
-				fmt.Println(SignalMessage)
+				fmt.Fprintln(s.Output, SignalMessage)
 				s.cleanup()
 				os.Exit(0)
 			default:
-				fmt.Printf(MonitoringSignal, sig)
+				fmt.Fprintf(s.Output, MonitoringSignal, sig)
 			}
 		}
-	}()
+	})
+}
+
+// beginOperation starts a cancellable operation and returns its context.
+// While the operation is running, Ctrl+C cancels the operation instead of ending the session.
+// The returned function must be called when the operation is finished.
+func (s *Session) beginOperation() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(s.Ctx)
+	s.opMu.Lock()
+	s.opCancel = cancel
+	s.opMu.Unlock()
+
+	return ctx, func() {
+		s.opMu.Lock()
+		s.opCancel = nil
+		s.opMu.Unlock()
+		cancel()
+	}
+}
+
+// beginTimedOperation starts a cancellable operation like beginOperation, which is also cancelled
+// when the timeout expires, so a stalled API never blocks the terminal indefinitely.
+func (s *Session) beginTimedOperation(timeout time.Duration) (context.Context, func()) {
+	ctx, done := s.beginOperation()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, func() {
+		cancel()
+		done()
+	}
+}
+
+// reportTimeout tells the user that the command was cancelled by its deadline, with suggestions.
+// It reports whether the context expired, so the caller can skip its own, less helpful, error message.
+func reportTimeout(ctx context.Context, command string, timeout time.Duration) bool {
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return false
+	}
+	logger.Error(ErrorOperationTimedOut, command, timeout)
+	return true
+}
+
+// cancelOperation cancels the running cancellable operation.
+// It returns false if no operation is running.
+func (s *Session) cancelOperation() bool {
+	s.opMu.Lock()
+	defer s.opMu.Unlock()
+	if s.opCancel == nil {
+		return false
+	}
+	s.opCancel()
+	s.opCancel = nil
+	return true
 }
 
 // processInput reads user input from the terminal. It returns true if the session
 // should end, either due to a command or an error.
 func (s *Session) processInput() bool {
-	PrintPrefixWithTimeStamp(YouNerd, "")
-	userInput, err := bufio.NewReader(os.Stdin).ReadString(byte(nl.NewLineChars))
+	s.printStatusBar() // Redrawn before each prompt, so it reflects the last message.
+	fprintPrefixWithTimeStamp(s.Output, YouNerd, "")
+	line, queued, err := s.Input.next(s.Ctx)
 	if err != nil {
+		if err == io.EOF {
+			return true // The input was closed (e.g., the end of piped input), nothing more to read.
+		}
 		logger.Error(ErrorReadingUserInput, err)
 		return false // Continue the loop, hoping for a successful read next time
 	}
+	if queued || line.handle != nil {
+		// Echo the line that was typed ahead or submitted by the worker, so the transcript shows what is processed.
+		fmt.Fprintln(s.Output, line.text)
+	}
+	if line.handle != nil {
+		return s.handleLine(line.handle)
+	}
+	return s.HandleInput(line.text)
+}
 
-	userInput = strings.TrimSpace(userInput)
+// HandleInput handles a line of user input like the interactive loop does: a command is executed,
+// anything else is sent to the AI. It lets other Go programs drive the session without its input reader.
+// It returns true if the session should end.
+func (s *Session) HandleInput(userInput string) bool {
 	s.lastInput = userInput // Store the last input
+	return s.handleLine(func(s *Session) bool {
+		fmt.Fprintln(s.Output) // Add newline if it's a command or unrecognized command
+		if isCommand(userInput) {
+			return s.handleCommand(userInput)
+		}
+		return s.handleUserInput(userInput)
+	})
+}
+
+// handleLine runs the handler of a line of input, or of the work submitted by the background worker (see Submit).
+// It returns true if the session should end.
+func (s *Session) handleLine(handle func(s *Session) bool) bool {
+	// The panic diagnostics follow the session that handles the input.
+	activeSession.Store(s)
+
+	// Save the chat history once the input is handled, if persistent storage is enabled.
+	defer s.saveHistory()
 
-	if isCommand(userInput) {
-		return s.handleCommand(userInput)
+	// In dry-run mode nothing is sent, so the chat history is restored once the input is handled.
+	if s.DryRun {
+		defer s.ChatHistory.restore(s.ChatHistory.snapshot())
 	}
-	return s.handleUserInput(userInput)
+
+	// Lines typed from now on are queued until this input is handled.
+	s.Input.SetBusy(true)
+	defer s.Input.SetBusy(false)
+
+	return handle(s)
 }
 
 // handleUserInput processes the user's input. If the input is a command, it is handled
 // accordingly. Otherwise, the input is sent to the AI for a response. It returns true
 // if the session should end.
 func (s *Session) handleUserInput(input string) bool {
+	_, end := s.sendUserInput(input)
+	return end
+}
+
+// sendUserInput sends the input to the AI like handleUserInput, and also returns the response of the AI,
+// which is empty if nothing was sent (e.g., the outbound filter blocked the input, or in dry-run mode).
+func (s *Session) sendUserInput(input string) (response string, end bool) {
+	if s.ViewOnly {
+		logger.Error(ErrorViewerCannotSend)
+		return "", false // Continue the session
+	}
 	if !s.ensureClientIsValid() {
-		return true // End the session if the client is not valid
+		return "", true // End the session if the client is not valid
+	}
+
+	// Replace the commands in the input with their output, so it is inspected by the outbound filter too.
+	input, ok := s.expandInlineCommands(input)
+	if !ok {
+		return "", false // Continue the session
 	}
 
-	s.ChatHistory.AddMessage(YouNerd, input, s.ChatConfig) // Add the user's input to the chat history
+	// Inspect the input before it leaves the machine; blocked input is never sent nor stored.
+	input, ok = s.filterOutbound(input)
+	if !ok {
+		return "", false // Continue the session
+	}
 
-	if success := s.sendInputToAI(input); !success {
-		s.endSession() // Ensure the session ends with cleanup.
-		return true    // End the session if sending input to AI failed
+	// Replace the references with the content of the files and pages; the chat history keeps only the references.
+	prompt, ok := s.includeReferences(input)
+	if !ok {
+		return "", false // Continue the session
 	}
 
-	return false // Continue the session
+	// Keep the history below the model's input limit, if auto-compaction is enabled.
+	s.autoCompact(prompt)
+	// Warn about a prompt that is too large and offer to compact the history, if the preflight check is enabled.
+	s.preflight(prompt)
+
+	// Note: The input is added to the chat history together with the response, once it has arrived.
+	response, success := s.sendInputToAI(input, prompt)
+	if !success {
+		s.endSession()  // Ensure the session ends with cleanup.
+		return "", true // End the session if sending input to AI failed
+	}
+
+	return response, false // Continue the session
 }
 
 // ensureClientIsValid checks the validity of the current client and renews it if necessary.
@@ -189,15 +402,17 @@ func (s *Session) ensureClientIsValid() bool {
 	return true // Client was successfully renewed
 }
 
-// sendInputToAI sends the user input to the AI and updates the chat history with the AI's response.
-// It returns true if the input was successfully sent and the response was received, otherwise false.
-func (s *Session) sendInputToAI(input string) bool {
+// sendInputToAI sends the prompt to the AI and updates the chat history with the user input and the AI's response.
+// The prompt is the input with the content of the included files and pages (see includeReferences).
+// It returns the response and true if the input was successfully sent and the response was received, otherwise false.
+// If sending fails, the chat history is left as it was.
+func (s *Session) sendInputToAI(input, prompt string) (string, bool) {
+	var response string
 	// Define a retryable operation for sending input to the AI.
 	operation := RetryableOperation{
 		retryFunc: func() (bool, error) {
-			// Fix Duplicated by using Magic "_" Identifier
-			// Send the input message to the AI, discarding the response.
-			_, err := s.SendMessage(s.Ctx, s.Client, input)
+			var err error
+			response, err = s.sendExchange(s.Ctx, input, prompt)
 			// If there's an error, the operation is not successful.
 			return err == nil, err
 		},
@@ -208,18 +423,23 @@ func (s *Session) sendInputToAI(input string) bool {
 
 	if err != nil || !success {
 		logger.Error(ErrorSendingMessage, err)
-		return false // Sending input to AI failed.
+		return "", false // Sending input to AI failed.
 	}
 
-	return true // Input was successfully sent to AI.
+	return response, true // Input was successfully sent to AI.
 }
 
 // cleanup releases resources used by the session. It cancels the context and closes
 // the AI client connection.
 func (s *Session) cleanup() {
-	s.ChatHistory.cleanup() // Perform Clean
+	s.closeStore()            // Save the history and release the session lock
+	s.Input.RestoreTerminal() // Turn the echo back on, if the line editor turned it off
+	sendTelemetry()           // Only if the user opted in
+	s.ChatHistory.cleanup()   // Perform Clean
 	s.Cancel()
-	s.Client.Close()
+	if s.Client != nil { // A viewer session has no client
+		s.Client.Close()
+	}
 }
 
 // endSession terminates the chat session and performs necessary cleanup operations. It should be
@@ -0,0 +1,105 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+// TestHandleInputReplay drives a session against the recorded interactions in testdata/chat.json, without an API key.
+func TestHandleInputReplay(t *testing.T) {
+	if !canReadResponseStream() {
+		t.Skip("the JSON decoder of this Go version cannot read the streamed responses of the client")
+	}
+	recorder, err := NewRecorder("testdata/chat.json", RecorderModeReplay, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := genai.NewClient(context.Background(), recorder.ClientOptions()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	// Print a line at a time, so the typing effect does not slow the test down.
+	effect := appConfig.TypingEffect
+	appConfig.TypingEffect = TypingEffectLine
+	defer func() { appConfig.TypingEffect = effect }()
+
+	var output bytes.Buffer
+	session := NewSessionWithClient(client)
+	session.SetIO(strings.NewReader(""), &output)
+	if session.HandleInput("What is a goroutine?") {
+		t.Fatal("HandleInput ended the session")
+	}
+
+	if !strings.Contains(output.String(), "A goroutine is a lightweight thread managed by the Go runtime.") {
+		t.Errorf("the response is not printed, the output is %q", output.String())
+	}
+	if stats := session.ChatHistory.GetMessageStats(); stats.UserMessages != 1 || stats.AIMessages != 1 {
+		t.Errorf("the chat history has %d user and %d AI messages, want 1 of each", stats.UserMessages, stats.AIMessages)
+	}
+	if remaining := recorder.Remaining(); remaining != 0 {
+		t.Errorf("%d recorded interactions were not replayed", remaining)
+	}
+}
+
+// TestFitContextHistoryReplay trims the context of a session to the input token limit of the model, fetched and
+// counted through the recorded interactions in testdata/context.json. Unlike a streamed response, these requests can
+// be replayed with any JSON decoder.
+func TestFitContextHistoryReplay(t *testing.T) {
+	recorder, err := NewRecorder("testdata/context.json", RecorderModeReplay, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := genai.NewClient(context.Background(), recorder.ClientOptions()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	session := NewSessionWithClient(client)
+	session.Quiet = true
+	session.ChatHistory.AddMessage(YouNerd, strings.Repeat("channel ", 25), session.ChatConfig)
+	session.ChatHistory.AddMessage(AiNerd, strings.Repeat("goroutine ", 25), session.ChatConfig)
+	session.ChatHistory.AddMessage(YouNerd, strings.Repeat("context ", 25), session.ChatConfig)
+	session.ChatHistory.AddMessage(AiNerd, strings.Repeat("waitgroup ", 25), session.ChatConfig)
+
+	// The estimate comes close to the limit of 300 tokens, so the context is counted with the API, which counts
+	// 400 tokens. The oldest exchange is left out, after which the estimate is far enough below the limit.
+	history := session.fitContextHistory(context.Background(), "What is a goroutine?")
+
+	if strings.Contains(history, "channel") || strings.Contains(history, "goroutine") {
+		t.Errorf("the oldest exchange was not left out of the context %q", history)
+	}
+	if !strings.Contains(history, "context") || !strings.Contains(history, "waitgroup") {
+		t.Errorf("the latest exchange was left out of the context %q", history)
+	}
+	if remaining := recorder.Remaining(); remaining != 0 {
+		t.Errorf("%d recorded interactions were not replayed", remaining)
+	}
+}
+
+// canReadResponseStream reports whether the JSON decoder reads a stream of responses the way the stream reader of the
+// client expects: after the last response, Decode fails and Token returns the closing bracket. The decoder of
+// GOEXPERIMENT=jsonv2 fails on the closing bracket, so no streamed response can be read.
+func canReadResponseStream() bool {
+	decoder := json.NewDecoder(strings.NewReader("[{}]"))
+	var response json.RawMessage
+	if _, err := decoder.Token(); err != nil || decoder.Decode(&response) != nil {
+		return false
+	}
+	if decoder.Decode(&response) == nil {
+		return false
+	}
+	token, _ := decoder.Token()
+	return token == json.Delim(']')
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: The setup wizard writes the same config file that LoadAppConfig reads, so every answer can still be
+// overridden by environment variables later. The API key is stored with owner-only permissions (0600),
+// but keeping it in the API_KEY environment variable remains the safer option.
+
+package terminal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/option"
+)
+
+// ResolveAPIKey returns the API key from the API_KEY environment variable, falling back to the config file.
+func ResolveAPIKey() string {
+	if key := strings.TrimSpace(os.Getenv(APIKey)); key != "" {
+		return key
+	}
+	return appConfig.APIKey
+}
+
+// NeedsSetup reports whether no API key is configured, meaning the setup wizard should run on startup.
+func NeedsSetup() bool {
+	return ResolveAPIKey() == ""
+}
+
+// RunSetupWizard guides the user through the first-time configuration: the API key, the preferred model,
+// the safety level and the color theme. The API key is validated with a cheap request before anything is written.
+// The answers are saved to the config file and applied to the running application.
+//
+// Returns:
+//
+//	*AppConfig: The updated application config.
+//	error: An error if the input ends before the setup is complete or the config file cannot be written.
+func RunSetupWizard() (*AppConfig, error) {
+	// Note: No session has started yet, so the wizard asks on the terminal, like a new session would.
+	terminal := &Session{Input: stdinQueue, Output: stdout}
+	return terminal.runSetupWizard()
+}
+
+// runSetupWizard is RunSetupWizard, asking on the input and the output of the session (see ":setup").
+func (s *Session) runSetupWizard() (*AppConfig, error) {
+	logger.Any(SetupWelcome, ApplicationName)
+
+	key, err := s.askAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	model, err := s.askSetupChoice(SetupAskModel, setupModels(), GeminiPro)
+	if err != nil {
+		return nil, err
+	}
+	safety, err := s.askSetupChoice(SetupAskSafety, []string{Low, Default, High, Unspecified, None}, Default)
+	if err != nil {
+		return nil, err
+	}
+	theme, err := s.askSetupChoice(SetupAskTheme, themeNames(), DefaultTheme)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := updateAppConfigFile(func(config *AppConfig) {
+		config.APIKey = key
+		config.Model = model
+		config.SafetyLevel = safety
+		config.Theme = theme
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply the answers to the running application, keeping any environment variable overrides.
+	appConfig = loadAppConfigOrDefault()
+	applyTheme(appConfig.Theme)
+	logger.Any(SetupCompleted, path)
+	return appConfig, nil
+}
+
+// askAPIKey asks for the API key until a key passes validation.
+func (s *Session) askAPIKey() (string, error) {
+	for {
+		key, err := s.askSetupLine(SetupAskAPIKey)
+		if err != nil {
+			return "", err
+		}
+		if key == "" {
+			continue
+		}
+
+		logger.Any(SetupValidatingAPIKey)
+		if err = validateAPIKey(key); err == nil {
+			return key, nil
+		}
+		logger.Error(ErrorInvalidAPIKey, err)
+	}
+}
+
+// validateAPIKey checks the API key with the same lightweight request that is used when a session starts.
+func validateAPIKey(key string) error {
+	client, err := genai.NewClient(context.Background(), option.WithAPIKey(key))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	valid, err := SendDummyMessage(client)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return errors.New(ErrorLowLevelInvalidAPIKey)
+	}
+	return nil
+}
+
+// askSetupChoice asks the user to pick one of the options. An empty answer selects the default.
+func (s *Session) askSetupChoice(question string, options []string, defaultOption string) (string, error) {
+	prompt := fmt.Sprintf(SetupChoiceFormat, question, strings.Join(options, dotStringComma), defaultOption)
+	for {
+		answer, err := s.askSetupLine(prompt)
+		if err != nil {
+			return "", err
+		}
+		answer = strings.ToLower(answer)
+		if answer == "" {
+			return defaultOption, nil
+		}
+		if slices.Contains(options, answer) {
+			return answer, nil
+		}
+		logger.Error(ErrorInvalidSetupChoice, answer)
+	}
+}
+
+// askSetupLine prints the question and reads the answer from the input of the session.
+func (s *Session) askSetupLine(question string) (string, error) {
+	fprintPrefixWithTimeStamp(s.Output, SYSTEMPREFIX, "")
+	fmt.Fprint(s.Output, question)
+	line, err := s.Input.ReadLine()
+	if errors.Is(err, io.EOF) {
+		return "", errors.New(ErrorSetupAborted)
+	}
+	return strings.TrimSpace(line), err
+}
+
+// setupModels returns the supported models in a stable order, the default model first.
+func setupModels() []string {
+	models := []string{GeminiPro}
+	for model, valid := range supportedModels {
+		if valid && model != GeminiPro {
+			models = append(models, model)
+		}
+	}
+	slices.Sort(models[1:])
+	return models
+}
+
+// applyAppConfig applies the preferred model, safety level, persona and history options from the config to the session.
+func (s *Session) applyAppConfig(config *AppConfig) {
+	s.ChatConfig.DeduplicateUserMessages = config.DeduplicateUserMessages
+	if config.Model != "" {
+		s.DefaultModelName = config.Model
+		s.CurrentModelName = ""
+	}
+	if option, ok := safetyOptions[config.SafetyLevel]; ok {
+		option.Setter(s.SafetySettings)
+	}
+	if config.SystemInstruction != "" {
+		s.SystemInstruction = config.SystemInstruction
+	}
+}
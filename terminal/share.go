@@ -0,0 +1,140 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// sanitizeTranscript converts chat history messages into transcript entries.
+// ANSI color codes are removed and the data covered by the configured redaction profiles is masked.
+func sanitizeTranscript(messages []string) []TranscriptEntry {
+	filter := transcriptRedactionFilter()
+	entries := make([]TranscriptEntry, 0, len(messages))
+	for _, message := range messages {
+		message = strings.TrimSpace(ansiRegex.ReplaceAllString(message, ""))
+		prefix := YouNerd
+		for _, candidate := range []string{AiNerd, SYSTEMPREFIX, YouNerd} {
+			if strings.HasPrefix(message, candidate) {
+				prefix = candidate
+				message = strings.TrimSpace(strings.TrimPrefix(message, candidate))
+				break
+			}
+		}
+		// Note: The redaction filter only masks, so it never returns an error.
+		redacted, _, _ := filter.Apply(message)
+		entries = append(entries, TranscriptEntry{Prefix: prefix, Text: redacted})
+	}
+	return entries
+}
+
+// BuildTranscriptMarkdown renders chat history messages as a sanitized Markdown document.
+func BuildTranscriptMarkdown(messages []string, exportedAt time.Time) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, TranscriptMarkdownHeader, ApplicationName, exportedAt.Format(time.RFC1123))
+
+	for _, entry := range sanitizeTranscript(messages) {
+		fmt.Fprintf(&builder, TranscriptMarkdownMessage, strings.TrimSuffix(entry.Prefix, PrefixChar), entry.Text)
+	}
+	return builder.String()
+}
+
+// exportTranscriptFile writes the transcript of the messages to path, as HTML if the path ends in .html or .htm,
+// otherwise as Markdown.
+func exportTranscriptFile(messages []string, path string, exportedAt time.Time) error {
+	document := BuildTranscriptMarkdown(messages, exportedAt)
+	if ext := strings.ToLower(filepath.Ext(path)); ext == dotHTML || ext == dotHTM {
+		var err error
+		if document, err = BuildTranscriptHTML(messages, exportedAt); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, []byte(document), 0600)
+}
+
+// CreateGist uploads the content as a private (secret) GitHub Gist and returns its URL.
+//
+// Parameters:
+//
+//	ctx context.Context: The context for controlling the cancellation of the request.
+//	token string: A GitHub token with the "gist" scope.
+//	filename string: The name of the file inside the Gist.
+//	content string: The file content.
+//
+// Returns:
+//
+//	string: The HTML URL of the created Gist.
+//	error: An error if the request fails or GitHub does not create the Gist.
+func CreateGist(ctx context.Context, token, filename, content string) (string, error) {
+	payload, err := json.Marshal(GistRequest{
+		Description: fmt.Sprintf(GistDescription, ApplicationName),
+		Public:      false,
+		Files:       map[string]GistFile{filename: {Content: content}},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, GitHubRequestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, GitHubGistAPIURL, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set(HeaderAuthorization, BearerPrefix+token)
+	req.Header.Set(HeaderAccept, GitHubAcceptJSON)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	// Ensure the body of the response is closed when the function returns.
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf(ErrorGistNonCreatedStatusCode, resp.StatusCode)
+	}
+
+	var gist GistResponse
+	if err := json.NewDecoder(resp.Body).Decode(&gist); err != nil {
+		return "", err
+	}
+	return gist.HTMLURL, nil
+}
+
+// shareTranscript exports the transcript. With a GitHub token it is uploaded as a private Gist,
+// otherwise it is written to a Markdown file in the current directory.
+// It returns the Gist URL or the file path.
+func shareTranscript(ctx context.Context, markdown string, exportedAt time.Time) (string, error) {
+	filename := fmt.Sprintf(TranscriptFileName, exportedAt.Format(TranscriptTimeFormat))
+	token := os.Getenv(GitHubToken)
+	if token == "" {
+		if err := os.WriteFile(filename, []byte(markdown), 0600); err != nil {
+			return "", err
+		}
+		return filename, nil
+	}
+
+	var url string
+	operation := RetryableOperation{
+		retryFunc: func() (bool, error) {
+			var err error
+			url, err = CreateGist(ctx, token, filename, markdown)
+			return err == nil, err
+		},
+	}
+	if _, err := operation.retryWithExponentialBackoff(standardAPIErrorHandler); err != nil {
+		return "", err
+	}
+	return url, nil
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+
+package terminal
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// NewSpinner creates a new Spinner that shows the given message next to an animation, written to output.
+func NewSpinner(output io.Writer, message string) *Spinner {
+	return &Spinner{
+		output:   output,
+		message:  message,
+		frames:   spinnerFrames,
+		interval: SpinnerInterval,
+		done:     make(chan struct{}),
+	}
+}
+
+// Start begins animating the spinner in the background until Stop is called.
+func (sp *Spinner) Start() {
+	sp.wg.Add(1)
+	go func() {
+		defer sp.wg.Done()
+		ticker := time.NewTicker(sp.interval)
+		defer ticker.Stop()
+		for frame := 0; ; frame++ {
+			fmt.Fprintf(sp.output, SpinnerFormat, sp.frames[frame%len(sp.frames)], sp.message)
+			select {
+			case <-ticker.C:
+			case <-sp.done:
+				return
+			}
+		}
+	}()
+}
+
+// Stop halts the animation and clears the spinner line, so the next output starts on a clean line.
+// It is safe to call Stop more than once.
+func (sp *Spinner) Stop() {
+	sp.stopOnce.Do(func() {
+		close(sp.done)
+		sp.wg.Wait()
+		fmt.Fprint(sp.output, ClearLine)
+	})
+}
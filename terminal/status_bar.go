@@ -0,0 +1,27 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: The status bar is a plain line printed above each input prompt, rather than a line pinned to the bottom
+// of the screen, so it works in any terminal and does not interfere with scrollback or piped output.
+// It can be turned off with STATUS_BAR=false or "hide_status_bar": true in the config file.
+
+package terminal
+
+import (
+	"fmt"
+)
+
+// statusLine returns the one-line summary of the session state: the model, the tokens used and the safety level.
+func (s *Session) statusLine() string {
+	return fmt.Sprintf(StatusBarFormat, currentModelName(), totalTokenCount, s.SafetySettings.Level())
+}
+
+// printStatusBar prints the status line before the input prompt, unless it is disabled.
+// Viewer sessions never call the API, so they have no status to show.
+func (s *Session) printStatusBar() {
+	if s.ViewOnly || (appConfig != nil && appConfig.HideStatusBar) {
+		return
+	}
+	fmt.Fprintln(s.Output, s.statusLine())
+}
@@ -0,0 +1,356 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: Persistent storage is optional and enabled by naming a session (SESSION_NAME or "session_name" in the config file).
+// The history of a named session is stored as JSON in the "sessions" directory next to the config file.
+// Only one instance may write a session at a time: the writer holds a lock file containing its PID,
+// and any other instance opening the same session falls back to read-only, so the history file is never corrupted.
+
+package terminal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// sessionNameRegex restricts session names to characters that are safe in a file name on every platform.
+var sessionNameRegex = regexp.MustCompile(SessionNamePattern)
+
+// OpenSessionStore opens the persistent storage of the named session and acquires its lock.
+// If another running instance holds the lock, the store is opened read-only instead of failing,
+// so the stored history can still be used without being overwritten.
+//
+// Parameters:
+//
+//	dir string: The directory containing the stored sessions.
+//	name string: The session name.
+//
+// Returns:
+//
+//	*SessionStore: The opened store.
+//	error: An error if the name is invalid or the lock cannot be created.
+func OpenSessionStore(dir, name string) (*SessionStore, error) {
+	if !sessionNameRegex.MatchString(name) {
+		return nil, fmt.Errorf(ErrorInvalidSessionName, name)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	store := &SessionStore{
+		name:     name,
+		path:     filepath.Join(dir, name+SessionFileExtension),
+		lockPath: filepath.Join(dir, name+SessionLockExtension),
+	}
+	err := acquireSessionLock(store.lockPath)
+	var locked *SessionLockedError
+	switch {
+	case errors.As(err, &locked):
+		logger.Any(InfoSessionReadOnly, name, locked.PID)
+		store.readOnly = true
+	case err != nil:
+		return nil, err
+	}
+	return store, nil
+}
+
+// OpenSessionViewer opens the stored session read-only, without taking the lock,
+// so a session can be reviewed even while another instance is writing it.
+// Unlike OpenSessionStore, it fails if the session was never saved, since there is nothing to view.
+func OpenSessionViewer(dir, name string) (*SessionStore, error) {
+	if !sessionNameRegex.MatchString(name) {
+		return nil, fmt.Errorf(ErrorInvalidSessionName, name)
+	}
+	store := &SessionStore{
+		name:     name,
+		path:     filepath.Join(dir, name+SessionFileExtension),
+		lockPath: filepath.Join(dir, name+SessionLockExtension),
+		readOnly: true,
+	}
+	if _, err := os.Stat(store.path); errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf(ErrorStoredSessionNotFound, name, dir)
+	}
+	return store, nil
+}
+
+// loadViewerHistory loads the named stored session into a new chat history for viewer mode.
+func loadViewerHistory(name string) (*ChatHistory, *SessionStore, error) {
+	dir, err := sessionsDir()
+	if err != nil {
+		return nil, nil, err
+	}
+	store, err := OpenSessionViewer(dir, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	history := NewChatHistory()
+	if err := store.Load(history); err != nil {
+		return nil, nil, err
+	}
+	return history, store, nil
+}
+
+// sessionsDir returns the directory of the stored sessions, next to the application config file.
+func sessionsDir() (string, error) {
+	configPath, err := AppConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configPath), SessionsDir), nil
+}
+
+// defaultSessionStore opens the storage of the session named in the application config.
+// It returns nil if persistent storage is disabled or cannot be opened, in which case the history stays in memory only.
+func defaultSessionStore() *SessionStore {
+	if appConfig.SessionName == "" {
+		return nil
+	}
+	dir, err := sessionsDir()
+	if err != nil {
+		logger.Error(ErrorFailedToOpenSession, appConfig.SessionName, err)
+		return nil
+	}
+	store, err := OpenSessionStore(dir, appConfig.SessionName)
+	if err != nil {
+		logger.Error(ErrorFailedToOpenSession, appConfig.SessionName, err)
+		return nil
+	}
+	return store
+}
+
+// acquireSessionLock creates the lock file exclusively and writes the current PID to it.
+// A lock left behind by a process that is no longer running is removed and acquired again (see takeOverSessionLock).
+func acquireSessionLock(lockPath string) error {
+	for attempt := 0; ; attempt++ {
+		err := createSessionLock(lockPath, os.Getpid())
+		if err == nil || !errors.Is(err, fs.ErrExist) {
+			return err
+		}
+
+		pid := readSessionLockPID(lockPath)
+		if attempt > 0 || processRunning(pid) {
+			return &SessionLockedError{Path: lockPath, PID: pid}
+		}
+		// Note: The lock is stale (e.g., the owner crashed), so it is safe to take over.
+		if err := takeOverSessionLock(lockPath, pid); err != nil {
+			return err
+		}
+	}
+}
+
+// createSessionLock creates the lock file exclusively with the given PID. It fails with fs.ErrExist if the lock is taken.
+func createSessionLock(lockPath string, pid int) error {
+	file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = file.WriteString(strconv.Itoa(pid))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// takeOverSessionLock removes the stale lock left by the process with the given PID. The lock is renamed to a name
+// of this process first, and only removed if it still holds that PID: another instance may have taken it over
+// since it was read, and its new lock is then put back instead of being removed.
+// The put-back never overwrites a lock that a third instance created in the meantime; that one is kept.
+func takeOverSessionLock(lockPath string, pid int) error {
+	stalePath := lockPath + "." + strconv.Itoa(os.Getpid()) + SessionStaleSuffix
+	if err := os.Rename(lockPath, stalePath); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil // Another instance removed it first; the lock is created again or found taken.
+		}
+		return err
+	}
+	if owner := readSessionLockPID(stalePath); owner != pid {
+		if err := createSessionLock(lockPath, owner); errors.Is(err, fs.ErrExist) {
+			owner = readSessionLockPID(lockPath) // A third instance holds the lock now; give up rather than overwrite it.
+		} else if err != nil {
+			return err
+		}
+		if err := os.Remove(stalePath); err != nil {
+			return err
+		}
+		return &SessionLockedError{Path: lockPath, PID: owner}
+	}
+	logger.Debug(DebugStaleSessionLock, lockPath, pid)
+	return os.Remove(stalePath)
+}
+
+// readSessionLockPID returns the PID stored in the lock file, or 0 if it cannot be read.
+func readSessionLockPID(lockPath string) int {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
+
+// processRunning reports whether a process with the given PID is running.
+// An unknown PID (0) is treated as running, since a lock that is still being written must not be taken over.
+func processRunning(pid int) bool {
+	if pid <= 0 {
+		return true
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false // On Windows, FindProcess fails for processes that no longer exist.
+	}
+	// Note: Signal 0 only checks for existence. A permission error still means the process exists.
+	return !errors.Is(process.Signal(syscall.Signal(0)), os.ErrProcessDone)
+}
+
+// Error implements the error interface.
+func (e *SessionLockedError) Error() string {
+	return fmt.Sprintf(ErrorSessionLocked, e.Path, e.PID)
+}
+
+// ReadOnly reports whether the store was opened without the lock, in which case Save does nothing.
+func (s *SessionStore) ReadOnly() bool {
+	return s.readOnly
+}
+
+// Load replaces the chat history with the stored one. A session that was never saved leaves the history unchanged.
+func (s *SessionStore) Load(history *ChatHistory) error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var stored StoredSession
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return fmt.Errorf(ErrorInvalidSessionFile, s.path, err)
+	}
+	// Note: The stored hashes are not trusted, since they may have been written by an older version.
+	// Without hashes, restore indexes the messages again.
+	history.restore(chatHistorySnapshot{
+		messages:           stored.Messages,
+		userMessageCount:   stored.UserMessageCount,
+		aiMessageCount:     stored.AIMessageCount,
+		systemMessageCount: stored.SystemMessageCount,
+	})
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.contextCache = stored.ContextCache
+	return nil
+}
+
+// Save writes the chat history to the session file. The file is replaced atomically,
+// so a crash while saving never leaves a truncated history behind.
+// It does nothing if the store is read-only or closed.
+func (s *SessionStore) Save(history *ChatHistory) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.readOnly || s.closed {
+		return nil
+	}
+
+	data, err := marshalStoredSession(history.snapshot(), s.contextCache)
+	if err != nil {
+		return err
+	}
+	return writeSessionFile(s.path, data)
+}
+
+// marshalStoredSession returns the chat history snapshot in the JSON format of a stored session.
+func marshalStoredSession(snapshot chatHistorySnapshot, cache *ContextCache) ([]byte, error) {
+	return json.MarshalIndent(StoredSession{
+		SavedAt:            time.Now().Format(time.RFC3339),
+		Messages:           snapshot.messages,
+		Hashes:             snapshot.hashes,
+		UserMessageCount:   snapshot.userMessageCount,
+		AIMessageCount:     snapshot.aiMessageCount,
+		SystemMessageCount: snapshot.systemMessageCount,
+		ContextCache:       cache,
+	}, "", "  ")
+}
+
+// writeSessionFile replaces the file at path with data atomically, through a temporary file.
+func writeSessionFile(path string, data []byte) error {
+	tmpPath := path + SessionTempExtension
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// setContextCache replaces the cache stored with the session, which is written by the next Save.
+func (s *SessionStore) setContextCache(cache *ContextCache) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.contextCache = cache
+}
+
+// Close releases the lock, if it is held. It is safe to call more than once.
+func (s *SessionStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if s.readOnly {
+		return nil
+	}
+	return os.Remove(s.lockPath)
+}
+
+// saveHistory saves the chat history to the persistent storage, if it is enabled.
+// Failures are reported but never end the session, since the history is still available in memory.
+func (s *Session) saveHistory() {
+	if s.Store == nil {
+		return
+	}
+	if err := s.Store.Save(s.ChatHistory); err != nil {
+		logger.Error(ErrorFailedToSaveSession, s.Store.name, err)
+	}
+}
+
+// loadHistory replaces the chat history with the stored one, if persistent storage is enabled.
+// A stored session that cannot be loaded is left untouched and persistent storage is disabled for this run,
+// rather than overwriting the file with a new history.
+func (s *Session) loadHistory() {
+	if s.Store == nil {
+		return
+	}
+	if err := s.Store.Load(s.ChatHistory); err != nil {
+		logger.Error(ErrorFailedToOpenSession, s.Store.name, err)
+		s.Store.Close()
+		s.Store = nil
+		return
+	}
+	s.contextCache = s.Store.contextCache
+	stats := s.ChatHistory.GetMessageStats()
+	if total := stats.UserMessages + stats.AIMessages + stats.SystemMessages; total > 0 {
+		logger.Any(InfoSessionLoaded, total, s.Store.name)
+	}
+}
+
+// closeStore saves the chat history one last time and releases the session lock.
+func (s *Session) closeStore() {
+	if s.Store == nil {
+		return
+	}
+	s.saveHistory()
+	if err := s.Store.Close(); err != nil {
+		logger.Debug(DebugSessionLockNotReleased, s.Store.lockPath, err)
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+
+package terminal
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// NewStreamColorizer creates a StreamColorizer for the delimiters, colors and formatting
+// described by options. The Text field of options is ignored; text is passed to Write instead.
+//
+// Italic text, bold italic text and list markers are not handled here; they are rendered
+// line by line beforehand by renderEmphasis and SingleCharColorize.
+func NewStreamColorizer(options ColorizationOptions) *StreamColorizer {
+	sc := &StreamColorizer{
+		colors:         make(map[string]string, len(options.ColorPairs)/2),
+		keepDelimiters: options.KeepDelimiters,
+		formatting:     options.Formatting,
+	}
+	for i := 0; i+1 < len(options.ColorPairs); i += 2 {
+		delimiter, color := options.ColorPairs[i], options.ColorPairs[i+1]
+		sc.delimiters = append(sc.delimiters, delimiter)
+		sc.colors[delimiter] = color
+	}
+	// Longer delimiters must be matched first, so "```" is never read as three "`".
+	slices.SortStableFunc(sc.delimiters, func(a, b string) int {
+		return len(b) - len(a)
+	})
+	return sc
+}
+
+// Write colorizes the next chunk of text and returns the part of it that is ready to be printed.
+// A trailing partial delimiter (for example a single "`" that may become "```") or an incomplete
+// UTF-8 sequence is held back until the next call to Write or Flush.
+func (sc *StreamColorizer) Write(chunk string) string {
+	return sc.process(chunk, false)
+}
+
+// Flush returns the text held back by Write and closes any span left open, so no color or
+// formatting leaks past the end of the text. The colorizer can be reused afterwards.
+func (sc *StreamColorizer) Flush() string {
+	var result strings.Builder
+	result.WriteString(sc.process("", true))
+	if sc.open != "" {
+		sc.closeSpan(&result, false)
+	}
+	return result.String()
+}
+
+// process runs the state machine over the pending text followed by chunk.
+// Unless final is set, anything that cannot be decided yet is kept in pending.
+func (sc *StreamColorizer) process(chunk string, final bool) string {
+	text := sc.pending + chunk
+	sc.pending = ""
+
+	var result strings.Builder
+	result.Grow(len(text) * 2) // Preallocate with an estimated size
+
+	for i := 0; i < len(text); {
+		n, ok := sc.step(&result, text[i:], final)
+		if !ok {
+			sc.pending = text[i:]
+			break
+		}
+		i += n
+	}
+	return result.String()
+}
+
+// step handles the start of text and returns how many bytes were consumed.
+// It returns false if more input is needed to decide.
+func (sc *StreamColorizer) step(result *strings.Builder, text string, final bool) (int, bool) {
+	if n, ok, handled := sc.delimiter(result, text, final); handled || !ok {
+		return n, ok
+	}
+
+	// Keep multi-byte characters whole, so each returned chunk is valid UTF-8.
+	if text[0] >= utf8.RuneSelf && !final && !utf8.FullRuneInString(text) {
+		return 0, false
+	}
+	_, size := utf8.DecodeRuneInString(text)
+	result.WriteString(text[:size])
+	return size, true
+}
+
+// delimiter opens or closes a span if text starts with a delimiter.
+func (sc *StreamColorizer) delimiter(result *strings.Builder, text string, final bool) (n int, ok, handled bool) {
+	for _, d := range sc.candidates() {
+		if strings.HasPrefix(text, d) {
+			if d == sc.open {
+				sc.closeSpan(result, true)
+			} else if sc.open == "" {
+				sc.openSpan(result, d)
+			} else {
+				// Spans do not nest, so write the delimiter as is.
+				result.WriteString(d)
+			}
+			return len(d), true, true
+		}
+		if !final && len(text) < len(d) && strings.HasPrefix(d, text) {
+			// This might still become a delimiter once the next chunk arrives.
+			return 0, false, false
+		}
+	}
+	return 0, true, false
+}
+
+// candidates returns the delimiters that may appear next, longest first.
+func (sc *StreamColorizer) candidates() []string {
+	if sc.inCode() {
+		// Inside code, only the closing delimiter matters.
+		return []string{sc.open}
+	}
+	return sc.delimiters
+}
+
+// openSpan starts a span for the delimiter d.
+// The fences of a code block are colored on their own, and the code between them keeps its colors (see renderDiffBlocks).
+func (sc *StreamColorizer) openSpan(result *strings.Builder, d string) {
+	sc.open = d
+	if d == TripleBacktick {
+		sc.writeFence(result)
+		return
+	}
+	if format, hasFormat := sc.formatting[d]; hasFormat {
+		result.WriteString(format)
+	}
+	result.WriteString(sc.colors[d])
+	if shouldKeepDelimiter(d, sc.keepDelimiters) {
+		result.WriteString(d)
+	}
+}
+
+// closeSpan ends the open span. The closing delimiter is only written if it was
+// actually seen and the delimiter is kept.
+func (sc *StreamColorizer) closeSpan(result *strings.Builder, withDelimiter bool) {
+	defer func() { sc.open = "" }()
+	if sc.open == TripleBacktick {
+		if withDelimiter {
+			sc.writeFence(result)
+		}
+		return
+	}
+	if withDelimiter && shouldKeepDelimiter(sc.open, sc.keepDelimiters) {
+		result.WriteString(sc.open)
+	}
+	result.WriteString(ColorReset)
+	if _, hasFormat := sc.formatting[sc.open]; hasFormat {
+		result.WriteString(ResetBoldText)
+		result.WriteString(ResetItalicText)
+	}
+}
+
+// writeFence writes the triple backticks of a code block in their color, if they are kept.
+func (sc *StreamColorizer) writeFence(result *strings.Builder) {
+	if shouldKeepDelimiter(TripleBacktick, sc.keepDelimiters) {
+		result.WriteString(sc.colors[TripleBacktick] + TripleBacktick + ColorReset)
+	}
+}
+
+// inCode reports whether the open span is code, in which no other Markdown applies.
+func (sc *StreamColorizer) inCode() bool {
+	return sc.open == TripleBacktick || sc.open == SingleBacktick
+}
+
+// shouldKeepDelimiter checks if a delimiter should be kept in the final result.
+func shouldKeepDelimiter(delimiter string, keepDelimiters map[string]bool) bool {
+	keep, exists := keepDelimiters[delimiter]
+	return exists && keep
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: The supervisor only tracks the long-running background goroutines (the scheduler, the input reader and the signal handler).
+// Short-lived goroutines, such as the fan-out and token counting workers, report their errors to the caller instead.
+// The summarizer and the version checker run in the foreground of their commands, so they are not supervised either.
+
+package terminal
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// NewSupervisor creates a Supervisor without any workers.
+func NewSupervisor() *Supervisor {
+	return &Supervisor{workers: make(map[string]*WorkerStatus)}
+}
+
+// Go runs the worker in a new goroutine under supervision. If the worker panics, the panic is reported
+// through the panic recovery hook and the worker is restarted, up to MaxWorkerRestarts times.
+// A worker that returns normally is marked as stopped and is not restarted.
+//
+// Parameters:
+//
+//	name string: The name shown by ":workers". Starting a worker with the same name again replaces its status.
+//	run func(): The work loop of the worker.
+func (s *Supervisor) Go(name string, run func()) {
+	s.mu.Lock()
+	if _, ok := s.workers[name]; !ok {
+		s.order = append(s.order, name)
+	}
+	status := &WorkerStatus{Name: name, State: WorkerRunning, Since: time.Now()}
+	s.workers[name] = status
+	s.mu.Unlock()
+
+	go func() {
+		for s.runRecovered(status, run) {
+			time.Sleep(WorkerRestartDelay)
+			s.update(func() {
+				status.State = WorkerRunning
+				status.Since = time.Now()
+			})
+		}
+	}()
+}
+
+// runRecovered runs the worker once and reports whether it panicked and should be restarted.
+// The status is updated before the panic is reported, since printing the report takes a while.
+func (s *Supervisor) runRecovered(status *WorkerStatus, run func()) (restart bool) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		restarts := 0
+		s.update(func() {
+			status.LastPanic = fmt.Sprint(r)
+			status.Since = time.Now()
+			if status.Restarts >= MaxWorkerRestarts {
+				status.State = WorkerFailed
+				return
+			}
+			status.Restarts++
+			status.State = WorkerRestarting
+			restarts = status.Restarts
+		})
+		logger.reportPanic(r)
+
+		if restarts == 0 {
+			logger.Error(ErrorWorkerGaveUp, status.Name, MaxWorkerRestarts)
+			return
+		}
+		logger.Any(InfoWorkerRestarting, status.Name, restarts, MaxWorkerRestarts)
+		restart = true
+	}()
+
+	run()
+	s.update(func() {
+		status.State = WorkerStopped
+		status.Since = time.Now()
+	})
+	return false
+}
+
+// update changes the status of a worker while holding the lock.
+func (s *Supervisor) update(change func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	change()
+}
+
+// Statuses returns a copy of the status of every worker, in the order they were first started.
+func (s *Supervisor) Statuses() []WorkerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statuses := make([]WorkerStatus, 0, len(s.order))
+	for _, name := range s.order {
+		statuses = append(statuses, *s.workers[name])
+	}
+	return statuses
+}
+
+// formatWorkerStatuses formats the worker statuses as a table.
+func formatWorkerStatuses(statuses []WorkerStatus, now time.Time) string {
+	var builder strings.Builder
+	table := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, WorkerTableHeader)
+	for _, status := range statuses {
+		lastPanic := status.LastPanic
+		if lastPanic == "" {
+			lastPanic = WorkerNoPanic
+		}
+		fmt.Fprintf(table, WorkerTableRow,
+			status.Name,
+			status.State,
+			status.Restarts,
+			now.Sub(status.Since).Round(time.Second),
+			lastPanic,
+		)
+	}
+	table.Flush()
+	return builder.String()
+}
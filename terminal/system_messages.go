@@ -0,0 +1,76 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: System messages are grouped into categories by the prefix of their text, and each category has its own limit:
+// a new summary replaces the previous summary, a new persona or seed prompt replaces the previous one, and only the most recent
+// notices are kept. A message of one category never removes a message of another one.
+
+package terminal
+
+import (
+	"slices"
+	"strings"
+)
+
+// systemMessageRules lists the categories of system messages, in the order they are matched.
+// The last rule has no prefix and matches every other system message.
+var systemMessageRules = []SystemMessageRule{
+	{Category: SystemCategorySummary, Prefix: SummaryPrefix, Keep: 1},
+	{Category: SystemCategoryPersona, Prefix: PersonaPrefix, Keep: 1},
+	{Category: SystemCategorySeed, Prefix: SeedPromptPrefix, Keep: 1},
+	{Category: SystemCategoryNotice, Keep: MaxSystemNotices},
+}
+
+// systemMessageRule returns the rule of the category the formatted system message belongs to.
+func systemMessageRule(message string) SystemMessageRule {
+	text := strings.TrimPrefix(strings.TrimPrefix(message, SYSTEMPREFIX), " ")
+	for _, rule := range systemMessageRules {
+		if rule.Prefix == "" || strings.HasPrefix(text, rule.Prefix) {
+			return rule
+		}
+	}
+	return systemMessageRules[len(systemMessageRules)-1]
+}
+
+// addSystemMessage appends the formatted system message and removes the oldest messages of the same category
+// beyond the limit of that category. The caller must hold the lock.
+func (h *ChatHistory) addSystemMessage(message string) {
+	rule := systemMessageRule(message)
+	h.Messages = append(h.Messages, message)
+
+	// Walk from the newest message, so the oldest messages of the category are the ones removed.
+	seen := 0
+	kept := make([]string, 0, len(h.Messages))
+	for i := len(h.Messages) - 1; i >= 0; i-- {
+		if isSysMessage(h.Messages[i]) && systemMessageRule(h.Messages[i]).Category == rule.Category {
+			if seen++; seen > rule.Keep {
+				continue
+			}
+		}
+		kept = append(kept, h.Messages[i])
+	}
+	slices.Reverse(kept)
+
+	h.Messages = kept
+	h.reindexHashes()
+	h.SystemMessageCount = 0
+	for _, message := range h.Messages {
+		if isSysMessage(message) {
+			h.SystemMessageCount++
+		}
+	}
+}
+
+// systemMessagesOf returns the text of the system messages of the category without the system prefix, oldest first.
+func (h *ChatHistory) systemMessagesOf(category string) []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	var texts []string
+	for _, message := range h.Messages {
+		if isSysMessage(message) && systemMessageRule(message).Category == category {
+			texts = append(texts, strings.TrimSpace(strings.TrimPrefix(message, SYSTEMPREFIX)))
+		}
+	}
+	return texts
+}
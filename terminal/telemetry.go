@@ -0,0 +1,164 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: Telemetry is disabled unless the user opts in (":telemetry on", TELEMETRY=true or "telemetry" in the config file),
+// and even then nothing is sent without an endpoint. The payload is limited to TelemetryReport: the version, the platform
+// and aggregate counters. Errors are reduced to a fixed set of classes, so no error message, prompt or response ever leaves the machine.
+
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"runtime"
+	"strings"
+)
+
+// NewTelemetry creates a Telemetry with all counters at zero.
+func NewTelemetry() *Telemetry {
+	return &Telemetry{errorClasses: make(map[string]int64)}
+}
+
+// RecordMessageSent counts a message sent to the AI.
+func (t *Telemetry) RecordMessageSent() {
+	t.messagesSent.Add(1)
+}
+
+// RecordError counts a failed operation by the class of its error (see classifyError).
+func (t *Telemetry) RecordError(err error) {
+	class := classifyError(err)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.errorClasses[class]++
+}
+
+// Report returns the payload that would be sent, with a copy of the current counters.
+func (t *Telemetry) Report() TelemetryReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return TelemetryReport{
+		Version:      CurrentVersion,
+		OS:           runtime.GOOS,
+		Arch:         runtime.GOARCH,
+		MessagesSent: t.messagesSent.Load(),
+		ErrorClasses: maps.Clone(t.errorClasses),
+	}
+}
+
+// Send posts the report to the endpoint as JSON.
+//
+// Parameters:
+//
+//	ctx context.Context: The context for controlling the cancellation of the request.
+//	endpoint string: The URL the report is sent to.
+//
+// Returns:
+//
+//	error: An error if the request fails or the endpoint does not accept the report.
+func (t *Telemetry) Send(ctx context.Context, endpoint string) error {
+	payload, err := json.Marshal(t.Report())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, TelemetryTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(HeaderContentType, ContentTypeJSON)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	// Ensure the body of the response is closed when the function returns.
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf(ErrorTelemetryStatusCode, resp.StatusCode)
+	}
+	return nil
+}
+
+// classifyError reduces an error to one of the telemetry error classes, so the message itself is never reported.
+func classifyError(err error) string {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return TelemetryErrorCanceled
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return TelemetryErrorNetwork
+	}
+
+	message := err.Error()
+	switch {
+	case strings.Contains(message, Code500):
+		return TelemetryErrorServer
+	case strings.Contains(message, Code429), strings.Contains(message, ResourceExhausted):
+		return TelemetryErrorQuota
+	case strings.Contains(message, Code403), strings.Contains(message, PermissionDenied):
+		return TelemetryErrorPermission
+	default:
+		return TelemetryErrorOther
+	}
+}
+
+// telemetryEnabled reports whether the user opted in to telemetry.
+func telemetryEnabled() bool {
+	return appConfig != nil && appConfig.Telemetry
+}
+
+// sendTelemetry sends the counters if telemetry is enabled and an endpoint is configured.
+// Failures are only logged in debug mode, since telemetry must never get in the way of the user.
+func sendTelemetry() {
+	if !telemetryEnabled() || appConfig.TelemetryEndpoint == "" {
+		return
+	}
+	if err := telemetry.Send(context.Background(), appConfig.TelemetryEndpoint); err != nil {
+		logger.Debug(DebugTelemetryNotSent, err)
+		return
+	}
+	logger.Debug(DebugTelemetrySent, appConfig.TelemetryEndpoint)
+}
+
+// setTelemetry saves the telemetry choice to the config file and applies it to the running application.
+func setTelemetry(enabled bool) error {
+	path, err := updateAppConfigFile(func(config *AppConfig) {
+		config.Telemetry = enabled
+	})
+	if err != nil {
+		return err
+	}
+	appConfig.Telemetry = enabled
+	logger.Any(InfoTelemetrySaved, path)
+	return nil
+}
+
+// printTelemetryStatus shows whether telemetry is enabled, where it is sent and the exact payload.
+func printTelemetryStatus() {
+	state := Off
+	if telemetryEnabled() {
+		state = On
+	}
+	endpoint := TelemetryNoEndpoint
+	if appConfig != nil && appConfig.TelemetryEndpoint != "" {
+		endpoint = appConfig.TelemetryEndpoint
+	}
+	logger.Any(InfoTelemetryStatus, state, endpoint)
+
+	payload, err := json.MarshalIndent(telemetry.Report(), "", "  ")
+	if err != nil {
+		logger.Error(ErrorHandlingCommand, err)
+		return
+	}
+	logger.Any(InfoTelemetryPayload, string(payload))
+}
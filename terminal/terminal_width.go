@@ -0,0 +1,50 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+
+package terminal
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// terminalColumns is the width of the terminal kept up to date by watchTerminalResize, or 0 if it is not watched.
+var terminalColumns atomic.Int32
+
+// terminalWidth returns the width of the terminal in columns. If the output is not a terminal, COLUMNS is used,
+// and DefaultTerminalWidth if it is not set either.
+func terminalWidth() int {
+	if width := terminalColumns.Load(); width > 0 {
+		return int(width)
+	}
+	return measureTerminalWidth()
+}
+
+// measureTerminalWidth measures the width of the terminal, see terminalWidth.
+func measureTerminalWidth() int {
+	if width, err := consoleWidth(); err == nil && width > 0 {
+		return width
+	}
+	if width, err := strconv.Atoi(os.Getenv(ColumnsEnv)); err == nil && width > 0 {
+		return width
+	}
+	return DefaultTerminalWidth
+}
+
+// separatorLine returns a separator line spanning the width of the terminal.
+func separatorLine() string {
+	return strings.Repeat(SeparatorChar, terminalWidth())
+}
+
+// visibleWidth returns the width of the widest line of text, ignoring ANSI codes.
+func visibleWidth(text string) int {
+	width := 0
+	for _, line := range strings.Split(ansiRegex.ReplaceAllString(text, ""), StringNewLine) {
+		width = max(width, utf8.RuneCountInString(line))
+	}
+	return width
+}
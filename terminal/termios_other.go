@@ -0,0 +1,16 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+
+//go:build unix && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+// +build unix,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package terminal
+
+import "golang.org/x/sys/unix"
+
+// The requests that get and set the mode of a terminal (see newLineReader).
+const (
+	ioctlGetTermios = unix.TCGETS
+	ioctlSetTermios = unix.TCSETS
+)
@@ -0,0 +1,118 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: Token counts only depend on the model and the content, so they are cached by a SHA-256 hash of both.
+// Repeated ":tokencount :file" runs on unchanged files are then answered without an API round trip.
+
+package terminal
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// NewTokenCountCache creates a TokenCountCache and loads any entries previously saved to path.
+// An empty path keeps the cache in memory only.
+func NewTokenCountCache(path string) *TokenCountCache {
+	cache := &TokenCountCache{
+		entries: make(map[string]int),
+		path:    path,
+	}
+	if path == "" {
+		return cache
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		// Note: A missing cache file is normal on first use.
+		return cache
+	}
+	if err = json.Unmarshal(data, &cache.entries); err != nil {
+		logger.Debug(DebugTokenCountCacheDiscarded, path, err)
+		cache.entries = make(map[string]int)
+	}
+	return cache
+}
+
+// defaultTokenCountCache creates the token count cache stored next to the application config file.
+// It falls back to an in-memory cache if the location cannot be determined.
+func defaultTokenCountCache() *TokenCountCache {
+	configPath, err := AppConfigPath()
+	if err != nil {
+		return NewTokenCountCache("")
+	}
+	return NewTokenCountCache(filepath.Join(filepath.Dir(configPath), TokenCountCacheFile))
+}
+
+// TokenCountCacheKey returns the cache key for the given token count parameters.
+// The key covers the model name and the full content, so any change to either results in a new key.
+func TokenCountCacheKey(params TokenCountParams) string {
+	// Note: The fields are separated by a NUL byte, so different field boundaries cannot produce the same key.
+	data := [][]byte{[]byte(params.ModelName), {0}, []byte(params.ImageFormat), {0}, []byte(params.Input)}
+	for _, image := range params.ImageData {
+		data = append(data, []byte{0}, image)
+	}
+	return hashSHA256(data...)
+}
+
+// Get returns the cached token count for the key and whether it was found.
+func (c *TokenCountCache) Get(key string) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	count, ok := c.entries[key]
+	return count, ok
+}
+
+// Set stores the token count for the key and saves the cache to disk if it is persistent.
+// When the cache is full, an arbitrary entry is evicted to keep the file small.
+func (c *TokenCountCache) Set(key string, count int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= MaxTokenCountCacheEntries {
+		for oldKey := range c.entries {
+			delete(c.entries, oldKey)
+			break
+		}
+	}
+	c.entries[key] = count
+
+	if err := c.save(); err != nil {
+		logger.Debug(DebugTokenCountCacheNotSaved, c.path, err)
+	}
+}
+
+// save writes the cache entries to disk. It must be called with the mutex held.
+func (c *TokenCountCache) save() error {
+	if c.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data, 0600)
+}
+
+// countTokensCached returns the token count for the parameters, using the cache when possible.
+// The context allows the API request to be cancelled.
+func countTokensCached(ctx context.Context, params TokenCountParams) (int, error) {
+	key := TokenCountCacheKey(params)
+	if count, ok := tokenCountCache.Get(key); ok {
+		logger.Debug(DebugTokenCountCacheHit, count)
+		return count, nil
+	}
+
+	count, err := params.countTokensWithClient(ctx)
+	if err != nil {
+		return 0, err
+	}
+	tokenCountCache.Set(key, count)
+	return count, nil
+}
@@ -19,8 +19,8 @@ import (
 
 // CountTokens uses a generative AI model to count the number of tokens in the provided text input or image data.
 // It returns the token count and any error encountered in the process. A new client is created and closed within the function.
-func (p *TokenCountParams) CountTokens() (int, error) {
-	ctx := context.Background()
+// Cancelling the context (e.g., with Ctrl+C or when the session ends) stops the in-flight requests.
+func (p *TokenCountParams) CountTokens(ctx context.Context) (int, error) {
 	return p.countTokensWithClient(ctx)
 }
 
@@ -0,0 +1,55 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: This is an offline approximation, not the model's real tokenizer. It is only used when the API is unreachable
+// or when the user explicitly asks for an estimate, and its results are always labeled as estimates.
+
+package terminal
+
+import (
+	"unicode"
+)
+
+// EstimateTokens approximates the number of tokens in the text without calling the API.
+//
+// The approximation follows how subword tokenizers usually behave:
+//
+//   - Runs of letters and digits count as one token per ApproxCharsPerToken characters (at least one).
+//   - Each CJK character counts as one token, since they are rarely merged.
+//   - Each punctuation mark or symbol counts as one token.
+//   - Whitespace is free.
+func EstimateTokens(text string) int {
+	tokens := 0
+	wordLength := 0
+	flushWord := func() {
+		if wordLength > 0 {
+			tokens += (wordLength + ApproxCharsPerToken - 1) / ApproxCharsPerToken
+			wordLength = 0
+		}
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			flushWord()
+			tokens++
+		case unicode.IsLetter(r), unicode.IsDigit(r):
+			wordLength++
+		case unicode.IsSpace(r):
+			flushWord()
+		default:
+			flushWord()
+			tokens++
+		}
+	}
+	flushWord()
+
+	return tokens
+}
+
+// EstimateTokens approximates the number of tokens for the text and images in the parameters.
+// Each image is counted as ApproxTokensPerImage tokens, matching the fixed cost the API charges per image.
+func (p *TokenCountParams) EstimateTokens() int {
+	return EstimateTokens(p.Input) + len(p.ImageData)*ApproxTokensPerImage
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+
+package terminal
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// splitTokenCountOutput separates the ":format" and ":output" options from the file paths of ":tokencount".
+// Without ":format", the format is taken from the extension of the ":output" file (JSON unless it is ".csv").
+// It returns an error if an option is incomplete or the format is unknown.
+func splitTokenCountOutput(args []string) ([]string, TokenCountOutput, error) {
+	var filePaths []string
+	var output TokenCountOutput
+	for i := 0; i < len(args); i++ {
+		if args[i] != FormatArgs && args[i] != OutputArgs {
+			filePaths = append(filePaths, args[i])
+			continue
+		}
+		if i+1 >= len(args) {
+			return nil, output, fmt.Errorf(ErrorWhileTypingCommandArgs, TokenCountCommands, args)
+		}
+		if args[i] == FormatArgs {
+			output.Format = strings.ToLower(args[i+1])
+		} else {
+			output.Path = args[i+1]
+		}
+		i++
+	}
+
+	if output.Format == "" && output.Path != "" {
+		output.Format = TokenCountFormatJSON
+		if strings.EqualFold(filepath.Ext(output.Path), dotString+TokenCountFormatCSV) {
+			output.Format = TokenCountFormatCSV
+		}
+	}
+	switch output.Format {
+	case "", TokenCountFormatJSON, TokenCountFormatCSV:
+		return filePaths, output, nil
+	default:
+		return nil, output, fmt.Errorf(ErrorInvalidTokenCountFormat, output.Format)
+	}
+}
+
+// format returns the report as JSON or CSV. The CSV has a header row and ends with a row for the total.
+func (r TokenCountReport) format(format string) (string, error) {
+	if format != TokenCountFormatCSV {
+		data, err := json.MarshalIndent(r, "", "  ")
+		return string(data) + StringNewLine, err
+	}
+
+	var builder strings.Builder
+	writer := csv.NewWriter(&builder)
+	rows := [][]string{{"path", "tokens", "estimated"}}
+	for _, file := range r.Files {
+		rows = append(rows, []string{file.Path, strconv.Itoa(file.Tokens), strconv.FormatBool(file.Estimated)})
+	}
+	rows = append(rows, []string{TokenCountCSVTotal, strconv.Itoa(r.TotalTokens), strconv.FormatBool(r.Estimated)})
+	if err := writer.WriteAll(rows); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
+
+// writeTokenCountReport prints the report to w in the requested format, or saves it to the output file.
+func writeTokenCountReport(w io.Writer, report TokenCountReport, output TokenCountOutput) {
+	text, err := report.format(output.Format)
+	if err != nil {
+		logger.Error(ErrorFailedToSaveTokenCounts, err)
+		return
+	}
+	if output.Path == "" {
+		fmt.Fprint(w, text)
+		return
+	}
+	if err := os.WriteFile(output.Path, []byte(text), 0600); err != nil {
+		logger.Error(ErrorFailedToSaveTokenCounts, err)
+		return
+	}
+	logger.Any(InfoTokenCountSaved, output.Path)
+}
@@ -17,3 +17,6 @@ type ModelConfig func(m *genai.GenerativeModel)
 
 // RetryableFunc is a type that represents a function that can be retried.
 type RetryableFunc func() (bool, error)
+
+// CommandHook is a function that is notified after a command has been executed (see CommandRegistry.AddHook).
+type CommandHook func(event CommandEvent)
@@ -6,3 +6,9 @@ package terminal
 
 // MessageType categorizes the source of a chat message.
 type MessageType int
+
+// DiffOp describes how a message differs between two sessions (see diffMessages).
+type DiffOp int
+
+// FilterAction describes what the OutboundFilter should do when a rule matches.
+type FilterAction int
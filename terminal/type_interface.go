@@ -13,3 +13,23 @@ type Worker interface {
 	Start(ctx context.Context) error
 	Stop() error
 }
+
+// OutboundRule defines a pluggable rule evaluated by the OutboundFilter before user input
+// reaches the model. Apply returns the (possibly masked) text and the action taken.
+type OutboundRule interface {
+	Name() string
+	Apply(text string) (string, FilterAction)
+}
+
+// inputLineReader reads the input of the prompt queue line by line, e.g., with a line editor (see newLineReader).
+// Restore gives the terminal back in the state it was found in, if the reader changed it.
+type inputLineReader interface {
+	ReadLine() (string, error)
+	Restore()
+}
+
+// InlineCommand is implemented by the commands that can be used in a prompt, e.g. "!{:cryptorand :length 8}".
+// Inline returns the text that replaces the command in the prompt instead of printing it.
+type InlineCommand interface {
+	Inline(session *Session, parts []string) (string, error)
+}
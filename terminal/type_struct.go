@@ -8,9 +8,14 @@
 package terminal
 
 import (
+	"bufio"
 	"context"
-	"log"
+	"encoding/json"
+	"html/template"
+	"io"
+	"regexp"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	genai "github.com/google/generative-ai-go/genai"
@@ -72,6 +77,21 @@ type ChatConfig struct {
 	// when sending context to the AI. This allows the AI to generate responses that are
 	// relevant to the current conversation flow without being overwhelmed by too much history.
 	HistorySendToAI int
+
+	// DeduplicateUserMessages drops a user message that is already in the history.
+	// It is off by default, so asking the same question twice is kept as two messages.
+	DeduplicateUserMessages bool
+}
+
+// Spinner displays a small animation while a long-running operation is in progress.
+type Spinner struct {
+	output   io.Writer
+	message  string
+	frames   []string
+	interval time.Duration
+	done     chan struct{}
+	wg       sync.WaitGroup
+	stopOnce sync.Once
 }
 
 // ChatWorker is responsible for handling background tasks related to chat sessions.
@@ -79,6 +99,251 @@ type ChatWorker struct {
 	session *Session
 	ticker  *time.Ticker
 	done    chan bool
+	// mu protects schedules and nextID, which are changed by commands while the worker is running.
+	mu        sync.Mutex
+	schedules []*ScheduledPrompt
+	nextID    int
+	// lastBackup and backupDigest are only used by the worker itself (see runDueBackup).
+	lastBackup   time.Time
+	backupDigest string
+	// lastSummary is only used by the worker itself (see runDueSummary).
+	lastSummary time.Time
+}
+
+// PromptQueue reads the terminal input in the background and queues the lines typed while a prompt is in flight.
+type PromptQueue struct {
+	input     io.Reader
+	output    io.Writer // Echoes the lines typed, if the input is read by the line editor
+	startOnce sync.Once
+	ready     chan struct{} // Signaled when a line is queued or the input fails
+	// mu protects the fields below, which are shared between the reader goroutine and the session.
+	mu        sync.Mutex
+	reader    inputLineReader // Set once the queue starts reading the input
+	pending   []queuedLine
+	direct    chan string // Set while ReadLine waits for a line
+	busy      bool
+	intercept func(line string) bool
+	err       error // The error that stopped the reader, e.g., io.EOF
+}
+
+// queuedLine is a line waiting in the prompt queue. A line submitted by the background worker (see Submit) carries
+// the function that handles it on the main loop, instead of HandleInput.
+type queuedLine struct {
+	text   string
+	handle func(s *Session) bool
+}
+
+// bufferedLineReader reads the input as it is, when it is not a terminal or the line editing is not available.
+type bufferedLineReader struct {
+	reader *bufio.Reader
+}
+
+// FanoutResult is the outcome of a single prompt sent by ":fanout".
+type FanoutResult struct {
+	Prompt   string
+	Response string
+	Err      error
+}
+
+// SessionStore persists the chat history of a named session to a JSON file, guarded by a lock file
+// so only one running instance writes to it.
+type SessionStore struct {
+	name     string
+	path     string
+	lockPath string
+	readOnly bool // True when another instance holds the lock
+	closed   bool
+	mu       sync.Mutex
+	// contextCache is the cache of the chat history stored with the session (see ContextCache).
+	contextCache *ContextCache
+}
+
+// StoredSession is the on-disk format of a stored chat history.
+type StoredSession struct {
+	SavedAt            string         `json:"saved_at"`
+	Messages           []string       `json:"messages"`
+	Hashes             map[string]int `json:"hashes"`
+	UserMessageCount   int            `json:"user_message_count"`
+	AIMessageCount     int            `json:"ai_message_count"`
+	SystemMessageCount int            `json:"system_message_count"`
+	// ContextCache is the cached content of the chat history, reused after a restart until it expires.
+	ContextCache *ContextCache `json:"context_cache,omitempty"`
+}
+
+// StoredHistory is a chat history read from a stored session file, for other Go programs (see OpenHistory).
+// It is a read-only copy: changing it never changes the file.
+type StoredHistory struct {
+	SavedAt  time.Time // When the session was saved, or the zero time if it is unknown
+	messages []HistoryMessage
+}
+
+// HistoryMessage is a single message of a stored chat history.
+type HistoryMessage struct {
+	Type MessageType // Who sent the message: UserMessage, AIMessage or SystemMessage
+	Text string      // The message text without the sender prefix and color codes
+}
+
+// ContextCache refers to cached content on the API that holds the first messages of the chat history
+// sent as context, so it does not have to be sent again with every message (see CONTEXT_CACHE).
+type ContextCache struct {
+	Name      string    `json:"name"`       // The name of the cached content
+	Model     string    `json:"model"`      // The model the content was cached for
+	Start     int       `json:"start"`      // The position of the first cached message in the chat history
+	Messages  int       `json:"messages"`   // The number of cached messages
+	Digest    string    `json:"digest"`     // The digest of the cached messages (see hashMessages)
+	ExpiresAt time.Time `json:"expires_at"` // When the cached content expires
+}
+
+// SessionLockedError is returned when the session lock is held by another running instance.
+type SessionLockedError struct {
+	Path string // The lock file
+	PID  int    // The process holding the lock, or 0 if unknown
+}
+
+// ErrorHistory keeps the most recent error messages for the ":report" command. It is safe for concurrent use.
+type ErrorHistory struct {
+	mu      sync.Mutex
+	entries []RecordedError
+	limit   int
+}
+
+// RecordedError is an error message logged by the application.
+type RecordedError struct {
+	Time    time.Time
+	Message string
+}
+
+// RetryStats counts the outcomes of retryable operations for the ":report" command.
+type RetryStats struct {
+	Operations   atomic.Int64 // Operations that were started
+	Retries      atomic.Int64 // Retries after a retryable error
+	Exhausted    atomic.Int64 // Operations that failed after the maximum number of retries
+	NonRetryable atomic.Int64 // Operations that failed with a non-retryable error
+	OverBudget   atomic.Int64 // Operations that failed without retrying because the retry budget was exhausted
+}
+
+// Telemetry counts the events reported by the opt-in telemetry. It only holds counters, never any content.
+type Telemetry struct {
+	messagesSent atomic.Int64
+	mu           sync.Mutex
+	errorClasses map[string]int64
+}
+
+// Supervisor tracks the background goroutines and restarts them when they panic (see Supervisor.Go).
+type Supervisor struct {
+	mu      sync.Mutex
+	workers map[string]*WorkerStatus
+	order   []string
+}
+
+// WorkerStatus is the state of a supervised background goroutine, as shown by ":workers".
+type WorkerStatus struct {
+	Name      string
+	State     string
+	Restarts  int
+	Since     time.Time // When the worker entered its current state
+	LastPanic string
+}
+
+// TelemetryReport is the complete payload sent by the telemetry.
+type TelemetryReport struct {
+	Version      string           `json:"version"`
+	OS           string           `json:"os"`
+	Arch         string           `json:"arch"`
+	MessagesSent int64            `json:"messages_sent"`
+	ErrorClasses map[string]int64 `json:"error_classes"`
+}
+
+// SystemMessageRule describes a category of system messages and how many of them are kept in the chat history.
+type SystemMessageRule struct {
+	Category string // The name of the category, e.g., "summary"
+	Prefix   string // The prefix of the message text that identifies the category (empty matches any)
+	Keep     int    // The number of most recent messages of the category kept in the history
+}
+
+// CompactResult describes a compaction of the chat history (see Session.compactHistory).
+type CompactResult struct {
+	Removed      int // Messages replaced by the summary
+	TokensBefore int // Estimated tokens of the history before compacting
+	TokensAfter  int // Estimated tokens of the history after compacting
+}
+
+// MessageDiff is a single message of a history diff (see diffMessages).
+type MessageDiff struct {
+	Op      DiffOp // Whether the message was removed, added or is in both sessions
+	Message string // The formatted message
+}
+
+// DiagnosticField is a single line of the environment diagnostics (see environmentDiagnostics).
+type DiagnosticField struct {
+	Name  string
+	Value string
+}
+
+// CommandSpec declares how the registry routes the arguments of a command to its handlers (see CommandRegistry.RegisterSpec).
+// The first argument selects a subcommand when one is registered with that name. Otherwise, without arguments or
+// with free arguments, the arguments are checked by the validator and passed to Execute.
+type CommandSpec struct {
+	Handler     CommandHandler            // Handler executes the command itself.
+	Subcommands map[string]CommandHandler // Subcommands maps the name of each subcommand to its handler.
+	FreeArgs    bool                      // FreeArgs passes arguments that are not a subcommand (e.g., a prompt or a path) to Execute.
+	Validate    func(parts []string) bool // Validate checks the arguments before Execute, if set (e.g., the IsValid method of Handler).
+}
+
+// CommandError is the failure of a command, returned by HandleCommand. Its Code tells the kind of failure
+// (e.g., CommandErrorInvalidArgs), so programs driving the chat can handle it without parsing the message.
+type CommandError struct {
+	Command string // The command name, e.g., ":checkmodel"
+	Code    string // The error code, one of the CommandError constants
+	Err     error  // The underlying error, whose message is shown to the user
+}
+
+// CommandEvent describes a single command execution, passed to the registered CommandHook functions.
+type CommandEvent struct {
+	Name        string        // The command name, e.g., ":checkmodel"
+	Args        []string      // The arguments after the command name
+	Time        time.Time     // When the command started
+	Duration    time.Duration // How long the command took
+	Recognized  bool          // Whether the command is registered
+	EndsSession bool          // Whether the command ended the session
+	Err         error         // The error returned by the command handler, if any
+}
+
+// AuditLog appends command events to a local JSON Lines file.
+type AuditLog struct {
+	path   string
+	filter *OutboundFilter // Masks secrets in the recorded arguments
+	mu     sync.Mutex
+}
+
+// AuditEntry is a single line of the audit log.
+type AuditEntry struct {
+	Time       string `json:"time"`
+	Command    string `json:"command"`
+	Args       string `json:"args,omitempty"`
+	Outcome    string `json:"outcome"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// ScheduledPrompt is a prompt that the ChatWorker sends to the AI on a cron schedule.
+type ScheduledPrompt struct {
+	ID         int       // The ID used to cancel the prompt
+	Spec       string    // The cron expression, e.g., "0 9 * * *"
+	Prompt     string    // The prompt sent to the AI
+	OutputFile string    // Optional file the responses are appended to
+	Next       time.Time // The next time the prompt runs
+	schedule   *CronSchedule
+}
+
+// CronSchedule is a parsed cron expression. Each field is a bit set of the allowed values.
+type CronSchedule struct {
+	minutes     uint64
+	hours       uint64
+	daysOfMonth uint64
+	months      uint64
+	daysOfWeek  uint64
+	anyDayOfMon bool // The day-of-month field is "*"
+	anyDayOfWk  bool // The day-of-week field is "*"
 }
 
 // ColorizationOptions encapsulates the settings necessary for the Colorize function to apply color to text.
@@ -94,6 +359,8 @@ type ColorizationOptions struct {
 // ColorizationPartOptions holds the options for colorizing parts of a text.
 // It contains the text to be colorized, the delimiter that marks the text to be colorized,
 // the color to apply, and maps that determine whether to keep delimiters and how to format the text.
+//
+// Deprecated: This type is no longer used, since Colorize is implemented by StreamColorizer.
 type ColorizationPartOptions struct {
 	Text           string            // Text is the string that will be processed for colorization.
 	Delimiter      string            // Delimiter is the string that marks the beginning and end of text to be colorized.
@@ -102,11 +369,70 @@ type ColorizationPartOptions struct {
 	Formatting     map[string]string // Formatting is a map of delimiters to their corresponding ANSI formatting codes.
 }
 
+// StreamColorizer implements Colorize incrementally, for Markdown that arrives in chunks,
+// such as a streamed response. It keeps track of the spans that are still open, so a
+// delimiter may be opened in one chunk and closed in a later one.
+//
+// Note: Spans do not nest. While a span is open, only its own closing delimiter is
+// recognized; other delimiters are written as they are.
+type StreamColorizer struct {
+	delimiters     []string          // delimiters holds the configured delimiters, longest first.
+	colors         map[string]string // colors maps each delimiter to its ANSI color code.
+	keepDelimiters map[string]bool   // keepDelimiters dictates whether each delimiter is kept in the output.
+	formatting     map[string]string // formatting maps delimiters to their ANSI formatting codes.
+	open           string            // open is the delimiter of the span currently open, if any.
+	pending        string            // pending is the tail of the last chunk that cannot be decided yet.
+}
+
+// emphasisRun is a run of asterisks in a line of Markdown, see renderEmphasis.
+type emphasisRun struct {
+	start     int   // start is the offset of the first asterisk in the line.
+	end       int   // end is the offset just past the last asterisk.
+	remaining int   // remaining is the number of asterisks not used for emphasis, which are written as is.
+	canOpen   bool  // canOpen reports whether the run may open emphasis.
+	canClose  bool  // canClose reports whether the run may close emphasis.
+	opens     []int // opens lists the emphasis opened by the run as asterisk counts, innermost first.
+	closes    []int // closes lists the emphasis closed by the run as asterisk counts, innermost first.
+}
+
+// FileDiff is the part of a unified diff that changes a single file.
+type FileDiff struct {
+	OldPath string     // OldPath is the path in the "---" header, or /dev/null for a new file.
+	NewPath string     // NewPath is the path in the "+++" header.
+	Hunks   []DiffHunk // Hunks are the changes to the file, in order.
+}
+
+// DiffHunk is a single hunk of a unified diff.
+type DiffHunk struct {
+	Header   string   // Header is the "@@" line of the hunk.
+	OldStart int      // OldStart is the 1-based line in the original file where the hunk starts.
+	Lines    []string // Lines are the context (" "), removed ("-") and added ("+") lines of the hunk.
+}
+
+// HistoryTransaction collects changes to a ChatHistory and applies them together (see ChatHistory.Begin).
+type HistoryTransaction struct {
+	history *ChatHistory
+	changes []func(h *ChatHistory) // Applied in order, with the lock of history held
+	done    bool                   // Committed or rolled back
+}
+
+// Memory is the on-disk format of the remembered facts, which are shared by all sessions (see ":remember").
+type Memory struct {
+	Facts  []MemoryFact `json:"facts"`
+	NextID int          `json:"next_id"` // The ID of the last remembered fact
+}
+
+// MemoryFact is a remembered fact.
+type MemoryFact struct {
+	ID        int       `json:"id"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 // DebugOrErrorLogger provides a simple logger with support for debug and error logging.
-// It encapsulates a standard log.Logger and adds functionality for conditional debug
-// logging and colorized error output.
+// It prints with the typing effect to the output of the chat, and adds functionality for
+// conditional debug logging and colorized error output.
 type DebugOrErrorLogger struct {
-	logger          *log.Logger
 	debugMode       bool
 	PrintTypingChat func(string, time.Duration)
 }
@@ -119,6 +445,8 @@ type ErrorASCIIArt struct {
 // FormattingOptions encapsulates the settings required to apply formatting to a text segment.
 // It includes the text to be formatted, the delimiter used to identify the text segment,
 // the color to apply, and a map specifying the ANSI formatting codes associated with different delimiters.
+//
+// Deprecated: This type is only used by the deprecated ApplyFormatting.
 type FormattingOptions struct {
 	Text       string            // Text is the string to which formatting will be applied.
 	Delimiter  string            // Delimiter is the string used to identify segments of text for formatting.
@@ -141,6 +469,97 @@ type GitHubRelease struct {
 	Date    string `json:"published_at"` // Published Date
 }
 
+// Recorder is an http.RoundTripper that records the requests of a genai.Client and their responses to a fixture
+// file, or replays them from it without calling the API (see NewRecorder).
+type Recorder struct {
+	path         string
+	mode         string
+	apiKey       string
+	filter       *OutboundFilter // Masks secrets in the recorded bodies
+	interactions []RecordedInteraction
+	next         int // The index of the next interaction to replay
+	mu           sync.Mutex
+}
+
+// RecordedInteraction is a request and its response, as stored in a fixture file.
+type RecordedInteraction struct {
+	Method       string `json:"method"`
+	Path         string `json:"path"`
+	RequestBody  string `json:"request_body,omitempty"`
+	Status       int    `json:"status"`
+	ContentType  string `json:"content_type,omitempty"`
+	ResponseBody string `json:"response_body"`
+}
+
+// TranscriptEntry is a single sanitized message of an exported transcript.
+type TranscriptEntry struct {
+	Prefix string // The sender prefix, e.g., YouNerd, AiNerd or SYSTEMPREFIX
+	Text   string // The message text with colors removed and sensitive data masked
+}
+
+// ImportParser converts the content of an exported chat into transcript entries.
+// The selector chooses a conversation when the export contains several of them.
+type ImportParser func(data []byte, selector string) ([]TranscriptEntry, error)
+
+// ChatGPTConversation is a conversation in a ChatGPT "conversations.json" export.
+// Messages are stored as a tree in Mapping; CurrentNode is the last message of the active branch.
+type ChatGPTConversation struct {
+	Title       string                 `json:"title"`
+	UpdateTime  float64                `json:"update_time"`
+	CurrentNode string                 `json:"current_node"`
+	Mapping     map[string]ChatGPTNode `json:"mapping"`
+}
+
+// ChatGPTNode is a node of the message tree of a ChatGPT conversation.
+type ChatGPTNode struct {
+	Message *ChatGPTMessage `json:"message"`
+	Parent  string          `json:"parent"`
+}
+
+// ChatGPTMessage is a single message of a ChatGPT conversation.
+type ChatGPTMessage struct {
+	Author struct {
+		Role string `json:"role"`
+	} `json:"author"`
+	Content struct {
+		// Parts are usually strings, but can be objects for non-text content.
+		Parts []json.RawMessage `json:"parts"`
+	} `json:"content"`
+}
+
+// GeminiTakeoutActivity is an entry of the Gemini Apps activity exported by Google Takeout.
+type GeminiTakeoutActivity struct {
+	Title        string `json:"title"`
+	Time         string `json:"time"`
+	SafeHTMLItem []struct {
+		HTML string `json:"html"`
+	} `json:"safeHtmlItem"`
+}
+
+// transcriptHTMLMessage is a transcript entry prepared for the HTML template.
+type transcriptHTMLMessage struct {
+	Class   string        // CSS class selecting the sender color
+	Sender  string        // The sender name shown above the message
+	Content template.HTML // The rendered and escaped message body
+}
+
+// GistRequest is the payload for creating a GitHub Gist.
+type GistRequest struct {
+	Description string              `json:"description"` // A short description shown on the Gist page
+	Public      bool                `json:"public"`      // False creates a secret Gist that is only reachable by URL
+	Files       map[string]GistFile `json:"files"`       // The files of the Gist, keyed by file name
+}
+
+// GistFile is a single file inside a GitHub Gist.
+type GistFile struct {
+	Content string `json:"content"` // The file content
+}
+
+// GistResponse holds the fields of a created GitHub Gist that the application needs.
+type GistResponse struct {
+	HTMLURL string `json:"html_url"` // The URL of the Gist page
+}
+
 // MessageStats encapsulates the counts of different types of messages in the chat history.
 // It holds separate counts for user messages, AI messages, and system messages.
 type MessageStats struct {
@@ -149,6 +568,23 @@ type MessageStats struct {
 	SystemMessages int // SystemMessages is the count of system-generated messages.
 }
 
+// OutboundFilter inspects user input before it is sent to the AI model.
+// It holds an ordered list of pluggable rules that can either mask matching content
+// or block the whole message, complementing SafetySettings which only filter responses.
+type OutboundFilter struct {
+	Enabled bool           // Enabled indicates whether the filter inspects outbound content.
+	rules   []OutboundRule // rules are evaluated in insertion order.
+	mu      sync.RWMutex   // mu protects rules.
+}
+
+// RegexRule is an OutboundRule backed by a regular expression.
+type RegexRule struct {
+	RuleName    string         // RuleName is the identifier shown when the rule triggers.
+	Pattern     *regexp.Regexp // Pattern is the compiled expression to match.
+	Action      FilterAction   // Action is either FilterMask or FilterBlock.
+	Replacement string         // Replacement is used in place of each match when masking.
+}
+
 // RetryableOperation encapsulates an operation that may need to be retried upon failure.
 // It contains a retryFunc of type RetryableFunc, which is a function that performs
 // the actual operation and returns a success flag along with an error if one occurred.
@@ -183,25 +619,93 @@ type RetryableOperation struct {
 	retryFunc RetryableFunc
 }
 
+// RetryPolicy controls how retryWithExponentialBackoff spaces its attempts.
+// The delay doubles after every attempt, is capped at MaxDelay, and is then reduced by a random
+// fraction of up to Jitter, so clients that failed together do not all retry at the same moment.
+type RetryPolicy struct {
+	MaxRetries int           // Total number of attempts
+	BaseDelay  time.Duration // Delay after the first failed attempt
+	MaxDelay   time.Duration // Upper bound of the delay between attempts
+	Jitter     float64       // Fraction of the delay (0 to 1) that is randomized
+	// RetryableStatusCodes lists the HTTP status codes of the errors that are retried (by default, only 500).
+	RetryableStatusCodes []int
+	// Budget is the number of retries a session can spend in a burst, earning one back every BudgetRefill (see RetryBudget).
+	Budget       int
+	BudgetRefill time.Duration
+}
+
+// RetryBudget limits the retries of all operations of a session. It is a token bucket:
+// every retry spends a token, and tokens are earned back over time up to the capacity.
+type RetryBudget struct {
+	mu       sync.Mutex
+	capacity float64
+	tokens   float64
+	refill   time.Duration
+	last     time.Time
+}
+
+// RetryPolicyConfig is the "retry_policy" section of the config file. Omitted fields keep the defaults.
+type RetryPolicyConfig struct {
+	MaxRetries     int      `json:"max_retries,omitempty"`
+	BaseDelayMs    int      `json:"base_delay_ms,omitempty"`
+	MaxDelayMs     int      `json:"max_delay_ms,omitempty"`
+	Jitter         *float64 `json:"jitter,omitempty"` // A pointer, so 0 can disable the jitter
+	Budget         int      `json:"budget,omitempty"`
+	BudgetRefillMs int      `json:"budget_refill_ms,omitempty"`
+	// RetryableStatusCodes replaces the default list of retried HTTP status codes.
+	RetryableStatusCodes []int `json:"retryable_status_codes,omitempty"`
+}
+
 // Session encapsulates the state and functionality for a chat session with a generative AI model.
 // It holds the AI client, chat history, and context for managing the session lifecycle.
 type Session struct {
-	Client           *genai.Client      // Client is the generative AI client used to communicate with the AI model.
-	ChatHistory      *ChatHistory       // ChatHistory stores the history of the chat session.
-	ChatConfig       *ChatConfig        // ChatConfig contains the settings for managing the chat history size.
-	Ctx              context.Context    // Ctx is the context governing the session, used for cancellation.
-	Cancel           context.CancelFunc // Cancel is a function to cancel the context, used for cleanup.
-	Ended            bool               // Ended indicates whether the session has ended.
-	SafetySettings   *SafetySettings    // Holds the current safety settings for the session.
-	CurrentModelName string             // Holds the current AI model name
-	DefaultModelName string             // Default AI model name to use if no current model is set
+	Client             *genai.Client       // Client is the generative AI client used to communicate with the AI model.
+	ChatHistory        *ChatHistory        // ChatHistory stores the history of the chat session.
+	ChatConfig         *ChatConfig         // ChatConfig contains the settings for managing the chat history size.
+	Ctx                context.Context     // Ctx is the context governing the session, used for cancellation.
+	Cancel             context.CancelFunc  // Cancel is a function to cancel the context, used for cleanup.
+	Ended              bool                // Ended indicates whether the session has ended.
+	SafetySettings     *SafetySettings     // Holds the current safety settings for the session.
+	CurrentModelName   string              // Holds the current AI model name
+	DefaultModelName   string              // Default AI model name to use if no current model is set
+	OutboundFilter     *OutboundFilter     // Inspects user input before it is sent to the AI model.
+	GenerationSettings *GenerationSettings // Holds the user-adjustable generation parameters for the session.
+	Worker             *ChatWorker         // Runs background tasks, such as scheduled prompts.
+	DryRun             bool                // When true, requests are printed instead of being sent to the AI.
+	Quiet              bool                // When true, non-essential system messages (e.g., token counts) are not printed.
+	Store              *SessionStore       // Persists the chat history of a named session (nil when disabled).
+	ViewOnly           bool                // When true, the session only browses a stored history and never calls the API.
+	RetryBudget        *RetryBudget        // Limits the retries of all operations of this session.
+	RetryPolicy        RetryPolicy         // Retries the failed requests of this session (see ":retrypolicy").
+	StartedAt          time.Time           // When the session was started, for the uptime shown by ":info".
+	Input              *PromptQueue        // Reads the user input of the session (see SetIO).
+	Output             io.Writer           // Receives everything the session prints for the user (see SetIO).
+	SystemInstruction  string              // The persona the AI follows during the whole session (see ":persona").
+	inputTokenLimits   map[string]int      // Input token limit of each model, cached for auto-compaction.
+	// Named copies of the chat history (see ":checkpoint").
+	checkpoints map[string]chatHistorySnapshot
+	// incognito keeps the response out of the chat history while an incognito prompt is sent (see ":incognito").
+	incognito bool
+	// historyTx collects the chat history changes of the command being executed, if any (see withHistoryTransaction).
+	historyTx *HistoryTransaction
+	// contextCache is the cached content of the chat history sent as context, if any (see CONTEXT_CACHE).
+	contextCache *ContextCache
+	// contextCacheUnsupported is the model that failed to cache content, so it is not tried again.
+	contextCacheUnsupported string
+	// inputTokenLimitErrs holds why the input token limit of a model could not be fetched, so it is not fetched again.
+	inputTokenLimitErrs map[string]error
+	// memory holds the remembered facts (see ":remember").
+	memory *Memory
 	// mu protects the concurrent access to session's state, ensuring thread safety.
 	// It should be locked when accessing or modifying the session's state.
 	mu sync.Mutex
 	// this reference pretty useful, which can handle runtime 24/7, unlike original ai chat session systems.
 	// for example, if session is ended not cause of client, then it will be renew with previous chat history.
 	lastInput string // Stores the last user input for reference
-
+	// opMu protects opCancel. It is separate from mu so an interrupt never waits on other session state.
+	opMu sync.Mutex
+	// opCancel cancels the currently running cancellable operation, if any (see beginOperation).
+	opCancel context.CancelFunc
 }
 
 // SafetyOption is a function type that takes a pointer to a SafetySettings
@@ -214,6 +718,188 @@ type SafetyOption struct {
 	Valid  bool
 }
 
+// AppConfig holds the user configuration loaded from the application config file
+// and environment variables.
+type AppConfig struct {
+	// AllowedFileExtensions lists the text file extensions accepted by the token count file feature.
+	AllowedFileExtensions []string `json:"allowed_file_extensions,omitempty"`
+	// RedactionProfiles lists the redaction profiles applied to exported and shared transcripts.
+	RedactionProfiles []string `json:"redaction_profiles,omitempty"`
+	// AuditLog enables recording every executed command to the audit log file.
+	AuditLog bool `json:"audit_log,omitempty"`
+	// SessionName enables persistent storage of the chat history under the given name.
+	SessionName string `json:"session_name,omitempty"`
+	// APIKey is used when the API_KEY environment variable is not set. It is written by the setup wizard.
+	APIKey string `json:"api_key,omitempty"`
+	// Model is the preferred model for new sessions. MODEL_NAME takes precedence.
+	Model string `json:"model,omitempty"`
+	// SystemInstruction is the persona of new sessions (see ":persona"). SYSTEM_INSTRUCTION takes precedence.
+	SystemInstruction string `json:"system_instruction,omitempty"`
+	// SafetyLevel is the safety level applied to new sessions (e.g., "default" or "high").
+	SafetyLevel string `json:"safety_level,omitempty"`
+	// Theme selects the color theme (see themes).
+	Theme string `json:"theme,omitempty"`
+	// CrashReports enables writing a crash report file when a panic is recovered.
+	CrashReports bool `json:"crash_reports,omitempty"`
+	// Telemetry opts in to sending aggregate counters (see TelemetryReport) when a session ends.
+	Telemetry bool `json:"telemetry,omitempty"`
+	// TelemetryEndpoint is the URL the telemetry counters are sent to.
+	TelemetryEndpoint string `json:"telemetry_endpoint,omitempty"`
+	// AutoCompactPercent compacts the chat history before a send once it exceeds this percentage
+	// of the model's input token limit (0 disables it, see ":compact").
+	AutoCompactPercent int `json:"auto_compact_percent,omitempty"`
+	// TypingEffect is the granularity of the typing effect: "char" (the default), "word" or "line".
+	TypingEffect string `json:"typing_effect,omitempty"`
+	// ContextCache caches a long chat history on the API, so it is not sent again with every message.
+	ContextCache bool `json:"context_cache,omitempty"`
+	// MemoryFacts is the number of most recently remembered facts sent with every message (0 disables them).
+	MemoryFacts int `json:"memory_facts"`
+	// ExtractMemoryFacts remembers the durable facts about the user when the conversation is summarized or compacted.
+	ExtractMemoryFacts bool `json:"extract_memory_facts,omitempty"`
+	// CommandSafety maps commands (e.g., ":aitranslate") to the safety level used while they run,
+	// instead of the safety settings of the session.
+	CommandSafety map[string]string `json:"command_safety,omitempty"`
+	// DeduplicateUserMessages drops a user message that is already in the chat history (see ChatConfig).
+	DeduplicateUserMessages bool `json:"deduplicate_user_messages,omitempty"`
+	// HideStatusBar turns off the status line printed above the input prompt.
+	HideStatusBar bool `json:"hide_status_bar,omitempty"`
+	// Preflight checks the tokens of each prompt before it is sent: "estimate" (offline) or "count" (with the API).
+	// It warns if they exceed PreflightTokens or the model's input limit, and offers to compact the history.
+	Preflight string `json:"preflight,omitempty"`
+	// PreflightTokens is the number of tokens above which the preflight check warns (0 only checks the model's limit).
+	PreflightTokens int `json:"preflight_tokens,omitempty"`
+	// Quiet starts the sessions in quiet mode, where non-essential system messages are hidden (see ":quiet").
+	Quiet bool `json:"quiet,omitempty"`
+	// Prompts overrides the built-in prompts by name (see ":prompt").
+	Prompts map[string]string `json:"prompts,omitempty"`
+	// HTTP configures the shared HTTP client used for GitHub, Gists and telemetry.
+	HTTP *HTTPConfig `json:"http,omitempty"`
+	// RetryPolicy tunes the retries of failed API and GitHub requests (see RetryPolicy).
+	RetryPolicy *RetryPolicyConfig `json:"retry_policy,omitempty"`
+	// WebSearch configures the search engine of ":search :web" and of the web_search tool of the AI.
+	WebSearch *WebSearchConfig `json:"web_search,omitempty"`
+	// Backup periodically copies the chat history to a directory, even when it is not stored (see BackupConfig).
+	Backup *BackupConfig `json:"backup,omitempty"`
+	// AutoSummary periodically replaces the oldest messages of a long chat history with a summary (see AutoSummaryConfig).
+	AutoSummary *AutoSummaryConfig `json:"auto_summary,omitempty"`
+}
+
+// BackupConfig holds the settings of the automatic backups of the chat history (see runDueBackup).
+type BackupConfig struct {
+	// Dir is the directory the backups are written to. BACKUP_DIR takes precedence.
+	Dir string `json:"dir"`
+	// IntervalMinutes is the time between two backups, 5 minutes by default.
+	IntervalMinutes int `json:"interval_minutes,omitempty"`
+	// Keep is the number of most recent backups kept, 10 by default.
+	Keep int `json:"keep,omitempty"`
+}
+
+// AutoSummaryConfig holds the settings of the background summary of the chat history (see runDueSummary).
+// The history is summarized once it exceeds Messages or Tokens, whichever is reached first.
+type AutoSummaryConfig struct {
+	// Messages is the number of user and AI messages above which the history is summarized. AUTO_SUMMARY_MESSAGES takes precedence.
+	Messages int `json:"messages,omitempty"`
+	// Tokens is the estimated number of tokens of the history above which it is summarized.
+	Tokens int `json:"tokens,omitempty"`
+	// IntervalMinutes is the time between two checks, 1 minute by default.
+	IntervalMinutes int `json:"interval_minutes,omitempty"`
+	// Keep is the number of most recent user and AI messages that are not summarized, 10 by default.
+	Keep int `json:"keep,omitempty"`
+}
+
+// WebSearchConfig holds the settings of the web search (see searchWeb).
+type WebSearchConfig struct {
+	// Engine is the search API: "brave", "google" or "searxng".
+	Engine string `json:"engine"`
+	// Endpoint is the URL of the SearXNG instance. For Brave and Google, it replaces the URL of the API.
+	Endpoint string `json:"endpoint,omitempty"`
+	// APIKey is the key of the Brave or Google API. WEB_SEARCH_API_KEY takes precedence.
+	APIKey string `json:"api_key,omitempty"`
+	// EngineID is the ID of the Google Programmable Search Engine ("cx").
+	EngineID string `json:"engine_id,omitempty"`
+	// MaxResults is the number of results used, 5 by default.
+	MaxResults int `json:"max_results,omitempty"`
+	// Tool lets the AI search the web on its own, through function calling.
+	Tool bool `json:"tool,omitempty"`
+}
+
+// WebSearchResult is a single result of a web search.
+type WebSearchResult struct {
+	Title   string
+	URL     string
+	Snippet string
+}
+
+// HTTPConfig holds the settings of the shared HTTP client (see NewHTTPClient).
+type HTTPConfig struct {
+	// TimeoutSeconds bounds every request, including reading the response. Defaults to 30 seconds.
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+	// Proxy is the URL of the proxy for all requests. Without it, the standard proxy environment variables are used.
+	Proxy string `json:"proxy,omitempty"`
+	// MinTLSVersion is the minimum TLS version, "1.2" (default) or "1.3".
+	MinTLSVersion string `json:"min_tls_version,omitempty"`
+	// CAFile is a PEM file with extra trusted certificates, added to the system ones.
+	CAFile string `json:"ca_file,omitempty"`
+	// DisableKeepAlives closes the connection after every request instead of reusing it.
+	DisableKeepAlives bool `json:"disable_keep_alives,omitempty"`
+	// IdleConnTimeoutSeconds is how long an idle connection is kept for reuse.
+	IdleConnTimeoutSeconds int `json:"idle_conn_timeout_seconds,omitempty"`
+	// MaxIdleConnsPerHost is the number of idle connections kept per host.
+	MaxIdleConnsPerHost int `json:"max_idle_conns_per_host,omitempty"`
+}
+
+// RedactionProfile is a named group of masking rules that can be applied to exported transcripts.
+type RedactionProfile struct {
+	Name  string
+	Rules func() []OutboundRule
+}
+
+// TokenCountCache caches token counts keyed by a SHA-256 hash of the model name and content.
+// It is safe for concurrent use and can optionally be persisted to disk.
+type TokenCountCache struct {
+	mu      sync.Mutex
+	entries map[string]int
+	path    string // Location of the cache file; empty keeps the cache in memory only.
+}
+
+// ChunkOptions controls how ChunkText splits large inputs.
+type ChunkOptions struct {
+	// MaxTokens is the maximum number of estimated tokens per chunk.
+	MaxTokens int
+	// OverlapTokens is the number of estimated tokens repeated at the start of the next chunk.
+	OverlapTokens int
+}
+
+// Chunk is a token-bounded piece of a larger input.
+type Chunk struct {
+	Index           int    // Position of the chunk, starting at 0.
+	Text            string // Content of the chunk, including any overlap with the previous chunk.
+	EstimatedTokens int    // Estimated number of tokens in Text.
+}
+
+// GenerationOption is a user-adjustable generation parameter that can be changed
+// through the ":config set" command. The Setter parses and validates the raw value
+// before storing it in the session's GenerationSettings.
+type GenerationOption struct {
+	Setter func(session *Session, value string) error
+	Valid  bool
+}
+
+// GenerationSettings holds the generation parameters applied to the AI model
+// for every message in the session. Zero values mean the model default is used.
+type GenerationSettings struct {
+	// Temperature controls the randomness of the AI's responses.
+	Temperature float32
+	// TopP limits the sampling to the most likely tokens whose probabilities add up to it. Nil means the model default.
+	TopP *float32
+	// TopK limits the sampling to this number of most likely tokens. Nil means the model default.
+	TopK *int32
+	// MaxOutputTokens limits the length of the AI's responses.
+	MaxOutputTokens int32
+	// StopSequences makes the AI stop generating when any of these sequences is produced.
+	StopSequences []string
+}
+
 // SafetySettings encapsulates the content safety configuration for the AI model.
 // It defines thresholds for various categories of potentially harmful content,
 // allowing users to set the desired level of content filtering based on the
@@ -251,6 +937,36 @@ type TokenCountParams struct {
 	ImageData [][]byte // Image data as a slice of byte slices, each representing an image.
 }
 
+// TokenCountReport is the machine-readable result of ":tokencount ... :format json|csv".
+type TokenCountReport struct {
+	// Files are the counted files, in the order they were counted.
+	Files []TokenCountFile `json:"files"`
+	// TotalTokens is the sum of the tokens of all files.
+	TotalTokens int `json:"total_tokens"`
+	// Estimated is true if any of the counts is an offline estimate.
+	Estimated bool `json:"estimated"`
+}
+
+// TokenCountFile is the token count of a single file in a TokenCountReport.
+type TokenCountFile struct {
+	Path      string `json:"path"`
+	Tokens    int    `json:"tokens"`
+	Estimated bool   `json:"estimated"`
+}
+
+// TokenCountOutput selects the machine-readable format of the token counts and where they are written.
+// An empty Format prints the usual progress messages instead; an empty Path writes to the standard output.
+type TokenCountOutput struct {
+	Format string
+	Path   string
+}
+
+// cancelOnCloseBody releases the context of a request when its response body is closed (see getGitHubAPI).
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
 // TokenCountRequest encapsulates the parameters required for concurrent token counting
 // in text and images using a generative AI model. It is designed to be passed to
 // functions that initiate multiple goroutines for processing text and image data in parallel.
@@ -311,3 +1027,16 @@ type TypingPrinter struct {
 type NewLineChar struct {
 	NewLineChars rune
 }
+
+// fetchedPage is the text of a page included in a prompt, cached for URLCacheTTL.
+type fetchedPage struct {
+	text    string
+	fetched time.Time
+}
+
+// escapeStripWriter removes the escape sequences from the output written to a console that cannot process them
+// (see enableVirtualTerminal). A sequence split across writes is held back in pending until it ends.
+type escapeStripWriter struct {
+	output  io.Writer
+	pending string
+}
@@ -0,0 +1,282 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: The AI usually writes diffs by hand, so the hunk line counts are often wrong. They are ignored:
+// a hunk ends at the first line that is not part of it, and it is located by its context lines, starting
+// at the position given in its header.
+
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// renderDiffBlocks colorizes the code blocks in text that contain a unified diff: added lines in green,
+// removed lines in red, hunk headers in cyan and file headers in bold. A code block contains a diff if
+// its language is "diff" or "patch", or if it has hunk headers.
+func renderDiffBlocks(text string) string {
+	lines := strings.Split(text, StringNewLine)
+	blockStart := -1 // Index of the line that opened the current code block, if any
+	for i, line := range lines {
+		if strings.Count(line, TripleBacktick)%2 == 0 {
+			continue
+		}
+		if blockStart < 0 {
+			blockStart = i
+			continue
+		}
+		language := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(lines[blockStart]), TripleBacktick))
+		block := lines[blockStart+1 : i]
+		if language == DiffLanguage || language == PatchLanguage || isUnifiedDiff(block) {
+			for j, diffLine := range block {
+				block[j] = colorizeDiffLine(diffLine)
+			}
+		}
+		blockStart = -1
+	}
+	return strings.Join(lines, StringNewLine)
+}
+
+// isUnifiedDiff reports whether the lines contain a hunk header.
+func isUnifiedDiff(lines []string) bool {
+	for _, line := range lines {
+		if hunkHeaderRegex.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// colorizeDiffLine colorizes a single line of a unified diff.
+func colorizeDiffLine(line string) string {
+	switch {
+	case strings.HasPrefix(line, DiffOldFilePrefix), strings.HasPrefix(line, DiffNewFilePrefix),
+		strings.HasPrefix(line, DiffGitPrefix), strings.HasPrefix(line, DiffIndexPrefix):
+		return BoldText + line + ResetBoldText
+	case strings.HasPrefix(line, DiffHunkPrefix):
+		return colors.ColorCyan + line + colors.ColorReset
+	case strings.HasPrefix(line, DiffAddedPrefix):
+		return colors.ColorGreen + line + colors.ColorReset
+	case strings.HasPrefix(line, DiffRemovedPrefix):
+		return colors.ColorRed + line + colors.ColorReset
+	default:
+		return line
+	}
+}
+
+// parseUnifiedDiff parses the unified diffs in text, which may be surrounded by other text.
+// Hunks before any file header are returned as a file diff without paths.
+func parseUnifiedDiff(text string) []FileDiff {
+	var files []FileDiff
+	var hunk *DiffHunk
+	lines := strings.Split(text, StringNewLine)
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSuffix(lines[i], "\r")
+		switch {
+		case strings.HasPrefix(line, DiffOldFilePrefix) && i+1 < len(lines) && strings.HasPrefix(lines[i+1], DiffNewFilePrefix):
+			files = append(files, FileDiff{
+				OldPath: diffPath(line, DiffOldFilePrefix),
+				NewPath: diffPath(lines[i+1], DiffNewFilePrefix),
+			})
+			hunk = nil
+			i++
+		case hunkHeaderRegex.MatchString(line):
+			if len(files) == 0 {
+				files = append(files, FileDiff{})
+			}
+			match := hunkHeaderRegex.FindStringSubmatch(line)
+			oldStart, _ := strconv.Atoi(match[1])
+			file := &files[len(files)-1]
+			file.Hunks = append(file.Hunks, DiffHunk{Header: line, OldStart: oldStart})
+			hunk = &file.Hunks[len(file.Hunks)-1]
+		case hunk != nil && (line == "" || strings.ContainsAny(line[:1], DiffLinePrefixes)):
+			hunk.Lines = append(hunk.Lines, line)
+		case hunk != nil && strings.HasPrefix(line, DiffNoNewlinePrefix):
+			// The missing newline at the end of the file is not reproduced.
+		default:
+			hunk = nil
+		}
+	}
+	for i := range files {
+		for j := range files[i].Hunks {
+			// Empty lines after a hunk are usually not part of it.
+			hunkLines := files[i].Hunks[j].Lines
+			for len(hunkLines) > 0 && hunkLines[len(hunkLines)-1] == "" {
+				hunkLines = hunkLines[:len(hunkLines)-1]
+			}
+			files[i].Hunks[j].Lines = hunkLines
+		}
+	}
+	return files
+}
+
+// diffPath returns the path of a "---" or "+++" file header, without the "a/" or "b/" prefix of git diffs.
+func diffPath(line, prefix string) string {
+	path := strings.TrimPrefix(line, prefix)
+	// A timestamp may follow the path, separated by a tab.
+	path, _, _ = strings.Cut(path, "\t")
+	path = strings.TrimSpace(path)
+	if path == DevNull {
+		return path
+	}
+	if strings.HasPrefix(path, "a/") || strings.HasPrefix(path, "b/") {
+		path = path[2:]
+	}
+	return path
+}
+
+// matches reports whether the file diff changes the file at path.
+func (d FileDiff) matches(path string) bool {
+	for _, diffPath := range []string{d.NewPath, d.OldPath} {
+		if diffPath == "" || diffPath == DevNull {
+			continue
+		}
+		if filepath.Clean(diffPath) == filepath.Clean(path) ||
+			strings.HasSuffix(filepath.ToSlash(filepath.Clean(path)), "/"+filepath.ToSlash(filepath.Clean(diffPath))) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyHunks applies the hunks to content and returns the changed content.
+// Each hunk is located by its context and removed lines, searching outwards from the line given in its
+// header. Trailing whitespace is ignored when comparing lines.
+func applyHunks(content string, hunks []DiffHunk) (string, error) {
+	trailingNewline := content == "" || strings.HasSuffix(content, StringNewLine)
+	lines := strings.Split(strings.TrimSuffix(content, StringNewLine), StringNewLine)
+	if content == "" {
+		lines = nil
+	}
+
+	offset, minStart := 0, 0
+	for number, hunk := range hunks {
+		var oldLines, newLines []string
+		for _, line := range hunk.Lines {
+			prefix, text := byte(' '), ""
+			if line != "" {
+				prefix, text = line[0], line[1:]
+			}
+			if prefix != DiffAddedPrefix[0] {
+				oldLines = append(oldLines, text)
+			}
+			if prefix != DiffRemovedPrefix[0] {
+				newLines = append(newLines, text)
+			}
+		}
+
+		start := findHunk(lines, oldLines, hunk.OldStart-1+offset, minStart)
+		if len(oldLines) == 0 {
+			// A hunk that only adds lines is inserted after the line in its header.
+			start = min(max(hunk.OldStart+offset, minStart), len(lines))
+		}
+		if start < 0 {
+			return "", fmt.Errorf(ErrorDiffHunkMismatch, number+1, hunk.OldStart)
+		}
+		lines = append(lines[:start], append(newLines, lines[start+len(oldLines):]...)...)
+		offset += len(newLines) - len(oldLines)
+		minStart = start + len(newLines)
+	}
+
+	result := strings.Join(lines, StringNewLine)
+	if trailingNewline && len(lines) > 0 {
+		result += StringNewLine
+	}
+	return result, nil
+}
+
+// findHunk returns the index of the first of the wanted lines in lines, searching outwards from
+// expected but not before minStart. It returns -1 if they are not found.
+func findHunk(lines, wanted []string, expected, minStart int) int {
+	expected = max(expected, minStart)
+	for distance := 0; distance <= len(lines); distance++ {
+		for _, start := range []int{expected - distance, expected + distance} {
+			if start >= minStart && start+len(wanted) <= len(lines) && linesEqual(lines[start:start+len(wanted)], wanted) {
+				return start
+			}
+		}
+	}
+	return -1
+}
+
+// linesEqual reports whether the lines are equal, ignoring trailing whitespace.
+func linesEqual(a, b []string) bool {
+	for i := range a {
+		if strings.TrimRight(a[i], " \t\r") != strings.TrimRight(b[i], " \t\r") {
+			return false
+		}
+	}
+	return true
+}
+
+// latestFileDiff returns the diff for the file at path from the most recent AI response that has one.
+// A response with a single diff without file headers applies to any file.
+func (h *ChatHistory) latestFileDiff(path string) (FileDiff, error) {
+	messages := h.snapshot().messages
+	for i := len(messages) - 1; i >= 0; i-- {
+		if !strings.HasPrefix(strings.TrimSpace(messages[i]), AiNerd) {
+			continue
+		}
+		files := parseUnifiedDiff(messages[i])
+		for _, file := range files {
+			if file.matches(path) {
+				return file, nil
+			}
+		}
+		if len(files) == 1 && files[0].OldPath == "" && files[0].NewPath == "" && len(files[0].Hunks) > 0 {
+			return files[0], nil
+		}
+	}
+	return FileDiff{}, fmt.Errorf(ErrorNoDiffForFile, path)
+}
+
+// applyDiff applies the diff to the file at path, after showing it and asking for confirmation.
+// A diff that creates the file (from /dev/null) can be applied to a file that does not exist yet.
+//
+// Returns:
+//
+//	bool: Whether the diff was applied.
+//	error: An error if the file cannot be read or written, or the diff does not apply.
+func (s *Session) applyDiff(path string, diff FileDiff) (bool, error) {
+	mode := os.FileMode(0600)
+	content, err := os.ReadFile(path)
+	if info, statErr := os.Stat(path); statErr == nil {
+		mode = info.Mode().Perm()
+	}
+	if err != nil && !(errors.Is(err, os.ErrNotExist) && diff.OldPath == DevNull) {
+		return false, err
+	}
+	changed, err := applyHunks(string(content), diff.Hunks)
+	if err != nil {
+		return false, err
+	}
+
+	for _, hunk := range diff.Hunks {
+		fmt.Fprintln(s.Output, colorizeDiffLine(hunk.Header))
+		for _, line := range hunk.Lines {
+			fmt.Fprintln(s.Output, colorizeDiffLine(line))
+		}
+	}
+	if !s.confirm(fmt.Sprintf(ApplyDiffConfirmation, len(diff.Hunks), path)) {
+		return false, nil
+	}
+	return true, os.WriteFile(path, []byte(changed), mode)
+}
+
+// confirm prints the question and reports whether the user answered yes.
+func (s *Session) confirm(question string) bool {
+	fprintPrefixWithTimeStamp(s.Output, SYSTEMPREFIX, "")
+	fmt.Fprint(s.Output, question)
+	answer, err := s.Input.ReadLine()
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == YesShort || answer == YesLong
+}
@@ -4,13 +4,6 @@
 
 package terminal
 
-// handleK8sCommand would be a handler function for a hypothetical ":k8s" command.
-// Note: This command is secret of H0llyW00dzZ (Original Author) would be used to interact with a Kubernetes cluster.
-func (c *handleK8sCommand) Execute(session *Session) (bool, error) {
-	// currently unimplemented
-	return true, nil
-}
-
 // storageCommand would be a handler function for a hypothetical ":storage" command.
 func (c *storageCommand) Execute(session *Session) (bool, error) {
 	// currently unimplemented
@@ -29,13 +22,6 @@ func (c *loadhistoryfromstorageCommand) Execute(session *Session) (bool, error)
 	return true, nil
 }
 
-// reportshitFunctionthatTooComplexCommand would be a handler function for a hypothetical ":report" command.
-func (c *reportshitFunctionthatTooComplexCommand) Execute(session *Session) (bool, error) {
-	// currently unimplemented
-	// this would be used to report when goroutines panic in other side not because of this terminal LOL
-	return true, nil
-}
-
 // translateCommand would be a handler function for a hypothetical ":translate" command.
 func (cmd *translateCommand) Execute(session *Session) (bool, error) {
 	// currently unimplemented
@@ -52,13 +38,6 @@ func (cmd *fixDocsFormattingCommand) Execute(session *Session) (bool, error) {
 	return true, nil
 }
 
-// handlePromptfileCommand would be a handler function for a hypothetical ":prompt -f <file.txt> or <file.md>" command.
-// Note: this would be used to load the prompt from file, can be used for start the conversation with Google AI.
-func (cmd *handlePromptfileCommand) Execute(session *Session) (bool, error) {
-	// currently unimplemented
-	return true, nil
-}
-
 // handleKaliDocsCommand would be a handler function for a hypothetical ":kalidocs -t <tools name>" command.
 // Note: professional only
 func (cmd *handleKaliDocsCommand) Execute(session *Session) (bool, error) {
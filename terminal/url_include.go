@@ -0,0 +1,118 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+
+package terminal
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	// fetchedPages caches the text of the included pages by URL, so a page referenced again is not fetched again.
+	fetchedPages   = make(map[string]fetchedPage)
+	fetchedPagesMu sync.Mutex
+)
+
+// htmlSkippedRegex matches the parts of an HTML page that are not text for the reader.
+var htmlSkippedRegex = regexp.MustCompile(HTMLSkippedRegex)
+
+// fetchIncludedPage returns the text of the page at rawURL for a prompt. HTML pages are stripped to their text,
+// and the text is cut at URLTokenBudget. Pages are cached for URLCacheTTL.
+func (s *Session) fetchIncludedPage(rawURL string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" || parsed.Host == "" {
+		return "", fmt.Errorf(ErrorInvalidIncludeURL)
+	}
+
+	fetchedPagesMu.Lock()
+	page, ok := fetchedPages[rawURL]
+	fetchedPagesMu.Unlock()
+	if ok && time.Since(page.fetched) < URLCacheTTL {
+		return page.text, nil
+	}
+
+	ctx, done := s.beginTimedOperation(URLFetchTimeout)
+	defer done()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf(ErrorIncludeURLStatus, resp.Status)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "" && !strings.HasPrefix(mediaType, "text/") && !strings.HasSuffix(mediaType, "json") &&
+		!strings.HasSuffix(mediaType, "xml") {
+		return "", fmt.Errorf(ErrorIncludeURLContentType, mediaType)
+	}
+	// Read one byte more than the limit, to know whether the page was cut.
+	body, err := io.ReadAll(io.LimitReader(resp.Body, URLMaxBytes+1))
+	if err != nil {
+		return "", err
+	}
+	truncated := len(body) > URLMaxBytes
+	body = body[:min(len(body), URLMaxBytes)]
+
+	text := string(body)
+	if mediaType == "" || mediaType == "text/html" || mediaType == "application/xhtml+xml" {
+		text = pageToText(text)
+	}
+	text, cut := truncateToTokens(strings.ToValidUTF8(text, ""), URLTokenBudget)
+	if truncated || cut {
+		text += URLTruncated
+	}
+
+	fetchedPagesMu.Lock()
+	fetchedPages[rawURL] = fetchedPage{text: text, fetched: time.Now()}
+	fetchedPagesMu.Unlock()
+	return text, nil
+}
+
+// pageToText returns the text of an HTML page without scripts, styles and comments, one line per block element.
+func pageToText(page string) string {
+	text := htmlToText(htmlSkippedRegex.ReplaceAllString(page, ""))
+	var lines []string
+	for _, line := range strings.Split(text, StringNewLine) {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, StringNewLine)
+}
+
+// truncateToTokens cuts the text so its estimated number of tokens is at most budget, and reports whether it was cut.
+func truncateToTokens(text string, budget int) (string, bool) {
+	tokens := EstimateTokens(text)
+	if tokens <= budget {
+		return text, false
+	}
+	for tokens > budget {
+		// Cut in proportion to the excess, at the start of a character.
+		end := len(text) * budget / tokens
+		for end > 0 && !utf8.RuneStart(text[end]) {
+			end--
+		}
+		text = text[:end]
+		tokens = EstimateTokens(text)
+	}
+	return text, true
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: The watcher relies on the file notifications of the OS (see fsnotify), so a file that does not change costs nothing.
+
+package terminal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchFile sends the prompt with the file content to the AI, then sends it again every time the file changes,
+// until the context is cancelled (Ctrl+C).
+//
+// Changes are rate limited: after a response, the next one is sent at the earliest after WatchMinInterval,
+// even if the file was saved several times in between. Only the latest content is sent.
+func (s *Session) watchFile(ctx context.Context, filePath, prompt string) error {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf(ErrorFailedToReadFile, filePath, err)
+	}
+	watcher, err := watchFileDir(filePath)
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	if err := s.sendWatchedFile(ctx, filePath, prompt, content); err != nil {
+		return err
+	}
+	hash, lastSent := hashSHA256(content), time.Now()
+
+	target := filepath.Clean(filePath)
+	// wake fires once a change has settled, or once the rate limit allows sending the pending change.
+	var wake <-chan time.Time
+	pending := false
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			logger.Debug(DebugWatchFailed, filePath, err)
+			continue
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			// Note: Editors often save by writing a new file and renaming it over the old one, which is a create.
+			if filepath.Clean(event.Name) == target && event.Has(fsnotify.Write|fsnotify.Create) {
+				// Wait until the file settles, so a file that is still being written is not sent half-saved.
+				wake = time.After(WatchSettleDelay)
+			}
+			continue
+		case <-wake:
+			wake = nil
+		}
+
+		// Note: A file that cannot be read is probably being replaced; it is read again on its next event.
+		if current, err := os.ReadFile(filePath); err == nil {
+			if currentHash := hashSHA256(current); currentHash != hash {
+				content, hash, pending = current, currentHash, true
+			}
+		}
+		if !pending {
+			continue
+		}
+		if wait := WatchMinInterval - time.Since(lastSent); wait > 0 {
+			logger.Debug(DebugWatchRateLimited, filePath, wait.Round(time.Second))
+			wake = time.After(wait)
+			continue
+		}
+
+		if err := s.sendWatchedFile(ctx, filePath, prompt, content); err != nil {
+			return err
+		}
+		pending = false
+		lastSent = time.Now()
+	}
+}
+
+// watchFileDir returns a watcher of the directory of the file. The directory is watched instead of the file,
+// since replacing the file (e.g., when an editor saves it) ends a watch on the file itself.
+func watchFileDir(filePath string) (*fsnotify.Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := watcher.Add(filepath.Dir(filePath)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	return watcher, nil
+}
+
+// sendWatchedFile sends the prompt and the file content to the AI and prints the response.
+// The content passes through the outbound filter like any other user input. Only a short note is stored in
+// the chat history instead of the full content, so repeated sends do not flood the history.
+func (s *Session) sendWatchedFile(ctx context.Context, filePath, prompt string, content []byte) error {
+	message, ok := s.filterOutbound(fmt.Sprintf(WatchPromptFormat, prompt, filePath, content))
+	if !ok {
+		return nil // The filter already informed the user; keep watching for the next change.
+	}
+
+	logger.Any(InfoWatchSending, filePath)
+	note := fmt.Sprintf(ContextUserInvokeWatchCommand, prompt, filePath)
+
+	operation := RetryableOperation{
+		retryFunc: func() (bool, error) {
+			_, err := s.sendExchange(ctx, note, message)
+			return err == nil, err
+		},
+	}
+	if _, err := operation.retryWithExponentialBackoff(standardAPIErrorHandler); err != nil {
+		if ctx.Err() != nil {
+			return nil // Cancelled by the user while waiting for the response.
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+//
+// Note: The web search is optional and off until an engine is configured (see WebSearchConfig). The user searches with
+// ":search :web", and with "tool" enabled, the AI may search on its own through function calling (see answerToolCalls).
+
+package terminal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	genai "github.com/google/generative-ai-go/genai"
+)
+
+// validateWebSearch checks that the engine of the web search is known and has the settings it requires.
+func validateWebSearch(config *WebSearchConfig) error {
+	if config == nil {
+		return nil
+	}
+	switch config.Engine {
+	case WebSearchBrave, WebSearchGoogle:
+		if config.APIKey == "" {
+			return fmt.Errorf(ErrorWebSearchRequires, config.Engine, "api_key or "+WebSearchAPIKeyEnv)
+		}
+		if config.Engine == WebSearchGoogle && config.EngineID == "" {
+			return fmt.Errorf(ErrorWebSearchRequires, config.Engine, "engine_id")
+		}
+	case WebSearchSearXNG:
+		if config.Endpoint == "" {
+			return fmt.Errorf(ErrorWebSearchRequires, config.Engine, "endpoint")
+		}
+	default:
+		return fmt.Errorf(ErrorInvalidWebSearch, config.Engine)
+	}
+	return nil
+}
+
+// webSearchToolEnabled reports whether the AI may search the web on its own.
+func webSearchToolEnabled() bool {
+	return appConfig != nil && appConfig.WebSearch != nil && appConfig.WebSearch.Tool
+}
+
+// webSearchTool declares the function the AI can call to search the web.
+func webSearchTool() *genai.Tool {
+	return &genai.Tool{
+		FunctionDeclarations: []*genai.FunctionDeclaration{{
+			Name:        WebSearchToolName,
+			Description: WebSearchToolDescription,
+			Parameters: &genai.Schema{
+				Type: genai.TypeObject,
+				Properties: map[string]*genai.Schema{
+					WebSearchQueryParam: {Type: genai.TypeString, Description: "The search query."},
+				},
+				Required: []string{WebSearchQueryParam},
+			},
+		}},
+	}
+}
+
+// searchWeb searches the web with the configured engine and returns the top results.
+func searchWeb(ctx context.Context, query string) ([]WebSearchResult, error) {
+	if appConfig == nil || appConfig.WebSearch == nil {
+		return nil, fmt.Errorf(ErrorWebSearchDisabled)
+	}
+	config := appConfig.WebSearch
+	maxResults := config.MaxResults
+	if maxResults <= 0 {
+		maxResults = DefaultWebSearchResults
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, WebSearchTimeout)
+	defer cancel()
+	req, err := newWebSearchRequest(ctx, config, query, maxResults)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf(ErrorIncludeURLStatus, resp.Status)
+	}
+
+	results, err := decodeWebSearchResults(config.Engine, resp)
+	if err != nil {
+		return nil, err
+	}
+	return results[:min(len(results), maxResults)], nil
+}
+
+// newWebSearchRequest builds the request of the engine for the query.
+func newWebSearchRequest(ctx context.Context, config *WebSearchConfig, query string, maxResults int) (*http.Request, error) {
+	params := url.Values{}
+	endpoint := config.Endpoint
+	switch config.Engine {
+	case WebSearchBrave:
+		if endpoint == "" {
+			endpoint = BraveSearchURL
+		}
+		params.Set("q", query)
+		params.Set("count", strconv.Itoa(maxResults))
+	case WebSearchGoogle:
+		if endpoint == "" {
+			endpoint = GoogleSearchURL
+		}
+		params.Set("key", config.APIKey)
+		params.Set("cx", config.EngineID)
+		params.Set("q", query)
+		// The Google API returns at most 10 results.
+		params.Set("num", strconv.Itoa(min(maxResults, 10)))
+	default:
+		endpoint = strings.TrimSuffix(endpoint, "/") + "/search"
+		params.Set("q", query)
+		params.Set("format", "json")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(HeaderAccept, "application/json")
+	if config.Engine == WebSearchBrave {
+		req.Header.Set(BraveTokenHeader, config.APIKey)
+	}
+	return req, nil
+}
+
+// decodeWebSearchResults decodes the JSON response of the engine.
+func decodeWebSearchResults(engine string, resp *http.Response) ([]WebSearchResult, error) {
+	var results []WebSearchResult
+	switch engine {
+	case WebSearchBrave:
+		var body struct {
+			Web struct {
+				Results []struct {
+					Title       string `json:"title"`
+					URL         string `json:"url"`
+					Description string `json:"description"`
+				} `json:"results"`
+			} `json:"web"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			return nil, err
+		}
+		for _, result := range body.Web.Results {
+			results = append(results, WebSearchResult{Title: result.Title, URL: result.URL, Snippet: result.Description})
+		}
+	case WebSearchGoogle:
+		var body struct {
+			Items []struct {
+				Title   string `json:"title"`
+				Link    string `json:"link"`
+				Snippet string `json:"snippet"`
+			} `json:"items"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			return nil, err
+		}
+		for _, item := range body.Items {
+			results = append(results, WebSearchResult{Title: item.Title, URL: item.Link, Snippet: item.Snippet})
+		}
+	default:
+		var body struct {
+			Results []struct {
+				Title   string `json:"title"`
+				URL     string `json:"url"`
+				Content string `json:"content"`
+			} `json:"results"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			return nil, err
+		}
+		for _, result := range body.Results {
+			results = append(results, WebSearchResult{Title: result.Title, URL: result.URL, Snippet: result.Content})
+		}
+	}
+	return results, nil
+}
+
+// formatWebSearchResults returns the results as a numbered list, with the title, URL and snippet of each.
+// Snippets may contain HTML highlighting, which is stripped, and like AI output, results never carry escape sequences.
+func formatWebSearchResults(results []WebSearchResult) string {
+	lines := make([]string, 0, len(results))
+	for i, result := range results {
+		title, link := stripControlSequences(result.Title), stripControlSequences(result.URL)
+		lines = append(lines, fmt.Sprintf(WebSearchResultFormat, i+1, title, link, stripControlSequences(htmlToText(result.Snippet))))
+	}
+	return strings.Join(lines, StringNewLine)
+}
+
+// searchWebCommand searches the web for ":search :web", prints the results and asks the AI to summarize them.
+// The command and the summary are added to the chat history.
+func (s *Session) searchWebCommand(command, query string) (bool, error) {
+	ctx, done := s.beginTimedOperation(WebSearchTimeout)
+	results, err := searchWeb(ctx, query)
+	done()
+	if err != nil {
+		return false, fmt.Errorf(ErrorFailedToSearchWeb, err)
+	}
+	if len(results) == 0 {
+		logger.Any(InfoWebSearchNoResults, query)
+		return false, nil
+	}
+
+	formatted := formatWebSearchResults(results)
+	s.printPaged(formatted, DefaultPageSize)
+	return executeCommand(s, command, func(string) string {
+		return fmt.Sprintf(promptTemplate(PromptTemplateWebSearch), query, formatted)
+	})
+}
+
+// answerToolCalls answers the web searches the AI asks for in the response, and returns the response that
+// follows them. After WebSearchMaxToolCalls rounds, the last response is returned as it is.
+func (s *Session) answerToolCalls(ctx context.Context, cs *genai.ChatSession, resp *genai.GenerateContentResponse) (*genai.GenerateContentResponse, error) {
+	for round := 0; round < WebSearchMaxToolCalls; round++ {
+		var responses []genai.Part
+		for _, cand := range resp.Candidates {
+			for _, call := range cand.FunctionCalls() {
+				responses = append(responses, s.answerToolCall(ctx, call))
+			}
+		}
+		if len(responses) == 0 {
+			return resp, nil
+		}
+		var err error
+		if resp, err = cs.SendMessage(ctx, responses...); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// answerToolCall runs the web search of a function call. Failures are reported to the AI, which can answer without it.
+func (s *Session) answerToolCall(ctx context.Context, call genai.FunctionCall) genai.FunctionResponse {
+	query, _ := call.Args[WebSearchQueryParam].(string)
+	if call.Name != WebSearchToolName || query == "" {
+		return genai.FunctionResponse{Name: call.Name, Response: map[string]any{"error": "unknown function or missing query"}}
+	}
+	s.notify(InfoWebSearching, query)
+	results, err := searchWeb(ctx, query)
+	if err != nil {
+		logger.Debug(ErrorFailedToSearchWeb, err)
+		return genai.FunctionResponse{Name: call.Name, Response: map[string]any{"error": err.Error()}}
+	}
+	return genai.FunctionResponse{Name: call.Name, Response: map[string]any{"results": formatWebSearchResults(results)}}
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2024 H0llyW00dzZ
+//
+// License: MIT License
+
+//go:build windows
+// +build windows
+
+package windows
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// EnableVirtualTerminal enables the processing of ANSI escape codes on the consoles the standard output
+// and the standard error are connected to. Outputs that are not a console are skipped. It returns the
+// error of the first console that does not support escape codes (before Windows 10).
+func EnableVirtualTerminal() error {
+	for _, file := range []*os.File{os.Stdout, os.Stderr} {
+		handle := windows.Handle(file.Fd())
+		var mode uint32
+		if err := windows.GetConsoleMode(handle, &mode); err != nil {
+			continue
+		}
+		if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 {
+			continue
+		}
+		if err := windows.SetConsoleMode(handle, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConsoleWidth returns the width of the visible part of the console the standard output is connected to.
+func ConsoleWidth() (int, error) {
+	var info windows.ConsoleScreenBufferInfo
+	if err := windows.GetConsoleScreenBufferInfo(windows.Handle(os.Stdout.Fd()), &info); err != nil {
+		return 0, err
+	}
+	return int(info.Window.Right-info.Window.Left) + 1, nil
+}
@@ -0,0 +1,24 @@
+// Package windows provides utilities to prepare the Windows console for terminal-based
+// applications. It enables the processing of ANSI escape codes, which cmd.exe and
+// PowerShell do not do by default, and measures the width of the visible part of the
+// console.
+
+// Without virtual terminal processing, the colors and styles of the output are printed
+// as raw escape codes. Consoles before Windows 10 cannot process escape codes at all,
+// in which case EnableVirtualTerminal returns an error, and the caller is expected to
+// remove the escape codes from its output instead.
+
+// Usage:
+// Call EnableVirtualTerminal once at startup, before anything is printed. Outputs that
+// are not a console (e.g., redirected to a file) are left as they are.
+
+// Example:
+//  if err := windows.EnableVirtualTerminal(); err != nil {
+//  	// Print without colors and styles.
+//  }
+//  width, err := windows.ConsoleWidth()
+
+// Note that the package is built only on Windows. It is the counterpart of the
+// linux_or_unix package, whose terminal behavior follows Unix-like conventions.
+
+package windows
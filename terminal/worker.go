@@ -12,21 +12,28 @@ import (
 // NewChatWorker creates a new ChatWorker for a given chat session.
 func NewChatWorker(session *Session) *ChatWorker {
 	return &ChatWorker{
-		session: session,
-		ticker:  time.NewTicker(1 * time.Second), // Adjust the ticker interval as needed.
-		done:    make(chan bool),
+		session:    session,
+		ticker:     time.NewTicker(1 * time.Second), // Adjust the ticker interval as needed.
+		done:       make(chan bool),
+		lastBackup: time.Now(), // The first backup is made after the first interval.
+		// The history is first checked for the background summary after the first interval.
+		lastSummary: time.Now(),
 	}
 }
 
 // Start begins the background work loop of the ChatWorker.
 func (cw *ChatWorker) Start(ctx context.Context) error {
-	go func() {
+	supervisor.Go(WorkerScheduler, func() {
 		for {
 			select {
-			case <-cw.ticker.C:
-				// Note: The current implementation is a prototype sample logic. These tasks are placeholders
-				// and are not yet implemented. They serve as examples of the kind of periodic work a ChatWorker
-				// might perform. Future development will include concrete implementations as per the application's requirements.
+			case now := <-cw.ticker.C:
+				// Run the scheduled prompts that are due (see ":schedule").
+				// Note: Other periodic work for the chat session can be added here as well.
+				cw.runDueSchedules(now)
+				// Back up the chat history when the interval of the backup config has passed.
+				cw.runDueBackup(now)
+				// Let the main loop summarize the oldest messages when the history grew beyond the auto summary limits.
+				cw.runDueSummary(now)
 			case <-cw.done:
 				// Handle cleanup and shutdown of the worker.
 				return
@@ -35,7 +42,7 @@ func (cw *ChatWorker) Start(ctx context.Context) error {
 				return
 			}
 		}
-	}()
+	})
 	return nil
 }
 